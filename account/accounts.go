@@ -0,0 +1,212 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/getlantern/radiance/common/settings"
+	"github.com/getlantern/radiance/events"
+	"github.com/getlantern/radiance/traces"
+)
+
+var (
+	// ErrUnknownAccount is returned when switching to or removing an account that isn't stored on
+	// this device.
+	ErrUnknownAccount = errors.New("account not stored on this device")
+	// ErrActiveAccount is returned when removing the active account, which must be logged out
+	// instead.
+	ErrActiveAccount = errors.New("account is active")
+)
+
+// StoredAccount is an account the user is logged in to on this device.
+type StoredAccount struct {
+	UserID    string `json:"userId"`
+	Email     string `json:"email,omitempty"`
+	UserLevel string `json:"userLevel,omitempty"`
+	// Active is true for the account currently in use. Only the active account's settings are
+	// used, for config fetches and requests to the account servers alike.
+	Active bool `json:"active"`
+}
+
+// accountSettings returns the settings that belong to the active account, which are swapped out
+// when switching accounts. Device-wide settings, such as the device ID and VPN preferences, are
+// shared by all accounts.
+func accountSettings() settings.Settings {
+	return settings.GetAllFor(
+		settings.UserIDKey,
+		settings.TokenKey,
+		settings.UserLevelKey,
+		settings.EmailKey,
+		settings.DevicesKey,
+		settings.JwtTokenKey,
+		settings.UserDataKey,
+		settings.UserFetchedKey,
+		settings.OAuthLoginKey,
+		settings.OAuthProviderKey,
+	)
+}
+
+// storedAccounts returns the settings of the inactive accounts, keyed by user ID.
+func storedAccounts() map[string]map[string]any {
+	stored := make(map[string]map[string]any)
+	if settings.Exists(settings.AccountsKey) {
+		if err := settings.GetStruct(settings.AccountsKey, &stored); err != nil {
+			slog.Warn("Failed to read stored accounts", "error", err)
+			return make(map[string]map[string]any)
+		}
+	}
+	return stored
+}
+
+func setStoredAccounts(stored map[string]map[string]any) error {
+	// Set merges maps into the existing value, so clear it first for removed accounts to go.
+	if err := settings.Clear(settings.AccountsKey); err != nil {
+		return err
+	}
+	if len(stored) == 0 {
+		return nil
+	}
+	// Round trip through JSON so the settings are stored as they'd be read back from disk.
+	buf, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("marshaling stored accounts: %w", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return fmt.Errorf("unmarshaling stored accounts: %w", err)
+	}
+	return settings.Set(settings.AccountsKey, m)
+}
+
+// stashActive adds the active account, if any, to stored.
+func stashActive(stored map[string]map[string]any) {
+	userID := settings.GetString(settings.UserIDKey)
+	if userID == "" {
+		return
+	}
+	s := make(map[string]any)
+	for key, value := range accountSettings() {
+		if value != nil {
+			s[key.String()] = value
+		}
+	}
+	stored[userID] = s
+}
+
+// Accounts returns the accounts stored on this device, ordered by user ID.
+func (a *Client) Accounts() []StoredAccount {
+	a.accountsMu.Lock()
+	defer a.accountsMu.Unlock()
+	stored := storedAccounts()
+	stashActive(stored)
+	active := settings.GetString(settings.UserIDKey)
+	accounts := make([]StoredAccount, 0, len(stored))
+	for userID, s := range stored {
+		email, _ := s[settings.EmailKey.String()].(string)
+		level, _ := s[settings.UserLevelKey.String()].(string)
+		accounts = append(accounts, StoredAccount{
+			UserID:    userID,
+			Email:     email,
+			UserLevel: level,
+			Active:    userID == active,
+		})
+	}
+	slices.SortFunc(accounts, func(x, y StoredAccount) int { return strings.Compare(x.UserID, y.UserID) })
+	return accounts
+}
+
+// AddAccount stores the active account so it can be switched back to with
+// [Client.SwitchAccount], and replaces it with a new anonymous user, which the user can then log
+// in to another account as.
+func (a *Client) AddAccount(ctx context.Context) (*UserData, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "add_account")
+	defer span.End()
+
+	a.accountsMu.Lock()
+	stored := storedAccounts()
+	stashActive(stored)
+	err := setStoredAccounts(stored)
+	a.accountsMu.Unlock()
+	if err != nil {
+		return nil, traces.RecordError(ctx, fmt.Errorf("storing active account: %w", err))
+	}
+	if err := a.clearActive(); err != nil {
+		return nil, traces.RecordError(ctx, err)
+	}
+	return a.NewUser(ctx)
+}
+
+// SwitchAccount makes the stored account with the given user ID the active one, storing the
+// previously active account in its place. No request is made to the account servers; a
+// [UserChangeEvent] is emitted, which refetches the config for the new account.
+func (a *Client) SwitchAccount(ctx context.Context, userID string) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "switch_account")
+	defer span.End()
+
+	a.accountsMu.Lock()
+	defer a.accountsMu.Unlock()
+	if userID == settings.GetString(settings.UserIDKey) {
+		return nil
+	}
+	stored := storedAccounts()
+	target, ok := stored[userID]
+	if !ok {
+		return traces.RecordError(ctx, ErrUnknownAccount)
+	}
+	stashActive(stored)
+	delete(stored, userID)
+	if err := setStoredAccounts(stored); err != nil {
+		return traces.RecordError(ctx, fmt.Errorf("storing active account: %w", err))
+	}
+	if err := a.clearActive(); err != nil {
+		return traces.RecordError(ctx, err)
+	}
+	restore := make(settings.Settings)
+	for key := range accountSettings() {
+		if value, ok := target[key.String()]; ok {
+			restore[key] = value
+		}
+	}
+	if err := settings.Patch(restore); err != nil {
+		return traces.RecordError(ctx, fmt.Errorf("restoring account: %w", err))
+	}
+	events.Emit(UserChangeEvent{})
+	return nil
+}
+
+// RemoveAccount forgets the stored account with the given user ID without logging it out on the
+// server. The active account can't be removed; use [Client.Logout] instead.
+func (a *Client) RemoveAccount(userID string) error {
+	a.accountsMu.Lock()
+	defer a.accountsMu.Unlock()
+	if userID == settings.GetString(settings.UserIDKey) {
+		return ErrActiveAccount
+	}
+	stored := storedAccounts()
+	if _, ok := stored[userID]; !ok {
+		return ErrUnknownAccount
+	}
+	delete(stored, userID)
+	return setStoredAccounts(stored)
+}
+
+// clearActive clears the active account's settings and the salt cached for its email.
+func (a *Client) clearActive() error {
+	keys := slices.Collect(maps.Keys(accountSettings()))
+	if err := settings.Clear(keys...); err != nil {
+		return fmt.Errorf("clearing active account: %w", err)
+	}
+	a.setSalt(nil)
+	if err := writeSalt(nil, a.saltPath); err != nil {
+		return fmt.Errorf("clearing salt: %w", err)
+	}
+	return nil
+}
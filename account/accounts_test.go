@@ -0,0 +1,62 @@
+package account
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getlantern/radiance/account/protos"
+	"github.com/getlantern/radiance/common/settings"
+	"github.com/getlantern/radiance/events"
+)
+
+func TestSwitchAccount(t *testing.T) {
+	ac, _ := newTestClient(t)
+	ctx := context.Background()
+	settings.Set(settings.UserIDKey, int64(456))
+	settings.Set(settings.TokenKey, "token-456")
+	settings.Set(settings.EmailKey, "work@example.com")
+	settings.Set(settings.UserLevelKey, "pro")
+	settings.Set(settings.UserDataKey, &UserData{
+		LegacyID:       456,
+		LegacyUserData: &protos.LoginResponse_UserData{UserId: 456, UserLevel: "pro"},
+	})
+
+	data, err := ac.AddAccount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), data.LegacyID)
+	assert.Empty(t, settings.GetString(settings.EmailKey), "the new user doesn't inherit the stored account's settings")
+	assert.Equal(t, []StoredAccount{
+		{UserID: "123", Active: true},
+		{UserID: "456", Email: "work@example.com", UserLevel: "pro"},
+	}, ac.Accounts())
+
+	changed := make(chan struct{}, 1)
+	sub := events.Subscribe(func(UserChangeEvent) { changed <- struct{}{} })
+	defer sub.Unsubscribe()
+
+	require.NoError(t, ac.SwitchAccount(ctx, "456"))
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("no UserChangeEvent after switching accounts")
+	}
+	assert.Equal(t, "456", settings.GetString(settings.UserIDKey))
+	assert.Equal(t, "token-456", settings.GetString(settings.TokenKey))
+	assert.True(t, settings.IsPro())
+	cached, _, err := ac.CachedUserData()
+	require.NoError(t, err)
+	assert.Equal(t, int64(456), cached.LegacyID)
+	assert.Equal(t, []StoredAccount{
+		{UserID: "123"},
+		{UserID: "456", Email: "work@example.com", UserLevel: "pro", Active: true},
+	}, ac.Accounts())
+
+	assert.ErrorIs(t, ac.SwitchAccount(ctx, "789"), ErrUnknownAccount)
+	assert.ErrorIs(t, ac.RemoveAccount("456"), ErrActiveAccount)
+	require.NoError(t, ac.RemoveAccount("123"))
+	assert.Len(t, ac.Accounts(), 1)
+}
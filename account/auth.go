@@ -28,14 +28,30 @@ func (a *Client) fetchSalt(ctx context.Context, email string) (*protos.GetSaltRe
 	return &salt, nil
 }
 
+// passwordProof performs the SRP authentication flow for the account with the given lower case
+// email, returning the proof that the client knows the password and the salt it was derived with.
+// Requests that require the password are authenticated with a proof from here.
+func (a *Client) passwordProof(ctx context.Context, email, password string) (proof, salt []byte, err error) {
+	salt, err = a.getSalt(ctx, email)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err = a.clientProof(ctx, email, password, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, salt, nil
+}
+
 // clientProof performs the SRP authentication flow to generate the client proof for the given email and password.
 func (a *Client) clientProof(ctx context.Context, email, password string, salt []byte) ([]byte, error) {
-	srpClient, err := newSRPClient(email, password, salt)
+	client, err := newSRPClient(email, password, salt)
 	if err != nil {
 		return nil, err
 	}
+	defer client.wipe()
 
-	A := srpClient.EphemeralPublic()
+	A := client.EphemeralPublic()
 	data := &protos.PrepareRequest{
 		Email: email,
 		A:     A.Bytes(),
@@ -50,19 +66,20 @@ func (a *Client) clientProof(ctx context.Context, email, password string, salt [
 		return nil, fmt.Errorf("unmarshaling prepare response: %w", err)
 	}
 	B := big.NewInt(0).SetBytes(srpB.B)
-	if err = srpClient.SetOthersPublic(B); err != nil {
+	if err = client.SetOthersPublic(B); err != nil {
 		return nil, err
 	}
 
-	key, err := srpClient.Key()
+	key, err := client.Key()
 	if err != nil || key == nil {
 		return nil, fmt.Errorf("user_not_found error while generating Client key %w", err)
 	}
-	if !srpClient.GoodServerProof(salt, email, srpB.Proof) {
-		return nil, fmt.Errorf("user_not_found checking server proof %w", err)
+	// GoodServerProof compares the proofs in constant time.
+	if !client.GoodServerProof(salt, email, srpB.Proof) {
+		return nil, errors.New("user_not_found checking server proof")
 	}
 
-	proof, err := srpClient.ClientProof()
+	proof, err := client.ClientProof()
 	if err != nil {
 		return nil, fmt.Errorf("user_not_found generating client proof %w", err)
 	}
@@ -83,7 +100,15 @@ func (a *Client) getSalt(ctx context.Context, email string) ([]byte, error) {
 
 const group = srp.RFC5054Group3072
 
-func newSRPClient(email, password string, salt []byte) (*srp.SRP, error) {
+// srpClient is an SRP client that keeps references to the secrets the SRP library holds, so they
+// can be wiped once the client is done with.
+type srpClient struct {
+	*srp.SRP
+	x   *big.Int
+	key []byte
+}
+
+func newSRPClient(email, password string, salt []byte) (*srpClient, error) {
 	if len(salt) == 0 || len(password) == 0 || len(email) == 0 {
 		return nil, errors.New("salt, password and email should not be empty")
 	}
@@ -93,17 +118,63 @@ func newSRPClient(email, password string, salt []byte) (*srp.SRP, error) {
 		return nil, fmt.Errorf("failed to generate encrypted key: %w", err)
 	}
 
-	return srp.NewSRPClient(srp.KnownGroups[group], encryptedKey, nil), nil
+	return &srpClient{SRP: srp.NewSRPClient(srp.KnownGroups[group], encryptedKey, nil), x: encryptedKey}, nil
+}
+
+// Key returns the session key.
+func (c *srpClient) Key() ([]byte, error) {
+	key, err := c.SRP.Key()
+	c.key = key
+	return key, err
+}
+
+// wipe zeroes the key derived from the password and the session key. The client can't be used
+// afterwards.
+func (c *srpClient) wipe() {
+	wipeInt(c.x)
+	clear(c.key)
+}
+
+// newVerifier generates a new salt and the SRP verifier of the password with it, for registering
+// the password with the server. Callers should clear the verifier once it's sent.
+func newVerifier(email, password string) (salt, verifier []byte, err error) {
+	salt, err = generateSalt()
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := newSRPClient(email, password, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer client.wipe()
+	v, err := client.Verifier()
+	if err != nil {
+		return nil, nil, err
+	}
+	verifier = v.Bytes()
+	wipeInt(v)
+	return salt, verifier, nil
 }
 
 func generateEncryptedKey(password, email string, salt []byte) (*big.Int, error) {
 	if len(salt) == 0 || len(password) == 0 || len(email) == 0 {
 		return nil, errors.New("salt or password or email is empty")
 	}
-	combinedInput := password + email
-	encryptedKey := pbkdf2.Key([]byte(combinedInput), salt, 4096, 32, sha256.New)
-	encryptedKeyBigInt := big.NewInt(0).SetBytes(encryptedKey)
-	return encryptedKeyBigInt, nil
+	// The password string itself can't be wiped, but the copies of it made here can.
+	combinedInput := append([]byte(password), email...)
+	defer clear(combinedInput)
+	encryptedKey := pbkdf2.Key(combinedInput, salt, 4096, 32, sha256.New)
+	defer clear(encryptedKey)
+	return new(big.Int).SetBytes(encryptedKey), nil
+}
+
+// wipeInt zeroes x in place. Setting it to zero would leave its value in memory.
+func wipeInt(x *big.Int) {
+	if x == nil {
+		return
+	}
+	clear(x.Bits())
+	x.SetInt64(0)
 }
 
 func generateSalt() ([]byte, error) {
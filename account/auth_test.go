@@ -0,0 +1,34 @@
+package account
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVerifier(t *testing.T) {
+	email := "test@example.com"
+	ac, state := newTestClient(t)
+	salt, verifier, err := newVerifier(email, "password")
+	require.NoError(t, err)
+	state.salt[email] = salt
+	state.verifier = verifier
+
+	_, err = ac.Login(context.Background(), email, "password")
+	assert.NoError(t, err, "wiping the client's secrets must not affect the verifier")
+}
+
+func TestWipeInt(t *testing.T) {
+	x, ok := new(big.Int).SetString("123456789012345678901234567890", 10)
+	require.True(t, ok)
+	words := x.Bits()
+	wipeInt(x)
+	assert.Zero(t, x.Sign())
+	for _, w := range words {
+		assert.Zero(t, w)
+	}
+	wipeInt(nil)
+}
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -15,6 +16,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -23,6 +25,7 @@ import (
 	"github.com/getlantern/radiance/common"
 	"github.com/getlantern/radiance/common/env"
 	"github.com/getlantern/radiance/common/settings"
+	"github.com/getlantern/radiance/events"
 )
 
 const tracerName = "github.com/getlantern/radiance/account"
@@ -37,7 +40,17 @@ type Client struct {
 
 	salt     []byte
 	saltPath string
-	mu       sync.RWMutex
+	// oauth receives the redirect of the OAuth login started with StartOAuthLogin, if any.
+	oauth  *oauthSession
+	reauth func(ctx context.Context) error
+	mu     sync.RWMutex
+	// accountsMu serializes changes to the stored accounts, see accounts.go.
+	accountsMu sync.Mutex
+
+	// policyMu guards the request timeouts and circuit breakers, see policy.go.
+	policyMu sync.Mutex
+	timeouts map[string]time.Duration
+	breakers map[string]*breaker
 }
 
 // NewClient creates a new account client with the given HTTP client and data directory for caching
@@ -94,70 +107,78 @@ func (a *Client) sendRequest(
 		url = a.baseURL() + url
 	}
 
-	// Bound the request with a timeout to prevent hanging indefinitely due to network issues or 502 Bad Gateway loops.
-	timeoutCtx, cancel := context.WithTimeout(ctx, common.DefaultHTTPTimeout)
-	defer cancel()
-
-	var bodyReader io.Reader
+	var data []byte
 	contentType := ""
 	if body != nil {
+		var err error
 		if pb, ok := body.(proto.Message); ok {
-			data, err := proto.Marshal(pb)
-			if err != nil {
+			if data, err = proto.Marshal(pb); err != nil {
 				return nil, fmt.Errorf("marshaling protobuf request: %w", err)
 			}
-			bodyReader = bytes.NewReader(data)
 			contentType = "application/x-protobuf"
 		} else {
-			data, err := json.Marshal(body)
-			if err != nil {
+			if data, err = json.Marshal(body); err != nil {
 				return nil, fmt.Errorf("marshaling JSON request: %w", err)
 			}
-			bodyReader = bytes.NewReader(data)
 			contentType = "application/json"
 		}
 	}
-	req, err := http.NewRequestWithContext(timeoutCtx, method, url, bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	req.Header.Set(common.AppNameHeader, common.Name)
-	req.Header.Set(common.VersionHeader, common.GetVersion())
-	req.Header.Set(common.PlatformHeader, common.Platform)
-	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
-		req.Header.Set("Accept", contentType)
-	}
-	if len(queryParams) > 0 {
-		q := req.URL.Query()
-		for k, v := range queryParams {
-			q.Set(k, v)
+	newRequest := func() (*http.Request, error) {
+		var bodyReader io.Reader
+		if data != nil {
+			bodyReader = bytes.NewReader(data)
 		}
-		req.URL.RawQuery = q.Encode()
-	}
-
-	if env.GetBool(env.PrintCurl) {
-		slog.Debug("CURL command", "curl", curlFromRequest(req))
+		req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		req.Header.Set(common.AppNameHeader, common.Name)
+		req.Header.Set(common.VersionHeader, common.GetVersion())
+		req.Header.Set(common.PlatformHeader, common.Platform)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("Accept", contentType)
+		}
+		if len(queryParams) > 0 {
+			q := req.URL.Query()
+			for k, v := range queryParams {
+				q.Set(k, v)
+			}
+			req.URL.RawQuery = q.Encode()
+		}
+		return req, nil
 	}
 
-	resp, err := a.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("sending request: %w", err)
+	var (
+		respBody []byte
+		resp     *http.Response
+		err      error
+	)
+	for attempt := 0; ; attempt++ {
+		var req *http.Request
+		if req, err = newRequest(); err != nil {
+			return nil, err
+		}
+		respBody, resp, err = a.do(ctx, req)
+		if err == nil || !isIdempotent(method) || attempt == maxRetries || !IsRetryable(err) {
+			break
+		}
+		wait, ok := retryDelay(attempt, err)
+		if !ok {
+			break
+		}
+		slog.Debug("Retrying request", "method", method, "url", url, "wait", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
 	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading response: %w", err)
-	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		sanitized := sanitizeResponseBody(respBody)
-		slog.Debug("error response", "path", req.URL.Path, "status", resp.StatusCode, "body", string(sanitized))
-		return nil, fmt.Errorf("unexpected status %v body %s", resp.StatusCode, sanitized)
+		return nil, err
 	}
 
 	if len(respBody) == 0 {
@@ -169,6 +190,47 @@ func (a *Client) sendRequest(
 	return respBody, nil
 }
 
+// do sends a single attempt of req, through the circuit breaker of the backend it's addressed to
+// and bounded by the timeout set for its path.
+func (a *Client) do(ctx context.Context, req *http.Request) ([]byte, *http.Response, error) {
+	// Bound the request with a timeout to prevent hanging indefinitely due to network issues or 502 Bad Gateway loops.
+	timeoutCtx, cancel := context.WithTimeout(ctx, a.requestTimeout(req.URL.Path))
+	defer cancel()
+	req = req.WithContext(timeoutCtx)
+
+	cb := a.breaker(req.URL.Host)
+	if err := cb.allow(); err != nil {
+		return nil, nil, err
+	}
+	if env.GetBool(env.PrintCurl) {
+		slog.Debug("CURL command", "curl", curlFromRequest(req))
+	}
+
+	respBody, resp, err := func() ([]byte, *http.Response, error) {
+		resp, err := a.httpClient.Do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sending request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading response: %w", err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			sanitized := sanitizeResponseBody(respBody)
+			slog.Debug("error response", "path", req.URL.Path, "status", resp.StatusCode, "body", string(sanitized))
+			return nil, resp, newStatusError(resp.StatusCode, resp.Header, sanitized)
+		}
+		return respBody, resp, nil
+	}()
+	if ctx.Err() == nil {
+		// a request canceled by the caller says nothing about the backend
+		cb.record(err)
+	}
+	return respBody, resp, err
+}
+
 // sendProRequest sends a request to the Pro server, automatically adding the required headers,
 // including the device ID, user ID, and Pro token from settings, if available. If the URL is relative,
 // the Pro server base URL will be prepended.
@@ -181,17 +243,60 @@ func (a *Client) sendProRequest(
 	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
 		url = a.proBaseURL() + url
 	}
-	headers := map[string]string{
-		common.DeviceIDHeader: settings.GetString(settings.DeviceIDKey),
+	send := func() ([]byte, error) {
+		headers := map[string]string{
+			common.DeviceIDHeader: settings.GetString(settings.DeviceIDKey),
+		}
+		if tok := settings.GetString(settings.TokenKey); tok != "" {
+			headers[common.ProTokenHeader] = tok
+		}
+		if uid := settings.GetString(settings.UserIDKey); uid != "" {
+			headers[common.UserIDHeader] = uid
+		}
+		maps.Copy(headers, additionalheaders)
+		return a.sendRequest(ctx, method, url, queryParams, headers, body)
 	}
-	if tok := settings.GetString(settings.TokenKey); tok != "" {
-		headers[common.ProTokenHeader] = tok
+	resp, err := send()
+	if !isUnauthorized(err) || settings.GetString(settings.TokenKey) == "" {
+		return resp, err
 	}
-	if uid := settings.GetString(settings.UserIDKey); uid != "" {
-		headers[common.UserIDHeader] = uid
+
+	// The user's credentials were rejected. Give the reauth handler, if any, a chance to renew them,
+	// unless this request is being made by the handler itself.
+	a.mu.RLock()
+	reauth := a.reauth
+	a.mu.RUnlock()
+	if reauth != nil && ctx.Value(reauthKey{}) == nil {
+		if rerr := reauth(context.WithValue(ctx, reauthKey{}, true)); rerr != nil {
+			slog.Warn("Failed to renew expired credentials", "error", rerr)
+		} else if resp, err = send(); !isUnauthorized(err) {
+			return resp, err
+		}
 	}
-	maps.Copy(headers, additionalheaders)
-	return a.sendRequest(ctx, method, url, queryParams, headers, body)
+	slog.Warn("Credentials rejected by the pro server", "path", url)
+	events.Emit(AuthExpiredEvent{})
+	return nil, fmt.Errorf("%w: %w", ErrAuthExpired, err)
+}
+
+// ErrAuthExpired is returned when the pro server rejects the user's credentials, e.g. because the
+// token expired or was revoked.
+var ErrAuthExpired = errors.New("authentication expired")
+
+// AuthExpiredEvent is emitted when the pro server rejects the user's credentials and they couldn't
+// be renewed, so the user has to log in again.
+type AuthExpiredEvent struct {
+	events.Event
+}
+
+// reauthKey marks the context of requests made by the reauth handler.
+type reauthKey struct{}
+
+// SetReauthHandler sets the function called to renew the user's credentials when the pro server
+// rejects them. If it succeeds, the rejected request is retried once with the renewed credentials.
+func (a *Client) SetReauthHandler(fn func(ctx context.Context) error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reauth = fn
 }
 
 // curlFromRequest generates a curl command string from an [http.Request].
@@ -0,0 +1,52 @@
+package account
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getlantern/radiance/common/settings"
+	"github.com/getlantern/radiance/events"
+)
+
+func TestSendProRequest_AuthExpired(t *testing.T) {
+	ac, state := newTestClient(t)
+	state.expiredToken = "expired"
+	settings.Set(settings.UserIDKey, "123")
+	settings.Set(settings.TokenKey, "expired")
+
+	expired := make(chan struct{}, 1)
+	sub := events.Subscribe(func(AuthExpiredEvent) { expired <- struct{}{} })
+	defer sub.Unsubscribe()
+
+	_, err := ac.FetchUserData(context.Background())
+	require.ErrorIs(t, err, ErrAuthExpired)
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("no AuthExpiredEvent")
+	}
+
+	reauths := 0
+	ac.SetReauthHandler(func(ctx context.Context) error {
+		reauths++
+		return settings.Set(settings.TokenKey, "renewed")
+	})
+	_, err = ac.FetchUserData(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, reauths)
+
+	// a handler that doesn't help doesn't cause another attempt
+	settings.Set(settings.TokenKey, "expired")
+	ac.SetReauthHandler(func(ctx context.Context) error {
+		reauths++
+		_, err := ac.FetchUserData(ctx)
+		return err
+	})
+	_, err = ac.FetchUserData(context.Background())
+	require.ErrorIs(t, err, ErrAuthExpired)
+	assert.Equal(t, 2, reauths)
+}
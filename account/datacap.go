@@ -9,13 +9,16 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
 
 	"github.com/getlantern/radiance/common"
 	"github.com/getlantern/radiance/common/settings"
+	"github.com/getlantern/radiance/events"
 	"github.com/getlantern/radiance/traces"
 )
 
@@ -148,3 +151,115 @@ func (a *Client) connectDataCapSSE(ctx context.Context, handler func(*DataCapInf
 	}
 	return traces.RecordError(ctx, errors.New("datacap SSE stream ended unexpectedly"))
 }
+
+// DataCapThresholdEvent is emitted by a [DataCapMeter] when the estimated data cap usage first
+// reaches 80% and 100% of the allotment in an allotment period.
+type DataCapThresholdEvent struct {
+	events.Event
+	// Percent is the threshold crossed, 80 or 100.
+	Percent int                  `json:"percent"`
+	Usage   *DataCapUsageDetails `json:"usage"`
+}
+
+var dataCapThresholds = []int{100, 80}
+
+// DataCapMeter estimates data cap usage between the updates from the server, which lag behind the
+// user's traffic, by adding the bytes measured locally through the tunnel since the last update.
+// It's safe for concurrent use.
+type DataCapMeter struct {
+	mu     sync.Mutex
+	info   *DataCapInfo
+	used   int64 // reported by the server
+	quota  int64
+	period string
+	// local counts the tunnel bytes measured since the last server update.
+	local     int64
+	lastTotal int64
+	// notified is the highest threshold reported in the current period.
+	notified int
+}
+
+// NewDataCapMeter returns a DataCapMeter with no usage data.
+func NewDataCapMeter() *DataCapMeter {
+	return &DataCapMeter{}
+}
+
+// Update sets the usage reported by the server, which accounts for all traffic measured locally
+// so far.
+func (m *DataCapMeter) Update(info *DataCapInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.info = info
+	m.local = 0
+	m.used, m.quota = 0, 0
+	if info == nil || !info.Enabled || info.Usage == nil {
+		return
+	}
+	m.used, _ = strconv.ParseInt(info.Usage.BytesUsed, 10, 64)
+	m.quota, _ = strconv.ParseInt(info.Usage.BytesAllotted, 10, 64)
+	if info.Usage.AllotmentStartTime != m.period {
+		m.period = info.Usage.AllotmentStartTime
+		m.notified = 0
+	}
+	m.checkThresholdsLocked()
+}
+
+// ObserveTunnelBytes records the tunnel's cumulative byte counters. Counters that go down are
+// taken to mean the tunnel restarted and counts from zero again. It reports whether the estimated
+// usage changed.
+func (m *DataCapMeter) ObserveTunnelBytes(up, down int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	total := up + down
+	delta := total - m.lastTotal
+	if delta < 0 {
+		delta = total
+	}
+	m.lastTotal = total
+	if delta == 0 || m.quota <= 0 {
+		return false
+	}
+	m.local += delta
+	m.checkThresholdsLocked()
+	return true
+}
+
+// Info returns the last usage reported by the server, with the bytes used since then added, or nil
+// if the server hasn't reported any.
+func (m *DataCapMeter) Info() *DataCapInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.infoLocked()
+}
+
+func (m *DataCapMeter) infoLocked() *DataCapInfo {
+	if m.info == nil || m.info.Usage == nil {
+		return m.info
+	}
+	usage := *m.info.Usage
+	usage.BytesUsed = strconv.FormatInt(m.used+m.local, 10)
+	return &DataCapInfo{Enabled: m.info.Enabled, Usage: &usage}
+}
+
+func (m *DataCapMeter) checkThresholdsLocked() {
+	if m.quota <= 0 {
+		return
+	}
+	percent := (m.used + m.local) * 100 / m.quota
+	reached := 0
+	for _, t := range dataCapThresholds {
+		if percent >= int64(t) {
+			reached = t
+			break
+		}
+	}
+	// A server update lagging behind the local estimate can drop usage back below a threshold
+	// already reported. That isn't a new period, which Update resets notified for, so the
+	// threshold isn't reported again.
+	if reached <= m.notified {
+		return
+	}
+	m.notified = reached
+	slog.Info("Data cap threshold reached", "percent", reached)
+	events.Emit(DataCapThresholdEvent{Percent: reached, Usage: m.infoLocked().Usage})
+}
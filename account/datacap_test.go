@@ -9,6 +9,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/getlantern/radiance/events"
 )
 
 func TestReadSSE_BasicEvent(t *testing.T) {
@@ -121,3 +123,63 @@ func TestReadSSE_ScannerError(t *testing.T) {
 	// Scanner should have errored.
 	assert.Error(t, scanErr())
 }
+
+func TestDataCapMeter(t *testing.T) {
+	thresholds := make(chan int, 4)
+	sub := events.Subscribe(func(evt DataCapThresholdEvent) { thresholds <- evt.Percent })
+	defer sub.Unsubscribe()
+	expectThreshold := func(want int) {
+		t.Helper()
+		select {
+		case got := <-thresholds:
+			assert.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatalf("no %d%% threshold event", want)
+		}
+	}
+	expectNoThreshold := func() {
+		t.Helper()
+		select {
+		case got := <-thresholds:
+			t.Fatalf("unexpected %d%% threshold event", got)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	info := func(used, start string) *DataCapInfo {
+		return &DataCapInfo{Enabled: true, Usage: &DataCapUsageDetails{
+			BytesAllotted:      "1000",
+			BytesUsed:          used,
+			AllotmentStartTime: start,
+		}}
+	}
+
+	m := NewDataCapMeter()
+	assert.Nil(t, m.Info())
+	assert.False(t, m.ObserveTunnelBytes(100, 100), "nothing to estimate without a cap")
+
+	m.Update(info("500", "day1"))
+	assert.True(t, m.ObserveTunnelBytes(200, 150))
+	assert.Equal(t, "650", m.Info().Usage.BytesUsed)
+	assert.False(t, m.ObserveTunnelBytes(200, 150))
+
+	m.ObserveTunnelBytes(250, 250)
+	expectThreshold(80)
+	assert.Equal(t, "800", m.Info().Usage.BytesUsed)
+
+	// the tunnel restarted
+	m.ObserveTunnelBytes(100, 100)
+	expectThreshold(100)
+	assert.Equal(t, "1000", m.Info().Usage.BytesUsed)
+
+	// the server's report replaces the estimate, even when it lags just below a threshold
+	// already reported
+	m.Update(info("950", "day1"))
+	assert.Equal(t, "950", m.Info().Usage.BytesUsed)
+	m.ObserveTunnelBytes(150, 150)
+	assert.Equal(t, "1050", m.Info().Usage.BytesUsed)
+	expectNoThreshold()
+
+	m.Update(info("850", "day2"))
+	expectThreshold(80)
+	expectNoThreshold()
+}
@@ -0,0 +1,61 @@
+package account
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	C "github.com/getlantern/common"
+)
+
+// Entitlements is what the user's plan allows, derived from the user data and data cap reported by
+// the pro server and the features enabled in the config. Features should be gated on it rather
+// than on the user level directly.
+type Entitlements struct {
+	Pro bool `json:"pro"`
+	// ExpiresAt is when the user's Pro plan ends, or zero if the user isn't Pro.
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+	// Devices is the number of devices linked to the account. The pro server doesn't report the
+	// plan's device limit; logging in on a device past it fails with [ErrTooManyDevices].
+	Devices int `json:"devices"`
+	// DataCapBytes is the data the user may use per allotment period, or zero if it isn't capped.
+	DataCapBytes int64 `json:"dataCapBytes,omitempty"`
+	// ServerGroups are the server groups the pro server lists for the user.
+	ServerGroups []string `json:"serverGroups,omitempty"`
+	// Features are the features enabled in the config, keyed by name.
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// NewEntitlements derives the user's entitlements from their user data, data cap, and the
+// features enabled in the config. Any of them may be nil if unknown.
+func NewEntitlements(data *UserData, dataCap *DataCapInfo, features map[string]bool) *Entitlements {
+	e := &Entitlements{Features: features}
+	if ud := data.GetLegacyUserData(); ud != nil {
+		e.Pro = strings.EqualFold(ud.UserLevel, "pro")
+		if e.Pro && ud.Expiration > 0 {
+			e.ExpiresAt = time.Unix(ud.Expiration, 0)
+		}
+		e.Devices = len(ud.Devices)
+		e.ServerGroups = ud.Servers
+	}
+	if dataCap != nil && dataCap.Enabled && dataCap.Usage != nil {
+		e.DataCapBytes, _ = strconv.ParseInt(dataCap.Usage.BytesAllotted, 10, 64)
+	}
+	return e
+}
+
+// Unlimited reports whether the user's data usage is uncapped.
+func (e *Entitlements) Unlimited() bool {
+	return e.DataCapBytes <= 0
+}
+
+// HasFeature reports whether the named config feature is enabled for the user.
+func (e *Entitlements) HasFeature(name string) bool {
+	return e.Features[name]
+}
+
+// CanUsePrivateServers reports whether the user may launch private servers, which is gated by the
+// [C.GCP] feature.
+func (e *Entitlements) CanUsePrivateServers() bool {
+	return e.HasFeature(C.GCP)
+}
@@ -0,0 +1,36 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	C "github.com/getlantern/common"
+
+	"github.com/getlantern/radiance/account/protos"
+)
+
+func TestNewEntitlements(t *testing.T) {
+	free := NewEntitlements(nil, nil, nil)
+	assert.False(t, free.Pro)
+	assert.True(t, free.Unlimited())
+	assert.False(t, free.CanUsePrivateServers())
+
+	expiration := time.Now().Add(30 * 24 * time.Hour).Unix()
+	data := &UserData{LegacyUserData: &protos.LoginResponse_UserData{
+		UserLevel:  "Pro",
+		Expiration: expiration,
+		Devices:    []*protos.LoginResponse_Device{{Id: "a"}, {Id: "b"}},
+		Servers:    []string{"pro"},
+	}}
+	dataCap := &DataCapInfo{Enabled: true, Usage: &DataCapUsageDetails{BytesAllotted: "500000000"}}
+	e := NewEntitlements(data, dataCap, map[string]bool{C.GCP: true})
+	assert.True(t, e.Pro)
+	assert.Equal(t, expiration, e.ExpiresAt.Unix())
+	assert.Equal(t, 2, e.Devices)
+	assert.Equal(t, []string{"pro"}, e.ServerGroups)
+	assert.Equal(t, int64(500000000), e.DataCapBytes)
+	assert.False(t, e.Unlimited())
+	assert.True(t, e.CanUsePrivateServers())
+}
@@ -0,0 +1,148 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AuthError is returned when the server rejects the request's credentials.
+type AuthError struct {
+	Status  int
+	Message string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("authentication failed (status %d): %s", e.Status, e.Message)
+}
+
+// RateLimitedError is returned when the server refuses the request because too many have been
+// made. RetryAfter is how long the server asked the client to wait, or zero if it didn't say.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+	Message    string
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited, retry after %v: %s", e.RetryAfter, e.Message)
+	}
+	return "rate limited: " + e.Message
+}
+
+// ServerError is returned when the server fails to handle the request.
+type ServerError struct {
+	Status  int
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("server error (status %d): %s", e.Status, e.Message)
+}
+
+// ValidationError is returned when the server rejects the request as invalid. Field is the
+// request field the server objected to, if it said which.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+	}
+	return "invalid request: " + e.Message
+}
+
+// statusError is returned by sendRequest for a response with a non-2xx status that doesn't fall
+// into one of the classes above.
+type statusError struct {
+	status int
+	body   []byte
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %v body %s", e.status, e.body)
+}
+
+// newStatusError classifies a response with a non-2xx status.
+func newStatusError(status int, header http.Header, body []byte) error {
+	msg, field := errorMessage(body)
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return &AuthError{Status: status, Message: msg}
+	case status == http.StatusTooManyRequests:
+		return &RateLimitedError{RetryAfter: parseRetryAfter(header.Get("Retry-After")), Message: msg}
+	case status >= 500:
+		return &ServerError{Status: status, Message: msg}
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return &ValidationError{Field: field, Message: msg}
+	}
+	return &statusError{status: status, body: body}
+}
+
+// errorMessage extracts the error message, and the offending field if any, from a JSON error
+// body. Other bodies are used as the message as is.
+func errorMessage(body []byte) (msg, field string) {
+	var resp struct {
+		Error string `json:"error"`
+		Field string `json:"field"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error != "" {
+		return resp.Error, resp.Field
+	}
+	return string(body), ""
+}
+
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+func isUnauthorized(err error) bool {
+	var ae *AuthError
+	return errors.As(err, &ae) && ae.Status == http.StatusUnauthorized
+}
+
+// IsRetryable reports whether the request that failed with err may succeed if retried later:
+// the server was overloaded or failed, or it couldn't be reached. Requests rejected for what was
+// sent, such as bad credentials or invalid input, aren't retryable.
+func IsRetryable(err error) bool {
+	var (
+		rle *RateLimitedError
+		se  *ServerError
+		ne  net.Error
+	)
+	switch {
+	case errors.As(err, &rle), errors.As(err, &se):
+		return true
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &ne):
+		return true
+	}
+	return false
+}
+
+// RetryAfter returns how long the server asked the client to wait before retrying, if err is a
+// [RateLimitedError], and zero otherwise.
+func RetryAfter(err error) time.Duration {
+	var rle *RateLimitedError
+	if errors.As(err, &rle) {
+		return rle.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStatusError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		header    http.Header
+		body      string
+		want      error
+		retryable bool
+	}{
+		{
+			name:   "unauthorized",
+			status: http.StatusUnauthorized,
+			body:   "invalid token",
+			want:   &AuthError{Status: http.StatusUnauthorized, Message: "invalid token"},
+		},
+		{
+			name:      "rate limited",
+			status:    http.StatusTooManyRequests,
+			header:    http.Header{"Retry-After": {"30"}},
+			body:      `{"error":"slow down"}`,
+			want:      &RateLimitedError{RetryAfter: 30 * time.Second, Message: "slow down"},
+			retryable: true,
+		},
+		{
+			name:      "server error",
+			status:    http.StatusBadGateway,
+			body:      "bad gateway",
+			want:      &ServerError{Status: http.StatusBadGateway, Message: "bad gateway"},
+			retryable: true,
+		},
+		{
+			name:   "validation",
+			status: http.StatusBadRequest,
+			body:   `{"error":"not an email address","field":"email"}`,
+			want:   &ValidationError{Field: "email", Message: "not an email address"},
+		},
+		{
+			name:   "other",
+			status: http.StatusNotFound,
+			body:   "not found",
+			want:   &statusError{status: http.StatusNotFound, body: []byte("not found")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newStatusError(tt.status, tt.header, []byte(tt.body))
+			assert.Equal(t, tt.want, err)
+			assert.Equal(t, tt.retryable, IsRetryable(fmt.Errorf("wrapped: %w", err)))
+		})
+	}
+}
+
+func TestSendRequest_TypedErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+	ac := &Client{httpClient: ts.Client(), authURL: ts.URL}
+
+	_, err := ac.sendRequest(context.Background(), "POST", "/", nil, nil, nil)
+	var rle *RateLimitedError
+	require.ErrorAs(t, err, &rle)
+	assert.Equal(t, time.Minute, RetryAfter(err))
+	assert.True(t, IsRetryable(err))
+
+	ts.Close()
+	_, err = ac.sendRequest(context.Background(), "POST", "/", nil, nil, nil)
+	require.Error(t, err)
+	assert.True(t, IsRetryable(err), "unreachable server: %v", err)
+	assert.Zero(t, RetryAfter(err))
+}
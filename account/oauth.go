@@ -0,0 +1,165 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	oauthCallbackPath = "/oauth/callback"
+	// oauthLoginTimeout is how long the callback listener waits for the user to finish signing in.
+	oauthLoginTimeout = 10 * time.Minute
+)
+
+// ErrNoOAuthLogin is returned by CompleteOAuthLogin when no OAuth login has been started.
+var ErrNoOAuthLogin = errors.New("no OAuth login in progress")
+
+// oauthSession listens on loopback for the auth server's redirect at the end of an OAuth login,
+// which carries the user's JWT in the token query parameter.
+type oauthSession struct {
+	srv *http.Server
+	// state is part of the redirect URL the auth server is given, so it comes back with the token
+	// without the server having to pass it through. A page the user visits doesn't know it, so it
+	// can't log them in to someone else's account by sending its own token to the listener.
+	state  string
+	result chan oauthResult
+	timer  *time.Timer
+}
+
+type oauthResult struct {
+	token string
+	err   error
+}
+
+func newOAuthSession() (*oauthSession, string, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, "", fmt.Errorf("generate OAuth state: %w", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, "", fmt.Errorf("listen for OAuth callback: %w", err)
+	}
+	s := &oauthSession{state: base64.RawURLEncoding.EncodeToString(nonce), result: make(chan oauthResult, 1)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+oauthCallbackPath, s.handleCallback)
+	s.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("OAuth callback listener failed", "error", err)
+		}
+	}()
+	s.timer = time.AfterFunc(oauthLoginTimeout, func() {
+		s.finish(oauthResult{err: errors.New("timed out waiting for OAuth login")})
+		s.close()
+	})
+	returnTo := url.URL{
+		Scheme:   "http",
+		Host:     l.Addr().String(),
+		Path:     oauthCallbackPath,
+		RawQuery: url.Values{"state": {s.state}}.Encode(),
+	}
+	return s, returnTo.String(), nil
+}
+
+func (s *oauthSession) handleCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(s.state)) != 1 {
+		// Not the redirect of this login. It's ignored rather than failing the login, so it can't
+		// be used to cancel one either.
+		slog.Warn("Ignoring OAuth callback with a mismatched state")
+		http.Error(w, "Login failed. Return to Lantern and try again.", http.StatusBadRequest)
+		return
+	}
+	token := query.Get("token")
+	if token == "" {
+		err := errors.New("OAuth login failed: no token in redirect")
+		if reason := query.Get("error"); reason != "" {
+			err = fmt.Errorf("OAuth login failed: %s", reason)
+		}
+		http.Error(w, "Login failed. Return to Lantern and try again.", http.StatusBadRequest)
+		s.finish(oauthResult{err: err})
+		return
+	}
+	fmt.Fprintln(w, "Login complete. You can close this window and return to Lantern.")
+	s.finish(oauthResult{token: token})
+}
+
+// finish records the outcome of the login. Only the first outcome counts.
+func (s *oauthSession) finish(res oauthResult) {
+	select {
+	case s.result <- res:
+	default:
+	}
+}
+
+func (s *oauthSession) close() {
+	s.timer.Stop()
+	// Shutdown rather than Close, so the browser gets the response to the redirect.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.srv.Shutdown(ctx)
+}
+
+// StartOAuthLogin starts an OAuth login with provider that completes on this device, and returns
+// the URL the user must open in a browser to sign in. The auth server redirects the browser to a
+// listener on loopback once the user has signed in; call CompleteOAuthLogin to wait for it and log
+// the user in. Starting another login abandons the previous one.
+func (a *Client) StartOAuthLogin(ctx context.Context, provider string) (string, error) {
+	sess, returnTo, err := newOAuthSession()
+	if err != nil {
+		return "", err
+	}
+	loginURL, err := a.oauthLoginURL(provider, returnTo)
+	if err != nil {
+		sess.close()
+		return "", err
+	}
+	a.mu.Lock()
+	prev := a.oauth
+	a.oauth = sess
+	a.mu.Unlock()
+	if prev != nil {
+		prev.finish(oauthResult{err: errors.New("OAuth login abandoned")})
+		prev.close()
+	}
+	return loginURL, nil
+}
+
+// CompleteOAuthLogin waits for the user to finish the OAuth login started with StartOAuthLogin and
+// logs them in with the resulting token, as OAuthLoginCallback does. If ctx is done first, the
+// login can still be completed by calling CompleteOAuthLogin again.
+func (a *Client) CompleteOAuthLogin(ctx context.Context) (*UserData, error) {
+	a.mu.RLock()
+	sess := a.oauth
+	a.mu.RUnlock()
+	if sess == nil {
+		return nil, ErrNoOAuthLogin
+	}
+
+	var res oauthResult
+	select {
+	case res = <-sess.result:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	a.mu.Lock()
+	if a.oauth == sess {
+		a.oauth = nil
+	}
+	a.mu.Unlock()
+	sess.close()
+	if res.err != nil {
+		return nil, res.err
+	}
+	return a.OAuthLoginCallback(ctx, res.token)
+}
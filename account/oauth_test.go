@@ -0,0 +1,83 @@
+package account
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getlantern/radiance/common/settings"
+)
+
+// startOAuthLogin starts an OAuth login and returns the redirect URL the auth server would send
+// the browser to, without the token.
+func startOAuthLogin(t *testing.T, ac *Client) string {
+	loginURL, err := ac.StartOAuthLogin(context.Background(), "google")
+	require.NoError(t, err)
+	u, err := url.Parse(loginURL)
+	require.NoError(t, err)
+	assert.Empty(t, u.Query().Get("state"), "the state should only be sent as part of returnTo")
+	returnTo, err := url.Parse(u.Query().Get("returnTo"))
+	require.NoError(t, err)
+	require.NotEmpty(t, returnTo.Query().Get("state"))
+	return returnTo.String()
+}
+
+func TestCompleteOAuthLogin(t *testing.T) {
+	ac, _ := newTestClient(t)
+	settings.Set(settings.DeviceIDKey, "deviceId")
+	returnTo := startOAuthLogin(t, ac)
+
+	mockToken := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJlbWFpbCI6InRlc3RAZXhhbXBsZS5jb20iLCJsZWdhY3lfdXNlcl9pZCI6MTIzNDUsImxlZ2FjeV90b2tlbiI6InRlc3QtdG9rZW4ifQ.test"
+	forged, err := url.Parse(returnTo)
+	require.NoError(t, err)
+	forged.RawQuery = url.Values{"token": {"forged"}, "state": {"wrong"}}.Encode()
+	resp, err := http.Get(forged.String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "a callback with the wrong state should be rejected")
+
+	resp, err = http.Get(returnTo + "&" + url.Values{"token": {mockToken}}.Encode())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, err := ac.CompleteOAuthLogin(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "test@example.com", data.Id)
+	assert.Equal(t, mockToken, settings.GetString(settings.JwtTokenKey))
+	assert.True(t, settings.GetBool(settings.OAuthLoginKey))
+
+	_, err = ac.CompleteOAuthLogin(context.Background())
+	assert.ErrorIs(t, err, ErrNoOAuthLogin)
+}
+
+func TestCompleteOAuthLogin_Failed(t *testing.T) {
+	ac, _ := newTestClient(t)
+	returnTo := startOAuthLogin(t, ac)
+
+	resp, err := http.Get(returnTo + "&error=access_denied")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	_, err = ac.CompleteOAuthLogin(context.Background())
+	assert.ErrorContains(t, err, "access_denied")
+}
+
+func TestCompleteOAuthLogin_Canceled(t *testing.T) {
+	ac, _ := newTestClient(t)
+	startOAuthLogin(t, ac)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ac.CompleteOAuthLogin(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// starting another login abandons the pending one
+	startOAuthLogin(t, ac)
+	t.Cleanup(func() { ac.oauth.close() })
+}
@@ -0,0 +1,188 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getlantern/radiance/common"
+)
+
+const (
+	// maxRetries is how many times an idempotent request is retried after a retryable failure.
+	maxRetries     = 2
+	retryBaseDelay = 500 * time.Millisecond
+	// maxRetryDelay caps the wait between attempts. A request the server asks to be retried
+	// later than that fails instead, so the caller isn't blocked for long.
+	maxRetryDelay = 5 * time.Second
+
+	// breakerThreshold is the number of consecutive failures to reach a backend after which its
+	// circuit breaker trips, and requests to it fail fast for breakerCooldown.
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without sending the request when the backend it's addressed to has
+// been unreachable for several requests in a row.
+var ErrCircuitOpen = errors.New("backend unreachable, request not sent")
+
+// SetRequestTimeout sets the timeout of each attempt of the requests to path, e.g. "/user-data",
+// overriding [common.DefaultHTTPTimeout]. A zero timeout restores the default.
+func (a *Client) SetRequestTimeout(path string, timeout time.Duration) {
+	a.policyMu.Lock()
+	defer a.policyMu.Unlock()
+	if timeout <= 0 {
+		delete(a.timeouts, path)
+		return
+	}
+	if a.timeouts == nil {
+		a.timeouts = make(map[string]time.Duration)
+	}
+	a.timeouts[path] = timeout
+}
+
+// requestTimeout returns the timeout for a request to urlPath. The paths timeouts are set for are
+// relative to the server's base URL, so they're matched against the end of urlPath. If several
+// match, e.g. "/user-data" and "/subscription/user-data", the longest, most specific one wins.
+func (a *Client) requestTimeout(urlPath string) time.Duration {
+	a.policyMu.Lock()
+	defer a.policyMu.Unlock()
+	timeout, matched := common.DefaultHTTPTimeout, ""
+	for path, t := range a.timeouts {
+		if strings.HasSuffix(urlPath, path) && len(path) > len(matched) {
+			timeout, matched = t, path
+		}
+	}
+	return timeout
+}
+
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// retryDelay returns how long to wait before retrying a request that failed with err on the given
+// attempt, counting from zero, and false if it shouldn't be retried.
+func retryDelay(attempt int, err error) (time.Duration, bool) {
+	if wait := RetryAfter(err); wait > 0 {
+		return wait, wait <= maxRetryDelay
+	}
+	wait := min(retryBaseDelay<<attempt, maxRetryDelay)
+	// add jitter between 80% and 120% of wait time to avoid thundering herd
+	return time.Duration(float64(wait) * (0.8 + 0.4*rand.Float64())), true
+}
+
+// BreakerState describes the circuit breaker of a backend the client talks to, for diagnostics.
+type BreakerState struct {
+	// Backend is the host of the backend.
+	Backend string `json:"backend"`
+	// Open is true while requests to the backend fail fast with [ErrCircuitOpen].
+	Open bool `json:"open"`
+	// Failures is the number of consecutive failures to reach the backend.
+	Failures  int       `json:"failures"`
+	OpenedAt  time.Time `json:"opened_at,omitzero"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// BreakerStates returns the state of the circuit breaker of each backend the client has sent
+// requests to, ordered by host.
+func (a *Client) BreakerStates() []BreakerState {
+	a.policyMu.Lock()
+	defer a.policyMu.Unlock()
+	states := make([]BreakerState, 0, len(a.breakers))
+	for _, b := range a.breakers {
+		states = append(states, b.state())
+	}
+	slices.SortFunc(states, func(x, y BreakerState) int { return strings.Compare(x.Backend, y.Backend) })
+	return states
+}
+
+func (a *Client) breaker(host string) *breaker {
+	a.policyMu.Lock()
+	defer a.policyMu.Unlock()
+	if a.breakers == nil {
+		a.breakers = make(map[string]*breaker)
+	}
+	b, ok := a.breakers[host]
+	if !ok {
+		b = &breaker{host: host}
+		a.breakers[host] = b
+	}
+	return b
+}
+
+// breaker is the circuit breaker of a backend. Once it trips, requests fail fast until the
+// cooldown has passed; the next request is then let through, and either closes the breaker by
+// succeeding or trips it again by failing.
+type breaker struct {
+	mu       sync.Mutex
+	host     string
+	failures int
+	openedAt time.Time
+	lastErr  string
+}
+
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures >= breakerThreshold && time.Since(b.openedAt) < breakerCooldown {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// record records the outcome of a request. Only failures to reach the backend count; the backend
+// is up if it rejected the request.
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !isUnreachable(err) {
+		b.failures, b.openedAt, b.lastErr = 0, time.Time{}, ""
+		return
+	}
+	b.failures++
+	b.lastErr = err.Error()
+	if b.failures >= breakerThreshold {
+		if b.failures == breakerThreshold {
+			slog.Warn("Backend unreachable, failing requests to it fast", "backend", b.host, "cooldown", breakerCooldown, "error", err)
+		}
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *breaker) state() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := BreakerState{Backend: b.host, Failures: b.failures, LastError: b.lastErr}
+	if b.failures >= breakerThreshold {
+		s.OpenedAt = b.openedAt
+		s.Open = time.Since(b.openedAt) < breakerCooldown
+	}
+	return s
+}
+
+// isUnreachable reports whether err means the backend couldn't be reached, as opposed to the
+// backend handling the request and rejecting it.
+func isUnreachable(err error) bool {
+	var (
+		se *ServerError
+		ne net.Error
+	)
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &se):
+		return se.Status == http.StatusBadGateway ||
+			se.Status == http.StatusServiceUnavailable ||
+			se.Status == http.StatusGatewayTimeout
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &ne):
+		return true
+	}
+	return false
+}
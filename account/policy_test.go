@@ -0,0 +1,89 @@
+package account
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendRequest_Retries(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	ac := &Client{httpClient: ts.Client(), authURL: ts.URL}
+
+	resp, err := ac.sendRequest(context.Background(), "GET", "/", nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(resp))
+	assert.Equal(t, 2, requests)
+
+	requests = 0
+	_, err = ac.sendRequest(context.Background(), "POST", "/", nil, nil, nil)
+	var se *ServerError
+	assert.ErrorAs(t, err, &se)
+	assert.Equal(t, 1, requests, "non-idempotent requests aren't retried")
+}
+
+func TestSendRequest_Timeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+	ac := &Client{httpClient: ts.Client(), authURL: ts.URL}
+	ac.SetRequestTimeout("/slow", 50*time.Millisecond)
+
+	start := time.Now()
+	_, err := ac.sendRequest(context.Background(), "POST", "/slow", nil, nil, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	ac.SetRequestTimeout("/user-data", time.Minute)
+	ac.SetRequestTimeout("/subscription/user-data", time.Hour)
+	for range 10 {
+		assert.Equal(t, time.Hour, ac.requestTimeout("/api/v1/subscription/user-data"), "the longest matching path wins")
+	}
+	assert.Equal(t, time.Minute, ac.requestTimeout("/api/v1/user-data"))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer ts.Close()
+	ac := &Client{httpClient: ts.Client(), authURL: ts.URL}
+
+	for range breakerThreshold {
+		_, err := ac.sendRequest(context.Background(), "POST", "/", nil, nil, nil)
+		var se *ServerError
+		require.ErrorAs(t, err, &se)
+	}
+	_, err := ac.sendRequest(context.Background(), "POST", "/", nil, nil, nil)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	states := ac.BreakerStates()
+	require.Len(t, states, 1)
+	assert.True(t, states[0].Open)
+	assert.Equal(t, breakerThreshold, states[0].Failures)
+
+	// once the cooldown has passed, a successful request closes the breaker
+	b := ac.breaker(states[0].Backend)
+	b.openedAt = time.Now().Add(-breakerCooldown)
+	b.record(nil)
+	assert.NoError(t, b.allow())
+	assert.False(t, ac.BreakerStates()[0].Open)
+}
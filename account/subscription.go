@@ -3,6 +3,7 @@ package account
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/url"
@@ -49,6 +50,12 @@ type SubscriptionPlans struct {
 	Plans                []*protos.Plan                     `json:"plans"`
 }
 
+// SubscriptionData is the state of the user's subscription.
+type SubscriptionData = protos.LoginResponse_UserData_SubscriptionData
+
+// ErrNoSubscription is returned by Subscription when the user has no subscription.
+var ErrNoSubscription = errors.New("no subscription")
+
 type SubscriptionResponse struct {
 	CustomerID     string `json:"customerId"`
 	SubscriptionID string `json:"subscriptionId"`
@@ -62,6 +69,15 @@ func (a *Client) SubscriptionPlans(ctx context.Context, channel string) (string,
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "subscription_plans")
 	defer span.End()
 
+	_, resp, err := a.fetchPlans(ctx, channel)
+	if err != nil {
+		return "", err
+	}
+	return string(resp), nil
+}
+
+// fetchPlans retrieves the plans for the channel, returning them both parsed and as sent.
+func (a *Client) fetchPlans(ctx context.Context, channel string) (*SubscriptionPlans, []byte, error) {
 	params := map[string]string{
 		"locale":              settings.GetString(settings.LocaleKey),
 		"distributionChannel": channel,
@@ -69,18 +85,98 @@ func (a *Client) SubscriptionPlans(ctx context.Context, channel string) (string,
 	resp, err := a.sendProRequest(ctx, "GET", "/plans-v5", params, nil, nil)
 	if err != nil {
 		slog.Error("retrieving plans", "error", err)
-		return "", traces.RecordError(ctx, err)
+		return nil, nil, traces.RecordError(ctx, err)
 	}
 	var plans SubscriptionPlans
 	if err := json.Unmarshal(resp, &plans); err != nil {
-		return "", traces.RecordError(ctx, fmt.Errorf("unmarshaling plans response: %w", err))
+		return nil, nil, traces.RecordError(ctx, fmt.Errorf("unmarshaling plans response: %w", err))
 	}
 	if plans.BaseResponse != nil && plans.Error != "" {
 		err = fmt.Errorf("received bad response: %s", plans.Error)
 		slog.Error("retrieving plans", "error", err)
-		return "", traces.RecordError(ctx, err)
+		return nil, nil, traces.RecordError(ctx, err)
 	}
-	return string(resp), nil
+	return &plans, resp, nil
+}
+
+// ErrUnknownPlan is returned by [Client.QuotePlanChange] when the plan isn't offered on the
+// channel in the requested currency.
+var ErrUnknownPlan = errors.New("plan not offered")
+
+// PlanQuote is what switching to a plan would cost now, with the time left on the current
+// subscription credited against the plan's price. Amounts are in the currency's smallest unit,
+// e.g. cents. It's an estimate for showing before purchase; the payment provider determines the
+// final charge.
+type PlanQuote struct {
+	PlanID   string `json:"planId"`
+	Currency string `json:"currency"`
+	// Price is the full price of the plan.
+	Price int64 `json:"price"`
+	// Credit is the unused part of what was paid for the current subscription, pro-rated by the
+	// time left in its term.
+	Credit int64 `json:"credit"`
+	// Due is Price less Credit, or zero if the credit covers the whole price.
+	Due int64 `json:"due"`
+	// CurrentPlanID and CurrentPlanEndsAt describe the current subscription, if the user has one.
+	CurrentPlanID     string    `json:"currentPlanId,omitempty"`
+	CurrentPlanEndsAt time.Time `json:"currentPlanEndsAt,omitzero"`
+}
+
+// QuotePlanChange quotes switching to the plan with the given ID, as listed for the channel, in
+// currency, e.g. "usd". Users without a subscription are quoted the plan's full price.
+func (a *Client) QuotePlanChange(ctx context.Context, channel, planID, currency string) (*PlanQuote, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "quote_plan_change")
+	defer span.End()
+
+	plans, _, err := a.fetchPlans(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	currency = strings.ToLower(currency)
+	price, ok := planPrice(plans.Plans, planID, currency)
+	if !ok {
+		return nil, traces.RecordError(ctx, fmt.Errorf("%w: %q in %s", ErrUnknownPlan, planID, currency))
+	}
+	quote := &PlanQuote{PlanID: planID, Currency: currency, Price: price, Due: price}
+
+	sub, err := a.Subscription(ctx)
+	switch {
+	case errors.Is(err, ErrNoSubscription):
+		return quote, nil
+	case err != nil:
+		return nil, traces.RecordError(ctx, err)
+	}
+	quote.CurrentPlanID = sub.PlanID
+	start, end := time.Unix(sub.StartAt, 0), time.Unix(sub.EndAt, 0)
+	if sub.EndAt > 0 {
+		quote.CurrentPlanEndsAt = end
+	}
+	paid, ok := planPrice(plans.Plans, sub.PlanID, currency)
+	if !ok || sub.StartAt <= 0 || !end.After(start) {
+		// Quoting the full price would overstate what the user pays, so fail rather than guess.
+		return nil, traces.RecordError(ctx, fmt.Errorf("cannot pro-rate current plan %q", sub.PlanID))
+	}
+	quote.Credit = proratedCredit(paid, start, end, time.Now())
+	quote.Due = max(price-quote.Credit, 0)
+	return quote, nil
+}
+
+func planPrice(plans []*protos.Plan, id, currency string) (int64, bool) {
+	for _, p := range plans {
+		if p.GetId() == id {
+			price, ok := p.GetPrice()[currency]
+			return price, ok
+		}
+	}
+	return 0, false
+}
+
+// proratedCredit returns the part of paid that covers the time from now until end of the term
+// from start to end.
+func proratedCredit(paid int64, start, end, now time.Time) int64 {
+	term := end.Sub(start)
+	left := min(max(end.Sub(now), 0), term)
+	return paid * int64(left/time.Second) / int64(term/time.Second)
 }
 
 // NewStripeSubscription creates a new Stripe subscription for the given email and plan ID.
@@ -112,7 +208,8 @@ type VerifySubscriptionResponse struct {
 // VerifySubscription verifies a subscription for a given service (Google or Apple). data
 // should contain the information required by service to verify the subscription, such as the
 // purchase token for Google Play or the receipt for Apple. The status and subscription ID are returned
-// along with any error that occurred during the verification process.
+// along with any error that occurred during the verification process. Once the purchase is verified,
+// the user data is refreshed so the user's new tier takes effect.
 func (a *Client) VerifySubscription(ctx context.Context, service SubscriptionService, data map[string]string) (string, error) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "verify_subscription")
 	defer span.End()
@@ -133,8 +230,12 @@ func (a *Client) VerifySubscription(ctx context.Context, service SubscriptionSer
 		slog.Error("verifying subscription", "error", err)
 		return "", traces.RecordError(ctx, fmt.Errorf("verifying subscription: %w", err))
 	}
+	// The purchase went through, so a failure to refresh is only logged; the tier is picked up on
+	// the next fetch.
+	if _, err := a.fetchUserData(ctx); err != nil {
+		slog.Warn("refreshing user data after verifying subscription", "error", err)
+	}
 	return string(resp), nil
-
 }
 
 type RestoreSubscriptionResponse struct {
@@ -174,6 +275,24 @@ func (a *Client) RestoreSubscription(ctx context.Context, service SubscriptionSe
 	return &result, nil
 }
 
+// Subscription fetches the user data and returns the current state of the user's subscription,
+// such as its plan, status, and whether it auto-renews. Stripe subscriptions are canceled and
+// renewed in the billing portal; see [Client.StripeBillingPortalURL].
+func (a *Client) Subscription(ctx context.Context) (*SubscriptionData, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "subscription")
+	defer span.End()
+
+	user, err := a.fetchUserData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sub := user.LegacyUserData.SubscriptionData
+	if sub == nil || sub.SubscriptionID == "" {
+		return nil, ErrNoSubscription
+	}
+	return sub, nil
+}
+
 // StripeBillingPortalURL generates the Stripe billing portal URL for the given user ID.
 // baseURL = common.GetProServerURL
 func (a *Client) StripeBillingPortalURL(ctx context.Context, baseURL, userID, proToken string) (string, error) {
@@ -263,7 +382,8 @@ type PurchaseResponse struct {
 	Status               string      `json:"status"`
 }
 
-// ActivationCode is used to purchase a subscription using a reseller code.
+// ActivationCode is used to purchase a subscription using a reseller code. The user data is then
+// refreshed so the user's new tier and expiry take effect.
 func (a *Client) ActivationCode(ctx context.Context, email, resellerCode string) (*PurchaseResponse, error) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "activation_code")
 	defer span.End()
@@ -288,5 +408,8 @@ func (a *Client) ActivationCode(ctx context.Context, email, resellerCode string)
 		slog.Error("retrieving subscription status", "error", purchase.Error)
 		return nil, traces.RecordError(ctx, fmt.Errorf("received bad response: %s", purchase.Error))
 	}
+	if _, err := a.fetchUserData(ctx); err != nil {
+		slog.Warn("refreshing user data after activation", "error", err)
+	}
 	return &purchase, nil
 }
@@ -3,9 +3,13 @@ package account
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/getlantern/radiance/account/protos"
+	"github.com/getlantern/radiance/common/settings"
 )
 
 func TestSubscriptionPaymentRedirect(t *testing.T) {
@@ -117,7 +121,8 @@ func TestNewUser(t *testing.T) {
 }
 
 func TestVerifySubscription(t *testing.T) {
-	ac, _ := newTestClient(t)
+	ac, ts := newTestClient(t)
+	ts.userLevel = "pro"
 	data := map[string]string{
 		"email":  "test@getlantern.org",
 		"planID": "1y-usd-10",
@@ -125,6 +130,66 @@ func TestVerifySubscription(t *testing.T) {
 	resp, err := ac.VerifySubscription(context.Background(), AppleService, data)
 	require.NoError(t, err)
 	assert.NotEmpty(t, resp)
+	assert.Equal(t, "pro", settings.GetString(settings.UserLevelKey), "user data should be refreshed")
+}
+
+func TestSubscription(t *testing.T) {
+	ac, ts := newTestClient(t)
+	_, err := ac.Subscription(context.Background())
+	assert.ErrorIs(t, err, ErrNoSubscription)
+
+	ts.subscription = &SubscriptionData{
+		SubscriptionID: "sub_123",
+		PlanID:         "1y-usd-10",
+		Status:         "active",
+		AutoRenew:      true,
+	}
+	sub, err := ac.Subscription(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "sub_123", sub.SubscriptionID)
+	assert.Equal(t, "active", sub.Status)
+	assert.True(t, sub.AutoRenew)
+}
+
+func TestQuotePlanChange(t *testing.T) {
+	ac, ts := newTestClient(t)
+	ts.plans = []*protos.Plan{
+		{Id: "1m-usd", Price: map[string]int64{"usd": 1200}},
+		{Id: "1y-usd", Price: map[string]int64{"usd": 6000}},
+	}
+	ctx := context.Background()
+
+	quote, err := ac.QuotePlanChange(ctx, "store", "1y-usd", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, &PlanQuote{PlanID: "1y-usd", Currency: "usd", Price: 6000, Due: 6000}, quote)
+
+	_, err = ac.QuotePlanChange(ctx, "store", "1y-usd", "eur")
+	assert.ErrorIs(t, err, ErrUnknownPlan)
+
+	now := time.Now()
+	ts.subscription = &SubscriptionData{
+		SubscriptionID: "sub_123",
+		PlanID:         "1m-usd",
+		StartAt:        now.Add(-20 * 24 * time.Hour).Unix(),
+		EndAt:          now.Add(10 * 24 * time.Hour).Unix(),
+	}
+	quote, err = ac.QuotePlanChange(ctx, "store", "1y-usd", "usd")
+	require.NoError(t, err)
+	assert.InDelta(t, 400, quote.Credit, 1)
+	assert.Equal(t, quote.Price-quote.Credit, quote.Due)
+	assert.Equal(t, "1m-usd", quote.CurrentPlanID)
+
+	ts.subscription.PlanID = "legacy"
+	_, err = ac.QuotePlanChange(ctx, "store", "1y-usd", "usd")
+	assert.Error(t, err)
+}
+
+func TestProratedCredit(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(100 * time.Hour)
+	assert.Equal(t, int64(1000), proratedCredit(1000, start, end, start.Add(-time.Hour)))
+	assert.Equal(t, int64(250), proratedCredit(1000, start, end, start.Add(75*time.Hour)))
+	assert.Zero(t, proratedCredit(1000, start, end, end.Add(time.Hour)))
 }
 
 func TestRestoreSubscription(t *testing.T) {
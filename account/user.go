@@ -8,7 +8,9 @@ import (
 	"log/slog"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"google.golang.org/protobuf/proto"
@@ -59,6 +61,49 @@ func (a *Client) FetchUserData(ctx context.Context) (*UserData, error) {
 	return a.fetchUserData(ctx)
 }
 
+// ErrNoUserData is returned by CachedUserData when no user data has been stored.
+var ErrNoUserData = errors.New("no user data")
+
+// CachedUserData returns the user data stored by the last call to the server and when it was
+// received, without contacting the server.
+func (a *Client) CachedUserData() (*UserData, time.Time, error) {
+	var data UserData
+	if err := settings.GetStruct(settings.UserDataKey, &data); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get user data from settings: %w", err)
+	}
+	if data.LegacyUserData == nil {
+		return nil, time.Time{}, ErrNoUserData
+	}
+	var fetched time.Time
+	if ts := settings.GetInt64(settings.UserFetchedKey); ts != 0 {
+		fetched = time.Unix(ts, 0)
+	}
+	return &data, fetched, nil
+}
+
+// EnsureFresh returns the stored user data if it was received less than maxAge ago, and fetches
+// it from the server otherwise. If the server can't be reached, the stored data is returned
+// regardless of its age, so the user's account state is still available offline; the error is
+// only returned if there's no stored data to fall back on.
+func (a *Client) EnsureFresh(ctx context.Context, maxAge time.Duration) (*UserData, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "ensure_fresh_user_data")
+	defer span.End()
+
+	cached, fetched, cacheErr := a.CachedUserData()
+	if cacheErr == nil && !fetched.IsZero() && time.Since(fetched) < maxAge {
+		return cached, nil
+	}
+	data, err := a.fetchUserData(ctx)
+	if err == nil {
+		return data, nil
+	}
+	if cacheErr != nil || errors.Is(err, ErrAuthExpired) {
+		return nil, err
+	}
+	slog.Warn("Failed to refresh user data, using cached data", "fetched", fetched, "error", err)
+	return cached, nil
+}
+
 // fetchUserData calls the /user-data endpoint and stores the result via storeData.
 func (a *Client) fetchUserData(ctx context.Context) (*UserData, error) {
 	resp, err := a.sendProRequest(ctx, "GET", "/user-data", nil, nil, nil)
@@ -89,6 +134,11 @@ func (a *Client) storeData(ctx context.Context, resp UserDataResponse) (*UserDat
 		LegacyToken:    resp.Token,
 		LegacyUserData: resp.LoginResponse_UserData,
 	}
+	// The pro server doesn't know the fields that come from the auth server at login.
+	var old UserData
+	if err := settings.GetStruct(settings.UserDataKey, &old); err == nil && old.LegacyID == login.LegacyID {
+		login.Id, login.EmailConfirmed = old.Id, old.EmailConfirmed
+	}
 	a.setData(login)
 	return login, nil
 }
@@ -132,22 +182,15 @@ func (a *Client) SignUp(ctx context.Context, email, password string) ([]byte, *p
 	defer span.End()
 
 	lowerCaseEmail := strings.ToLower(email)
-	salt, err := generateSalt()
-	if err != nil {
-		return nil, nil, traces.RecordError(ctx, err)
-	}
-	srpClient, err := newSRPClient(lowerCaseEmail, password, salt)
-	if err != nil {
-		return nil, nil, traces.RecordError(ctx, err)
-	}
-	verifierKey, err := srpClient.Verifier()
+	salt, verifier, err := newVerifier(lowerCaseEmail, password)
 	if err != nil {
 		return nil, nil, traces.RecordError(ctx, err)
 	}
+	defer clear(verifier)
 	data := &protos.SignupRequest{
 		Email:                 lowerCaseEmail,
 		Salt:                  salt,
-		Verifier:              verifierKey.Bytes(),
+		Verifier:              verifier,
 		SkipEmailConfirmation: true,
 		// Set temp always to true for now
 		// If new user faces any issue while sign up user can sign up again
@@ -195,6 +238,24 @@ var ErrNoSalt = errors.New("no salt available")
 var ErrNotLoggedIn = errors.New("not logged in")
 var ErrInvalidCode = errors.New("invalid code")
 
+// ErrTooManyDevices is returned by Login when the account has reached its device limit. The
+// returned error is a [*TooManyDevicesError] listing the linked devices, one of which must be
+// removed, e.g. with [Client.LoginAndReplaceDevice], before this device can log in.
+var ErrTooManyDevices = errors.New("too many devices")
+
+// TooManyDevicesError is returned by Login when the account has reached its device limit.
+type TooManyDevicesError struct {
+	Devices []settings.Device `json:"devices"`
+}
+
+func (e *TooManyDevicesError) Error() string {
+	return fmt.Sprintf("%v: %d devices linked to the account", ErrTooManyDevices, len(e.Devices))
+}
+
+func (e *TooManyDevicesError) Unwrap() error {
+	return ErrTooManyDevices
+}
+
 // SignupEmailResendCode requests that the sign-up code be resent via email.
 func (a *Client) SignupEmailResendCode(ctx context.Context, email string) error {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "sign_up_email_resend_code")
@@ -222,11 +283,54 @@ func (a *Client) SignupEmailConfirmation(ctx context.Context, email, code string
 		Code:  code,
 	}
 	_, err := a.sendRequest(ctx, "POST", "/users/signup/complete/email", nil, nil, data)
-	return traces.RecordError(ctx, err)
+	if err != nil {
+		return traces.RecordError(ctx, err)
+	}
+	a.setEmailConfirmed(email)
+	return nil
+}
+
+// ResendEmailVerification resends the code that verifies the logged-in user's email, for users who
+// didn't confirm it when signing up. The code is then passed to VerifyEmail.
+func (a *Client) ResendEmailVerification(ctx context.Context) error {
+	email := settings.GetString(settings.EmailKey)
+	if email == "" {
+		return ErrNotLoggedIn
+	}
+	return a.SignupEmailResendCode(ctx, email)
+}
+
+// VerifyEmail verifies the logged-in user's email with the code sent by ResendEmailVerification or
+// at sign-up.
+func (a *Client) VerifyEmail(ctx context.Context, code string) error {
+	email := settings.GetString(settings.EmailKey)
+	if email == "" {
+		return ErrNotLoggedIn
+	}
+	return a.SignupEmailConfirmation(ctx, email, code)
+}
+
+// setEmailConfirmed marks the stored user data's email as confirmed, if it's email.
+func (a *Client) setEmailConfirmed(email string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var data UserData
+	if err := settings.GetStruct(settings.UserDataKey, &data); err != nil || data.LegacyUserData == nil {
+		return
+	}
+	if data.EmailConfirmed || !strings.EqualFold(data.LegacyUserData.Email, email) {
+		return
+	}
+	data.EmailConfirmed = true
+	if err := settings.Set(settings.UserDataKey, &data); err != nil {
+		slog.Error("failed to set user data in settings", "error", err)
+		return
+	}
+	events.Emit(UserChangeEvent{})
 }
 
 func writeSalt(salt []byte, path string) error {
-	if err := os.WriteFile(path, salt, fileperm.File); err != nil {
+	if err := os.WriteFile(path, salt, fileperm.Secret); err != nil {
 		return fmt.Errorf("writing salt to %s: %w", path, err)
 	}
 	return nil
@@ -251,16 +355,12 @@ func (a *Client) Login(ctx context.Context, email, password string) (*UserData,
 	defer span.End()
 
 	lowerCaseEmail := strings.ToLower(email)
-	salt, err := a.getSalt(ctx, lowerCaseEmail)
+	proof, salt, err := a.passwordProof(ctx, lowerCaseEmail, password)
 	if err != nil {
 		return nil, traces.RecordError(ctx, err)
 	}
 
 	deviceID := settings.GetString(settings.DeviceIDKey)
-	proof, err := a.clientProof(ctx, lowerCaseEmail, password, salt)
-	if err != nil {
-		return nil, err
-	}
 
 	loginData := &protos.LoginRequest{
 		Email:    lowerCaseEmail,
@@ -290,9 +390,37 @@ func (a *Client) Login(ctx context.Context, email, password string) (*UserData,
 	}
 	settings.Set(settings.OAuthLoginKey, false)
 	settings.Set(settings.OAuthProviderKey, "")
+	if loginResp.LegacyUserData == nil {
+		tooMany := &TooManyDevicesError{Devices: make([]settings.Device, 0, len(loginResp.Devices))}
+		for _, d := range loginResp.Devices {
+			tooMany.Devices = append(tooMany.Devices, settings.Device{ID: d.Id, Name: d.Name})
+		}
+		return nil, traces.RecordError(ctx, tooMany)
+	}
 	return &loginResp, nil
 }
 
+// LoginAndReplaceDevice logs the user in, first removing deviceToRemove from the account if it has
+// reached its device limit. deviceToRemove must be one of the devices listed by the
+// [*TooManyDevicesError] Login returned; if it isn't, nothing is removed.
+func (a *Client) LoginAndReplaceDevice(ctx context.Context, email, password, deviceToRemove string) (*UserData, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "login_and_replace_device")
+	defer span.End()
+
+	userData, err := a.Login(ctx, email, password)
+	var tooMany *TooManyDevicesError
+	if !errors.As(err, &tooMany) {
+		return userData, err
+	}
+	if !slices.ContainsFunc(tooMany.Devices, func(d settings.Device) bool { return d.ID == deviceToRemove }) {
+		return nil, traces.RecordError(ctx, fmt.Errorf("device %q isn't linked to the account: %w", deviceToRemove, err))
+	}
+	if _, err := a.RemoveDevice(ctx, deviceToRemove); err != nil {
+		return nil, err
+	}
+	return a.Login(ctx, email, password)
+}
+
 // Logout logs the user out. No-op if there is no user account logged in.
 func (a *Client) Logout(ctx context.Context, email string) (*UserData, error) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "logout")
@@ -338,24 +466,17 @@ func (a *Client) CompleteRecoveryByEmail(ctx context.Context, email, newPassword
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "complete_recovery_by_email")
 	defer span.End()
 	lowerCaseEmail := strings.ToLower(email)
-	newSalt, err := generateSalt()
-	if err != nil {
-		return traces.RecordError(ctx, err)
-	}
-	srpClient, err := newSRPClient(lowerCaseEmail, newPassword, newSalt)
-	if err != nil {
-		return traces.RecordError(ctx, err)
-	}
-	verifierKey, err := srpClient.Verifier()
+	newSalt, verifier, err := newVerifier(lowerCaseEmail, newPassword)
 	if err != nil {
 		return traces.RecordError(ctx, err)
 	}
+	defer clear(verifier)
 
 	data := &protos.CompleteRecoveryByEmailRequest{
 		Email:       lowerCaseEmail,
 		Code:        code,
 		NewSalt:     newSalt,
-		NewVerifier: verifierKey.Bytes(),
+		NewVerifier: verifier,
 	}
 	_, err = a.sendRequest(ctx, "POST", "/users/recovery/complete/email", nil, nil, data)
 	if err != nil {
@@ -394,12 +515,7 @@ func (a *Client) ValidateEmailRecoveryCode(ctx context.Context, email, code stri
 func (a *Client) VerifyPassword(ctx context.Context, email, password string) ([]byte, error) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "verify_password")
 	defer span.End()
-	lowerCaseEmail := strings.ToLower(email)
-	salt, err := a.getSalt(ctx, lowerCaseEmail)
-	if err != nil {
-		return nil, traces.RecordError(ctx, err)
-	}
-	proof, err := a.clientProof(ctx, lowerCaseEmail, password, salt)
+	proof, _, err := a.passwordProof(ctx, strings.ToLower(email), password)
 	if err != nil {
 		return nil, traces.RecordError(ctx, err)
 	}
@@ -414,7 +530,7 @@ func (a *Client) StartChangeEmail(ctx context.Context, newEmail, password string
 	lowerCaseEmail := strings.ToLower(settings.GetString(settings.EmailKey))
 	lowerCaseNewEmail := strings.ToLower(newEmail)
 
-	proof, err := a.VerifyPassword(ctx, lowerCaseEmail, password)
+	proof, _, err := a.passwordProof(ctx, lowerCaseEmail, password)
 	if err != nil {
 		return traces.RecordError(ctx, err)
 	}
@@ -434,27 +550,19 @@ func (a *Client) CompleteChangeEmail(ctx context.Context, newEmail, password, co
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "complete_change_email")
 	defer span.End()
 
-	newSalt, err := generateSalt()
-	if err != nil {
-		return traces.RecordError(ctx, err)
-	}
-
 	newEmail = strings.ToLower(newEmail)
-	srpClient, err := newSRPClient(newEmail, password, newSalt)
-	if err != nil {
-		return traces.RecordError(ctx, err)
-	}
-	verifierKey, err := srpClient.Verifier()
+	newSalt, verifier, err := newVerifier(newEmail, password)
 	if err != nil {
 		return traces.RecordError(ctx, err)
 	}
+	defer clear(verifier)
 
 	data := &protos.CompleteChangeEmailRequest{
 		OldEmail:    settings.GetString(settings.EmailKey),
 		NewEmail:    newEmail,
 		Code:        code,
 		NewSalt:     newSalt,
-		NewVerifier: verifierKey.Bytes(),
+		NewVerifier: verifier,
 	}
 	_, err = a.sendRequest(ctx, "POST", "/users/change_email/complete/email", nil, nil, data)
 	if err != nil {
@@ -484,14 +592,10 @@ func (a *Client) DeleteAccount(ctx context.Context, email, password string) (*Us
 		Token:     settings.GetString(settings.JwtTokenKey),
 	}
 	if !settings.GetBool(settings.OAuthLoginKey) {
-		salt, err := a.getSalt(ctx, lowerCaseEmail)
+		proof, _, err := a.passwordProof(ctx, lowerCaseEmail, password)
 		if err != nil {
 			return nil, traces.RecordError(ctx, err)
 		}
-		proof, err := a.clientProof(ctx, lowerCaseEmail, password, salt)
-		if err != nil {
-			return nil, err
-		}
 		data.Proof = proof
 	} else {
 		if data.Token == "" {
@@ -515,6 +619,12 @@ func (a *Client) DeleteAccount(ctx context.Context, email, password string) (*Us
 
 // OAuthLoginURL initiates the OAuth login process for the specified provider.
 func (a *Client) OAuthLoginURL(ctx context.Context, provider string) (string, error) {
+	return a.oauthLoginURL(provider, "lantern://auth")
+}
+
+// oauthLoginURL returns the URL of the OAuth login with provider, which redirects to returnTo
+// once the user has signed in.
+func (a *Client) oauthLoginURL(provider, returnTo string) (string, error) {
 	authURL := a.authURL
 	if authURL == "" {
 		authURL = common.GetBaseURL()
@@ -527,7 +637,7 @@ func (a *Client) OAuthLoginURL(ctx context.Context, provider string) (string, er
 	query.Set("deviceId", settings.GetString(settings.DeviceIDKey))
 	query.Set("userId", settings.GetString(settings.UserIDKey))
 	query.Set("proToken", settings.GetString(settings.TokenKey))
-	query.Set("returnTo", "lantern://auth")
+	query.Set("returnTo", returnTo)
 	loginURL.RawQuery = query.Encode()
 	// Persist the provider so it's available after the callback completes.
 	if err := settings.Set(settings.OAuthProviderKey, provider); err != nil {
@@ -600,6 +710,63 @@ func (a *Client) RemoveDevice(ctx context.Context, deviceID string) (*LinkRespon
 	return &link, nil
 }
 
+// LinkCodeResponse holds a code that links another device to the user's account.
+type LinkCodeResponse struct {
+	*protos.BaseResponse `json:",inline"`
+	Code                 string `json:"code"`
+	// ExpireAt is when the code expires, in Unix seconds.
+	ExpireAt int64 `json:"expireAt"`
+}
+
+// RequestLinkCode requests a short code that another device can redeem with RedeemLinkCode to be
+// linked to the logged-in user's account, without the user entering their password on it.
+func (a *Client) RequestLinkCode(ctx context.Context) (*LinkCodeResponse, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "request_link_code")
+	defer span.End()
+
+	resp, err := a.sendProRequest(ctx, "POST", "/link-code-request", nil, nil, nil)
+	if err != nil {
+		return nil, traces.RecordError(ctx, err)
+	}
+	var code LinkCodeResponse
+	if err := json.Unmarshal(resp, &code); err != nil {
+		return nil, traces.RecordError(ctx, fmt.Errorf("error unmarshalling link code response: %w", err))
+	}
+	if code.BaseResponse != nil && code.BaseResponse.Error != "" {
+		return nil, traces.RecordError(ctx, fmt.Errorf("failed to request link code: %s", code.BaseResponse.Error))
+	}
+	return &code, nil
+}
+
+// RedeemLinkCode links this device, under deviceName, to the account of the user who requested
+// code with RequestLinkCode, and logs the user in.
+func (a *Client) RedeemLinkCode(ctx context.Context, code, deviceName string) (*UserData, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "redeem_link_code")
+	defer span.End()
+
+	data := map[string]string{
+		"code":       code,
+		"deviceName": deviceName,
+	}
+	resp, err := a.sendProRequest(ctx, "POST", "/link-code-redeem", nil, nil, data)
+	if err != nil {
+		return nil, traces.RecordError(ctx, err)
+	}
+	var link LinkResponse
+	if err := json.Unmarshal(resp, &link); err != nil {
+		return nil, traces.RecordError(ctx, fmt.Errorf("error unmarshalling redeem link code response: %w", err))
+	}
+	if link.BaseResponse != nil && link.BaseResponse.Error != "" {
+		return nil, traces.RecordError(ctx, fmt.Errorf("failed to redeem link code: %s", link.BaseResponse.Error))
+	}
+	if link.UserID == 0 || link.ProToken == "" {
+		return nil, traces.RecordError(ctx, errors.New("no user in redeem link code response"))
+	}
+	// Switch to the linked user before fetching their data.
+	a.setData(&UserData{LegacyID: int64(link.UserID), LegacyToken: link.ProToken})
+	return a.fetchUserData(ctx)
+}
+
 type ReferralAttachResponse struct {
 	*protos.BaseResponse `json:",inline"`
 	Providers            map[string][]*protos.PaymentMethod `json:"providers"`
@@ -609,14 +776,55 @@ type ReferralAttachResponse struct {
 	Type                 string                             `json:"referralType"`
 }
 
+// ReferralInfo is the user's referral code and the users who signed up with it.
+type ReferralInfo struct {
+	Code      string                                    `json:"code"`
+	Referrals []*protos.LoginResponse_UserData_Referral `json:"referrals"`
+	// BonusDays and BonusMonths are the Pro time earned from referrals.
+	BonusDays   string `json:"bonusDays,omitempty"`
+	BonusMonths string `json:"bonusMonths,omitempty"`
+}
+
+// ReferralInfo fetches the user data and returns the user's referral code and referrals.
+func (a *Client) ReferralInfo(ctx context.Context) (*ReferralInfo, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "referral_info")
+	defer span.End()
+
+	user, err := a.fetchUserData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data := user.LegacyUserData
+	return &ReferralInfo{
+		Code:        data.Code,
+		Referrals:   data.Referrals,
+		BonusDays:   data.BonusDays,
+		BonusMonths: data.BonusMonths,
+	}, nil
+}
+
 // ReferralAttach attaches a referral code to the current user. A non-empty
 // channel uses the v2 API, which also returns the plans, providers, and
 // discount for the referral; the legacy v1 API returns only a BaseResponse.
+// The user data is then refreshed, as the referral may extend the user's Pro
+// time, emitting a [UserChangeEvent] if it did.
 func (a *Client) ReferralAttach(ctx context.Context, code, channel string) (*ReferralAttachResponse, error) {
+	var (
+		resp *ReferralAttachResponse
+		err  error
+	)
 	if channel == "" {
-		return a.referralAttachV1(ctx, code)
+		resp, err = a.referralAttachV1(ctx, code)
+	} else {
+		resp, err = a.referralAttachV2(ctx, code, channel)
+	}
+	if err != nil {
+		return nil, err
 	}
-	return a.referralAttachV2(ctx, code, channel)
+	if _, err := a.fetchUserData(ctx); err != nil {
+		slog.Warn("refreshing user data after attaching referral", "error", err)
+	}
+	return resp, nil
 }
 
 func (a *Client) referralAttachV1(ctx context.Context, code string) (*ReferralAttachResponse, error) {
@@ -733,6 +941,11 @@ func (a *Client) setData(data *UserData) {
 		}
 	}
 
+	var old UserData
+	if err := settings.GetStruct(settings.UserDataKey, &old); err == nil && old.LegacyUserData != nil {
+		changed = changed || old.LegacyUserData.Expiration != data.LegacyUserData.Expiration
+	}
+
 	if len(data.Devices) > 0 {
 		devices := []settings.Device{}
 		for _, d := range data.Devices {
@@ -749,6 +962,9 @@ func (a *Client) setData(data *UserData) {
 	if err := settings.Set(settings.UserDataKey, data); err != nil {
 		slog.Error("failed to set login response in settings", "error", err)
 	}
+	if err := settings.Set(settings.UserFetchedKey, time.Now().Unix()); err != nil {
+		slog.Error("failed to set user data fetch time in settings", "error", err)
+	}
 
 	// We only consider the user to have changed if there was a previous user.
 	if existingUser && changed {
@@ -765,6 +981,7 @@ func (a *Client) ClearUser() {
 		settings.DevicesKey,
 		settings.JwtTokenKey,
 		settings.UserDataKey,
+		settings.UserFetchedKey,
 	)
 	if err != nil {
 		slog.Warn("failed to clear user info", "error", err)
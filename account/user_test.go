@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/1Password/srp"
 	"github.com/stretchr/testify/assert"
@@ -17,7 +18,9 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"github.com/getlantern/radiance/account/protos"
+	"github.com/getlantern/radiance/common"
 	"github.com/getlantern/radiance/common/settings"
+	"github.com/getlantern/radiance/events"
 )
 
 // testServer holds server-side SRP state for the mock auth server.
@@ -41,6 +44,18 @@ type testServer struct {
 	referralAttachV2Channel                      string
 	referralAttachV2Error                        string
 	paymentRedirectResponse                      any
+	// tooManyDevices, if set, makes logins fail with the device limit until one is removed.
+	tooManyDevices []*protos.LoginResponse_Device
+	removedDevice  string
+	userLevel      string
+	subscription   *protos.LoginResponse_UserData_SubscriptionData
+	plans          []*protos.Plan
+	expiredToken   string
+	expiration     int64
+	referrals      []*protos.LoginResponse_UserData_Referral
+	// userDataFetches counts requests to /user-data, which fail while userDataDown is set.
+	userDataFetches int
+	userDataDown    bool
 }
 
 func writeProtoResponse(w http.ResponseWriter, msg proto.Message) {
@@ -127,6 +142,14 @@ func newTestServer(t *testing.T) (*httptest.Server, *testServer) {
 	})
 
 	mux.HandleFunc("/users/login", func(w http.ResponseWriter, r *http.Request) {
+		if state.tooManyDevices != nil {
+			writeProtoResponse(w, &protos.LoginResponse{
+				LegacyID:    123,
+				LegacyToken: "test-token",
+				Devices:     state.tooManyDevices,
+			})
+			return
+		}
 		writeProtoResponse(w, &protos.LoginResponse{
 			LegacyUserData: &protos.LoginResponse_UserData{
 				DeviceID: "deviceId",
@@ -166,16 +189,36 @@ func newTestServer(t *testing.T) (*httptest.Server, *testServer) {
 	})
 
 	mux.HandleFunc("/user-data", func(w http.ResponseWriter, r *http.Request) {
+		state.userDataFetches++
+		if state.userDataDown {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if tok := r.Header.Get(common.ProTokenHeader); tok != "" && tok == state.expiredToken {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
 		writeJSONResponse(w, UserDataResponse{
 			BaseResponse: &protos.BaseResponse{},
 			LoginResponse_UserData: &protos.LoginResponse_UserData{
-				UserId: 123,
-				Token:  "test-token",
+				UserId:           123,
+				Token:            "test-token",
+				UserLevel:        state.userLevel,
+				SubscriptionData: state.subscription,
+				Expiration:       state.expiration,
+				Code:             "REF123",
+				Referrals:        state.referrals,
 			},
 		})
 	})
 
 	mux.HandleFunc("/user-link-remove", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			DeviceID string `json:"deviceId"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		state.removedDevice = body.DeviceID
+		state.tooManyDevices = nil
 		writeJSONResponse(w, LinkResponse{
 			BaseResponse: &protos.BaseResponse{},
 			UserID:       123,
@@ -183,6 +226,28 @@ func newTestServer(t *testing.T) (*httptest.Server, *testServer) {
 		})
 	})
 
+	mux.HandleFunc("/link-code-request", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, LinkCodeResponse{
+			BaseResponse: &protos.BaseResponse{},
+			Code:         "123456",
+			ExpireAt:     1700000000,
+		})
+	})
+
+	mux.HandleFunc("/link-code-redeem", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["code"] != "123456" {
+			writeJSONResponse(w, LinkResponse{BaseResponse: &protos.BaseResponse{Error: "invalid code"}})
+			return
+		}
+		writeJSONResponse(w, LinkResponse{
+			BaseResponse: &protos.BaseResponse{},
+			UserID:       456,
+			ProToken:     "linked-token",
+		})
+	})
+
 	mux.HandleFunc("/referral-attach", func(w http.ResponseWriter, r *http.Request) {
 		var body struct {
 			Code string `json:"code"`
@@ -210,9 +275,13 @@ func newTestServer(t *testing.T) (*httptest.Server, *testServer) {
 
 	// Subscription endpoints
 	mux.HandleFunc("/plans-v5", func(w http.ResponseWriter, r *http.Request) {
+		plans := state.plans
+		if plans == nil {
+			plans = []*protos.Plan{{Id: "1y-usd-10", Description: "Pro Plan"}}
+		}
 		writeJSONResponse(w, SubscriptionPlans{
 			BaseResponse: &protos.BaseResponse{},
-			Plans:        []*protos.Plan{{Id: "1y-usd-10", Description: "Pro Plan"}},
+			Plans:        plans,
 		})
 	})
 
@@ -343,6 +412,37 @@ func TestLogin(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestLogin_TooManyDevices(t *testing.T) {
+	email := "test@example.com"
+	ac, state := newTestClientWithSRP(t, email, "password")
+	state.tooManyDevices = []*protos.LoginResponse_Device{
+		{Id: "device-1", Name: "Phone"},
+		{Id: "device-2", Name: "Laptop"},
+	}
+
+	_, err := ac.Login(context.Background(), email, "password")
+	require.ErrorIs(t, err, ErrTooManyDevices)
+	var tooMany *TooManyDevicesError
+	require.ErrorAs(t, err, &tooMany)
+	assert.Equal(t, []settings.Device{{ID: "device-1", Name: "Phone"}, {ID: "device-2", Name: "Laptop"}}, tooMany.Devices)
+	assert.Equal(t, "test-token", settings.GetString(settings.TokenKey), "credentials are needed to remove a device")
+}
+
+func TestLoginAndReplaceDevice(t *testing.T) {
+	email := "test@example.com"
+	ac, state := newTestClientWithSRP(t, email, "password")
+	state.tooManyDevices = []*protos.LoginResponse_Device{{Id: "device-1", Name: "Phone"}}
+
+	_, err := ac.LoginAndReplaceDevice(context.Background(), email, "password", "unknown")
+	require.ErrorIs(t, err, ErrTooManyDevices)
+	assert.Empty(t, state.removedDevice, "no device should be removed")
+
+	userData, err := ac.LoginAndReplaceDevice(context.Background(), email, "password", "device-1")
+	require.NoError(t, err)
+	assert.NotNil(t, userData.LegacyUserData)
+	assert.Equal(t, "device-1", state.removedDevice)
+}
+
 func TestLogout(t *testing.T) {
 	ac, _ := newTestClient(t)
 	settings.Set(settings.DeviceIDKey, "deviceId")
@@ -434,6 +534,20 @@ func TestOAuthLoginCallback_InvalidToken(t *testing.T) {
 	assert.Contains(t, err.Error(), "error decoding JWT")
 }
 
+func TestLinkCode(t *testing.T) {
+	ac, _ := newTestClient(t)
+	code, err := ac.RequestLinkCode(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "123456", code.Code)
+
+	_, err = ac.RedeemLinkCode(context.Background(), "000000", "TV")
+	assert.ErrorContains(t, err, "invalid code")
+
+	_, err = ac.RedeemLinkCode(context.Background(), code.Code, "TV")
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), settings.GetInt64(settings.UserIDKey), "user data should be fetched for the linked user")
+}
+
 func TestReferralAttach_EmptyChannelUsesV1(t *testing.T) {
 	ac, ts := newTestClient(t)
 	resp, err := ac.ReferralAttach(context.Background(), "AFF123", "")
@@ -443,6 +557,35 @@ func TestReferralAttach_EmptyChannelUsesV1(t *testing.T) {
 	assert.Empty(t, ts.referralAttachV2Code)
 }
 
+func TestReferralAttach_RefreshesUserData(t *testing.T) {
+	ac, ts := newTestClient(t)
+	_, err := ac.FetchUserData(context.Background())
+	require.NoError(t, err)
+
+	changed := make(chan struct{}, 1)
+	sub := events.Subscribe(func(UserChangeEvent) { changed <- struct{}{} })
+	defer sub.Unsubscribe()
+
+	ts.expiration = 1900000000
+	_, err = ac.ReferralAttach(context.Background(), "AFF123", "")
+	require.NoError(t, err)
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("no UserChangeEvent after the expiry changed")
+	}
+}
+
+func TestReferralInfo(t *testing.T) {
+	ac, ts := newTestClient(t)
+	ts.referrals = []*protos.LoginResponse_UserData_Referral{{UserId: "456", Converted: true, BonusDaysEarned: 30}}
+	info, err := ac.ReferralInfo(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "REF123", info.Code)
+	require.Len(t, info.Referrals, 1)
+	assert.EqualValues(t, 30, info.Referrals[0].BonusDaysEarned)
+}
+
 func TestReferralAttach_V1Error(t *testing.T) {
 	ac, ts := newTestClient(t)
 	ts.referralAttachV1Error = "invalid code"
@@ -474,3 +617,64 @@ func TestReferralAttach_V2Error(t *testing.T) {
 	assert.Nil(t, resp)
 	assert.Contains(t, err.Error(), "invalid code")
 }
+
+func TestEnsureFresh(t *testing.T) {
+	ac, state := newTestClient(t)
+	settings.Set(settings.TokenKey, "test-token")
+	settings.Set(settings.UserIDKey, "123")
+
+	_, _, err := ac.CachedUserData()
+	assert.ErrorIs(t, err, ErrNoUserData)
+
+	state.userDataDown = true
+	_, err = ac.EnsureFresh(context.Background(), time.Hour)
+	assert.Error(t, err, "nothing to fall back on without cached data")
+
+	state.userDataDown = false
+	data, err := ac.EnsureFresh(context.Background(), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), data.LegacyID)
+
+	cached, fetched, err := ac.CachedUserData()
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), cached.LegacyID)
+	assert.WithinDuration(t, time.Now(), fetched, 2*time.Second)
+
+	fetches := state.userDataFetches
+	_, err = ac.EnsureFresh(context.Background(), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, fetches, state.userDataFetches, "fresh data is served from the cache")
+
+	settings.Set(settings.UserFetchedKey, time.Now().Add(-2*time.Hour).Unix())
+	state.userDataDown = true
+	data, err = ac.EnsureFresh(context.Background(), time.Hour)
+	require.NoError(t, err, "stale data is used while the server is unreachable")
+	assert.Equal(t, int64(123), data.LegacyID)
+	assert.Greater(t, state.userDataFetches, fetches)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	email := "test@example.com"
+	ac, _ := newTestClientWithSRP(t, email, "password")
+	settings.Set(settings.TokenKey, "test-token")
+
+	assert.ErrorIs(t, ac.ResendEmailVerification(context.Background()), ErrNotLoggedIn)
+
+	ac.setData(&UserData{
+		LegacyID:       123,
+		LegacyToken:    "test-token",
+		Id:             email,
+		LegacyUserData: &protos.LoginResponse_UserData{UserId: 123, Email: email},
+	})
+	require.NoError(t, ac.ResendEmailVerification(context.Background()))
+	require.NoError(t, ac.VerifyEmail(context.Background(), "123456"))
+
+	data, _, err := ac.CachedUserData()
+	require.NoError(t, err)
+	assert.True(t, data.EmailConfirmed)
+
+	data, err = ac.FetchUserData(context.Background())
+	require.NoError(t, err)
+	assert.True(t, data.EmailConfirmed, "refreshing user data keeps the verification status")
+	assert.Equal(t, email, data.Id)
+}
@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/getlantern/radiance/events"
+	"github.com/getlantern/radiance/vpn"
+)
+
+// Metrics is a point-in-time snapshot of the daemon's health and traffic, combining everything a
+// dashboard needs into a single call. Traffic fields are zero while the tunnel is disconnected.
+type Metrics struct {
+	Status vpn.VPNStatus `json:"status"`
+	// UptimeSeconds is how long the current tunnel has been connected.
+	UptimeSeconds int64 `json:"uptime_seconds"`
+	// ActiveOutbound is the tag of the outbound currently carrying traffic.
+	ActiveOutbound string `json:"active_outbound,omitempty"`
+
+	BytesUp           int64          `json:"bytes_up"`
+	BytesDown         int64          `json:"bytes_down"`
+	Throughput        vpn.Throughput `json:"throughput"`
+	ActiveConnections int            `json:"active_connections"`
+	DNSConnections    int64          `json:"dns_connections"`
+
+	// URLTestResults maps outbound tags to latency in ms from the most recent URL test run.
+	URLTestResults map[string]uint16 `json:"url_test_results,omitempty"`
+	LastError      *vpn.TunnelError  `json:"last_error,omitempty"`
+
+	HeapBytes  uint64 `json:"heap_bytes"`
+	SysBytes   uint64 `json:"sys_bytes"`
+	Goroutines int    `json:"goroutines"`
+}
+
+func (r *LocalBackend) startURLTestResultListener() {
+	events.SubscribeContext(r.ctx, func(evt vpn.URLTestCompleteEvent) {
+		r.lastURLTest.Store(&evt)
+	})
+}
+
+// Metrics returns a snapshot of tunnel, traffic, and process metrics.
+func (r *LocalBackend) Metrics() Metrics {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	m := Metrics{
+		Status:     r.vpnClient.Status(),
+		LastError:  r.vpnClient.LastError(),
+		HeapBytes:  ms.HeapAlloc,
+		SysBytes:   ms.Sys,
+		Goroutines: runtime.NumGoroutine(),
+	}
+	if evt := r.lastURLTest.Load(); evt != nil {
+		m.URLTestResults = evt.Results
+	}
+	stats, err := r.vpnClient.Stats()
+	if err != nil {
+		return m
+	}
+	m.UptimeSeconds = int64(time.Since(stats.ConnectedAt).Seconds())
+	m.BytesUp = stats.BytesUp
+	m.BytesDown = stats.BytesDown
+	m.DNSConnections = stats.DNSConnections
+	if tp, err := r.vpnClient.Throughput(); err == nil {
+		m.Throughput = tp.Global
+		m.ActiveConnections = tp.ActiveConnections
+	}
+	if tag, err := r.vpnClient.CurrentSelectedServer(); err == nil {
+		m.ActiveOutbound = tag
+	}
+	return m
+}
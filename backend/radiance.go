@@ -3,6 +3,7 @@ package backend
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -13,6 +14,7 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"time"
 
@@ -75,15 +77,18 @@ type LocalBackend struct {
 	stopConnMetrics context.CancelFunc
 	connMetricsMu   sync.Mutex
 
-	dataCapCh   chan *account.DataCapInfo // latest datacap update; nil when stream not running
-	stopDataCap context.CancelFunc
-	dataCapMu   sync.Mutex
+	dataCapCh    chan *account.DataCapInfo // latest datacap update; nil when stream not running
+	dataCapMeter *account.DataCapMeter
+	stopDataCap  context.CancelFunc
+	dataCapMu    sync.Mutex
 
 	stopSelectionHistoryListener context.CancelFunc
 	selectionHistoryMu           sync.Mutex
 	selectionReporter            *selectionReporter
 
 	exhaustionGate exhaustionGate
+
+	lastURLTest atomic.Pointer[vpn.URLTestCompleteEvent]
 }
 
 type Options struct {
@@ -102,6 +107,9 @@ type Options struct {
 	// RADIANCE_* vars from the host process. Entries are set verbatim — no
 	// filtering.
 	EnvOverrides map[string]string
+	// OnConfigFetch, if set, is called after every config fetch attempt. See
+	// [config.Options.OnFetch].
+	OnConfigFetch func(config.FetchInfo)
 }
 
 // NewLocalBackend performs global initialization and returns a new LocalBackend instance.
@@ -177,6 +185,7 @@ func NewLocalBackend(ctx context.Context, opts Options) (*LocalBackend, error) {
 		AccountClient: accountClient,
 		HTTPClient:    kindling.HTTPClient(),
 		Logger:        slog.Default().With("service", "config_handler"),
+		OnFetch:       opts.OnConfigFetch,
 	}
 	r := &LocalBackend{
 		ctx:               ctx,
@@ -191,9 +200,10 @@ func NewLocalBackend(ctx context.Context, opts Options) (*LocalBackend, error) {
 		shutdownFuncs: []func() error{
 			telemetry.Close, kindling.Close,
 		},
-		closeOnce: sync.Once{},
-		deviceID:  platformDeviceID,
-		dataCapCh: make(chan *account.DataCapInfo, 1),
+		closeOnce:    sync.Once{},
+		deviceID:     platformDeviceID,
+		dataCapCh:    make(chan *account.DataCapInfo, 1),
+		dataCapMeter: account.NewDataCapMeter(),
 	}
 	r.sessionHistory = vpn.NewSessionHistory(slog.Default().With("service", "session_history"), r.sessionInfo())
 	r.shutdownFuncs = append(r.shutdownFuncs, func() error { r.sessionHistory.Close(); return nil })
@@ -203,6 +213,7 @@ func NewLocalBackend(ctx context.Context, opts Options) (*LocalBackend, error) {
 
 func (r *LocalBackend) Start() {
 	// eagerly start kindling so it's ready by the time we need to make network requests
+	kindling.SetServerSource(r.srvManager)
 	kindling.Init()
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -228,6 +239,7 @@ func (r *LocalBackend) Start() {
 	r.startVPNStatusListeners()
 	r.startAutoSelectedListener()
 	r.startSessionAutoSelectListener()
+	r.startURLTestResultListener()
 
 	// The server derives the country from the client IP, so it's stable for the
 	// session: react once to record it for issue reports and to apply the
@@ -238,9 +250,27 @@ func (r *LocalBackend) Start() {
 	})
 	// update VPN outbounds when new config is received
 	events.SubscribeContext(r.ctx, func(evt config.NewConfigEvent) {
+		// The config server reports the IP it saw, which identifies the network kindling's
+		// transport stats are kept by.
+		if evt.New != nil {
+			if network := kindling.NetworkForIP(evt.New.IP); network != "" {
+				kindling.SetNetwork(network)
+			}
+		}
 		r.applyConfig(evt.New)
 		go r.prewarmOfflineURLTests("config update")
 	})
+	// Stop using Lantern servers from an expired config, and bring them back once the server
+	// confirms the config again, which may not come with a NewConfigEvent.
+	events.SubscribeContext(r.ctx, func(evt config.ConfigFreshnessEvent) {
+		switch {
+		case evt.New.Expired && !evt.Old.Expired:
+			slog.Warn("Config expired, removing Lantern servers until it is refreshed")
+			r.applyConfig(&config.Config{})
+		case evt.Old.Expired && !evt.New.Expired:
+			r.applyCurrentConfig()
+		}
+	})
 	if r.applyCurrentConfig() {
 		go r.prewarmOfflineURLTests("cached config")
 	}
@@ -254,6 +284,10 @@ func (r *LocalBackend) applyCurrentConfig() bool {
 	if err != nil {
 		return false
 	}
+	if r.confHandler.Freshness().Expired {
+		slog.Warn("Saved config has expired, not using its servers until it is refreshed")
+		return false
+	}
 	setCountryCodeFromConfig(cfg)
 	applyTransportPolicy()
 	r.applyConfig(cfg)
@@ -455,10 +489,11 @@ func (r *LocalBackend) buildIssueReportMetadata() issueReportMetadata {
 
 // ReportIssue allows the user to report an issue with the application. It collects relevant
 // information about the user's environment such as country, device ID, user ID, subscription level,
-// and locale, and log files to include in the report.
+// and locale, and log files to include in the report. If attachDiagnostics is true, the tunnel
+// state and the config fetch and account backend status are included too, with PII scrubbed.
 //
 // ReportIssue is safe to call with a nil receiver.
-func (r *LocalBackend) ReportIssue(issueType issue.IssueType, description, email string, additionalAttachments []string, attachments []*issue.Attachment) error {
+func (r *LocalBackend) ReportIssue(issueType issue.IssueType, description, email string, attachDiagnostics bool, additionalAttachments []string, attachments []*issue.Attachment) error {
 	ctx, span := otel.Tracer(tracerName).Start(context.Background(), "report_issue")
 	defer span.End()
 
@@ -482,6 +517,13 @@ func (r *LocalBackend) ReportIssue(issueType issue.IssueType, description, email
 		Attachments:           attachments,
 		AdditionalAttachments: attachmentPaths,
 	}
+	if attachDiagnostics && r != nil {
+		diag, err := json.MarshalIndent(r.issueDiagnostics(), "", "  ")
+		if err != nil {
+			slog.Warn("Failed to marshal issue diagnostics", "error", err)
+		}
+		report.Diagnostics = diag
+	}
 	if err := meta.reporter.Report(ctx, report); err != nil {
 		slog.Error("Failed to report issue", "error", err)
 		return traces.RecordError(ctx, fmt.Errorf("failed to report issue: %w", err))
@@ -509,6 +551,29 @@ func baseIssueAttachments() []string {
 	return files
 }
 
+// issueDiagnostics is the snapshot of the client's state attached to issue reports.
+type issueDiagnostics struct {
+	CollectedAt     time.Time              `json:"collected_at"`
+	Tunnel          Metrics                `json:"tunnel"`
+	ConfigFreshness config.Freshness       `json:"config_freshness"`
+	ConfigFetches   []config.FetchInfo     `json:"config_fetches"`
+	AccountBackends []account.BreakerState `json:"account_backends"`
+	Features        map[string]bool        `json:"features"`
+	Kindling        kindling.StatusReport  `json:"kindling"`
+}
+
+func (r *LocalBackend) issueDiagnostics() issueDiagnostics {
+	return issueDiagnostics{
+		CollectedAt:     time.Now(),
+		Tunnel:          r.Metrics(),
+		ConfigFreshness: r.ConfigFreshness(),
+		ConfigFetches:   r.ConfigFetchHistory(),
+		AccountBackends: r.AccountBreakerStates(),
+		Features:        r.Features(),
+		Kindling:        r.KindlingStatus(),
+	}
+}
+
 /////////////////
 //  Settings   //
 /////////////////
@@ -519,6 +584,91 @@ func (r *LocalBackend) UpdateConfig() error {
 	return r.confHandler.Fetch()
 }
 
+// ForceRefreshConfig fetches and re-applies the latest configuration, even if it is unchanged.
+// Use it to recover from a suspected bad local config without waiting for the poll interval.
+func (r *LocalBackend) ForceRefreshConfig(ctx context.Context) error {
+	return r.confHandler.ForceRefresh(ctx)
+}
+
+// PreviewConfigUpdate fetches the latest configuration and returns how it differs from the
+// applied one without applying it. Use ApplyConfigPreview to apply it.
+func (r *LocalBackend) PreviewConfigUpdate(ctx context.Context) (*config.ConfigDiff, error) {
+	return r.confHandler.PreviewUpdate(ctx)
+}
+
+// ApplyConfigPreview applies the configuration fetched by the last PreviewConfigUpdate.
+func (r *LocalBackend) ApplyConfigPreview() error {
+	return r.confHandler.ApplyPreview()
+}
+
+// ConfigHistory returns the fetched configs retained for rollback, newest first.
+func (r *LocalBackend) ConfigHistory() ([]config.ConfigVersion, error) {
+	return r.confHandler.History()
+}
+
+// RollbackConfig applies the config with the given version ID, or the one fetched before the
+// current config if id is empty.
+func (r *LocalBackend) RollbackConfig(id string) error {
+	if id == "" {
+		return r.confHandler.Rollback()
+	}
+	return r.confHandler.RollbackTo(id)
+}
+
+// CountryOverride returns the country code config fetches use instead of the IP-derived one, or
+// "" if there is none.
+func (r *LocalBackend) CountryOverride() string {
+	return r.confHandler.CountryOverride()
+}
+
+// SetCountryOverride sets the country config fetches ask for, then refreshes the config. An empty
+// country clears the override.
+func (r *LocalBackend) SetCountryOverride(ctx context.Context, country string) error {
+	return r.confHandler.SetCountryOverride(ctx, country)
+}
+
+// ConfigSources returns every config source in the order they're currently tried, with their
+// health.
+func (r *LocalBackend) ConfigSources() []config.SourceStatus {
+	return r.confHandler.Sources()
+}
+
+// SetConfigSources replaces the config sources used in addition to the Lantern API.
+func (r *LocalBackend) SetConfigSources(sources []config.ConfigSource) error {
+	return r.confHandler.SetSources(sources)
+}
+
+// ConfigFreshness returns how recently the config server confirmed the applied config.
+func (r *LocalBackend) ConfigFreshness() config.Freshness {
+	return r.confHandler.Freshness()
+}
+
+// ConfigFetchHistory returns the most recent config fetch attempts, newest first.
+func (r *LocalBackend) ConfigFetchHistory() []config.FetchInfo {
+	return r.confHandler.FetchHistory()
+}
+
+// KindlingStatus returns which kindling transports served the most recent requests to Lantern's
+// API and config servers, and how each transport has fared.
+func (r *LocalBackend) KindlingStatus() kindling.StatusReport {
+	return kindling.Status()
+}
+
+// Announcements returns the messages and surveys the server currently targets at the user.
+func (r *LocalBackend) Announcements() []config.Announcement {
+	return config.Announcements()
+}
+
+// DismissAnnouncement records that the user dismissed the announcement with the given ID.
+func (r *LocalBackend) DismissAnnouncement(id string) error {
+	return config.DismissAnnouncement(id)
+}
+
+// ConfigFlags returns the server-controlled flags from the latest config as raw JSON values.
+func (r *LocalBackend) ConfigFlags() map[string]json.RawMessage {
+	return config.Flags()
+}
+
 // Features returns the features available in the current configuration, returned from the server in the
 // config response.
 func (r *LocalBackend) Features() map[string]bool {
@@ -706,6 +856,87 @@ func (r *LocalBackend) RemoveServers(tags []string) error {
 	return nil
 }
 
+// RenameServer changes the tag of a user server. A connected tunnel gets the renamed outbound
+// before the old one is removed, and a selection of the server follows it, so traffic through the
+// server isn't interrupted. Profiles listing the server are updated too. A server that isn't in
+// the tunnel, because it's disabled or not in the active profile, is left out of it.
+func (r *LocalBackend) RenameServer(oldTag, newTag string) error {
+	srv, err := r.srvManager.RenameServer(oldTag, newTag)
+	if err != nil {
+		return err
+	}
+	r.renameProfileServer(oldTag, newTag)
+	inTunnel := len(r.tunnelServers([]*servers.Server{srv})) > 0
+	if inTunnel {
+		if err := r.vpnClient.AddOutbounds(servers.ServerList{Servers: []*servers.Server{srv}}); err != nil && !errors.Is(err, vpn.ErrTunnelNotConnected) {
+			return fmt.Errorf("failed to add renamed outbound to VPN client: %w", err)
+		}
+	}
+	var selected servers.Server
+	if err := settings.GetStruct(settings.SelectedServerKey, &selected); err == nil && selected.Tag == oldTag {
+		r.persistSelection(newTag)
+		if inTunnel {
+			if err := r.vpnClient.SelectServer(newTag); err != nil && !errors.Is(err, vpn.ErrTunnelNotConnected) {
+				slog.Warn("Failed to select renamed server", "tag", newTag, "error", err)
+			}
+		}
+	}
+	if !inTunnel {
+		return nil
+	}
+	if err := r.vpnClient.RemoveOutbounds([]string{oldTag}); err != nil && !errors.Is(err, vpn.ErrTunnelNotConnected) {
+		return fmt.Errorf("failed to remove old outbound: %w", err)
+	}
+	return nil
+}
+
+// renameProfileServer replaces oldTag with newTag in the profiles listing it.
+func (r *LocalBackend) renameProfileServer(oldTag, newTag string) {
+	profiles, err := r.confHandler.Profiles()
+	if err != nil {
+		slog.Error("Failed to load profiles to rename server", "error", err)
+		return
+	}
+	for _, p := range profiles {
+		i := slices.Index(p.Servers, oldTag)
+		if i < 0 {
+			continue
+		}
+		p.Servers[i] = newTag
+		if err := r.confHandler.SaveProfile(p); err != nil {
+			slog.Error("Failed to rename server in profile", "profile", p.Name, "error", err)
+		}
+	}
+}
+
+// SetServerMetadata replaces the labels, notes, and color of a user server.
+func (r *LocalBackend) SetServerMetadata(tag string, md servers.ServerMetadata) error {
+	return r.srvManager.SetServerMetadata(tag, md)
+}
+
+// SetServerDisabled disables or enables a user server. A disabled server is taken out of the
+// tunnel, and an enabled one added back.
+func (r *LocalBackend) SetServerDisabled(tag string, disabled bool) error {
+	if err := r.srvManager.SetServerDisabled(tag, disabled); err != nil {
+		return err
+	}
+	if disabled {
+		r.clearSelectedIfMissing()
+		if err := r.vpnClient.RemoveOutbounds([]string{tag}); err != nil && !errors.Is(err, vpn.ErrTunnelNotConnected) {
+			return fmt.Errorf("failed to remove outbound: %w", err)
+		}
+		return nil
+	}
+	srv, found := r.srvManager.GetServerByTag(tag)
+	if !found {
+		return nil
+	}
+	if err := r.vpnClient.AddOutbounds(servers.ServerList{Servers: []*servers.Server{srv}}); err != nil && !errors.Is(err, vpn.ErrTunnelNotConnected) {
+		return fmt.Errorf("failed to add outbound: %w", err)
+	}
+	return nil
+}
+
 func (r *LocalBackend) AddServers(list servers.ServerList) error {
 	if err := r.srvManager.AddServers(list, false); err != nil {
 		return fmt.Errorf("failed to add servers to ServerManager: %w", err)
@@ -716,6 +947,27 @@ func (r *LocalBackend) AddServers(list servers.ServerList) error {
 	return nil
 }
 
+// AddServersBatch adds many servers at once, replacing or skipping those whose tag is already in
+// use, with a single save and a single update of the tunnel.
+func (r *LocalBackend) AddServersBatch(list servers.ServerList, replace bool) (servers.BatchResult, error) {
+	res, err := r.srvManager.AddServersBatch(list, replace)
+	if err != nil {
+		return res, err
+	}
+	changed := append(slices.Clone(res.Added), res.Replaced...)
+	list.Servers = slices.DeleteFunc(slices.Clone(list.Servers), func(srv *servers.Server) bool {
+		return !slices.Contains(changed, srv.Tag)
+	})
+	// Servers may come in disabled, such as from a backup, or be outside the active profile.
+	list.Servers = r.tunnelServers(list.Servers)
+	if err := r.vpnClient.AddOutbounds(list); err != nil && !errors.Is(err, vpn.ErrTunnelNotConnected) {
+		return res, fmt.Errorf("failed to add outbounds to VPN client: %w", err)
+	}
+	r.maybeProbeServers(changed)
+	r.maybeLocateServers(changed)
+	return res, nil
+}
+
 func (r *LocalBackend) AddServersByJSON(config string) ([]string, error) {
 	list, err := r.srvManager.AddServersByJSON(r.ctx, []byte(config))
 	if err != nil {
@@ -724,6 +976,8 @@ func (r *LocalBackend) AddServersByJSON(config string) ([]string, error) {
 	if err := r.vpnClient.AddOutbounds(*list); err != nil && !errors.Is(err, vpn.ErrTunnelNotConnected) {
 		return nil, fmt.Errorf("failed to add outbounds to VPN client: %w", err)
 	}
+	r.maybeProbeServers(list.Tags())
+	r.maybeLocateServers(list.Tags())
 	return list.Tags(), nil
 }
 
@@ -735,11 +989,86 @@ func (r *LocalBackend) AddServersByURL(urls []string, skipCertVerification bool)
 	if err := r.vpnClient.AddOutbounds(*list); err != nil && !errors.Is(err, vpn.ErrTunnelNotConnected) {
 		return nil, fmt.Errorf("failed to add outbounds to VPN client: %w", err)
 	}
+	r.maybeProbeServers(list.Tags())
+	r.maybeLocateServers(list.Tags())
 	return list.Tags(), nil
 }
 
+// ServerQRPayload returns the QR payload that shares the user server with the given tag.
+func (r *LocalBackend) ServerQRPayload(tag string) (string, error) {
+	return r.srvManager.ServerQRPayload(tag)
+}
+
+// ExportServer returns the user server with the given tag in the given format.
+func (r *LocalBackend) ExportServer(tag string, format servers.ExportFormat) (string, error) {
+	return r.srvManager.ExportServer(tag, format)
+}
+
+// AddServersByQRPayload adds the server or private server invite in a scanned QR payload and
+// returns the tags of the added servers.
+func (r *LocalBackend) AddServersByQRPayload(payload string, skipCertVerification bool) ([]string, error) {
+	inv, isInvite, err := servers.ParsePrivateServerInvite(payload)
+	if err != nil {
+		return nil, err
+	}
+	if !isInvite {
+		return r.AddServersByURL([]string{payload}, skipCertVerification)
+	}
+	if inv.Fingerprint != "" {
+		if err := r.srvManager.PinTrustedFingerprint(inv.IP, inv.Fingerprint); err != nil {
+			return nil, fmt.Errorf("failed to trust private server certificate: %w", err)
+		}
+	}
+	if err := r.AddPrivateServer(inv.Tag, inv.IP, inv.Port, inv.Token, C.ServerLocation{}, true); err != nil {
+		return nil, fmt.Errorf("failed to join private server: %w", err)
+	}
+	return []string{inv.Tag}, nil
+}
+
 func (r *LocalBackend) AddPrivateServer(tag, ip string, port int, accessToken string, loc C.ServerLocation, joined bool) error {
-	return r.srvManager.AddPrivateServer(tag, ip, port, accessToken, loc, joined)
+	if err := r.srvManager.AddPrivateServer(tag, ip, port, accessToken, loc, joined); err != nil {
+		return err
+	}
+	r.maybeProbeServers([]string{tag})
+	r.maybeLocateServers([]string{tag})
+	return nil
+}
+
+// ProbeServers checks whether the user servers with the given tags accept connections. The
+// results are also stored with the servers.
+func (r *LocalBackend) ProbeServers(tags []string) (map[string]servers.Reachability, error) {
+	return r.srvManager.ProbeServers(r.ctx, tags)
+}
+
+// maybeProbeServers probes newly added servers if [settings.ProbeServersOnAddKey] is set, so the
+// UI can warn about an unreachable server before it's selected. A failed probe doesn't fail the
+// add.
+func (r *LocalBackend) maybeProbeServers(tags []string) {
+	if len(tags) == 0 || !settings.GetBool(settings.ProbeServersOnAddKey) {
+		return
+	}
+	if _, err := r.srvManager.ProbeServers(r.ctx, tags); err != nil {
+		slog.Warn("Failed to probe added servers", "tags", tags, "error", err)
+	}
+}
+
+// maybeLocateServers sets the locations of newly added servers that have none from the GeoIP
+// database at [settings.ServerGeoIPDatabaseKey], if set, so they can be grouped by country and city.
+// Servers that can't be located are left as they are.
+func (r *LocalBackend) maybeLocateServers(tags []string) {
+	path := settings.GetString(settings.ServerGeoIPDatabaseKey)
+	if len(tags) == 0 || path == "" {
+		return
+	}
+	locator, err := servers.OpenMMDBLocator(path)
+	if err != nil {
+		slog.Warn("Failed to open GeoIP database", "path", path, "error", err)
+		return
+	}
+	defer locator.Close()
+	if _, err := r.srvManager.LocateServers(r.ctx, locator, tags); err != nil {
+		slog.Warn("Failed to locate added servers", "tags", tags, "error", err)
+	}
 }
 
 func (r *LocalBackend) InviteToPrivateServer(ip string, port int, accessToken string, inviteName string) (string, error) {
@@ -750,6 +1079,39 @@ func (r *LocalBackend) RevokePrivateServerInvite(ip string, port int, accessToke
 	return r.srvManager.RevokePrivateServerInvite(ip, port, accessToken, inviteName)
 }
 
+// RemovePrivateServer removes the user servers of the private server at ip, along with its trusted
+// certificate fingerprint, and takes them out of the tunnel.
+func (r *LocalBackend) RemovePrivateServer(ip string) error {
+	removed, err := r.srvManager.RemovePrivateServer(ip)
+	if len(removed) == 0 {
+		return err
+	}
+	tags := make([]string, 0, len(removed))
+	for _, srv := range removed {
+		tags = append(tags, srv.Tag)
+	}
+	r.clearSelectedIfMissing()
+	if rerr := r.vpnClient.RemoveOutbounds(tags); rerr != nil && !errors.Is(rerr, vpn.ErrTunnelNotConnected) {
+		return errors.Join(err, fmt.Errorf("failed to remove outbounds: %w", rerr))
+	}
+	return err
+}
+
+// TrustedFingerprints returns the trusted private server certificate fingerprints.
+func (r *LocalBackend) TrustedFingerprints() ([]servers.TrustedFingerprint, error) {
+	return r.srvManager.TrustedFingerprints()
+}
+
+// RemoveTrustedFingerprint forgets the trusted fingerprint of the private server at ip.
+func (r *LocalBackend) RemoveTrustedFingerprint(ip string) error {
+	return r.srvManager.RemoveTrustedFingerprint(ip)
+}
+
+// ReplaceTrustedFingerprint trusts the given certificate fingerprint for the private server at ip.
+func (r *LocalBackend) ReplaceTrustedFingerprint(ip, fingerprint string) error {
+	return r.srvManager.ReplaceTrustedFingerprint(ip, fingerprint)
+}
+
 // maxRetainedLanternServers caps the number of working Lantern servers retained
 // across config updates.
 const maxRetainedLanternServers = 60
@@ -864,7 +1226,7 @@ func (r *LocalBackend) clearSelectedIfMissing() {
 	if err := settings.GetStruct(settings.SelectedServerKey, &selected); err != nil {
 		return
 	}
-	if _, found := r.srvManager.GetServerByTag(selected.Tag); found {
+	if srv, found := r.srvManager.GetServerByTag(selected.Tag); found && !srv.Disabled {
 		return
 	}
 	// Persist before notifying the VPN client so the auto-select choice
@@ -951,6 +1313,27 @@ func (r *LocalBackend) flushSelectionHistory(storage vpn.AutoSelectHistoryStorag
 	if err := r.srvManager.UpdateSelectionHistory(history); err != nil {
 		slog.Warn("Failed to persist selection history", "error", err)
 	}
+	r.disableDeadServers()
+}
+
+// disableDeadServers disables user servers that have been failing for longer than
+// [settings.DisableDeadServersAfterKey] and takes them out of the tunnel.
+func (r *LocalBackend) disableDeadServers() {
+	maxAge := settings.GetDuration(settings.DisableDeadServersAfterKey)
+	if maxAge <= 0 {
+		return
+	}
+	tags, err := r.srvManager.DisableDeadServers(maxAge)
+	if err != nil {
+		slog.Warn("Failed to disable dead servers", "error", err)
+	}
+	if len(tags) == 0 {
+		return
+	}
+	r.clearSelectedIfMissing()
+	if err := r.vpnClient.RemoveOutbounds(tags); err != nil && !errors.Is(err, vpn.ErrTunnelNotConnected) {
+		slog.Warn("Failed to remove disabled servers from the tunnel", "tags", tags, "error", err)
+	}
 }
 
 func (r *LocalBackend) collectSelectionHistory(storage vpn.AutoSelectHistoryStorage) map[string]servers.SelectionHistory {
@@ -1062,7 +1445,7 @@ func (r *LocalBackend) getBoxOptions() vpn.BoxOptions {
 			bOptions.AdBlock = cfg.AdBlock
 		}
 	}
-	managedServers := r.srvManager.AllServers()
+	managedServers := r.tunnelServers(r.srvManager.AllServers())
 	appendManagedServerOptions(&bOptions.Options, managedServers)
 
 	seed := make(map[string]lbA.TagHistory)
@@ -1369,6 +1752,111 @@ func (r *LocalBackend) RemoveSplitTunnelItems(items vpn.SplitTunnelFilter) error
 	return r.splitTunnelMgr.RemoveItems(items)
 }
 
+//////////////
+// Profiles //
+//////////////
+
+// Profiles returns all saved profiles.
+func (r *LocalBackend) Profiles() ([]config.Profile, error) {
+	return r.confHandler.Profiles()
+}
+
+// ActiveProfile returns the active profile, or nil if none has been switched to.
+func (r *LocalBackend) ActiveProfile() (*config.Profile, error) {
+	return r.confHandler.ActiveProfile()
+}
+
+// SaveProfile saves the current preferred location, split tunnel rules, and DNS server as the
+// named profile. servers lists the tags of user-added servers available in the profile; if empty,
+// all are available. Saving the active profile re-applies it.
+func (r *LocalBackend) SaveProfile(name string, servers []string) error {
+	p := r.snapshotProfile(config.Profile{Name: name, Servers: servers})
+	if err := r.confHandler.SaveProfile(p); err != nil {
+		return err
+	}
+	if name == settings.GetString(settings.ActiveProfileKey) {
+		return r.applyProfile(name)
+	}
+	return nil
+}
+
+// DeleteProfile removes the named profile. The active profile can't be deleted.
+func (r *LocalBackend) DeleteProfile(name string) error {
+	return r.confHandler.DeleteProfile(name)
+}
+
+// SwitchProfile makes the named profile active and applies it, restarting the VPN if it is
+// connected. Changes made since switching to the previous profile are saved to it first.
+func (r *LocalBackend) SwitchProfile(name string) error {
+	prev, err := r.confHandler.ActiveProfile()
+	if err != nil {
+		return err
+	}
+	if prev != nil && prev.Name != name {
+		if err := r.confHandler.SaveProfile(r.snapshotProfile(*prev)); err != nil {
+			slog.Error("Failed to save profile before switching", "profile", prev.Name, "error", err)
+		}
+	}
+	return r.applyProfile(name)
+}
+
+func (r *LocalBackend) applyProfile(name string) error {
+	p, err := r.confHandler.SwitchProfile(name)
+	if err != nil {
+		return err
+	}
+	if err := r.splitTunnelMgr.SetItems(vpn.SplitTunnelFilterByType(p.SplitTunnel)); err != nil {
+		return fmt.Errorf("applying split tunnel rules for profile %s: %w", name, err)
+	}
+	if err := r.PatchSettings(settings.Settings{settings.SplitTunnelKey: p.SplitTunnelEnabled}); err != nil {
+		return err
+	}
+	var selected servers.Server
+	if err := settings.GetStruct(settings.SelectedServerKey, &selected); err == nil &&
+		selected.Tag != "" && !selected.IsLantern && !p.HasServer(selected.Tag) {
+		r.persistSelection(vpn.AutoSelectTag)
+	}
+	if r.vpnClient.Status() == vpn.Connected {
+		slog.Info("Restarting VPN to apply profile", "profile", name)
+		if err := r.RestartVPN(); err != nil {
+			return fmt.Errorf("failed to restart VPN for profile %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// snapshotProfile returns p with its settings replaced by the current ones. p.Servers is kept as
+// is since it is only ever set explicitly.
+func (r *LocalBackend) snapshotProfile(p config.Profile) config.Profile {
+	if err := settings.GetStruct(settings.PreferredLocationKey, &p.PreferredLocation); err != nil {
+		slog.Error("Failed to get preferred location from settings", "error", err)
+	}
+	p.SplitTunnelEnabled = r.splitTunnelMgr.IsEnabled()
+	p.SplitTunnel = r.splitTunnelMgr.Filters().ByType()
+	p.DNSServer = settings.GetString(settings.LocalDNSServerKey)
+	return p
+}
+
+// profileServers filters out user-added servers that aren't available in the active profile.
+func (r *LocalBackend) profileServers(all []*servers.Server) []*servers.Server {
+	p, err := r.confHandler.ActiveProfile()
+	if err != nil {
+		slog.Error("Failed to load active profile", "error", err)
+	}
+	if p == nil {
+		return all
+	}
+	return slices.DeleteFunc(all, func(srv *servers.Server) bool {
+		return !srv.IsLantern && !p.HasServer(srv.Tag)
+	})
+}
+
+// tunnelServers returns the servers in all that belong in the tunnel: those that are enabled and
+// available in the active profile.
+func (r *LocalBackend) tunnelServers(all []*servers.Server) []*servers.Server {
+	return slices.DeleteFunc(r.profileServers(all), func(srv *servers.Server) bool { return srv.Disabled })
+}
+
 /////////////
 // Account //
 /////////////
@@ -1381,10 +1869,36 @@ func (r *LocalBackend) Login(ctx context.Context, email, password string) (*acco
 	return r.accountClient.Login(ctx, email, password)
 }
 
+func (r *LocalBackend) LoginAndReplaceDevice(ctx context.Context, email, password, deviceToRemove string) (*account.UserData, error) {
+	return r.accountClient.LoginAndReplaceDevice(ctx, email, password, deviceToRemove)
+}
+
 func (r *LocalBackend) Logout(ctx context.Context, email string) (*account.UserData, error) {
 	return r.accountClient.Logout(ctx, email)
 }
 
+// Accounts returns the accounts stored on this device.
+func (r *LocalBackend) Accounts() []account.StoredAccount {
+	return r.accountClient.Accounts()
+}
+
+// AddAccount stores the active account and replaces it with a new anonymous user, so the user can
+// log in to another account.
+func (r *LocalBackend) AddAccount(ctx context.Context) (*account.UserData, error) {
+	return r.accountClient.AddAccount(ctx)
+}
+
+// SwitchAccount makes the stored account with the given user ID the active one. The config is
+// refetched for it.
+func (r *LocalBackend) SwitchAccount(ctx context.Context, userID string) error {
+	return r.accountClient.SwitchAccount(ctx, userID)
+}
+
+// RemoveAccount forgets the stored account with the given user ID.
+func (r *LocalBackend) RemoveAccount(userID string) error {
+	return r.accountClient.RemoveAccount(userID)
+}
+
 func (r *LocalBackend) FetchUserData(ctx context.Context) (*account.UserData, error) {
 	return r.accountClient.FetchUserData(ctx)
 }
@@ -1418,6 +1932,14 @@ func (r *LocalBackend) SignUp(ctx context.Context, email, password string) ([]by
 	return r.accountClient.SignUp(ctx, email, password)
 }
 
+func (r *LocalBackend) ResendEmailVerification(ctx context.Context) error {
+	return r.accountClient.ResendEmailVerification(ctx)
+}
+
+func (r *LocalBackend) VerifyEmail(ctx context.Context, code string) error {
+	return r.accountClient.VerifyEmail(ctx, code)
+}
+
 func (r *LocalBackend) SignupEmailConfirmation(ctx context.Context, email, code string) error {
 	return r.accountClient.SignupEmailConfirmation(ctx, email, code)
 }
@@ -1430,10 +1952,21 @@ func (r *LocalBackend) ValidateEmailRecoveryCode(ctx context.Context, email, cod
 	return r.accountClient.ValidateEmailRecoveryCode(ctx, email, code)
 }
 
+// DataCapInfo fetches the data cap usage from the server. While connected, the usage returned
+// and sent on DataCapUpdates includes the traffic measured through the tunnel since the server's
+// last update.
 func (r *LocalBackend) DataCapInfo(ctx context.Context) (*account.DataCapInfo, error) {
-	return r.accountClient.DataCapInfo(ctx)
+	info, err := r.accountClient.DataCapInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.dataCapMeter.Update(info)
+	return r.dataCapMeter.Info(), nil
 }
 
+// dataCapMeterInterval is how often the tunnel's byte counters are added to the data cap usage.
+const dataCapMeterInterval = 10 * time.Second
+
 // DataCapUpdates returns the channel that receives datacap updates from the
 // upstream SSE stream. The stream runs while the VPN is connected; the channel
 // is never closed so callers should select on it alongside a context or other
@@ -1453,17 +1986,24 @@ func (r *LocalBackend) updateDataCapStream(status vpn.VPNStatus) {
 		r.stopDataCap = cancel
 		go func() {
 			_ = r.accountClient.DataCapStream(ctx, func(info *account.DataCapInfo) {
-				// Non-blocking send; drops stale updates if the reader is slow.
+				r.dataCapMeter.Update(info)
+				r.publishDataCap(r.dataCapMeter.Info())
+			})
+		}()
+		go func() {
+			ticker := time.NewTicker(dataCapMeterInterval)
+			defer ticker.Stop()
+			for {
 				select {
-				case r.dataCapCh <- info:
-				default:
-					select {
-					case <-r.dataCapCh:
-					default:
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					up, down, ok := r.vpnClient.Bytes()
+					if ok && r.dataCapMeter.ObserveTunnelBytes(up, down) {
+						r.publishDataCap(r.dataCapMeter.Info())
 					}
-					r.dataCapCh <- info
 				}
-			})
+			}
 		}()
 		slog.Debug("Started datacap SSE stream")
 	} else if r.stopDataCap != nil {
@@ -1473,10 +2013,49 @@ func (r *LocalBackend) updateDataCapStream(status vpn.VPNStatus) {
 	}
 }
 
+// publishDataCap sends info on dataCapCh without blocking, dropping the stale update if the reader
+// is slow.
+func (r *LocalBackend) publishDataCap(info *account.DataCapInfo) {
+	r.dataCapMu.Lock()
+	defer r.dataCapMu.Unlock()
+	select {
+	case r.dataCapCh <- info:
+	default:
+		select {
+		case <-r.dataCapCh:
+		default:
+		}
+		r.dataCapCh <- info
+	}
+}
+
 func (r *LocalBackend) RemoveDevice(ctx context.Context, deviceID string) (*account.LinkResponse, error) {
 	return r.accountClient.RemoveDevice(ctx, deviceID)
 }
 
+// Entitlements returns what the user's plan allows, derived from the stored user data, the last
+// data cap usage reported, and the features enabled in the config.
+func (r *LocalBackend) Entitlements() *account.Entitlements {
+	userData, _, err := r.accountClient.CachedUserData()
+	if err != nil && !errors.Is(err, account.ErrNoUserData) {
+		slog.Warn("Failed to get user data for entitlements", "error", err)
+	}
+	return account.NewEntitlements(userData, r.dataCapMeter.Info(), r.Features())
+}
+
+// AccountBreakerStates returns the state of the circuit breakers of the account backends.
+func (r *LocalBackend) AccountBreakerStates() []account.BreakerState {
+	return r.accountClient.BreakerStates()
+}
+
+func (r *LocalBackend) RequestLinkCode(ctx context.Context) (*account.LinkCodeResponse, error) {
+	return r.accountClient.RequestLinkCode(ctx)
+}
+
+func (r *LocalBackend) RedeemLinkCode(ctx context.Context, code, deviceName string) (*account.UserData, error) {
+	return r.accountClient.RedeemLinkCode(ctx, code, deviceName)
+}
+
 func (r *LocalBackend) OAuthLoginCallback(ctx context.Context, oAuthToken string) (*account.UserData, error) {
 	return r.accountClient.OAuthLoginCallback(ctx, oAuthToken)
 }
@@ -1485,6 +2064,19 @@ func (r *LocalBackend) OAuthLoginURL(ctx context.Context, provider string) (stri
 	return r.accountClient.OAuthLoginURL(ctx, provider)
 }
 
+func (r *LocalBackend) StartOAuthLogin(ctx context.Context, provider string) (string, error) {
+	return r.accountClient.StartOAuthLogin(ctx, provider)
+}
+
+func (r *LocalBackend) CompleteOAuthLogin(ctx context.Context) (*account.UserData, error) {
+	return r.accountClient.CompleteOAuthLogin(ctx)
+}
+
+// EnsureFreshUserData returns the stored user data, fetching it first if it's older than maxAge.
+func (r *LocalBackend) EnsureFreshUserData(ctx context.Context, maxAge time.Duration) (*account.UserData, error) {
+	return r.accountClient.EnsureFresh(ctx, maxAge)
+}
+
 func (r *LocalBackend) UserDevices() ([]settings.Device, error) {
 	return settings.Devices()
 }
@@ -1517,6 +2109,14 @@ func (r *LocalBackend) ReferralAttach(ctx context.Context, code, channel string)
 	return r.accountClient.ReferralAttach(ctx, code, channel)
 }
 
+func (r *LocalBackend) ReferralInfo(ctx context.Context) (*account.ReferralInfo, error) {
+	return r.accountClient.ReferralInfo(ctx)
+}
+
+func (r *LocalBackend) Subscription(ctx context.Context) (*account.SubscriptionData, error) {
+	return r.accountClient.Subscription(ctx)
+}
+
 func (r *LocalBackend) StripeBillingPortalURL(ctx context.Context) (string, error) {
 	return r.accountClient.StripeBillingPortalURL(ctx,
 		common.GetProServerURL(), settings.GetString(settings.UserIDKey), settings.GetString(settings.TokenKey),
@@ -1531,6 +2131,12 @@ func (r *LocalBackend) SubscriptionPlans(ctx context.Context, channel string) (s
 	return r.accountClient.SubscriptionPlans(ctx, channel)
 }
 
+// QuotePlanChange quotes switching to the plan with the given ID, crediting the time left on
+// the current subscription.
+func (r *LocalBackend) QuotePlanChange(ctx context.Context, channel, planID, currency string) (*account.PlanQuote, error) {
+	return r.accountClient.QuotePlanChange(ctx, channel, planID, currency)
+}
+
 func (r *LocalBackend) VerifySubscription(ctx context.Context, service account.SubscriptionService, data map[string]string) (string, error) {
 	return r.accountClient.VerifySubscription(ctx, service, data)
 }
@@ -3,14 +3,18 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"golang.org/x/term"
 
+	"github.com/getlantern/radiance/account"
+	"github.com/getlantern/radiance/common/settings"
 	"github.com/getlantern/radiance/ipc"
 )
 
@@ -20,9 +24,14 @@ type AccountCmd struct {
 	Signup  *SignupCmd         `arg:"subcommand:signup" help:"create a new account"`
 	Recover *RecoverAccountCmd `arg:"subcommand:recover" help:"recover existing account"`
 
-	Usage    *UsageCmd    `arg:"subcommand:usage" help:"view data usage"`
-	Devices  *DevicesCmd  `arg:"subcommand:devices" help:"manage user devices"`
-	SetEmail *SetEmailCmd `arg:"subcommand:set-email" help:"change account email"`
+	Usage        *UsageCmd        `arg:"subcommand:usage" help:"view data usage"`
+	Entitlements *EntitlementsCmd `arg:"subcommand:entitlements" help:"show what your plan allows"`
+	Devices      *DevicesCmd      `arg:"subcommand:devices" help:"manage user devices"`
+	Accounts     *AccountsCmd     `arg:"subcommand:accounts" help:"list, add, and switch between accounts on this device"`
+	SetEmail     *SetEmailCmd     `arg:"subcommand:set-email" help:"change account email"`
+	VerifyEmail  *VerifyEmailCmd  `arg:"subcommand:verify-email" help:"verify account email"`
+	LinkCode     *LinkCodeCmd     `arg:"subcommand:link-code" help:"get a code to link another device"`
+	Link         *LinkCmd         `arg:"subcommand:link" help:"link this device with a code from another device"`
 }
 
 type LoginCmd struct {
@@ -38,8 +47,27 @@ type RecoverAccountCmd struct{}
 
 type SetEmailCmd struct{}
 
+type VerifyEmailCmd struct {
+	Resend bool `arg:"--resend" help:"send a new verification code first"`
+}
+
 type UsageCmd struct{}
 
+type EntitlementsCmd struct{}
+
+type LinkCodeCmd struct{}
+
+type LinkCmd struct {
+	Code       string `arg:"positional,required" help:"code shown on the logged-in device"`
+	DeviceName string `arg:"-n,--name" help:"name of this device (default: hostname)"`
+}
+
+type AccountsCmd struct {
+	Add    bool   `arg:"-a,--add" help:"keep the current account and start a new one to log in with"`
+	Switch string `arg:"-s,--switch" help:"switch to an account by user ID"`
+	Remove string `arg:"-r,--remove" help:"forget an inactive account by user ID"`
+}
+
 type DevicesCmd struct {
 	List   bool   `arg:"-l,--list" help:"list user devices"`
 	Remove string `arg:"-r,--remove" help:"remove a device by ID"`
@@ -57,10 +85,20 @@ func runAccount(ctx context.Context, c *ipc.Client, cmd *AccountCmd) error {
 		return accountRecover(ctx, c)
 	case cmd.Usage != nil:
 		return accountDataUsage(ctx, c)
+	case cmd.Entitlements != nil:
+		return accountEntitlements(ctx, c)
 	case cmd.Devices != nil:
 		return accountDevices(ctx, c, cmd.Devices)
+	case cmd.Accounts != nil:
+		return accountAccounts(ctx, c, cmd.Accounts)
 	case cmd.SetEmail != nil:
 		return accountSetEmail(ctx, c)
+	case cmd.VerifyEmail != nil:
+		return accountVerifyEmail(ctx, c, cmd.VerifyEmail)
+	case cmd.LinkCode != nil:
+		return accountLinkCode(ctx, c)
+	case cmd.Link != nil:
+		return accountLink(ctx, c, cmd.Link)
 	default:
 		return fmt.Errorf("no subcommand specified")
 	}
@@ -107,21 +145,18 @@ func accountLogin(ctx context.Context, c *ipc.Client, cmd *LoginCmd) error {
 		if provider == "" {
 			provider = "google"
 		}
-		url, err := c.OAuthLoginURL(ctx, provider)
+		url, err := c.StartOAuthLogin(ctx, provider)
 		if err != nil {
 			return err
 		}
 		fmt.Println("Open this URL in your browser to log in:")
 		fmt.Println(url)
-		fmt.Print("Enter OAuth token: ")
-		token, err := readLine()
-		if err != nil {
-			return err
-		}
-		userData, err := c.OAuthLoginCallback(ctx, token)
+		fmt.Println("Waiting for the login to complete...")
+		userData, err := c.CompleteOAuthLogin(ctx)
 		if err != nil {
 			return err
 		}
+		fmt.Println("Logged in successfully.")
 		return printJSON(userData)
 	}
 
@@ -135,6 +170,14 @@ func accountLogin(ctx context.Context, c *ipc.Client, cmd *LoginCmd) error {
 	}
 
 	userData, err := c.Login(ctx, email, password)
+	var tooMany *account.TooManyDevicesError
+	if errors.As(err, &tooMany) {
+		deviceID, promptErr := promptDeviceToRemove(tooMany.Devices)
+		if promptErr != nil {
+			return promptErr
+		}
+		userData, err = c.LoginAndReplaceDevice(ctx, email, password, deviceID)
+	}
 	if err != nil {
 		return err
 	}
@@ -142,6 +185,24 @@ func accountLogin(ctx context.Context, c *ipc.Client, cmd *LoginCmd) error {
 	return printJSON(userData)
 }
 
+// promptDeviceToRemove asks the user which of their linked devices to remove to make room for
+// this one, and returns its ID.
+func promptDeviceToRemove(devices []settings.Device) (string, error) {
+	fmt.Println("Your account has reached its device limit. Remove a device to log in on this one:")
+	for i, d := range devices {
+		fmt.Printf("  %d) %s (%s)\n", i+1, d.Name, d.ID)
+	}
+	choice, err := prompt("Device to remove: ")
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(choice))
+	if err != nil || n < 1 || n > len(devices) {
+		return "", fmt.Errorf("invalid choice %q", choice)
+	}
+	return devices[n-1].ID, nil
+}
+
 func accountLogout(ctx context.Context, c *ipc.Client) error {
 	email, err := requireLoggedIn(ctx, c)
 	if err != nil {
@@ -267,6 +328,44 @@ func accountSetEmail(ctx context.Context, c *ipc.Client) error {
 	return nil
 }
 
+func accountVerifyEmail(ctx context.Context, c *ipc.Client, cmd *VerifyEmailCmd) error {
+	if _, err := requireLoggedIn(ctx, c); err != nil {
+		return err
+	}
+	userData, err := c.UserData(ctx)
+	if err != nil {
+		return err
+	}
+	if userData.GetEmailConfirmed() {
+		fmt.Println("Email is already verified.")
+		return nil
+	}
+
+	if cmd.Resend {
+		if err := c.ResendEmailVerification(ctx); err != nil {
+			return err
+		}
+		fmt.Println("A verification code has been sent to your email.")
+	}
+	code, err := prompt("Verification code: ")
+	if err != nil {
+		return err
+	}
+	if err := c.VerifyEmail(ctx, code); err != nil {
+		return err
+	}
+	fmt.Println("Email verified.")
+	return nil
+}
+
+func accountEntitlements(ctx context.Context, c *ipc.Client) error {
+	e, err := c.Entitlements(ctx)
+	if err != nil {
+		return err
+	}
+	return printJSON(e)
+}
+
 func accountDataUsage(ctx context.Context, c *ipc.Client) error {
 	info, err := c.DataCapInfo(ctx)
 	if err != nil {
@@ -292,6 +391,37 @@ func accountDataUsage(ctx context.Context, c *ipc.Client) error {
 	return nil
 }
 
+func accountLinkCode(ctx context.Context, c *ipc.Client) error {
+	if _, err := requireLoggedIn(ctx, c); err != nil {
+		return err
+	}
+	code, err := c.RequestLinkCode(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Enter this code on the device to link: %s\n", code.Code)
+	if code.ExpireAt > 0 {
+		fmt.Printf("Expires at %s\n", time.Unix(code.ExpireAt, 0).Format(time.Kitchen))
+	}
+	return nil
+}
+
+func accountLink(ctx context.Context, c *ipc.Client, cmd *LinkCmd) error {
+	if err := requireLoggedOut(ctx, c); err != nil {
+		return err
+	}
+	name := cmd.DeviceName
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+	userData, err := c.RedeemLinkCode(ctx, cmd.Code, name)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Device linked.")
+	return printJSON(userData)
+}
+
 func accountDevices(ctx context.Context, c *ipc.Client, cmd *DevicesCmd) error {
 	if _, err := requireLoggedIn(ctx, c); err != nil {
 		return err
@@ -315,6 +445,35 @@ func accountDevices(ctx context.Context, c *ipc.Client, cmd *DevicesCmd) error {
 	}
 }
 
+func accountAccounts(ctx context.Context, c *ipc.Client, cmd *AccountsCmd) error {
+	switch {
+	case cmd.Add:
+		if _, err := c.AddAccount(ctx); err != nil {
+			return err
+		}
+		fmt.Println("Account kept. Log in to add another account with 'lantern account login'.")
+		return nil
+	case cmd.Switch != "":
+		if err := c.SwitchAccount(ctx, cmd.Switch); err != nil {
+			return err
+		}
+		fmt.Println("Switched account.")
+		return nil
+	case cmd.Remove != "":
+		if err := c.RemoveAccount(ctx, cmd.Remove); err != nil {
+			return err
+		}
+		fmt.Println("Account removed.")
+		return nil
+	default:
+		accounts, err := c.Accounts(ctx)
+		if err != nil {
+			return err
+		}
+		return printJSON(accounts)
+	}
+}
+
 // prompt prints a prompt and reads a line of input from stdin.
 func prompt(label string) (string, error) {
 	fmt.Print(label)
@@ -2,12 +2,330 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/getlantern/radiance/config"
 	"github.com/getlantern/radiance/ipc"
 )
 
-type UpdateConfigCmd struct{}
+type UpdateConfigCmd struct {
+	Force bool `arg:"-f,--force" help:"re-download and re-apply the config even if it hasn't changed"`
+}
 
-func runUpdateConfig(ctx context.Context, c *ipc.Client) error {
+func runUpdateConfig(ctx context.Context, c *ipc.Client, cmd *UpdateConfigCmd) error {
+	if cmd.Force {
+		return c.ForceRefreshConfig(ctx)
+	}
 	return c.UpdateConfig(ctx)
 }
+
+type ConfigCmd struct {
+	History  *ConfigHistoryCmd  `arg:"subcommand:history" help:"list retained config versions"`
+	Rollback *ConfigRollbackCmd `arg:"subcommand:rollback" help:"apply an earlier config version"`
+	Country  *ConfigCountryCmd  `arg:"subcommand:country" help:"show or override the country configs are tuned for"`
+	Preview  *ConfigPreviewCmd  `arg:"subcommand:preview" help:"show what the latest config would change without applying it"`
+	Apply    *ConfigApplyCmd    `arg:"subcommand:apply" help:"apply the config shown by 'config preview'"`
+	Sources  *ConfigSourcesCmd  `arg:"subcommand:sources" help:"list, add, or remove config sources"`
+	Flags    *ConfigFlagsCmd    `arg:"subcommand:flags" help:"list the server-controlled flags from the latest config"`
+	Fetches  *ConfigFetchesCmd  `arg:"subcommand:fetches" help:"show recent config fetch attempts"`
+	Messages *ConfigMessagesCmd `arg:"subcommand:messages" help:"list announcements from the server, or dismiss one"`
+}
+
+type ConfigHistoryCmd struct {
+	JSON bool `arg:"--json" help:"output JSON"`
+}
+
+type ConfigRollbackCmd struct {
+	ID string `arg:"positional" help:"version ID from 'config history' (default: the previous version)"`
+}
+
+type ConfigCountryCmd struct {
+	Country string `arg:"positional" help:"two-letter country code to request configs for"`
+	Clear   bool   `arg:"--clear" help:"go back to the country of the client IP"`
+}
+
+type ConfigPreviewCmd struct {
+	JSON bool `arg:"--json" help:"output JSON"`
+}
+
+type ConfigApplyCmd struct{}
+
+type ConfigFetchesCmd struct {
+	JSON bool `arg:"--json" help:"output JSON"`
+}
+
+type ConfigFlagsCmd struct {
+	JSON bool `arg:"--json" help:"output JSON"`
+}
+
+type ConfigMessagesCmd struct {
+	Dismiss string `arg:"--dismiss" help:"ID of an announcement to dismiss"`
+	All     bool   `arg:"--all" help:"include dismissed announcements"`
+	JSON    bool   `arg:"--json" help:"output JSON"`
+}
+
+type ConfigSourcesCmd struct {
+	Add      string `arg:"--add" help:"https base URL of a config server to add"`
+	Priority int    `arg:"--priority" help:"priority of the added source; lower is tried first, the Lantern API is 0"`
+	Remove   string `arg:"--remove" help:"base URL of a config server to remove"`
+	JSON     bool   `arg:"--json" help:"output JSON"`
+}
+
+func runConfig(ctx context.Context, c *ipc.Client, cmd *ConfigCmd) error {
+	switch {
+	case cmd.History != nil:
+		return configHistory(ctx, c, cmd.History.JSON)
+	case cmd.Rollback != nil:
+		if err := c.RollbackConfig(ctx, cmd.Rollback.ID); err != nil {
+			return err
+		}
+		fmt.Println("Config rolled back")
+		return nil
+	case cmd.Country != nil:
+		return configCountry(ctx, c, cmd.Country)
+	case cmd.Preview != nil:
+		return configPreview(ctx, c, cmd.Preview.JSON)
+	case cmd.Apply != nil:
+		if err := c.ApplyConfigPreview(ctx); err != nil {
+			return err
+		}
+		fmt.Println("Config applied")
+		return nil
+	case cmd.Sources != nil:
+		return configSources(ctx, c, cmd.Sources)
+	case cmd.Flags != nil:
+		return configFlags(ctx, c, cmd.Flags.JSON)
+	case cmd.Fetches != nil:
+		return configFetches(ctx, c, cmd.Fetches.JSON)
+	case cmd.Messages != nil:
+		return configMessages(ctx, c, cmd.Messages)
+	default:
+		return configHistory(ctx, c, false)
+	}
+}
+
+func configHistory(ctx context.Context, c *ipc.Client, asJSON bool) error {
+	versions, err := c.ConfigHistory(ctx)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(versions)
+	}
+	if len(versions) == 0 {
+		fmt.Println("No config history")
+		return nil
+	}
+	for _, v := range versions {
+		marker := " "
+		if v.Current {
+			marker = "*"
+		}
+		fmt.Printf("%s %s  %s\n", marker, v.ID, v.FetchedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func configCountry(ctx context.Context, c *ipc.Client, cmd *ConfigCountryCmd) error {
+	switch {
+	case cmd.Clear:
+		if err := c.SetCountryOverride(ctx, ""); err != nil {
+			return err
+		}
+		fmt.Println("Country override cleared")
+	case cmd.Country != "":
+		if err := c.SetCountryOverride(ctx, cmd.Country); err != nil {
+			return err
+		}
+		fmt.Printf("Requesting configs for %s\n", strings.ToUpper(cmd.Country))
+	default:
+		country, err := c.CountryOverride(ctx)
+		if err != nil {
+			return err
+		}
+		if country == "" {
+			country = "none (using the country of the client IP)"
+		}
+		fmt.Println("Country override:", country)
+	}
+	return nil
+}
+
+func configPreview(ctx context.Context, c *ipc.Client, asJSON bool) error {
+	diff, err := c.PreviewConfigUpdate(ctx)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(diff)
+	}
+	if diff.Empty() {
+		fmt.Println("Config is up to date")
+		return nil
+	}
+	for _, section := range []struct {
+		label string
+		items []string
+	}{
+		{"Servers added", diff.ServersAdded},
+		{"Servers removed", diff.ServersRemoved},
+		{"Servers changed", diff.ServersChanged},
+		{"Options changed", diff.OptionsChanged},
+		{"Flags changed", diff.FlagsChanged},
+	} {
+		if len(section.items) > 0 {
+			fmt.Printf("%s: %s\n", section.label, strings.Join(section.items, ", "))
+		}
+	}
+	fmt.Println("Run 'lantern config apply' to apply these changes.")
+	return nil
+}
+
+func configSources(ctx context.Context, c *ipc.Client, cmd *ConfigSourcesCmd) error {
+	current, err := c.ConfigSources(ctx)
+	if err != nil {
+		return err
+	}
+	if cmd.Add == "" && cmd.Remove == "" {
+		if cmd.JSON {
+			return printJSON(current)
+		}
+		now := time.Now()
+		for _, s := range current {
+			label := s.URL
+			if s.Default {
+				label += " (Lantern API)"
+			}
+			health := "healthy"
+			if !s.Healthy(now) {
+				health = fmt.Sprintf("backed off until %s after %d failures: %s",
+					s.RetryAt.Format(time.RFC3339), s.Failures, s.LastError)
+			}
+			fmt.Printf("%3d  %s  %s\n", s.Priority, label, health)
+		}
+		return nil
+	}
+
+	var sources []config.ConfigSource
+	for _, s := range current {
+		if !s.Default && s.URL != cmd.Remove && s.URL != cmd.Add {
+			sources = append(sources, s.ConfigSource)
+		}
+	}
+	if cmd.Add != "" {
+		sources = append(sources, config.ConfigSource{URL: cmd.Add, Priority: cmd.Priority})
+	}
+	if err := c.SetConfigSources(ctx, sources); err != nil {
+		return err
+	}
+	fmt.Println("Config sources updated")
+	return nil
+}
+
+func configFlags(ctx context.Context, c *ipc.Client, asJSON bool) error {
+	flags, err := c.ConfigFlags(ctx)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(flags)
+	}
+	if len(flags) == 0 {
+		fmt.Println("No flags set")
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(flags)) {
+		fmt.Printf("%s = %s\n", name, flags[name])
+	}
+	return nil
+}
+
+func configMessages(ctx context.Context, c *ipc.Client, cmd *ConfigMessagesCmd) error {
+	if cmd.Dismiss != "" {
+		if err := c.DismissAnnouncement(ctx, cmd.Dismiss); err != nil {
+			return err
+		}
+		fmt.Println("Announcement dismissed")
+		return nil
+	}
+	all, err := c.Announcements(ctx)
+	if err != nil {
+		return err
+	}
+	announcements := all
+	if !cmd.All {
+		announcements = slices.DeleteFunc(slices.Clone(all), func(a config.Announcement) bool { return a.Dismissed })
+	}
+	if cmd.JSON {
+		return printJSON(announcements)
+	}
+	if len(announcements) == 0 {
+		fmt.Println("No announcements")
+		return nil
+	}
+	for _, a := range announcements {
+		fmt.Printf("[%s] %s (%s)", a.ID, a.Title, a.Kind)
+		if a.Dismissed {
+			fmt.Print(" dismissed")
+		}
+		fmt.Println()
+		if a.Body != "" {
+			fmt.Println("  " + a.Body)
+		}
+		if a.URL != "" {
+			fmt.Println("  " + a.URL)
+		}
+	}
+	return nil
+}
+
+func configFetches(ctx context.Context, c *ipc.Client, asJSON bool) error {
+	fetches, err := c.ConfigFetchHistory(ctx)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(fetches)
+	}
+	freshness, err := c.ConfigFreshness(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case freshness.ConfirmedAt.IsZero():
+		fmt.Println("Config has not been confirmed by the server")
+	case freshness.Expired:
+		fmt.Println("Config expired; last confirmed", freshness.ConfirmedAt.Format(time.RFC3339))
+	case freshness.Stale:
+		fmt.Println("Config is stale; last confirmed", freshness.ConfirmedAt.Format(time.RFC3339))
+	default:
+		fmt.Println("Config last confirmed", freshness.ConfirmedAt.Format(time.RFC3339))
+	}
+	if len(fetches) == 0 {
+		fmt.Println("No config fetches yet")
+		return nil
+	}
+	for _, f := range fetches {
+		result := "unchanged"
+		switch {
+		case f.Error != "":
+			result = "error: " + f.Error
+		case f.Applied:
+			result = "applied"
+		}
+		status := "-"
+		if f.StatusCode != 0 {
+			status = fmt.Sprint(f.StatusCode)
+		}
+		transport := f.Transport
+		if transport == "" {
+			transport = "-"
+		}
+		fmt.Printf("%s  %6dms  %s  %s  %s  %s\n", f.Time.Format(time.RFC3339), f.DurationMs, status, transport, f.Source, result)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/getlantern/radiance/ipc"
+)
+
+type DebugCmd struct {
+	Pprof      *DebugPprofCmd      `arg:"subcommand:pprof" help:"download a pprof profile (heap, allocs, goroutine, block, mutex, profile, trace)"`
+	Goroutines *DebugGoroutinesCmd `arg:"subcommand:goroutines" help:"dump all goroutine stacks"`
+	Vars       *DebugVarsCmd       `arg:"subcommand:vars" help:"print expvar variables"`
+}
+
+type DebugPprofCmd struct {
+	Name    string `arg:"positional,required" help:"profile name"`
+	Seconds int    `arg:"--seconds" help:"sampling duration for profile and trace"`
+	Out     string `arg:"-o,--out" help:"write the profile to this file instead of stdout"`
+}
+
+type DebugGoroutinesCmd struct{}
+
+type DebugVarsCmd struct{}
+
+func runDebug(ctx context.Context, c *ipc.Client, cmd *DebugCmd) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch {
+	case cmd.Pprof != nil:
+		data, err = c.DebugProfile(ctx, cmd.Pprof.Name, cmd.Pprof.Seconds)
+		if err == nil && cmd.Pprof.Out != "" {
+			if err := os.WriteFile(cmd.Pprof.Out, data, 0o644); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s (%d bytes)\n", cmd.Pprof.Out, len(data))
+			return nil
+		}
+	case cmd.Goroutines != nil:
+		data, err = c.DebugGoroutines(ctx)
+	case cmd.Vars != nil:
+		vars, err := c.DebugVars(ctx)
+		if err != nil {
+			return debugError(err)
+		}
+		return printJSON(vars)
+	default:
+		return fmt.Errorf("must specify one of: pprof, goroutines, vars")
+	}
+	if err != nil {
+		return debugError(err)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+func debugError(err error) error {
+	if ipc.IsNotFound(err) {
+		return fmt.Errorf("debug endpoints are disabled; start lanternd with --debug-endpoints or set RADIANCE_DEBUG_ENDPOINTS=true: %w", err)
+	}
+	return err
+}
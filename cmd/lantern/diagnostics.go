@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/getlantern/radiance/account"
+	"github.com/getlantern/radiance/backend"
+	"github.com/getlantern/radiance/common"
+	"github.com/getlantern/radiance/ipc"
+	"github.com/getlantern/radiance/kindling"
+	"github.com/getlantern/radiance/vpn"
+)
+
+type DiagnosticsCmd struct {
+	Sessions int  `arg:"--sessions" default:"5" help:"number of recent sessions to include"`
+	JSON     bool `arg:"--json" help:"output JSON"`
+}
+
+// diagnostics is a best-effort snapshot of daemon state for bug reports. Each section is
+// collected independently; a failure is recorded in Errors instead of aborting the rest, since a
+// partially broken daemon is exactly when this is run.
+type diagnostics struct {
+	CLIVersion   string                 `json:"cli_version"`
+	CollectedAt  time.Time              `json:"collected_at"`
+	Status       *statusSnapshot        `json:"status,omitempty"`
+	Metrics      *backend.Metrics       `json:"metrics,omitempty"`
+	AutoSelected string                 `json:"auto_selected,omitempty"`
+	Sessions     []vpn.Session          `json:"sessions,omitempty"`
+	Features     map[string]bool        `json:"features,omitempty"`
+	Backends     []account.BreakerState `json:"account_backends,omitempty"`
+	Kindling     *kindling.StatusReport `json:"kindling,omitempty"`
+	Errors       map[string]string      `json:"errors,omitempty"`
+}
+
+func (d *diagnostics) fail(section string, err error) {
+	if d.Errors == nil {
+		d.Errors = make(map[string]string)
+	}
+	d.Errors[section] = err.Error()
+}
+
+func collectDiagnostics(ctx context.Context, c *ipc.Client, sessions int) diagnostics {
+	d := diagnostics{CLIVersion: common.Version, CollectedAt: time.Now()}
+	if snap, err := fetchStatus(ctx, c); err != nil {
+		d.fail("status", err)
+	} else {
+		d.Status = &snap
+	}
+	if m, err := c.VPNMetrics(ctx); err != nil {
+		d.fail("metrics", err)
+	} else {
+		d.Metrics = &m
+	}
+	if d.Status != nil && d.Status.Status == vpn.Connected {
+		if sel, err := c.AutoSelected(ctx); err != nil {
+			d.fail("auto_selected", err)
+		} else if sel != nil {
+			d.AutoSelected = sel.Tag
+		}
+	}
+	if sessions > 0 {
+		if s, err := c.VPNSessions(ctx, sessions); err != nil {
+			d.fail("sessions", err)
+		} else {
+			d.Sessions = s
+		}
+	}
+	if f, err := c.Features(ctx); err != nil {
+		d.fail("features", err)
+	} else {
+		d.Features = f
+	}
+	if b, err := c.AccountBreakerStates(ctx); err != nil {
+		d.fail("account_backends", err)
+	} else {
+		d.Backends = b
+	}
+	if k, err := c.KindlingStatus(ctx); err != nil {
+		d.fail("kindling", err)
+	} else {
+		d.Kindling = &k
+	}
+	return d
+}
+
+func runDiagnostics(ctx context.Context, c *ipc.Client, cmd *DiagnosticsCmd) error {
+	d := collectDiagnostics(ctx, c, cmd.Sessions)
+	if cmd.JSON {
+		return printJSON(d)
+	}
+	fmt.Printf("CLI version: %s\n", d.CLIVersion)
+	fmt.Printf("Collected:   %s\n", d.CollectedAt.Format(time.RFC3339))
+	if d.Status != nil {
+		fmt.Println("\n== Status ==")
+		renderStatus(*d.Status, false)
+		if d.AutoSelected != "" {
+			fmt.Println("Auto-selected: " + d.AutoSelected)
+		}
+	}
+	if d.Metrics != nil {
+		fmt.Println("\n== Metrics ==")
+		printMetrics(*d.Metrics)
+	}
+	if len(d.Sessions) > 0 {
+		fmt.Println("\n== Recent sessions ==")
+		for _, s := range d.Sessions {
+			line := fmt.Sprintf("  %s  %-24s %8s  ↓ %s ↑ %s",
+				s.ConnectedAt.Format(time.RFC3339), s.Server.Tag, s.Duration().Truncate(time.Second),
+				formatBytes(s.BytesDown), formatBytes(s.BytesUp))
+			if s.Error != "" {
+				line += "  error: " + s.Error
+			}
+			fmt.Println(line)
+		}
+	}
+	if len(d.Features) > 0 {
+		fmt.Println("\n== Features ==")
+		names := make([]string, 0, len(d.Features))
+		for name := range d.Features {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %s: %v\n", name, d.Features[name])
+		}
+	}
+	if len(d.Backends) > 0 {
+		fmt.Println("\n== Account backends ==")
+		for _, b := range d.Backends {
+			state := "ok"
+			if b.Open {
+				state = "unreachable since " + b.OpenedAt.Format(time.RFC3339)
+			}
+			line := fmt.Sprintf("  %s: %s, %d consecutive failures", b.Backend, state, b.Failures)
+			if b.LastError != "" {
+				line += "  last error: " + b.LastError
+			}
+			fmt.Println(line)
+		}
+	}
+	if d.Kindling != nil && len(d.Kindling.Transports) > 0 {
+		fmt.Println("\n== Kindling transports ==")
+		if d.Kindling.Current != "" {
+			fmt.Println("  Current: " + d.Kindling.Current)
+		}
+		if d.Kindling.Preferred != "" {
+			fmt.Println("  Preferred on this network: " + d.Kindling.Preferred)
+		}
+		for _, t := range d.Kindling.Transports {
+			line := fmt.Sprintf("  %s: served %d, %d handshakes (avg %.0fms), %d failures",
+				t.Name, t.Served, t.Handshakes, t.AvgHandshakeMs, t.Failures)
+			if t.LastError != "" {
+				line += "  last error: " + t.LastError
+			}
+			fmt.Println(line)
+		}
+	}
+	if len(d.Errors) > 0 {
+		fmt.Println("\n== Collection errors ==")
+		for section, msg := range d.Errors {
+			fmt.Printf("  %s: %s\n", section, msg)
+		}
+	}
+	return nil
+}
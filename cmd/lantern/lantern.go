@@ -26,19 +26,25 @@ type args struct {
 	Connect          *ConnectCmd          `arg:"subcommand:connect" help:"connect to VPN"`
 	Disconnect       *DisconnectCmd       `arg:"subcommand:disconnect" help:"disconnect VPN"`
 	ClearTunnelCache *ClearTunnelCacheCmd `arg:"subcommand:clear-cache" help:"clear the tunnel cache"`
+	Select           *SelectCmd           `arg:"subcommand:select" help:"switch servers without reconnecting"`
 	Status           *StatusCmd           `arg:"subcommand:status" help:"show VPN status"`
 	Servers          *ServersCmd          `arg:"subcommand:servers" help:"manage servers"`
 	Set              *SetCmd              `arg:"subcommand:set" help:"update one or more settings"`
 	Get              *GetCmd              `arg:"subcommand:get" help:"show one or all settings"`
 	SplitTunnel      *SplitTunnelCmd      `arg:"subcommand:split-tunnel" help:"split-tunnel filter management"`
+	Profile          *ProfileCmd          `arg:"subcommand:profile" help:"save and switch between named settings profiles"`
 	Features         *FeaturesCmd         `arg:"subcommand:features" help:"list available features and their status"`
 	Account          *AccountCmd          `arg:"subcommand:account" help:"login, signup, user data, devices, recovery"`
 	Subscription     *SubscriptionCmd     `arg:"subcommand:subscription" help:"plans, payments, and billing"`
 	ReportIssue      *ReportIssueCmd      `arg:"subcommand:report-issue" help:"report an issue"`
 	Throughput       *ThroughputCmd       `arg:"subcommand:throughput" help:"show throughput, globally and per outbound"`
+	Metrics          *MetricsCmd          `arg:"subcommand:metrics" help:"show traffic, DNS, outbound, and process metrics"`
+	Diagnostics      *DiagnosticsCmd      `arg:"subcommand:diagnostics" help:"collect a diagnostics snapshot from the daemon"`
 	Monitor          *MonitorCmd          `arg:"subcommand:monitor" help:"watch status, throughput, settings, recent history and errors; press q or Ctrl-C to quit"`
 	Logs             *LogsCmd             `arg:"subcommand:logs" help:"tail daemon logs; press q or Ctrl-C to quit"`
 	UpdateConfig     *UpdateConfigCmd     `arg:"subcommand:update-config" help:"force an immediate config fetch"`
+	Config           *ConfigCmd           `arg:"subcommand:config" help:"config history and rollback"`
+	Debug            *DebugCmd            `arg:"subcommand:debug" help:"profile the daemon (requires lanternd --debug-endpoints)"`
 	IP               *IPCmd               `arg:"subcommand:ip" help:"show public IP address"`
 	Version          *VersionCmd          `arg:"subcommand:version" help:"print version"`
 }
@@ -48,13 +54,14 @@ func (args) Description() string {
 }
 
 type ReportIssueCmd struct {
-	Type        int    `arg:"-t,--type,required" help:"0=purchase 1=signin 2=spinner 3=blocked-sites 4=slow 5=link-device 6=crash 9=other 10=update"`
-	Description string `arg:"-d,--desc,required" help:"issue description"`
-	Email       string `arg:"-e,--email" help:"email address"`
+	Type          int    `arg:"-t,--type,required" help:"0=purchase 1=signin 2=spinner 3=blocked-sites 4=slow 5=link-device 6=crash 9=other 10=update"`
+	Description   string `arg:"-d,--desc,required" help:"issue description"`
+	Email         string `arg:"-e,--email" help:"email address"`
+	NoDiagnostics bool   `arg:"--no-diagnostics" help:"don't attach the tunnel state and config fetch status"`
 }
 
 func runReportIssue(ctx context.Context, c *ipc.Client, cmd *ReportIssueCmd) error {
-	return c.ReportIssue(ctx, issue.IssueType(cmd.Type), cmd.Description, cmd.Email, nil, nil)
+	return c.ReportIssue(ctx, issue.IssueType(cmd.Type), cmd.Description, cmd.Email, !cmd.NoDiagnostics, nil, nil)
 }
 
 type LogsCmd struct {
@@ -151,7 +158,65 @@ func logEntryMeetsLevel(entry string, min slog.Level) bool {
 	return err != nil || lvl >= min
 }
 
-type VersionCmd struct{}
+type VersionCmd struct {
+	Daemon bool `arg:"--daemon" help:"also show the daemon's version and API handshake"`
+	JSON   bool `arg:"--json" help:"output JSON"`
+}
+
+type versionInfo struct {
+	CLIVersion string       `json:"cli_version"`
+	APIVersion int          `json:"api_version"`
+	Daemon     *ipc.APIInfo `json:"daemon,omitempty"`
+	DaemonErr  string       `json:"daemon_error,omitempty"`
+}
+
+// runVersion prints the CLI version. With --daemon it also shows the daemon's version and API
+// handshake so mismatched installs are easy to spot.
+func runVersion(ctx context.Context, c *ipc.Client, cmd *VersionCmd) error {
+	if !cmd.Daemon && !cmd.JSON {
+		fmt.Println(common.Version)
+		return nil
+	}
+	v := versionInfo{CLIVersion: common.Version, APIVersion: ipc.APIVersion}
+	if cmd.Daemon {
+		info, err := c.CheckAPI(ctx)
+		switch {
+		case err == nil || errors.Is(err, ipc.ErrIncompatibleAPI):
+			v.Daemon = &info
+			if err != nil {
+				v.DaemonErr = err.Error()
+			}
+		default:
+			v.DaemonErr = err.Error()
+		}
+	}
+	if cmd.JSON {
+		return printJSON(v)
+	}
+	fmt.Printf("CLI:    %s (API %d)\n", v.CLIVersion, v.APIVersion)
+	if v.Daemon != nil {
+		fmt.Printf("Daemon: %s (API %d)\n", v.Daemon.DaemonVersion, v.Daemon.APIVersion)
+		var caps []string
+		endpoints := 0
+		for _, c := range v.Daemon.Capabilities {
+			if c.IsEndpoint() {
+				endpoints++
+			} else {
+				caps = append(caps, string(c))
+			}
+		}
+		if len(caps) > 0 {
+			fmt.Printf("Capabilities: %s\n", strings.Join(caps, ", "))
+		}
+		if endpoints > 0 {
+			fmt.Printf("Endpoints: %d\n", endpoints)
+		}
+	}
+	if v.DaemonErr != "" {
+		fmt.Printf("Daemon error: %s\n", v.DaemonErr)
+	}
+	return nil
+}
 
 func main() {
 	// Watch-mode TUI frames are corrupted by stray library slog output on stderr.
@@ -180,15 +245,21 @@ func main() {
 func run(ctx context.Context, c *ipc.Client, a *args) error {
 	switch {
 	case a.Connect != nil:
-		return vpnConnect(ctx, c, a.Connect.Name, a.Connect.Wait)
+		return vpnConnect(ctx, c, a.Connect)
 	case a.Disconnect != nil:
-		return c.DisconnectVPN(ctx)
+		return vpnDisconnect(ctx, c, a.Disconnect)
+	case a.Select != nil:
+		return vpnSelect(ctx, c, a.Select)
 	case a.ClearTunnelCache != nil:
 		return runClearTunnelCache(ctx, c)
 	case a.Status != nil:
 		return vpnStatus(ctx, c, a.Status)
 	case a.Throughput != nil:
 		return vpnThroughput(ctx, c, a.Throughput)
+	case a.Metrics != nil:
+		return vpnMetrics(ctx, c, a.Metrics)
+	case a.Diagnostics != nil:
+		return runDiagnostics(ctx, c, a.Diagnostics)
 	case a.Servers != nil:
 		return runServers(ctx, c, a.Servers)
 	case a.Features != nil:
@@ -198,9 +269,13 @@ func run(ctx context.Context, c *ipc.Client, a *args) error {
 	case a.Get != nil:
 		return runGet(ctx, c, a.Get)
 	case a.UpdateConfig != nil:
-		return runUpdateConfig(ctx, c)
+		return runUpdateConfig(ctx, c, a.UpdateConfig)
+	case a.Config != nil:
+		return runConfig(ctx, c, a.Config)
 	case a.SplitTunnel != nil:
 		return runSplitTunnel(ctx, c, a.SplitTunnel)
+	case a.Profile != nil:
+		return runProfile(ctx, c, a.Profile)
 	case a.Account != nil:
 		return runAccount(ctx, c, a.Account)
 	case a.Subscription != nil:
@@ -211,11 +286,12 @@ func run(ctx context.Context, c *ipc.Client, a *args) error {
 		return runMonitor(ctx, c, a.Monitor)
 	case a.Logs != nil:
 		return tailLogs(ctx, c, a.Logs)
+	case a.Debug != nil:
+		return runDebug(ctx, c, a.Debug)
 	case a.IP != nil:
 		return runIP(ctx, a.IP)
 	case a.Version != nil:
-		fmt.Println(common.Version)
-		return nil
+		return runVersion(ctx, c, a.Version)
 	default:
 		return fmt.Errorf("no subcommand specified")
 	}
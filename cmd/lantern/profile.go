@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/getlantern/radiance/ipc"
+)
+
+type ProfileCmd struct {
+	List   *ProfileListCmd   `arg:"subcommand:list" help:"list saved profiles"`
+	Save   *ProfileSaveCmd   `arg:"subcommand:save" help:"save the current location, split tunnel, and DNS settings as a profile"`
+	Switch *ProfileSwitchCmd `arg:"subcommand:switch" help:"switch to a profile"`
+	Delete *ProfileDeleteCmd `arg:"subcommand:delete" help:"delete a profile"`
+}
+
+type ProfileListCmd struct {
+	JSON bool `arg:"--json" help:"output JSON"`
+}
+
+type ProfileSaveCmd struct {
+	Name    string   `arg:"positional,required" help:"profile name"`
+	Servers []string `arg:"--servers" help:"tags of user-added servers to include (default: all)"`
+}
+
+type ProfileSwitchCmd struct {
+	Name string `arg:"positional,required" help:"profile name"`
+}
+
+type ProfileDeleteCmd struct {
+	Name string `arg:"positional,required" help:"profile name"`
+}
+
+func runProfile(ctx context.Context, c *ipc.Client, cmd *ProfileCmd) error {
+	switch {
+	case cmd.List != nil:
+		return profileList(ctx, c, cmd.List.JSON)
+	case cmd.Save != nil:
+		if err := c.SaveProfile(ctx, cmd.Save.Name, cmd.Save.Servers); err != nil {
+			return err
+		}
+		fmt.Printf("Saved profile %s\n", cmd.Save.Name)
+		return nil
+	case cmd.Switch != nil:
+		if err := c.SwitchProfile(ctx, cmd.Switch.Name); err != nil {
+			return err
+		}
+		fmt.Printf("Switched to profile %s\n", cmd.Switch.Name)
+		return nil
+	case cmd.Delete != nil:
+		if err := c.DeleteProfile(ctx, cmd.Delete.Name); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted profile %s\n", cmd.Delete.Name)
+		return nil
+	default:
+		return profileList(ctx, c, false)
+	}
+}
+
+func profileList(ctx context.Context, c *ipc.Client, asJSON bool) error {
+	resp, err := c.Profiles(ctx)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(resp)
+	}
+	if len(resp.Profiles) == 0 {
+		fmt.Println("No profiles")
+		return nil
+	}
+	for _, p := range resp.Profiles {
+		marker := " "
+		if p.Name == resp.Active {
+			marker = "*"
+		}
+		servers := "all"
+		if len(p.Servers) > 0 {
+			servers = strings.Join(p.Servers, ",")
+		}
+		loc := p.PreferredLocation.Country
+		if loc == "" {
+			loc = "auto"
+		}
+		fmt.Printf("%s %s  location=%s servers=%s split-tunnel=%v\n", marker, p.Name, loc, servers, p.SplitTunnelEnabled)
+	}
+	return nil
+}
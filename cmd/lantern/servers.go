@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	C "github.com/getlantern/common"
 
@@ -14,12 +15,22 @@ import (
 )
 
 type ServersCmd struct {
-	List          *ServersListCmd    `arg:"subcommand:list" help:"list servers"`
-	Show          *ServersShowCmd    `arg:"subcommand:show" help:"display server by tag"`
-	AddJSON       *ServersAddJSONCmd `arg:"subcommand:add-json" help:"add servers from JSON config"`
-	AddURL        *ServersAddURLCmd  `arg:"subcommand:add-url" help:"add servers from URLs"`
-	Remove        *ServersRemoveCmd  `arg:"subcommand:remove" help:"remove servers by tag"`
-	PrivateServer *PrivateServerCmd  `arg:"subcommand:private" help:"private server operations"`
+	List          *ServersListCmd         `arg:"subcommand:list" help:"list servers"`
+	Show          *ServersShowCmd         `arg:"subcommand:show" help:"display server by tag"`
+	AddJSON       *ServersAddJSONCmd      `arg:"subcommand:add-json" help:"add servers from JSON config"`
+	AddURL        *ServersAddURLCmd       `arg:"subcommand:add-url" help:"add servers from URLs"`
+	QR            *ServersQRCmd           `arg:"subcommand:qr" help:"print the QR payload that shares a server"`
+	AddQR         *ServersAddQRCmd        `arg:"subcommand:add-qr" help:"add the server or private server invite in a scanned QR payload"`
+	Export        *ServersExportCmd       `arg:"subcommand:export" help:"export a user server for use on another device or client"`
+	Rename        *ServersRenameCmd       `arg:"subcommand:rename" help:"rename a user server"`
+	Metadata      *ServersMetadataCmd     `arg:"subcommand:metadata" help:"set the labels, notes, and color of a user server"`
+	Probe         *ServersProbeCmd        `arg:"subcommand:probe" help:"check whether user servers accept connections"`
+	Disable       *ServersDisableCmd      `arg:"subcommand:disable" help:"stop using a user server without removing it"`
+	Enable        *ServersEnableCmd       `arg:"subcommand:enable" help:"use a disabled user server again"`
+	Remove        *ServersRemoveCmd       `arg:"subcommand:remove" help:"remove servers by tag"`
+	Selected      *ServersSelectedCmd     `arg:"subcommand:selected" help:"show the selected server"`
+	AutoSelected  *ServersAutoSelectedCmd `arg:"subcommand:auto-selected" help:"show the server chosen by auto-select"`
+	PrivateServer *PrivateServerCmd       `arg:"subcommand:private" help:"private server operations"`
 }
 
 type ServersListCmd struct {
@@ -41,22 +52,70 @@ type ServersAddURLCmd struct {
 	SkipCertVerify bool     `arg:"--skip-cert-verify" help:"skip cert verification"`
 }
 
+type ServersQRCmd struct {
+	Tag string `arg:"positional,required" help:"server tag"`
+}
+
+type ServersAddQRCmd struct {
+	Payload        string `arg:"positional,required" help:"scanned QR payload"`
+	SkipCertVerify bool   `arg:"--skip-cert-verify" help:"skip cert verification"`
+}
+
+type ServersExportCmd struct {
+	Tag    string `arg:"positional,required" help:"server tag"`
+	Format string `arg:"--format" default:"sing-box" help:"export format: sing-box, ss, or clash"`
+}
+
+type ServersRenameCmd struct {
+	Tag    string `arg:"positional,required" help:"server tag"`
+	NewTag string `arg:"positional,required" help:"new server tag"`
+}
+
+type ServersMetadataCmd struct {
+	Tag    string   `arg:"positional,required" help:"server tag"`
+	Labels []string `arg:"--label,separate" help:"label to attach (repeatable)"`
+	Notes  string   `arg:"--notes" help:"free-form notes"`
+	Color  string   `arg:"--color" help:"display color, e.g. #ff8800"`
+}
+
+type ServersProbeCmd struct {
+	Tags []string `arg:"positional,required" help:"server tags to probe"`
+}
+
+type ServersDisableCmd struct {
+	Tag string `arg:"positional,required" help:"server tag"`
+}
+
+type ServersEnableCmd struct {
+	Tag string `arg:"positional,required" help:"server tag"`
+}
+
 type ServersRemoveCmd struct {
 	Tags []string `arg:"positional,required" help:"server tags to remove"`
 }
 
+type ServersSelectedCmd struct{}
+
+type ServersAutoSelectedCmd struct {
+	Watch bool `arg:"-w,--watch" help:"print each new auto-selection until interrupted"`
+}
+
 // ServerListEntry represents a server in the list output.
 type ServerListEntry struct {
 	Tag              string                    `json:"tag"`
 	Type             string                    `json:"type"`
 	Location         C.ServerLocation          `json:"location,omitempty"`
 	SelectionHistory *servers.SelectionHistory `json:"selection_history,omitempty"`
+	Reachability     *servers.Reachability     `json:"reachability,omitempty"`
+	Disabled         bool                      `json:"disabled,omitempty"`
 }
 
 type PrivateServerCmd struct {
 	Add          *PrivateServerAddCmd          `arg:"subcommand:add" help:"add a private server"`
+	Remove       *PrivateServerRemoveCmd       `arg:"subcommand:remove" help:"remove a private server's servers and trusted fingerprint"`
 	Invite       *PrivateServerInviteCmd       `arg:"subcommand:invite" help:"create an invite for a private server"`
 	RevokeInvite *PrivateServerRevokeInviteCmd `arg:"subcommand:revoke-invite" help:"revoke a private server invite"`
+	Fingerprints *PrivateServerFingerprintsCmd `arg:"subcommand:fingerprints" help:"manage trusted private server certificate fingerprints"`
 }
 
 // PrivateServerConn holds connection parameters for a private server.
@@ -67,12 +126,18 @@ type PrivateServerConn struct {
 }
 
 type PrivateServerAddCmd struct {
-	Tag string `arg:"positional,required" help:"tag to assign to the server"`
+	Tag         string `arg:"positional,required" help:"tag to assign to the server"`
+	Fingerprint string `arg:"--fingerprint" help:"SHA-256 fingerprint of the server certificate, in hex; required unless the certificate is publicly trusted"`
 	PrivateServerConn
 }
 
+type PrivateServerRemoveCmd struct {
+	IP string `arg:"positional,required" help:"server IP"`
+}
+
 type PrivateServerInviteCmd struct {
 	Name string `arg:"positional,required" help:"invitee name"`
+	QR   bool   `arg:"--qr" help:"print the invite as a QR payload instead of the invite code"`
 	PrivateServerConn
 }
 
@@ -81,6 +146,23 @@ type PrivateServerRevokeInviteCmd struct {
 	PrivateServerConn
 }
 
+type PrivateServerFingerprintsCmd struct {
+	List    *PrivateServerFingerprintListCmd    `arg:"subcommand:list" help:"list trusted fingerprints"`
+	Remove  *PrivateServerFingerprintRemoveCmd  `arg:"subcommand:remove" help:"forget the fingerprint trusted for a server"`
+	Replace *PrivateServerFingerprintReplaceCmd `arg:"subcommand:replace" help:"trust a new fingerprint for a server"`
+}
+
+type PrivateServerFingerprintListCmd struct{}
+
+type PrivateServerFingerprintRemoveCmd struct {
+	IP string `arg:"positional,required" help:"server IP"`
+}
+
+type PrivateServerFingerprintReplaceCmd struct {
+	IP          string `arg:"positional,required" help:"server IP"`
+	Fingerprint string `arg:"positional,required" help:"SHA-256 fingerprint of the server certificate, in hex"`
+}
+
 func runServers(ctx context.Context, c *ipc.Client, cmd *ServersCmd) error {
 	switch {
 	case cmd.Show != nil:
@@ -89,8 +171,42 @@ func runServers(ctx context.Context, c *ipc.Client, cmd *ServersCmd) error {
 		return printAddedServers(c.AddServersByJSON(ctx, cmd.AddJSON.Config))
 	case cmd.AddURL != nil:
 		return printAddedServers(c.AddServersByURL(ctx, cmd.AddURL.URLs, cmd.AddURL.SkipCertVerify))
+	case cmd.QR != nil:
+		payload, err := c.ServerQRPayload(ctx, cmd.QR.Tag)
+		if err != nil {
+			return err
+		}
+		fmt.Println(payload)
+		return nil
+	case cmd.Export != nil:
+		data, err := c.ExportServer(ctx, cmd.Export.Tag, servers.ExportFormat(cmd.Export.Format))
+		if err != nil {
+			return err
+		}
+		fmt.Println(strings.TrimSpace(data))
+		return nil
+	case cmd.Rename != nil:
+		return c.RenameServer(ctx, cmd.Rename.Tag, cmd.Rename.NewTag)
+	case cmd.Metadata != nil:
+		return c.SetServerMetadata(ctx, cmd.Metadata.Tag, servers.ServerMetadata{
+			Labels: cmd.Metadata.Labels,
+			Notes:  cmd.Metadata.Notes,
+			Color:  cmd.Metadata.Color,
+		})
+	case cmd.AddQR != nil:
+		return printAddedServers(c.AddServersByQRPayload(ctx, cmd.AddQR.Payload, cmd.AddQR.SkipCertVerify))
+	case cmd.Probe != nil:
+		return serversProbe(ctx, c, cmd.Probe.Tags)
+	case cmd.Disable != nil:
+		return c.SetServerDisabled(ctx, cmd.Disable.Tag, true)
+	case cmd.Enable != nil:
+		return c.SetServerDisabled(ctx, cmd.Enable.Tag, false)
 	case cmd.Remove != nil:
 		return c.RemoveServers(ctx, cmd.Remove.Tags)
+	case cmd.Selected != nil:
+		return serversSelected(ctx, c)
+	case cmd.AutoSelected != nil:
+		return serversAutoSelections(ctx, c, cmd.AutoSelected.Watch)
 	case cmd.PrivateServer != nil:
 		return runPrivateServer(ctx, c, cmd.PrivateServer)
 	case cmd.List != nil:
@@ -103,18 +219,62 @@ func runServers(ctx context.Context, c *ipc.Client, cmd *ServersCmd) error {
 func runPrivateServer(ctx context.Context, c *ipc.Client, cmd *PrivateServerCmd) error {
 	switch {
 	case cmd.Add != nil:
+		if cmd.Add.Fingerprint != "" {
+			if err := c.ReplaceTrustedFingerprint(ctx, cmd.Add.IP, cmd.Add.Fingerprint); err != nil {
+				return err
+			}
+		}
 		return c.AddPrivateServer(ctx, cmd.Add.Tag, cmd.Add.IP, cmd.Add.Port, cmd.Add.Token)
+	case cmd.Remove != nil:
+		return c.RemovePrivateServer(ctx, cmd.Remove.IP)
 	case cmd.Invite != nil:
 		code, err := c.InviteToPrivateServer(ctx, cmd.Invite.IP, cmd.Invite.Port, cmd.Invite.Token, cmd.Invite.Name)
 		if err != nil {
 			return err
 		}
+		if cmd.Invite.QR {
+			fps, err := c.TrustedFingerprints(ctx)
+			if err != nil {
+				return err
+			}
+			var fingerprint string
+			for _, fp := range fps {
+				if fp.IP == cmd.Invite.IP {
+					fingerprint = fp.Fingerprint
+				}
+			}
+			code = servers.PrivateServerInviteQRPayload(cmd.Invite.IP, cmd.Invite.Port, code, fingerprint, "")
+		}
 		fmt.Println(code)
 		return nil
 	case cmd.RevokeInvite != nil:
 		return c.RevokePrivateServerInvite(ctx, cmd.RevokeInvite.IP, cmd.RevokeInvite.Port, cmd.RevokeInvite.Token, cmd.RevokeInvite.Name)
+	case cmd.Fingerprints != nil:
+		return runPrivateServerFingerprints(ctx, c, cmd.Fingerprints)
 	default:
-		return fmt.Errorf("must specify one of: add, invite, revoke-invite")
+		return fmt.Errorf("must specify one of: add, remove, invite, revoke-invite, fingerprints")
+	}
+}
+
+func runPrivateServerFingerprints(ctx context.Context, c *ipc.Client, cmd *PrivateServerFingerprintsCmd) error {
+	switch {
+	case cmd.Remove != nil:
+		return c.RemoveTrustedFingerprint(ctx, cmd.Remove.IP)
+	case cmd.Replace != nil:
+		return c.ReplaceTrustedFingerprint(ctx, cmd.Replace.IP, cmd.Replace.Fingerprint)
+	default:
+		fps, err := c.TrustedFingerprints(ctx)
+		if err != nil {
+			return err
+		}
+		if len(fps) == 0 {
+			fmt.Println("No trusted fingerprints")
+			return nil
+		}
+		for _, fp := range fps {
+			fmt.Printf("  %s: %s (trusted %s)\n", fp.IP, fp.Fingerprint, fp.TrustedAt.Format(time.DateTime))
+		}
+		return nil
 	}
 }
 
@@ -162,6 +322,8 @@ func serversList(ctx context.Context, c *ipc.Client, showLatency, asJSON bool, l
 				Type:             s.Type,
 				Location:         s.Location,
 				SelectionHistory: s.SelectionHistory,
+				Reachability:     s.Reachability,
+				Disabled:         s.Disabled,
 			})
 		}
 		return printJSON(out)
@@ -179,9 +341,17 @@ func serversList(ctx context.Context, c *ipc.Client, showLatency, asJSON bool, l
 
 func printServerEntry(s *servers.Server, showLatency bool) {
 	fmt.Printf("  %s [%s]", s.Tag, s.Type)
+	if s.Metadata != nil && len(s.Metadata.Labels) > 0 {
+		fmt.Printf(" {%s}", strings.Join(s.Metadata.Labels, ", "))
+	}
 	if s.Location != (C.ServerLocation{}) {
 		fmt.Printf(" — %s, %s", s.Location.City, s.Location.Country)
 	}
+	if s.Disabled {
+		fmt.Print(" (disabled)")
+	} else if s.Reachability != nil && !s.Reachability.Reachable {
+		fmt.Print(" (appears unreachable)")
+	}
 	if !showLatency {
 		fmt.Println()
 		return
@@ -195,6 +365,25 @@ func printServerEntry(s *servers.Server, showLatency bool) {
 	fmt.Println(" (n/a)")
 }
 
+func serversProbe(ctx context.Context, c *ipc.Client, tags []string) error {
+	results, err := c.ProbeServers(ctx, tags)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		r, ok := results[tag]
+		switch {
+		case !ok:
+			fmt.Printf("  %s: can't be probed\n", tag)
+		case r.Reachable:
+			fmt.Printf("  %s: reachable (%dms)\n", tag, r.LatencyMs)
+		default:
+			fmt.Printf("  %s: unreachable: %s\n", tag, r.Error)
+		}
+	}
+	return nil
+}
+
 func serversGet(ctx context.Context, c *ipc.Client, tag string) error {
 	svr, exists, err := c.GetServerByTag(ctx, tag)
 	if err != nil {
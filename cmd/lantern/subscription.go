@@ -17,6 +17,9 @@ type SubscriptionCmd struct {
 	Referral        *ReferralCmd           `arg:"subcommand:referral" help:"attach referral code"`
 	StripeBilling   *StripeBillingCmd      `arg:"subcommand:stripe-billing" help:"get Stripe billing portal URL"`
 	Verify          *VerifySubscriptionCmd `arg:"subcommand:verify" help:"verify subscription"`
+	Status          *SubscriptionStatusCmd `arg:"subcommand:status" help:"show the current subscription"`
+	ReferralInfo    *ReferralInfoCmd       `arg:"subcommand:referral-info" help:"show your referral code and referrals"`
+	Quote           *QuoteCmd              `arg:"subcommand:quote" help:"quote switching to a plan, crediting the current subscription"`
 }
 
 type SubscriptionPlansCmd struct {
@@ -48,8 +51,18 @@ type ReferralCmd struct {
 	Channel string `arg:"--channel" help:"subscription channel (optional; when set, returns plans and discount)"`
 }
 
+type ReferralInfoCmd struct{}
+
+type QuoteCmd struct {
+	Plan     string `arg:"positional,required" help:"plan ID"`
+	Channel  string `arg:"-c,--channel" help:"subscription channel"`
+	Currency string `arg:"--currency" default:"usd" help:"currency code"`
+}
+
 type StripeBillingCmd struct{}
 
+type SubscriptionStatusCmd struct{}
+
 type VerifySubscriptionCmd struct {
 	Service    string `arg:"-s,--service" help:"stripe, apple, or google"`
 	VerifyData string `arg:"-d,--data" help:"verification data as JSON"`
@@ -71,6 +84,12 @@ func runSubscription(ctx context.Context, c *ipc.Client, cmd *SubscriptionCmd) e
 		return subStripeBilling(ctx, c, cmd.StripeBilling)
 	case cmd.Verify != nil:
 		return subVerify(ctx, c, cmd.Verify)
+	case cmd.Status != nil:
+		return subStatus(ctx, c)
+	case cmd.ReferralInfo != nil:
+		return subReferralInfo(ctx, c)
+	case cmd.Quote != nil:
+		return subQuote(ctx, c, cmd.Quote)
 	default:
 		return fmt.Errorf("no subcommand specified")
 	}
@@ -213,6 +232,14 @@ func subReferral(ctx context.Context, c *ipc.Client, cmd *ReferralCmd) error {
 	return nil
 }
 
+func subReferralInfo(ctx context.Context, c *ipc.Client) error {
+	info, err := c.ReferralInfo(ctx)
+	if err != nil {
+		return err
+	}
+	return printJSON(info)
+}
+
 func subStripeBilling(ctx context.Context, c *ipc.Client, cmd *StripeBillingCmd) error {
 	url, err := c.StripeBillingPortalURL(ctx)
 	if err != nil {
@@ -222,6 +249,18 @@ func subStripeBilling(ctx context.Context, c *ipc.Client, cmd *StripeBillingCmd)
 	return nil
 }
 
+func subStatus(ctx context.Context, c *ipc.Client) error {
+	sub, err := c.Subscription(ctx)
+	if ipc.IsNotFound(err) {
+		fmt.Println("No subscription.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return printJSON(sub)
+}
+
 func subVerify(ctx context.Context, c *ipc.Client, cmd *VerifySubscriptionCmd) error {
 	service := cmd.Service
 	verifyData := cmd.VerifyData
@@ -249,3 +288,11 @@ func subVerify(ctx context.Context, c *ipc.Client, cmd *VerifySubscriptionCmd) e
 	fmt.Println(result)
 	return nil
 }
+
+func subQuote(ctx context.Context, c *ipc.Client, cmd *QuoteCmd) error {
+	quote, err := c.QuotePlanChange(ctx, cmd.Channel, cmd.Plan, cmd.Currency)
+	if err != nil {
+		return err
+	}
+	return printJSON(quote)
+}
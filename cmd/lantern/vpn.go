@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/getlantern/radiance/backend"
 	"github.com/getlantern/radiance/ipc"
 	"github.com/getlantern/radiance/vpn"
 )
@@ -14,9 +15,21 @@ import (
 type ConnectCmd struct {
 	Name string `arg:"-n,--name" default:"auto" help:"server name to connect to"`
 	Wait bool   `arg:"-w,--wait" default:"false" help:"wait for IP change after connecting"`
+	JSON bool   `arg:"--json" help:"output the resulting status as JSON"`
 }
 
-type DisconnectCmd struct{}
+type DisconnectCmd struct {
+	JSON bool `arg:"--json" help:"output the resulting status as JSON"`
+}
+
+type SelectCmd struct {
+	Tag  string `arg:"positional,required" help:"server tag, or auto"`
+	JSON bool   `arg:"--json" help:"output the selected server as JSON"`
+}
+
+type MetricsCmd struct {
+	JSON bool `arg:"--json" help:"output JSON"`
+}
 
 type ClearTunnelCacheCmd struct{}
 
@@ -28,7 +41,8 @@ type ThroughputCmd struct {
 	JSON bool `arg:"--json" help:"output JSON"`
 }
 
-func vpnConnect(ctx context.Context, c *ipc.Client, tag string, wait bool) error {
+func vpnConnect(ctx context.Context, c *ipc.Client, cmd *ConnectCmd) error {
+	tag, wait := cmd.Name, cmd.Wait
 	tctx, tcancel := context.WithTimeout(ctx, 5*time.Second)
 	var prevIP string
 	if wait {
@@ -53,6 +67,14 @@ func vpnConnect(ctx context.Context, c *ipc.Client, tag string, wait bool) error
 		return fmt.Errorf("busy with VPN status: %s", status)
 	}
 
+	if cmd.JSON {
+		if wait {
+			waitCtx, waitCancel := context.WithTimeout(ctx, 30*time.Second)
+			waitForIPChange(waitCtx, prevIP, 100*time.Millisecond)
+			waitCancel()
+		}
+		return printStatusJSON(ctx, c)
+	}
 	fmt.Printf("Connected (tag: %s)\n", tag)
 	if !wait {
 		return nil
@@ -71,6 +93,70 @@ func vpnConnect(ctx context.Context, c *ipc.Client, tag string, wait bool) error
 	return nil
 }
 
+func vpnDisconnect(ctx context.Context, c *ipc.Client, cmd *DisconnectCmd) error {
+	if err := c.DisconnectVPN(ctx); err != nil {
+		return err
+	}
+	if cmd.JSON {
+		return printStatusJSON(ctx, c)
+	}
+	return nil
+}
+
+// vpnSelect switches servers on a running tunnel without reconnecting.
+func vpnSelect(ctx context.Context, c *ipc.Client, cmd *SelectCmd) error {
+	if err := c.SelectServer(ctx, cmd.Tag); err != nil {
+		return err
+	}
+	if cmd.JSON {
+		return serversSelected(ctx, c)
+	}
+	fmt.Printf("Selected %s\n", cmd.Tag)
+	return nil
+}
+
+func vpnMetrics(ctx context.Context, c *ipc.Client, cmd *MetricsCmd) error {
+	m, err := c.VPNMetrics(ctx)
+	if err != nil {
+		return err
+	}
+	if cmd.JSON {
+		return printJSON(m)
+	}
+	printMetrics(m)
+	return nil
+}
+
+func printMetrics(m backend.Metrics) {
+	fmt.Printf("Status:       %s\n", m.Status)
+	if m.Status == vpn.Connected {
+		fmt.Printf("Uptime:       %s\n", time.Duration(m.UptimeSeconds)*time.Second)
+		if m.ActiveOutbound != "" {
+			fmt.Printf("Outbound:     %s\n", m.ActiveOutbound)
+		}
+		fmt.Printf("Traffic:      ↓ %s   ↑ %s\n", formatBytes(m.BytesDown), formatBytes(m.BytesUp))
+		fmt.Printf("Throughput:   ↓ %s   ↑ %s\n", formatBitsPerSec(m.Throughput.Down), formatBitsPerSec(m.Throughput.Up))
+		fmt.Printf("Connections:  %d active, %d DNS\n", m.ActiveConnections, m.DNSConnections)
+	}
+	if m.LastError != nil {
+		fmt.Printf("Last error:   %s (%s)\n", m.LastError.Error, m.LastError.Time.Format(time.RFC3339))
+	}
+	fmt.Printf("Memory:       %s heap, %s sys\n", formatBytes(int64(m.HeapBytes)), formatBytes(int64(m.SysBytes)))
+	fmt.Printf("Goroutines:   %d\n", m.Goroutines)
+	if len(m.URLTestResults) == 0 {
+		return
+	}
+	tags := make([]string, 0, len(m.URLTestResults))
+	for tag := range m.URLTestResults {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	fmt.Println("\nLatest URL test:")
+	for _, tag := range tags {
+		fmt.Printf("  %-32s %dms\n", tag, m.URLTestResults[tag])
+	}
+}
+
 func runClearTunnelCache(ctx context.Context, c *ipc.Client) error {
 	if err := c.ClearTunnelCache(ctx); err != nil {
 		return err
@@ -197,6 +283,14 @@ func fetchStatus(ctx context.Context, c *ipc.Client) (statusSnapshot, error) {
 	return snap, nil
 }
 
+func printStatusJSON(ctx context.Context, c *ipc.Client) error {
+	snap, err := fetchStatus(ctx, c)
+	if err != nil {
+		return err
+	}
+	return printJSON(snap)
+}
+
 func renderStatus(snap statusSnapshot, asJSON bool) error {
 	if asJSON {
 		return printJSON(snap)
@@ -19,6 +19,7 @@ import (
 
 	"github.com/getlantern/radiance/backend"
 	"github.com/getlantern/radiance/common"
+	"github.com/getlantern/radiance/common/env"
 	"github.com/getlantern/radiance/internal"
 	"github.com/getlantern/radiance/ipc"
 	rlog "github.com/getlantern/radiance/log"
@@ -26,9 +27,10 @@ import (
 )
 
 type runCmd struct {
-	DataPath string `arg:"--data-path" help:"path to store data"`
-	LogPath  string `arg:"--log-path" help:"path to store logs"`
-	LogLevel string `arg:"--log-level" default:"info" help:"logging level (trace, debug, info, warn, error)"`
+	DataPath       string `arg:"--data-path" help:"path to store data"`
+	LogPath        string `arg:"--log-path" help:"path to store logs"`
+	LogLevel       string `arg:"--log-level" default:"info" help:"logging level (trace, debug, info, warn, error)"`
+	DebugEndpoints bool   `arg:"--debug-endpoints" help:"serve pprof, expvar, and goroutine dumps over IPC"`
 }
 
 type installCmd struct {
@@ -79,6 +81,9 @@ func main() {
 			err = babysit(os.Args[1:], dataPath, logPath, a.Run.LogLevel)
 			break
 		}
+		if a.Run.DebugEndpoints {
+			env.Set(env.DebugEndpoints.String(), "true")
+		}
 		ctx, cancel := context.WithCancel(context.Background())
 		// Shut down on stdin closure (babysit parent signals us) or OS signal.
 		go func() {
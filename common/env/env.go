@@ -30,6 +30,8 @@ var (
 	AppVersion       _key = "RADIANCE_VERSION"
 	BufPoolBudgetMB  _key = "RADIANCE_BUF_POOL_BUDGET_MB"
 	MemoryLimitMB    _key = "RADIANCE_MEM_LIMIT_MB"
+	IPCToken         _key = "RADIANCE_IPC_TOKEN"
+	DebugEndpoints   _key = "RADIANCE_DEBUG_ENDPOINTS"
 
 	Testing _key = "RADIANCE_TESTING"
 
@@ -0,0 +1,37 @@
+// Package filelock provides advisory locks on files shared between processes, such as the files
+// both the GUI and the daemon write.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/getlantern/radiance/common/fileperm"
+)
+
+// Lock is an exclusive advisory lock on a lock file.
+type Lock struct {
+	f *os.File
+}
+
+// Acquire blocks until it holds an exclusive lock on the file at path, creating the file if it
+// doesn't exist. The lock is advisory: it only excludes other callers of Acquire, in this process
+// or another one. It's released when the process exits, even if Release isn't called.
+func Acquire(path string) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, fileperm.File)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("locking %s: %w", filepath.Base(path), err)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release releases the lock.
+func (l *Lock) Release() error {
+	return errors.Join(unlockFile(l.f), l.f.Close())
+}
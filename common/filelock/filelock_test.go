@@ -0,0 +1,40 @@
+package filelock
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquireExcludesOtherHolders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.lock")
+	first, err := Acquire(path)
+	require.NoError(t, err)
+
+	acquired := make(chan *Lock)
+	go func() {
+		second, err := Acquire(path)
+		if err != nil {
+			close(acquired)
+			return
+		}
+		acquired <- second
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while another holder had it")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, first.Release())
+	select {
+	case second, ok := <-acquired:
+		require.True(t, ok, "second Acquire failed")
+		require.NoError(t, second.Release())
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock not acquired after it was released")
+	}
+}
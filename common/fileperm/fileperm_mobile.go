@@ -6,3 +6,7 @@ package fileperm
 import "os"
 
 const File os.FileMode = 0o644
+
+// Secret is used for files holding keys or credentials, which must never be readable by other
+// users.
+const Secret os.FileMode = 0o600
@@ -35,7 +35,8 @@ const (
 	DeviceIDKey    _key = "device_id"    // string/int
 
 	// Application behavior related keys.
-	TelemetryKey _key = "telemetry_enabled" // bool
+	TelemetryKey              _key = "telemetry_enabled"       // bool
+	DismissedAnnouncementsKey _key = "dismissed_announcements" // []string, IDs of announcements the user dismissed
 
 	// User account related keys.
 	EmailKey         _key = "email"          // string
@@ -47,6 +48,8 @@ const (
 	UserDataKey      _key = "user_data"      // [account.UserData]
 	OAuthLoginKey    _key = "oauth_login"    // bool
 	OAuthProviderKey _key = "oauth_provider" // string (e.g. "google", "apple", "email")
+	UserFetchedKey   _key = "user_fetched"   // int64, unix time [UserDataKey] was last received from the server
+	AccountsKey      _key = "accounts"       // map of user ID to the user account settings of the inactive accounts
 
 	// VPN related keys.
 	SmartRoutingKey   _key = "smart_routing"   // bool
@@ -56,6 +59,17 @@ const (
 	SelectedServerKey _key = "selected_server" // [servers.Server] Server.Options is not stored
 
 	PreferredLocationKey _key = "preferred_location" // [common.PreferredLocation]
+	LocalDNSServerKey    _key = "local_dns_server"   // string, overrides the locale-based default
+	CountryOverrideKey   _key = "country_override"   // string, ISO 3166-1 alpha-2 code sent instead of the IP-derived country
+	ActiveProfileKey     _key = "active_profile"     // string, see [config.Profile]
+	ConfigSourcesKey     _key = "config_sources"     // [][config.ConfigSource] in addition to the Lantern API
+	ConfigMaxAgeKey      _key = "config_max_age"     // duration after which an unconfirmed config is stale
+	ConfigExpiryKey      _key = "config_expiry"      // duration after which config servers are no longer used; unset means never
+
+	// User server related keys.
+	ProbeServersOnAddKey       _key = "probe_servers_on_add"       // bool, probe the reachability of user servers when they're added
+	DisableDeadServersAfterKey _key = "disable_dead_servers_after" // duration after which failing user servers are disabled; unset means never
+	ServerGeoIPDatabaseKey     _key = "server_geoip_database"      // path to a MaxMind database used to locate user servers added without a location
 
 	settingsFileName = "settings.json"
 	// legacySettingsFileName is what v9.0.x called the same file (it was
@@ -244,7 +258,7 @@ func migrateLegacySettingsIfNeeded(fileDir, canonicalPath string) {
 // if the write fails the caller falls through to the fresh-install path,
 // which is a worse UX but not a corruption risk.
 func writeMigrated(canonicalPath string, contents []byte, source string) {
-	if err := atomicfile.WriteFile(canonicalPath, contents, fileperm.File); err != nil {
+	if err := atomicfile.WriteFile(canonicalPath, contents, fileperm.Secret); err != nil {
 		slog.Warn("legacy settings migration: write failed",
 			"dst", canonicalPath, "source", source, "error", err)
 		return
@@ -290,7 +304,7 @@ func quarantineInvalidSettings(path string) {
 		return
 	}
 	invalidPath := filepath.Join(filepath.Dir(path), settingsInvalidFileName)
-	if err := atomicfile.WriteFile(invalidPath, rawSettings, fileperm.File); err != nil {
+	if err := atomicfile.WriteFile(invalidPath, rawSettings, fileperm.Secret); err != nil {
 		slog.Error("writing invalid settings copy", "path", invalidPath, "error", err)
 		return
 	}
@@ -423,7 +437,7 @@ func save() error {
 		return fmt.Errorf("could not marshal koanf file: %w", err)
 	}
 
-	err = atomicfile.WriteFile(k.filePath, out, fileperm.File)
+	err = atomicfile.WriteFile(k.filePath, out, fileperm.Secret)
 	if err != nil {
 		return fmt.Errorf("could not write koanf file: %w", err)
 	}
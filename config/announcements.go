@@ -0,0 +1,69 @@
+package config
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/getlantern/radiance/common/settings"
+)
+
+// announcementsFlag is the flag the server sends announcements in. The server targets them at the
+// user by the locale, user, and country of the config request, so the client shows all it gets.
+const announcementsFlag = "announcements"
+
+// Announcement is a message or survey the server wants shown to the user.
+type Announcement struct {
+	ID string `json:"id"`
+	// Kind is "message" or "survey".
+	Kind  string `json:"kind"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// URL is the survey or the page the announcement links to, if any.
+	URL string `json:"url,omitempty"`
+	// StartsAt and EndsAt bound when the announcement is shown. Either may be zero.
+	StartsAt time.Time `json:"starts_at,omitzero"`
+	EndsAt   time.Time `json:"ends_at,omitzero"`
+	// Dismissed is true if the user dismissed the announcement with [DismissAnnouncement].
+	Dismissed bool `json:"dismissed"`
+}
+
+// Announcements returns the announcements in the latest config that are currently running,
+// including the ones the user dismissed, which are marked as such.
+func Announcements() []Announcement {
+	raw, ok := Flags()[announcementsFlag]
+	if !ok {
+		return nil
+	}
+	var all []Announcement
+	if err := json.Unmarshal(raw, &all); err != nil {
+		slog.Warn("Failed to parse announcements", "error", err)
+		return nil
+	}
+	dismissed := settings.GetStringSlice(settings.DismissedAnnouncementsKey)
+	now := time.Now()
+	var current []Announcement
+	for _, a := range all {
+		if a.ID == "" || (!a.StartsAt.IsZero() && now.Before(a.StartsAt)) || (!a.EndsAt.IsZero() && !now.Before(a.EndsAt)) {
+			continue
+		}
+		a.Dismissed = slices.Contains(dismissed, a.ID)
+		current = append(current, a)
+	}
+	return current
+}
+
+// DismissAnnouncement records that the user dismissed the announcement with the given ID, so it's
+// no longer shown. Dismissals of announcements that are no longer running are forgotten.
+func DismissAnnouncement(id string) error {
+	current := Announcements()
+	ids := []string{id}
+	for _, a := range current {
+		if a.Dismissed && a.ID != id {
+			ids = append(ids, a.ID)
+		}
+	}
+	slices.Sort(ids)
+	return settings.Set(settings.DismissedAnnouncementsKey, ids)
+}
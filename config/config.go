@@ -5,6 +5,7 @@ package config
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,7 +31,6 @@ import (
 
 	"github.com/getlantern/radiance/account"
 	"github.com/getlantern/radiance/common"
-	"github.com/getlantern/radiance/common/atomicfile"
 	"github.com/getlantern/radiance/common/fileperm"
 	"github.com/getlantern/radiance/common/settings"
 	"github.com/getlantern/radiance/events"
@@ -62,6 +62,14 @@ type Options struct {
 	AccountClient *account.Client
 	Logger        *slog.Logger
 	HTTPClient    *http.Client
+	// HistorySize is how many fetched configs to keep on disk for rollback. Defaults to 5.
+	HistorySize int
+	// Clock and FS default to the system clock and the real file system.
+	Clock Clock
+	FS    FS
+	// OnFetch, if set, is called after every fetch attempt, e.g. so the tester can check fetch
+	// timing. It is called synchronously while no other fetch can start, so it must not block.
+	OnFetch func(FetchInfo)
 }
 
 // ConfigHandler handles fetching the proxy configuration from the proxy server. It provides access
@@ -85,11 +93,36 @@ type ConfigHandler struct {
 	fetchMu  sync.Mutex
 	fetching bool
 	pending  bool
+	// force makes the next fetch unconditional; see ForceRefresh.
+	force bool
+	// fetchRunMu is held while a fetch runs, so previews never overlap it. It guards the
+	// fetcher's conditional state, appliedHash, and preview.
+	fetchRunMu sync.Mutex
+	preview    *pendingConfig
 
 	pollInterval time.Duration
 	configPath   string
 	wgKeyPath    string
 	startOnce    sync.Once
+
+	historyDir  string
+	historySize int
+	// current holds the history ID of the applied config, once known.
+	current atomic.Value
+
+	profilesPath string
+	profilesMu   sync.Mutex
+
+	fetches fetchLog
+
+	// confirmedAt is when the server last confirmed the applied config, in Unix nanoseconds.
+	confirmedAt   atomic.Int64
+	freshnessMu   sync.Mutex
+	lastFreshness Freshness
+
+	// appliedHash is the SHA-256 of the raw response behind the applied config. Fetches that
+	// return identical bytes are not re-parsed or re-applied. Guarded by fetchRunMu.
+	appliedHash [sha256.Size]byte
 }
 
 // NewConfigHandler creates a new ConfigHandler that fetches the proxy configuration every pollInterval.
@@ -103,6 +136,10 @@ func NewConfigHandler(ctx context.Context, options Options) *ConfigHandler {
 	if logger == nil {
 		logger = slog.Default()
 	}
+	historySize := options.HistorySize
+	if historySize <= 0 {
+		historySize = defaultHistorySize
+	}
 	dir := options.DataPath
 	ch := &ConfigHandler{
 		ctx:          ctx,
@@ -110,15 +147,28 @@ func NewConfigHandler(ctx context.Context, options Options) *ConfigHandler {
 		pollInterval: pollInterval,
 		configPath:   filepath.Join(dir, internal.ConfigFileName),
 		wgKeyPath:    filepath.Join(dir, "wg.key"),
+		historyDir:   filepath.Join(dir, internal.ConfigHistoryDirName),
+		historySize:  historySize,
+		profilesPath: filepath.Join(dir, internal.ProfilesFileName),
 		logger:       logger,
 		options:      options,
 	}
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	if err := ch.files().MkdirAll(dir, 0o755); err != nil {
 		ch.logger.Error("creating config directory", "error", err)
 	}
 	if err := ch.loadConfig(); err != nil {
 		ch.logger.Error("failed to load config", "error", err)
 	}
+	if ch.config.Load() != nil {
+		if raw, err := ch.files().ReadFile(ch.rawConfigPath()); err == nil {
+			ch.appliedHash = sha256.Sum256(raw)
+			if f, err := parseFlags(raw); err == nil {
+				flags.Store(&f)
+			}
+			ch.loadConfirmedAt()
+		}
+	}
+	ch.lastFreshness = ch.Freshness()
 	return ch
 }
 
@@ -139,7 +189,7 @@ func (ch *ConfigHandler) Start() {
 var ErrNoWGKey = errors.New("no wg key")
 
 func (ch *ConfigHandler) loadWGKey() (wgtypes.Key, error) {
-	buf, err := atomicfile.ReadFile(ch.wgKeyPath)
+	buf, err := ch.files().ReadFile(ch.wgKeyPath)
 	if os.IsNotExist(err) {
 		return wgtypes.Key{}, ErrNoWGKey
 	}
@@ -174,7 +224,18 @@ func (ch *ConfigHandler) fetchConfig() error {
 
 	var lastErr error
 	for {
+		ch.fetchMu.Lock()
+		force := ch.force
+		ch.force = false
+		ch.fetchMu.Unlock()
+		ch.fetchRunMu.Lock()
+		if force {
+			ch.resetConditionalState()
+		}
+		start, prev := ch.clock().Now(), ch.config.Load()
 		lastErr = ch.doFetchConfig()
+		ch.recordFetch(start, ch.config.Load() != prev, lastErr)
+		ch.fetchRunMu.Unlock()
 		ch.fetchMu.Lock()
 		if !ch.pending {
 			ch.fetching = false
@@ -198,9 +259,12 @@ func (ch *ConfigHandler) doFetchConfig() error {
 			return fmt.Errorf("failed to generate wg keys: %w", keyErr)
 		}
 
-		if writeErr := atomicfile.WriteFile(ch.wgKeyPath, []byte(privateKey.String()), fileperm.File); writeErr != nil {
+		if writeErr := ch.files().WriteFile(ch.wgKeyPath, []byte(privateKey.String()), fileperm.Secret); writeErr != nil {
 			return fmt.Errorf("writing wg key file: %w", writeErr)
 		}
+		// The applied config embeds the old private key, so it must be re-applied even if the
+		// server sends the same bytes.
+		ch.appliedHash = [sha256.Size]byte{}
 	}
 
 	ch.logger.Info("Fetching config")
@@ -215,37 +279,97 @@ func (ch *ConfigHandler) doFetchConfig() error {
 	}
 	if resp == nil {
 		ch.logger.Info("no new config available")
+		ch.confirm()
 		return nil
 	}
 	ch.logger.Info("Config fetched from server")
+	hash := sha256.Sum256(resp)
+	if hash == ch.appliedHash && ch.config.Load() != nil {
+		ch.logger.Info("Fetched config is unchanged, not re-applying")
+		ch.commitFetch()
+		ch.confirm()
+		return nil
+	}
 
-	// Save the raw config for debugging
-	if writeErr := atomicfile.WriteFile(strings.TrimSuffix(ch.configPath, ".json")+"_raw.json", resp, fileperm.File); writeErr != nil {
-		ch.logger.Error("writing raw config file", "error", writeErr)
+	confResp, err := ch.prepareConfig(resp, privateKey)
+	if err != nil {
+		// Kept for diagnostics, away from the raw config, which must only ever hold the applied one.
+		if writeErr := ch.files().WriteFile(ch.invalidConfigPath(), resp, fileperm.Secret); writeErr != nil {
+			ch.logger.Error("writing rejected config file", "error", writeErr)
+		}
+		return err
 	}
+	return ch.applyFetched(confResp, resp)
+}
 
-	// Otherwise, we keep the previous config and store any error that might have occurred.
-	// We still want to keep the previous config if there was an error. This is important
-	// because the error could have been due to temporary network issues, such as brief
-	// power loss or internet disconnection.
-	// On the other hand, if we have a new config, we want to overwrite any previous error.
+// prepareConfig parses and validates a raw config response and fills in the client-side options.
+//
+// On error, the caller keeps the previous config. This is important because the error could
+// have been due to temporary network issues, such as brief power loss or internet disconnection.
+func (ch *ConfigHandler) prepareConfig(resp []byte, privateKey wgtypes.Key) (*Config, error) {
 	confResp, err := singjson.UnmarshalExtendedContext[C.ConfigResponse](box.BaseContext(), resp)
 	if err != nil {
 		ch.logger.Error("failed to parse config", "error", err)
-		return fmt.Errorf("parsing config: %w", err)
+		return nil, fmt.Errorf("parsing config: %w", err)
 	}
 	cleanTags(&confResp)
+	if err := validateConfig(&confResp); err != nil {
+		ch.logger.Error("rejecting invalid config", "error", err)
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			events.Emit(ConfigRejectedEvent{Problems: verr.Problems})
+		}
+		return nil, fmt.Errorf("validating config: %w", err)
+	}
 
 	setWireGuardKeyInOptions(confResp.Options.Endpoints, privateKey)
 	setCustomProtocolOptions(confResp.Options.Outbounds)
-	if err := ch.setConfig(&confResp); err != nil {
+	return &confResp, nil
+}
+
+// applyFetched applies a config prepared from the raw response. Must only be called with
+// fetchRunMu held.
+func (ch *ConfigHandler) applyFetched(cfg *Config, raw []byte) error {
+	if err := ch.setConfig(cfg); err != nil {
 		ch.logger.Error("failed to set config", "error", err)
 		return fmt.Errorf("setting config: %w", err)
 	}
+	ch.appliedHash = sha256.Sum256(raw)
+	// The applied hash, flags, and freshness are restored from the raw config on the next start.
+	if err := ch.files().WriteFile(ch.rawConfigPath(), raw, fileperm.Secret); err != nil {
+		ch.logger.Error("writing raw config file", "error", err)
+	}
+	ch.commitFetch()
+	if f, err := parseFlags(raw); err != nil {
+		ch.logger.Error("failed to parse config flags, keeping the previous ones", "error", err)
+	} else {
+		setFlags(f)
+	}
+	ch.recordHistory(cfg)
+	ch.confirm()
 	ch.logger.Info("Config fetched")
 	return nil
 }
 
+// rawConfigPath is where the unprocessed server response behind the applied config is kept,
+// next to config.json.
+func (ch *ConfigHandler) rawConfigPath() string {
+	return strings.TrimSuffix(ch.configPath, ".json") + "_raw.json"
+}
+
+// invalidConfigPath is where the last config that failed to parse or validate is kept.
+func (ch *ConfigHandler) invalidConfigPath() string {
+	return filepath.Join(filepath.Dir(ch.configPath), internal.ConfigInvalidFileName)
+}
+
+// commitFetch lets later fetches build on the last one, now that its config has been applied.
+// Must only be called with fetchRunMu held.
+func (ch *ConfigHandler) commitFetch() {
+	if f, ok := ch.ftr.(*fetcher); ok {
+		f.commit()
+	}
+}
+
 func setCustomProtocolOptions(outbounds []option.Outbound) {
 	for _, outbound := range outbounds {
 		switch opts := outbound.Options.(type) {
@@ -302,9 +426,19 @@ func setWireGuardKeyInOptions(endpoints []option.Endpoint, privateKey wgtypes.Ke
 func (ch *ConfigHandler) fetchLoop(defaultPollInterval time.Duration) {
 	backoff := common.NewBackoff(maxRetryDelay)
 	for {
-		if err := ch.fetchConfig(); err != nil {
+		err := ch.fetchConfig()
+		ch.checkFreshness()
+		if err != nil {
 			ch.logger.Error("Failed to fetch config. Retrying", "error", err)
-			backoff.Wait(ch.ctx)
+			if wait := account.RetryAfter(err); wait > 0 {
+				// the server asked us to wait; retrying sooner would only be refused again
+				select {
+				case <-ch.ctx.Done():
+				case <-ch.clock().After(wait):
+				}
+			} else {
+				backoff.Wait(ch.ctx)
+			}
 			if ch.ctx.Err() != nil {
 				return
 			}
@@ -326,11 +460,15 @@ func (ch *ConfigHandler) fetchLoop(defaultPollInterval time.Duration) {
 				"default", defaultPollInterval,
 			)
 		}
+		if ch.Freshness().Stale && interval > staleRetryInterval {
+			ch.logger.Debug("Config is stale, retrying sooner", "interval", staleRetryInterval)
+			interval = staleRetryInterval
+		}
 
 		select {
 		case <-ch.ctx.Done():
 			return
-		case <-time.After(interval):
+		case <-ch.clock().After(interval):
 		}
 	}
 }
@@ -347,6 +485,44 @@ func (ch *ConfigHandler) Fetch() error {
 	return ch.fetchConfig()
 }
 
+// ForceRefresh fetches and applies the latest config now, bypassing ETag, delta, and unchanged-
+// content checks so the server's current config is re-applied even if it matches the local one.
+// If a fetch is already in flight, the forced fetch runs right after it and ForceRefresh returns
+// nil without waiting. It returns ctx.Err() if ctx is done first; the fetch itself still runs to
+// completion in the background.
+func (ch *ConfigHandler) ForceRefresh(ctx context.Context) error {
+	if settings.GetBool(settings.ConfigFetchDisabledKey) {
+		return ErrConfigFetchDisabled
+	}
+	if !ch.started.Load() {
+		return fmt.Errorf("config handler not started")
+	}
+	ch.fetchMu.Lock()
+	ch.force = true
+	ch.fetchMu.Unlock()
+
+	done := make(chan error, 1)
+	go func() { done <- ch.fetchConfig() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// resetConditionalState drops everything that lets a fetch be skipped or shortened. Must only be
+// called with fetchRunMu held.
+func (ch *ConfigHandler) resetConditionalState() {
+	ch.appliedHash = [sha256.Size]byte{}
+	if f, ok := ch.ftr.(*fetcher); ok {
+		f.etag = ""
+		f.lastModified = time.Time{}
+		f.base = nil
+		f.pending = nil
+	}
+}
+
 // Stop stops the ConfigHandler from fetching new configurations.
 func (ch *ConfigHandler) Stop() {
 	ch.cancel()
@@ -365,7 +541,7 @@ func (ch *ConfigHandler) isClosed() bool {
 // nil.
 func (ch *ConfigHandler) loadConfig() error {
 	ch.logger.Debug("reading config file")
-	cfg, err := load(ch.configPath)
+	cfg, err := load(ch.files(), ch.configPath)
 	if err != nil {
 		return fmt.Errorf("reading config file: %w", err)
 	}
@@ -375,8 +551,8 @@ func (ch *ConfigHandler) loadConfig() error {
 	return nil
 }
 
-func load(path string) (*Config, error) {
-	rawConfig, err := atomicfile.ReadFile(path)
+func load(fsys FS, path string) (*Config, error) {
+	rawConfig, err := fsys.ReadFile(path)
 	if errors.Is(err, fs.ErrNotExist) {
 		return nil, nil // No config file yet
 	}
@@ -393,7 +569,7 @@ func load(path string) (*Config, error) {
 	// TODO: remove this migration once the old config format no longer appears
 	// on disk in the field.
 	if migrated, mErr := migrateToNewFmt(rawConfig); mErr == nil {
-		if err := saveConfig(migrated, path); err != nil {
+		if err := saveConfig(fsys, migrated, path); err != nil {
 			return nil, fmt.Errorf("saving migrated config: %w", err)
 		}
 		return migrated, nil
@@ -405,23 +581,23 @@ func load(path string) (*Config, error) {
 	// with no config; the next successful fetch repopulates config.json.
 	slog.Warn("config file is invalid; quarantining and starting without a config",
 		"path", path, "error", err)
-	quarantineInvalidConfig(path, rawConfig)
+	quarantineInvalidConfig(fsys, path, rawConfig)
 	return nil, nil
 }
 
 // quarantineInvalidConfig moves an unparseable config aside to
 // config.invalid.json so it no longer blocks loading on subsequent starts while
 // remaining available for diagnostics.
-func quarantineInvalidConfig(path string, buf []byte) {
+func quarantineInvalidConfig(fsys FS, path string, buf []byte) {
 	invalidPath := filepath.Join(filepath.Dir(path), internal.ConfigInvalidFileName)
-	if err := atomicfile.WriteFile(invalidPath, buf, fileperm.File); err != nil {
+	if err := fsys.WriteFile(invalidPath, buf, fileperm.Secret); err != nil {
 		// Keep the original in place when the copy fails so the unparseable
 		// config isn't lost entirely; the next fetch overwrites it regardless.
 		slog.Error("writing invalid config copy; leaving original in place", "path", invalidPath, "error", err)
 		return
 	}
 	slog.Warn("quarantined unparseable config for diagnostics", "path", invalidPath)
-	if err := os.Remove(path); err != nil {
+	if err := fsys.Remove(path); err != nil {
 		slog.Error("removing unparseable config file", "path", path, "error", err)
 	}
 }
@@ -444,7 +620,7 @@ func migrateToNewFmt(data []byte) (*Config, error) {
 }
 
 // saveConfig saves the config to the disk. It creates the config file if it doesn't exist.
-func saveConfig(cfg *Config, path string) error {
+func saveConfig(fsys FS, cfg *Config, path string) error {
 	// Marshal the config to bytes and write it to the config file.
 	// If the config is nil, we don't write anything.
 	// This is important because we don't want to overwrite the config file with an empty file.
@@ -452,7 +628,7 @@ func saveConfig(cfg *Config, path string) error {
 	if err != nil {
 		return fmt.Errorf("marshalling config: %w", err)
 	}
-	return atomicfile.WriteFile(path, buf, fileperm.File)
+	return fsys.WriteFile(path, buf, fileperm.Secret)
 }
 
 // GetConfig returns the current configuration. It returns an error if the config is not yet available.
@@ -473,7 +649,7 @@ func (ch *ConfigHandler) setConfig(cfg *Config) error {
 	oldConfig, _ := ch.GetConfig()
 	ch.config.Store(cfg)
 	ch.logger.Debug("Saving config", "path", ch.configPath)
-	if err := saveConfig(cfg, ch.configPath); err != nil {
+	if err := saveConfig(ch.files(), cfg, ch.configPath); err != nil {
 		ch.logger.Error("saving config", "error", err)
 		return fmt.Errorf("saving config: %w", err)
 	}
@@ -11,9 +11,16 @@ import (
 	"time"
 
 	C "github.com/getlantern/common"
+	singjson "github.com/sagernet/sing/common/json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	box "github.com/getlantern/lantern-box"
+
+	"github.com/getlantern/radiance/common"
+	"github.com/getlantern/radiance/common/fileperm"
+	"github.com/getlantern/radiance/common/settings"
+	"github.com/getlantern/radiance/events"
 	"github.com/getlantern/radiance/internal"
 	"github.com/getlantern/radiance/log"
 )
@@ -32,7 +39,7 @@ func TestSaveConfig(t *testing.T) {
 		},
 	}
 	// Save the config
-	err := saveConfig(&expectedConfig, configPath)
+	err := saveConfig(osFS{}, &expectedConfig, configPath)
 	require.NoError(t, err, "Should not return an error when saving config")
 
 	// Read the file content
@@ -54,7 +61,7 @@ func TestLoadQuarantinesUnparseableConfig(t *testing.T) {
 	require.NoError(t, os.WriteFile(configPath,
 		[]byte(`{"options":{"outbounds":[{"tag":"x","type":"future-proto"}]}}`), 0o600))
 
-	cfg, err := load(configPath)
+	cfg, err := load(osFS{}, configPath)
 	require.NoError(t, err, "unparseable config must not be a fatal error")
 	assert.Nil(t, cfg, "no config should be returned for an unparseable file")
 
@@ -177,9 +184,19 @@ func TestHandlerFetchConfig(t *testing.T) {
 		mockFetcher.response = []byte(`invalid json`)
 		mockFetcher.err = nil
 
-		err := ch.fetchConfig()
+		applied, err := ch.files().ReadFile(ch.rawConfigPath())
+		require.NoError(t, err)
+
+		err = ch.fetchConfig()
 		require.Error(t, err, "Should return an error when config parsing fails")
 		assert.Contains(t, err.Error(), "parsing config", "Error message should indicate parsing error")
+
+		raw, err := ch.files().ReadFile(ch.rawConfigPath())
+		require.NoError(t, err)
+		assert.Equal(t, applied, raw, "a rejected config must not replace the applied one")
+		rejected, err := ch.files().ReadFile(filepath.Join(tempDir, internal.ConfigInvalidFileName))
+		require.NoError(t, err)
+		assert.Equal(t, "invalid json", string(rejected))
 	})
 }
 
@@ -266,3 +283,488 @@ func (bf *BlockingFetcher) fetchConfig(ctx context.Context, preferred C.ServerLo
 	return bf.response, bf.err
 }
 
+func TestConfigHistoryRollback(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockFetcher := &MockFetcher{}
+	ch := &ConfigHandler{
+		configPath:  filepath.Join(tempDir, internal.ConfigFileName),
+		wgKeyPath:   filepath.Join(tempDir, "wg.key"),
+		historyDir:  filepath.Join(tempDir, internal.ConfigHistoryDirName),
+		historySize: 2,
+		ftr:         mockFetcher,
+		ctx:         ctx,
+		cancel:      cancel,
+		logger:      log.NoOpLogger(),
+	}
+
+	for _, city := range []string{"London", "Paris", "Berlin"} {
+		mockFetcher.response = []byte(`{"Servers":[{"Country":"XX","City":"` + city + `"}]}`)
+		require.NoError(t, ch.fetchConfig())
+	}
+
+	versions, err := ch.History()
+	require.NoError(t, err)
+	require.Len(t, versions, 2, "history should be pruned to historySize")
+	assert.True(t, versions[0].Current)
+	assert.False(t, versions[1].Current)
+	info, err := os.Stat(ch.historyDir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+	info, err = os.Stat(filepath.Join(ch.historyDir, versions[0].ID+".json"))
+	require.NoError(t, err)
+	assert.Equal(t, fileperm.Secret, info.Mode().Perm())
+
+	require.NoError(t, ch.Rollback())
+	cfg, err := ch.GetConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", cfg.Servers[0].City)
+
+	versions, err = ch.History()
+	require.NoError(t, err)
+	assert.True(t, versions[1].Current, "rollback should not add a history entry")
+
+	require.ErrorIs(t, ch.Rollback(), ErrNoPreviousConfig)
+	require.ErrorIs(t, ch.RollbackTo("nope"), ErrConfigVersionNotFound)
+
+	restarted := &ConfigHandler{
+		configPath: ch.configPath,
+		historyDir: ch.historyDir,
+		logger:     log.NoOpLogger(),
+	}
+	versions, err = restarted.History()
+	require.NoError(t, err)
+	assert.True(t, versions[1].Current, "the rollback should still be in effect after a restart")
+
+	require.NoError(t, ch.RollbackTo(versions[0].ID))
+	cfg, err = ch.GetConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "Berlin", cfg.Servers[0].City)
+
+	// An unparseable entry is reported and left alone rather than quarantined.
+	corrupt := filepath.Join(ch.historyDir, versions[1].ID+".json")
+	require.NoError(t, os.WriteFile(corrupt, []byte("not json"), 0o600))
+	require.Error(t, ch.RollbackTo(versions[1].ID))
+	buf, err := os.ReadFile(corrupt)
+	require.NoError(t, err)
+	assert.Equal(t, "not json", string(buf))
+	assert.NoFileExists(t, filepath.Join(ch.historyDir, internal.ConfigInvalidFileName))
+	cfg, err = ch.GetConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "Berlin", cfg.Servers[0].City)
+}
+
+func TestFetchConfigSkipsUnchanged(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockFetcher := &MockFetcher{response: []byte(`{"Servers":[{"Country":"US","City":"New York"}]}`)}
+	ch := &ConfigHandler{
+		configPath:  filepath.Join(tempDir, internal.ConfigFileName),
+		wgKeyPath:   filepath.Join(tempDir, "wg.key"),
+		historyDir:  filepath.Join(tempDir, internal.ConfigHistoryDirName),
+		historySize: 5,
+		ftr:         mockFetcher,
+		ctx:         ctx,
+		cancel:      cancel,
+		logger:      log.NoOpLogger(),
+	}
+
+	require.NoError(t, ch.fetchConfig())
+	first, err := ch.GetConfig()
+	require.NoError(t, err)
+	require.NoError(t, ch.fetchConfig())
+	second, err := ch.GetConfig()
+	require.NoError(t, err)
+	assert.Same(t, first, second, "identical response should not be re-applied")
+
+	versions, err := ch.History()
+	require.NoError(t, err)
+	assert.Len(t, versions, 1)
+}
+
+func TestForceRefreshReappliesUnchanged(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockFetcher := &MockFetcher{response: []byte(`{"Servers":[{"Country":"US","City":"New York"}]}`)}
+	ch := &ConfigHandler{
+		configPath: filepath.Join(tempDir, internal.ConfigFileName),
+		wgKeyPath:  filepath.Join(tempDir, "wg.key"),
+		ftr:        mockFetcher,
+		ctx:        ctx,
+		cancel:     cancel,
+		logger:     log.NoOpLogger(),
+	}
+	ch.started.Store(true)
+
+	require.NoError(t, ch.fetchConfig())
+	first, err := ch.GetConfig()
+	require.NoError(t, err)
+	require.NoError(t, ch.ForceRefresh(t.Context()))
+	second, err := ch.GetConfig()
+	require.NoError(t, err)
+	assert.NotSame(t, first, second, "forced refresh should re-apply an unchanged config")
+}
+
+func TestValidateConfig(t *testing.T) {
+	parse := func(t *testing.T, raw string) *Config {
+		cfg, err := singjson.UnmarshalExtendedContext[Config](box.BaseContext(), []byte(raw))
+		require.NoError(t, err)
+		return &cfg
+	}
+	tests := []struct {
+		name      string
+		raw       string
+		wantPaths []string
+	}{
+		{
+			name: "valid",
+			raw: `{"options":{
+				"outbounds":[{"type":"direct","tag":"infra"},{"type":"shadowsocks","tag":"ss","server":"1.2.3.4","server_port":443,"method":"aes-128-gcm","password":"x"}],
+				"dns":{"servers":[{"type":"udp","tag":"d1","server":"8.8.8.8"}],"final":"d1"}},
+				"non_selectable_outbounds":["infra"]}`,
+		},
+		{
+			name: "no outbounds",
+			raw:  `{}`,
+		},
+		{
+			name: "duplicate and missing tags",
+			raw: `{"options":{"outbounds":[
+				{"type":"shadowsocks","tag":"ss","server":"1.2.3.4","server_port":443,"method":"aes-128-gcm","password":"x"},
+				{"type":"shadowsocks","tag":"ss","server":"1.2.3.5","server_port":443,"method":"aes-128-gcm","password":"x"},
+				{"type":"shadowsocks","server":"1.2.3.6","server_port":443,"method":"aes-128-gcm","password":"x"}]}}`,
+			wantPaths: []string{"options.outbounds[1]", "options.outbounds[2]"},
+		},
+		{
+			name:      "nothing selectable",
+			raw:       `{"options":{"outbounds":[{"type":"direct","tag":"infra"}]}}`,
+			wantPaths: []string{"options"},
+		},
+		{
+			name: "dangling references",
+			raw: `{"options":{
+				"outbounds":[{"type":"shadowsocks","tag":"ss","server":"1.2.3.4","server_port":443,"method":"aes-128-gcm","password":"x"}],
+				"dns":{"servers":[{"type":"udp","tag":"d1","server":"8.8.8.8"}],"final":"d2"}},
+				"non_selectable_outbounds":["gone"]}`,
+			wantPaths: []string{"non_selectable_outbounds[0]", "options.dns.final"},
+		},
+		{
+			name:      "empty dns",
+			raw:       `{"options":{"dns":{}}}`,
+			wantPaths: []string{"options.dns"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(parse(t, tt.raw))
+			if len(tt.wantPaths) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrInvalidConfig)
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			var paths []string
+			for _, p := range verr.Problems {
+				paths = append(paths, p.Path)
+			}
+			assert.Equal(t, tt.wantPaths, paths)
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	require.NoError(t, settings.InitSettings(t.TempDir()))
+	defer settings.Reset()
+
+	ch := &ConfigHandler{
+		profilesPath: filepath.Join(t.TempDir(), internal.ProfilesFileName),
+		logger:       log.NoOpLogger(),
+	}
+	profiles, err := ch.Profiles()
+	require.NoError(t, err)
+	assert.Empty(t, profiles)
+
+	home := Profile{Name: "home", DNSServer: "1.1.1.1"}
+	work := Profile{
+		Name:              "work",
+		PreferredLocation: common.PreferredLocation{Country: "Germany", CountryCode: "DE"},
+		Servers:           []string{"office"},
+	}
+	require.NoError(t, ch.SaveProfile(work))
+	require.NoError(t, ch.SaveProfile(home))
+	profiles, err = ch.Profiles()
+	require.NoError(t, err)
+	assert.Equal(t, []Profile{home, work}, profiles, "profiles should be sorted by name")
+	info, err := os.Stat(ch.profilesPath)
+	require.NoError(t, err)
+	assert.Equal(t, fileperm.Secret, info.Mode().Perm())
+
+	_, err = ch.SwitchProfile("missing")
+	require.ErrorIs(t, err, ErrProfileNotFound)
+
+	p, err := ch.SwitchProfile("work")
+	require.NoError(t, err)
+	assert.True(t, p.HasServer("office"))
+	assert.False(t, p.HasServer("other"))
+	assert.Equal(t, "work", settings.GetString(settings.ActiveProfileKey))
+	var loc common.PreferredLocation
+	require.NoError(t, settings.GetStruct(settings.PreferredLocationKey, &loc))
+	assert.Equal(t, "DE", loc.CountryCode)
+
+	active, err := ch.ActiveProfile()
+	require.NoError(t, err)
+	assert.Equal(t, "work", active.Name)
+	require.ErrorIs(t, ch.DeleteProfile("work"), ErrProfileActive)
+
+	_, err = ch.SwitchProfile("home")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.1.1", settings.GetString(settings.LocalDNSServerKey))
+	require.NoError(t, ch.DeleteProfile("work"))
+	require.ErrorIs(t, ch.DeleteProfile("work"), ErrProfileNotFound)
+}
+
+func TestPreviewUpdate(t *testing.T) {
+	t.Cleanup(func() { flags.Store(nil) })
+	tempDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockFetcher := &MockFetcher{response: []byte(`{"options":{"outbounds":[
+		{"type":"shadowsocks","tag":"a","server":"1.2.3.4","server_port":443,"method":"aes-128-gcm","password":"x"},
+		{"type":"shadowsocks","tag":"b","server":"1.2.3.5","server_port":443,"method":"aes-128-gcm","password":"x"}]}}`)}
+	ch := &ConfigHandler{
+		configPath: filepath.Join(tempDir, internal.ConfigFileName),
+		wgKeyPath:  filepath.Join(tempDir, "wg.key"),
+		ftr:        mockFetcher,
+		ctx:        ctx,
+		cancel:     cancel,
+		logger:     log.NoOpLogger(),
+	}
+	ch.started.Store(true)
+	require.NoError(t, ch.fetchConfig())
+	applied, err := ch.GetConfig()
+	require.NoError(t, err)
+
+	mockFetcher.response = []byte(`{"options":{"outbounds":[
+		{"type":"shadowsocks","tag":"b","server":"1.2.3.6","server_port":443,"method":"aes-128-gcm","password":"x"},
+		{"type":"shadowsocks","tag":"c","server":"1.2.3.7","server_port":443,"method":"aes-128-gcm","password":"x"}]},
+		"features":{"new":true}}`)
+	diff, err := ch.PreviewUpdate(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, &ConfigDiff{
+		ServersAdded:   []string{"c"},
+		ServersRemoved: []string{"a"},
+		ServersChanged: []string{"b"},
+		OptionsChanged: []string{"features"},
+	}, diff)
+	current, err := ch.GetConfig()
+	require.NoError(t, err)
+	assert.Same(t, applied, current, "previewing must not apply the config")
+
+	require.NoError(t, ch.ApplyPreview())
+	current, err = ch.GetConfig()
+	require.NoError(t, err)
+	assert.True(t, current.Features["new"])
+	require.ErrorIs(t, ch.ApplyPreview(), ErrNoPreview, "a preview can only be applied once")
+
+	raw, err := os.ReadFile(ch.rawConfigPath())
+	require.NoError(t, err)
+	assert.Equal(t, string(mockFetcher.response), string(raw), "the applied config should be persisted")
+
+	diff, err = ch.PreviewUpdate(t.Context())
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+
+	mockFetcher.response = []byte(`{"options":{"outbounds":[
+		{"type":"shadowsocks","tag":"b","server":"1.2.3.6","server_port":443,"method":"aes-128-gcm","password":"x"},
+		{"type":"shadowsocks","tag":"c","server":"1.2.3.7","server_port":443,"method":"aes-128-gcm","password":"x"}]},
+		"features":{"new":true},"flags":{"rollout_pct":10}}`)
+	diff, err = ch.PreviewUpdate(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, &ConfigDiff{FlagsChanged: []string{"rollout_pct"}}, diff, "a change to only the flags should be previewed")
+	require.NoError(t, ch.ApplyPreview())
+	assert.Equal(t, 10, Flag("rollout_pct", 0))
+}
+
+func TestFlags(t *testing.T) {
+	t.Cleanup(func() { flags.Store(nil) })
+	changed := make(chan []string, 2)
+	sub := events.Subscribe(func(evt FlagsChangedEvent) { changed <- evt.Changed })
+	defer sub.Unsubscribe()
+
+	f, err := parseFlags([]byte(`{"country":"US","flags":{"new_transport":true,"rollout_pct": 10,"label":"b"}}`))
+	require.NoError(t, err)
+	setFlags(f)
+	assert.True(t, Flag("new_transport", false))
+	assert.Equal(t, 10, Flag("rollout_pct", 0))
+	assert.Equal(t, "b", Flag("label", "a"))
+	assert.Equal(t, "a", Flag("rollout_pct", "a"), "a flag of another type should fall back to the default")
+	assert.Equal(t, 5, Flag("missing", 5))
+	select {
+	case names := <-changed:
+		assert.Equal(t, []string{"label", "new_transport", "rollout_pct"}, names)
+	case <-time.After(time.Second):
+		t.Fatal("expected a FlagsChangedEvent")
+	}
+
+	f, err = parseFlags([]byte(`{"flags":{"new_transport":true,"rollout_pct":20}}`))
+	require.NoError(t, err)
+	setFlags(f)
+	select {
+	case names := <-changed:
+		assert.Equal(t, []string{"label", "rollout_pct"}, names, "only flags that changed should be reported")
+	case <-time.After(time.Second):
+		t.Fatal("expected a FlagsChangedEvent")
+	}
+}
+
+func TestFetchHistory(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockFetcher := &MockFetcher{response: []byte(`{"Servers":[{"Country":"US","City":"New York"}]}`)}
+	ch := &ConfigHandler{
+		configPath:  filepath.Join(tempDir, internal.ConfigFileName),
+		wgKeyPath:   filepath.Join(tempDir, "wg.key"),
+		historyDir:  filepath.Join(tempDir, internal.ConfigHistoryDirName),
+		historySize: 5,
+		ftr:         mockFetcher,
+		ctx:         ctx,
+		cancel:      cancel,
+		logger:      log.NoOpLogger(),
+	}
+	assert.Nil(t, ch.LastFetchInfo())
+
+	require.NoError(t, ch.fetchConfig())
+	require.NoError(t, ch.fetchConfig())
+	mockFetcher.err = errors.New("fetch error")
+	require.Error(t, ch.fetchConfig())
+
+	last := ch.LastFetchInfo()
+	require.NotNil(t, last)
+	assert.Contains(t, last.Error, "fetch error")
+
+	history := ch.FetchHistory()
+	require.Len(t, history, 3)
+	assert.False(t, history[1].Applied, "an unchanged config should not be reported as applied")
+	assert.True(t, history[2].Applied)
+	assert.Empty(t, history[2].Error)
+
+	for range fetchHistorySize {
+		require.Error(t, ch.fetchConfig())
+	}
+	assert.Len(t, ch.FetchHistory(), fetchHistorySize)
+}
+
+func TestConfigFreshness(t *testing.T) {
+	settings.InitSettings(t.TempDir())
+	defer settings.Reset()
+	require.NoError(t, settings.Set(settings.ConfigMaxAgeKey, "1h"))
+	require.NoError(t, settings.Set(settings.ConfigExpiryKey, "2h"))
+
+	tempDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockFetcher := &MockFetcher{response: []byte(`{"Servers":[{"Country":"US","City":"New York"}]}`)}
+	ch := &ConfigHandler{
+		configPath:  filepath.Join(tempDir, internal.ConfigFileName),
+		wgKeyPath:   filepath.Join(tempDir, "wg.key"),
+		historyDir:  filepath.Join(tempDir, internal.ConfigHistoryDirName),
+		historySize: 5,
+		ftr:         mockFetcher,
+		ctx:         ctx,
+		cancel:      cancel,
+		logger:      log.NoOpLogger(),
+	}
+	assert.True(t, ch.Freshness().Stale, "a config that was never confirmed should be stale")
+	assert.False(t, ch.Freshness().Expired)
+
+	require.NoError(t, ch.fetchConfig())
+	assert.Equal(t, Freshness{ConfirmedAt: ch.Freshness().ConfirmedAt}, ch.Freshness())
+
+	ch.confirmedAt.Store(time.Now().Add(-90 * time.Minute).UnixNano())
+	assert.True(t, ch.Freshness().Stale)
+	assert.False(t, ch.Freshness().Expired)
+
+	ch.confirmedAt.Store(time.Now().Add(-3 * time.Hour).UnixNano())
+	ch.checkFreshness()
+	assert.True(t, ch.Freshness().Expired)
+
+	fresh := make(chan ConfigFreshnessEvent, 1)
+	sub := events.Subscribe(func(evt ConfigFreshnessEvent) { fresh <- evt })
+	defer sub.Unsubscribe()
+	mockFetcher.response = nil
+	require.NoError(t, ch.fetchConfig())
+	select {
+	case evt := <-fresh:
+		assert.True(t, evt.Old.Expired)
+		assert.False(t, evt.New.Stale, "a not-modified response should confirm the config")
+	case <-time.After(time.Second):
+		t.Fatal("expected a ConfigFreshnessEvent")
+	}
+
+	reloaded := NewConfigHandler(ctx, Options{DataPath: tempDir, Logger: log.NoOpLogger()})
+	assert.WithinDuration(t, ch.Freshness().ConfirmedAt, reloaded.Freshness().ConfirmedAt, time.Second,
+		"the confirmation time should survive a restart")
+
+	require.NoError(t, ch.files().WriteFile(ch.rawConfigPath(), []byte(`{"Servers":[]}`), 0o600))
+	reloaded = NewConfigHandler(ctx, Options{DataPath: tempDir, Logger: log.NoOpLogger()})
+	assert.True(t, reloaded.Freshness().ConfirmedAt.IsZero(),
+		"the confirmation time should only be restored for the config that was confirmed")
+}
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time                       { return c.now }
+func (c *fakeClock) After(time.Duration) <-chan time.Time { return nil }
+
+// faultyFS is the real file system, except that writes to files named in failWrites fail.
+type faultyFS struct {
+	osFS
+	failWrites map[string]bool
+}
+
+func (f faultyFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	if f.failWrites[filepath.Base(name)] {
+		return errors.New("disk full")
+	}
+	return f.osFS.WriteFile(name, data, perm)
+}
+
+func TestConfigHandlerInjectedClockAndFS(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	fsys := faultyFS{failWrites: map[string]bool{"wg.key": true}}
+	var fetches []FetchInfo
+	ch := &ConfigHandler{
+		configPath: filepath.Join(tempDir, internal.ConfigFileName),
+		wgKeyPath:  filepath.Join(tempDir, "wg.key"),
+		ftr:        &MockFetcher{response: []byte(`{"Servers":[{"Country":"US","City":"New York"}]}`)},
+		ctx:        ctx,
+		cancel:     cancel,
+		logger:     log.NoOpLogger(),
+		options: Options{
+			Clock:   clock,
+			FS:      fsys,
+			OnFetch: func(info FetchInfo) { fetches = append(fetches, info) },
+		},
+	}
+
+	require.ErrorContains(t, ch.fetchConfig(), "disk full")
+	_, err := ch.GetConfig()
+	require.Error(t, err, "nothing should be applied when the wg key can't be saved")
+
+	delete(fsys.failWrites, "wg.key")
+	require.NoError(t, ch.fetchConfig())
+	require.Len(t, fetches, 2)
+	assert.Equal(t, clock.now, fetches[1].Time)
+	assert.True(t, clock.now.Equal(ch.Freshness().ConfirmedAt))
+
+	clock.now = clock.now.Add(defaultMaxConfigAge + time.Minute)
+	assert.True(t, ch.Freshness().Stale)
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/getlantern/radiance/common/settings"
+)
+
+// ErrInvalidCountry is returned by [ConfigHandler.SetCountryOverride] for anything other than a
+// two-letter country code.
+var ErrInvalidCountry = errors.New("invalid country code")
+
+// CountryOverride returns the country code sent in place of the one the config API infers from
+// the client IP, or "" if there is none.
+func (ch *ConfigHandler) CountryOverride() string {
+	return settings.GetString(settings.CountryOverrideKey)
+}
+
+// SetCountryOverride makes config fetches ask for configs tuned for country, an ISO 3166-1
+// alpha-2 code, instead of the country of the client IP. This helps users who are travelling or
+// already behind another VPN. An empty country clears the override.
+//
+// If config fetching is enabled, the config is refreshed so the change takes effect right away;
+// see [ConfigHandler.ForceRefresh] for how ctx is handled.
+func (ch *ConfigHandler) SetCountryOverride(ctx context.Context, country string) error {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country != "" && !isCountryCode(country) {
+		return fmt.Errorf("%w: %q", ErrInvalidCountry, country)
+	}
+	if country == ch.CountryOverride() {
+		return nil
+	}
+	if err := settings.Set(settings.CountryOverrideKey, country); err != nil {
+		return fmt.Errorf("saving country override: %w", err)
+	}
+	ch.logger.Info("Country override changed", "country", country)
+	if settings.GetBool(settings.ConfigFetchDisabledKey) || !ch.started.Load() {
+		return nil
+	}
+	return ch.ForceRefresh(ctx)
+}
+
+func isCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Delta responses follow RFC 3229: the client advertises the encodings it accepts in A-IM, and
+// the server may answer 226 IM Used with a body in one of them relative to the config identified
+// by If-None-Match. The only encoding supported is a JSON merge patch (RFC 7386).
+const (
+	deltaEncodingMergePatch = "merge-patch"
+	statusIMUsed            = 226
+)
+
+// applyMergePatch applies an RFC 7386 JSON merge patch to base and returns the resulting
+// document. Numbers are kept as written so large integers survive the round trip.
+func applyMergePatch(base, patch []byte) ([]byte, error) {
+	var doc, p any
+	if err := decodeJSONNumber(base, &doc); err != nil {
+		return nil, fmt.Errorf("decoding base config: %w", err)
+	}
+	if err := decodeJSONNumber(patch, &p); err != nil {
+		return nil, fmt.Errorf("decoding config patch: %w", err)
+	}
+	return json.Marshal(mergePatch(doc, p))
+}
+
+func mergePatch(target, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]any)
+	if !ok {
+		tm = make(map[string]any, len(pm))
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = mergePatch(tm[k], v)
+	}
+	return tm
+}
+
+func decodeJSONNumber(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
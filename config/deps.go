@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"time"
+
+	"github.com/getlantern/radiance/common/atomicfile"
+)
+
+// Clock is the source of time for a ConfigHandler. Tests can substitute a fake clock to drive
+// poll intervals and config staleness without waiting.
+type Clock interface {
+	Now() time.Time
+	// After behaves like [time.After].
+	After(d time.Duration) <-chan time.Time
+}
+
+// FS is the file system a ConfigHandler keeps its files in. Tests can substitute one that
+// simulates corrupt files or failed writes. WriteFile must replace files atomically, as
+// [atomicfile.WriteFile] does.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	Remove(name string) error
+	MkdirAll(path string, perm os.FileMode) error
+	ReadDir(name string) ([]os.DirEntry, error)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// osFS is the real file system. Reads and writes go through atomicfile, so files are replaced
+// atomically and encrypted at rest if that is enabled.
+type osFS struct{}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return atomicfile.ReadFile(name)
+}
+
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return atomicfile.WriteFile(name, data, perm)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFS) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (ch *ConfigHandler) clock() Clock {
+	if ch.options.Clock != nil {
+		return ch.options.Clock
+	}
+	return systemClock{}
+}
+
+func (ch *ConfigHandler) files() FS {
+	if ch.options.FS != nil {
+		return ch.options.FS
+	}
+	return osFS{}
+}
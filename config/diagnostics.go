@@ -0,0 +1,110 @@
+package config
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	fetchHistorySize = 20
+	// kindlingMethodHeader is set by kindling on the request sent over the transport that won the
+	// race, e.g. "fronted" or "amp".
+	kindlingMethodHeader = "X-Kindling-Method"
+)
+
+// FetchInfo describes a config fetch attempt, for diagnosing why a client has no or outdated
+// servers.
+type FetchInfo struct {
+	Time       time.Time `json:"time"`
+	DurationMs int64     `json:"duration_ms"`
+	// Source is the base URL of the config source that answered, or of the last one tried if
+	// none did.
+	Source string `json:"source,omitempty"`
+	// StatusCode is the HTTP status of the response, or 0 if no response was received.
+	StatusCode int `json:"status_code,omitempty"`
+	// Transport is the kindling transport the response came over, if known.
+	Transport string `json:"transport,omitempty"`
+	// Applied is true if the fetch applied a new config.
+	Applied bool   `json:"applied"`
+	Error   string `json:"error,omitempty"`
+}
+
+// responseInfo is what the fetcher knows about the last response it received.
+type responseInfo struct {
+	source     string
+	statusCode int
+	transport  string
+}
+
+func newResponseInfo(source string, resp *http.Response) responseInfo {
+	info := responseInfo{source: source}
+	if resp != nil {
+		info.statusCode = resp.StatusCode
+		if resp.Request != nil {
+			info.transport = resp.Request.Header.Get(kindlingMethodHeader)
+		}
+	}
+	return info
+}
+
+// fetchLog keeps the most recent fetch attempts.
+type fetchLog struct {
+	mu      sync.Mutex
+	entries []FetchInfo
+}
+
+func (l *fetchLog) add(info FetchInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) == fetchHistorySize {
+		l.entries = slices.Delete(l.entries, 0, 1)
+	}
+	l.entries = append(l.entries, info)
+}
+
+// list returns the entries, newest first.
+func (l *fetchLog) list() []FetchInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := slices.Clone(l.entries)
+	slices.Reverse(list)
+	return list
+}
+
+// LastFetchInfo returns the most recent config fetch attempt, or nil if there hasn't been one.
+func (ch *ConfigHandler) LastFetchInfo() *FetchInfo {
+	list := ch.fetches.list()
+	if len(list) == 0 {
+		return nil
+	}
+	return &list[0]
+}
+
+// FetchHistory returns up to the last 20 config fetch attempts, newest first.
+func (ch *ConfigHandler) FetchHistory() []FetchInfo {
+	return ch.fetches.list()
+}
+
+// recordFetch logs a fetch attempt that started at start and ended with err. Must be called
+// with fetchRunMu held.
+func (ch *ConfigHandler) recordFetch(start time.Time, applied bool, err error) {
+	info := FetchInfo{
+		Time:       start,
+		DurationMs: ch.clock().Now().Sub(start).Milliseconds(),
+		Applied:    applied,
+	}
+	if f, ok := ch.ftr.(*fetcher); ok {
+		info.Source = f.last.source
+		info.StatusCode = f.last.statusCode
+		info.Transport = f.last.transport
+	}
+	if err != nil {
+		info.Error = err.Error()
+	}
+	ch.fetches.add(info)
+	if ch.options.OnFetch != nil {
+		ch.options.OnFetch(info)
+	}
+}
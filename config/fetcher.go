@@ -50,6 +50,28 @@ type fetcher struct {
 	baseURL      string
 	apiClient    *account.Client
 	httpClient   *http.Client
+	verifier     signatureVerifier
+	// base is the last full config received, which delta responses are applied to. It is only
+	// kept in memory, like etag, so the first fetch after a restart is always a full one.
+	base []byte
+	// source is the base URL that lastModified, etag and base came from. They're only sent back
+	// to it, since other sources don't know about them.
+	source string
+	// pending holds the conditional state of the last full config received until commit is
+	// called once it has been applied. A config that is rejected must not be asked for with its
+	// own etag, or the server would answer 304 and a good one would never be fetched.
+	pending *fetchState
+	sources sourceTracker
+	// last describes the last response received, for diagnostics.
+	last responseInfo
+}
+
+// fetchState is what a fetch received that later fetches build on.
+type fetchState struct {
+	source       string
+	etag         string
+	base         []byte
+	lastModified time.Time
 }
 
 // newFetcher creates a new fetcher with the given http client.
@@ -63,6 +85,7 @@ func newFetcher(locale string, apiClient *account.Client, httpClient *http.Clien
 		baseURL:      common.GetBaseURL(),
 		apiClient:    apiClient,
 		httpClient:   httpClient,
+		verifier:     newSignatureVerifier(defaultConfigKeys),
 	}
 }
 
@@ -70,6 +93,8 @@ func newFetcher(locale string, apiClient *account.Client, httpClient *http.Clien
 func (f *fetcher) fetchConfig(ctx context.Context, preferred common.PreferredLocation, wgPublicKey string) ([]byte, error) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "config_fetcher.fetchConfig")
 	defer span.End()
+	f.last = responseInfo{}
+	f.pending = nil
 	// If we don't have a user ID or token, create a new user.
 	if err := f.ensureUser(ctx); err != nil {
 		return nil, fmt.Errorf("error creating user: %w", err)
@@ -97,10 +122,18 @@ func (f *fetcher) fetchConfig(ctx context.Context, preferred common.PreferredLoc
 	if err != nil {
 		return nil, fmt.Errorf("marshal config request: %w", err)
 	}
+	// Sources other than the Lantern API only get the parts of the request that don't identify
+	// the user.
+	anonReq := confReq
+	anonReq.UserID, anonReq.ProToken, anonReq.DeviceID = "", "", ""
+	anonBuf, err := json.Marshal(&anonReq)
+	if err != nil {
+		return nil, fmt.Errorf("marshal config request: %w", err)
+	}
 	addPayloadToSpan(ctx, confReq)
 
 	slog.Debug("sending config request", "request", string(buf))
-	buf, err = f.send(ctx, bytes.NewReader(buf))
+	buf, err = f.sendToSources(ctx, buf, anonBuf)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -109,11 +142,24 @@ func (f *fetcher) fetchConfig(ctx context.Context, preferred common.PreferredLoc
 		return nil, nil
 	}
 	slog.Log(nil, log.LevelTrace, "received config", "config", string(buf))
-
-	f.lastModified = time.Now()
 	return buf, nil
 }
 
+// commit adopts the conditional state of the last config fetched, once it has been applied.
+func (f *fetcher) commit() {
+	p := f.pending
+	if p == nil {
+		return
+	}
+	f.pending = nil
+	f.adoptSource(p.source)
+	f.base = p.base
+	if p.etag != "" {
+		f.etag = p.etag
+	}
+	f.lastModified = p.lastModified
+}
+
 func addPayloadToSpan(ctx context.Context, req C.ConfigRequest) {
 	span := trace.SpanFromContext(ctx)
 	if len(req.UserID) > 5 {
@@ -148,14 +194,46 @@ func (f *fetcher) ensureUser(ctx context.Context) error {
 	return nil
 }
 
-// send sends a request to the server with the given body and returns the response.
-func (f *fetcher) send(ctx context.Context, body io.Reader) ([]byte, error) {
+// sendToSources sends the request to each config source in turn until one succeeds. The Lantern
+// API is sent body and other sources anonBody, which leaves out the user's credentials.
+func (f *fetcher) sendToSources(ctx context.Context, body, anonBody []byte) ([]byte, error) {
+	var errs []error
+	for _, src := range f.sources.ordered(f.baseURL) {
+		reqBody := anonBody
+		if src.Default {
+			reqBody = body
+		}
+		buf, err := f.send(ctx, src.URL, bytes.NewReader(reqBody), src.Default)
+		if err == nil {
+			f.sources.succeeded(src.URL)
+			return buf, nil
+		}
+		f.sources.failed(src.URL, err)
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		if !src.Default {
+			err = fmt.Errorf("%s: %w", src.URL, err)
+		}
+		slog.Warn("Config source failed", "source", src.URL, "error", err)
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// send sends a request to the server at baseURL with the given body and returns the response.
+// Unless identify is set, the headers that identify the user are left out.
+func (f *fetcher) send(ctx context.Context, baseURL string, body io.Reader, identify bool) ([]byte, error) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "config_fetcher.send")
 	defer span.End()
-	req, err := common.NewRequestWithHeaders(ctx, http.MethodPost, f.baseURL+"/config-new", body)
+	req, err := common.NewRequestWithHeaders(ctx, http.MethodPost, baseURL+"/config-new", body)
 	if err != nil {
 		return nil, fmt.Errorf("could not create request: %w", err)
 	}
+	if !identify {
+		req.Header.Del(common.UserIDHeader)
+		req.Header.Del(common.DeviceIDHeader)
+	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Cache-Control", "no-cache")
 	// /config-new is POST-shaped (request carries last-known etag/version
@@ -167,32 +245,41 @@ func (f *fetcher) send(ctx context.Context, body io.Reader) ([]byte, error) {
 	// would fail the whole fetch instead of being routed around.
 	req.Header.Set(kindling.IdempotentHeader, "1")
 
-	if val := env.GetString(env.Country); val != "" {
-		slog.Info("Setting x-lantern-client-country header", "country", val)
-		req.Header.Set("x-lantern-client-country", val)
+	// The environment variable is a development override and wins over the user's setting.
+	country := env.GetString(env.Country)
+	if country == "" {
+		country = settings.GetString(settings.CountryOverrideKey)
+	}
+	if country != "" {
+		slog.Info("Setting x-lantern-client-country header", "country", country)
+		req.Header.Set(common.ClientCountryHeader, country)
 	}
 	if val := settings.GetString(settings.FeatureOverridesKey); val != "" {
 		slog.Info("Setting X-Lantern-Feature-Override header", "features", val)
 		req.Header.Set("X-Lantern-Feature-Override", val)
 	}
 
-	// Add If-Modified-Since header to the request
-	// Note that on the first run, lastModified is zero, so the server will return the latest config.
-	req.Header.Set("If-Modified-Since", f.lastModified.Format(http.TimeFormat))
-	if f.etag != "" {
-		req.Header.Set("If-None-Match", f.etag)
+	// The conditional headers are only sent to the source the config came from. On the first
+	// run there's none, so the server returns the latest config.
+	if f.source == baseURL {
+		if !f.lastModified.IsZero() {
+			req.Header.Set("If-Modified-Since", f.lastModified.Format(http.TimeFormat))
+		}
+		if f.etag != "" {
+			req.Header.Set("If-None-Match", f.etag)
+			if f.base != nil {
+				req.Header.Set("A-IM", deltaEncodingMergePatch)
+			}
+		}
 	}
 
 	resp, err := f.httpClient.Do(req)
 	if err != nil {
+		f.last = newResponseInfo(baseURL, nil)
 		return nil, traces.RecordError(ctx, fmt.Errorf("could not send request: %w", err))
 	}
 	defer resp.Body.Close()
-
-	// Update the etag from the response
-	if etag := resp.Header.Get("ETag"); etag != "" {
-		f.etag = etag
-	}
+	f.last = newResponseInfo(baseURL, resp)
 
 	// Note that Go's HTTP library should automatically have decompressed the response here.
 	buf, err := io.ReadAll(resp.Body)
@@ -200,24 +287,53 @@ func (f *fetcher) send(ctx context.Context, body io.Reader) ([]byte, error) {
 		return nil, traces.RecordError(ctx, fmt.Errorf("could not read response body: %w", err))
 	}
 	switch resp.StatusCode {
-	case http.StatusOK:
-		// 200 OK
-		return buf, nil
-	case http.StatusPartialContent:
-		// 206 Partial Content
+	case http.StatusOK, http.StatusPartialContent, statusIMUsed:
+		if err := f.verifier.verify(buf, resp.Header); err != nil {
+			return nil, traces.RecordError(ctx, err)
+		}
+		if resp.StatusCode == statusIMUsed {
+			if im := resp.Header.Get("IM"); im != deltaEncodingMergePatch || f.base == nil || f.source != baseURL {
+				return nil, traces.RecordError(ctx, fmt.Errorf("unexpected delta response (IM: %q)", im))
+			}
+			full, err := applyMergePatch(f.base, buf)
+			if err != nil {
+				return nil, traces.RecordError(ctx, fmt.Errorf("applying config delta: %w", err))
+			}
+			slog.Debug("Applied config delta", "patch_bytes", len(buf), "config_bytes", len(full))
+			buf = full
+		}
+		f.pending = &fetchState{source: baseURL, etag: resp.Header.Get("ETag"), base: buf, lastModified: time.Now()}
 		return buf, nil
 	case http.StatusNotModified:
 		// 304 Not Modified
 		slog.Debug("Config is not modified")
+		f.adoptSource(baseURL)
+		f.updateETag(resp)
 		return nil, nil
 	case http.StatusNoContent:
 		// 204 No Content
+		f.adoptSource(baseURL)
+		f.updateETag(resp)
 		return nil, nil
 	default:
 		return nil, fmt.Errorf("unexpected status code: %d. %s", resp.StatusCode, buf)
 	}
 }
 
+// adoptSource makes baseURL the source of the conditional state, dropping any state that came
+// from another source.
+func (f *fetcher) adoptSource(baseURL string) {
+	if f.source != baseURL {
+		f.source, f.etag, f.base, f.lastModified = baseURL, "", nil, time.Time{}
+	}
+}
+
+func (f *fetcher) updateETag(resp *http.Response) {
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		f.etag = etag
+	}
+}
+
 // singVersion returns the version of the sing-box module.
 func singVersion() string {
 	// First look for the sagernet/sing-box module version, and if it's not found, look for the getlantern/sing-box module version.
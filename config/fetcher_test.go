@@ -1,12 +1,15 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	C "github.com/getlantern/common"
 	"github.com/stretchr/testify/assert"
@@ -15,6 +18,7 @@ import (
 
 	"github.com/getlantern/radiance/common"
 	"github.com/getlantern/radiance/common/settings"
+	"github.com/getlantern/radiance/log"
 )
 
 func TestFetchConfig(t *testing.T) {
@@ -137,3 +141,197 @@ func TestUserIDFormatMatchesMain(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchConfigSignature(t *testing.T) {
+	settings.InitSettings(t.TempDir())
+	defer settings.Reset()
+	settings.Set(settings.UserIDKey, 1234567890)
+	settings.Set(settings.TokenKey, "mock-legacy-token")
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	_, otherPriv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	body := []byte(`{"key":"value"}`)
+
+	tests := []struct {
+		name    string
+		keyID   string
+		sig     []byte
+		wantErr bool
+	}{
+		{name: "valid signature", keyID: "k1", sig: ed25519.Sign(priv, body)},
+		{name: "unsigned", wantErr: true},
+		{name: "unknown key", keyID: "k2", sig: ed25519.Sign(priv, body), wantErr: true},
+		{name: "wrong key", keyID: "k1", sig: ed25519.Sign(otherPriv, body), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				if tt.sig != nil {
+					w.Header().Set(signatureHeader, base64.StdEncoding.EncodeToString(tt.sig))
+					w.Header().Set(signatureKeyIDHeader, tt.keyID)
+				}
+				w.Write(body)
+			}))
+			defer srv.Close()
+
+			f := newFetcher("en-US", nil, srv.Client()).(*fetcher)
+			f.baseURL = srv.URL
+			f.verifier = signatureVerifier{keys: map[string]ed25519.PublicKey{"k1": pub}}
+
+			got, err := f.fetchConfig(t.Context(), common.PreferredLocation{}, "")
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				assert.Nil(t, f.pending, "a rejected config must not advance the etag")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, body, got)
+			assert.Empty(t, f.etag, "the etag should only be taken once the config is applied")
+			f.commit()
+			assert.Equal(t, `"v1"`, f.etag)
+		})
+	}
+
+}
+
+func TestFetchConfigWithoutSigningKeys(t *testing.T) {
+	settings.InitSettings(t.TempDir())
+	defer settings.Reset()
+	settings.Set(settings.UserIDKey, 1234567890)
+	settings.Set(settings.TokenKey, "mock-legacy-token")
+
+	body := []byte(`{"key":"value"}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	f := newFetcher("en-US", nil, srv.Client()).(*fetcher)
+	f.baseURL = srv.URL
+	f.verifier = newSignatureVerifier(nil)
+
+	got, err := f.fetchConfig(t.Context(), common.PreferredLocation{}, "")
+	require.NoError(t, err, "unsigned configs should be accepted while no keys are configured")
+	assert.Equal(t, body, got)
+}
+
+func TestFetchConfigDelta(t *testing.T) {
+	settings.InitSettings(t.TempDir())
+	defer settings.Reset()
+	settings.Set(settings.UserIDKey, 1234567890)
+	settings.Set(settings.TokenKey, "mock-legacy-token")
+
+	var gotAIM []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAIM = append(gotAIM, r.Header.Get("A-IM"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.Header().Set("ETag", `"v2"`)
+			w.Header().Set("IM", deltaEncodingMergePatch)
+			w.WriteHeader(statusIMUsed)
+			w.Write([]byte(`{"b":null,"c":{"d":2}}`))
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"a":1,"b":2,"c":{"d":1,"e":9007199254740993}}`))
+	}))
+	defer srv.Close()
+
+	f := newFetcher("en-US", nil, srv.Client()).(*fetcher)
+	f.baseURL = srv.URL
+
+	_, err := f.fetchConfig(t.Context(), common.PreferredLocation{}, "")
+	require.NoError(t, err)
+	_, err = f.fetchConfig(t.Context(), common.PreferredLocation{}, "")
+	require.NoError(t, err, "without a commit, the first config should be fetched again in full")
+	f.commit()
+	got, err := f.fetchConfig(t.Context(), common.PreferredLocation{}, "")
+	require.NoError(t, err)
+	f.commit()
+	assert.JSONEq(t, `{"a":1,"c":{"d":2,"e":9007199254740993}}`, string(got))
+	assert.Equal(t, `"v2"`, f.etag)
+	assert.Equal(t, []string{"", "", deltaEncodingMergePatch}, gotAIM,
+		"deltas should only be requested once there is an applied base to apply them to")
+}
+
+func TestFetchConfigCountryOverride(t *testing.T) {
+	settings.InitSettings(t.TempDir())
+	defer settings.Reset()
+	settings.Set(settings.UserIDKey, 1234567890)
+	settings.Set(settings.TokenKey, "mock-legacy-token")
+
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(common.ClientCountryHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	f := newFetcher("en-US", nil, srv.Client()).(*fetcher)
+	f.baseURL = srv.URL
+
+	ch := &ConfigHandler{logger: log.NoOpLogger()}
+	require.ErrorIs(t, ch.SetCountryOverride(t.Context(), "Germany"), ErrInvalidCountry)
+	require.NoError(t, ch.SetCountryOverride(t.Context(), " de "))
+	assert.Equal(t, "DE", ch.CountryOverride())
+
+	_, err := f.fetchConfig(t.Context(), common.PreferredLocation{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "DE", got)
+
+	require.NoError(t, ch.SetCountryOverride(t.Context(), ""))
+	_, err = f.fetchConfig(t.Context(), common.PreferredLocation{}, "")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestFetchConfigSources(t *testing.T) {
+	settings.InitSettings(t.TempDir())
+	defer settings.Reset()
+	settings.Set(settings.UserIDKey, 1234567890)
+	settings.Set(settings.TokenKey, "mock-legacy-token")
+
+	var primaryHits, mirrorHits int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer primary.Close()
+	var mirrorReq C.ConfigRequest
+	var mirrorHeader http.Header
+	mirror := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorHits++
+		mirrorHeader = r.Header.Clone()
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&mirrorReq))
+		w.Write([]byte(`{"key":"mirror"}`))
+	}))
+	defer mirror.Close()
+
+	f := newFetcher("en-US", nil, mirror.Client()).(*fetcher)
+	f.baseURL = primary.URL
+	ch := &ConfigHandler{logger: log.NoOpLogger(), ftr: f}
+	require.ErrorIs(t, ch.SetSources([]ConfigSource{{URL: "ftp://mirror"}}), ErrInvalidSource)
+	require.ErrorIs(t, ch.SetSources([]ConfigSource{{URL: "http://mirror"}}), ErrInvalidSource, "sources must use https")
+	require.NoError(t, ch.SetSources([]ConfigSource{{URL: mirror.URL, Priority: 1}}))
+
+	got, err := f.fetchConfig(t.Context(), common.PreferredLocation{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, `{"key":"mirror"}`, string(got))
+	assert.Equal(t, 1, primaryHits)
+	assert.Empty(t, mirrorReq.UserID, "the user's credentials are only sent to the Lantern API")
+	assert.Empty(t, mirrorReq.ProToken)
+	assert.Empty(t, mirrorHeader.Get(common.UserIDHeader))
+	assert.Equal(t, "en-US", mirrorReq.Locale)
+
+	sources := ch.Sources()
+	require.Len(t, sources, 2)
+	assert.Equal(t, mirror.URL, sources[0].URL, "a failing source should be tried after healthy ones")
+	assert.False(t, sources[1].Healthy(time.Now()))
+	assert.Equal(t, 1, sources[1].Failures)
+
+	_, err = f.fetchConfig(t.Context(), common.PreferredLocation{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, primaryHits, "the failing source should be backed off")
+	assert.Equal(t, 2, mirrorHits)
+}
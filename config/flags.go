@@ -0,0 +1,112 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync/atomic"
+
+	"github.com/getlantern/radiance/events"
+)
+
+// Flags are server-controlled values read from the "flags" section of the config response. They
+// let experiments and gradual rollouts, such as enabling a new transport for a share of users, be
+// driven by the server without a client release. The server decides which users get which
+// values; the client only reads them.
+//
+// Flags follow the most recently fetched config and are not affected by rollbacks.
+
+// FlagsChangedEvent is emitted when a fetched config changes any flag.
+type FlagsChangedEvent struct {
+	events.Event
+	// Changed lists the names of flags that were added, removed, or changed value, sorted.
+	Changed []string
+}
+
+// FlagValue is the set of types a flag can be read as.
+type FlagValue interface {
+	~bool | ~string | ~int | ~int64 | ~float64
+}
+
+var flags atomic.Pointer[map[string]json.RawMessage]
+
+// Flag returns the value of the named flag, or def if the flag isn't set or can't be read as a T.
+func Flag[T FlagValue](name string, def T) T {
+	f := flags.Load()
+	if f == nil {
+		return def
+	}
+	raw, ok := (*f)[name]
+	if !ok {
+		return def
+	}
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		slog.Debug("Config flag has an unexpected type, using the default", "flag", name, "error", err)
+		return def
+	}
+	return v
+}
+
+// Flags returns every flag as raw JSON.
+func Flags() map[string]json.RawMessage {
+	f := flags.Load()
+	if f == nil {
+		return map[string]json.RawMessage{}
+	}
+	return maps.Clone(*f)
+}
+
+// parseFlags returns the flags section of a raw config response with each value compacted, so
+// formatting differences aren't reported as changes.
+func parseFlags(raw []byte) (map[string]json.RawMessage, error) {
+	var resp struct {
+		Flags map[string]json.RawMessage `json:"flags"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, err
+	}
+	f := make(map[string]json.RawMessage, len(resp.Flags))
+	for name, v := range resp.Flags {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, v); err != nil {
+			return nil, err
+		}
+		f[name] = buf.Bytes()
+	}
+	return f, nil
+}
+
+// setFlags replaces the flags and emits a FlagsChangedEvent if any changed.
+func setFlags(f map[string]json.RawMessage) {
+	old := flags.Swap(&f)
+	var prev map[string]json.RawMessage
+	if old != nil {
+		prev = *old
+	}
+	changed := changedFlags(prev, f)
+	if len(changed) == 0 {
+		return
+	}
+	slog.Info("Config flags changed", "flags", changed)
+	events.Emit(FlagsChangedEvent{Changed: changed})
+}
+
+// changedFlags returns the names of flags that were added, removed, or changed value, sorted.
+func changedFlags(prev, f map[string]json.RawMessage) []string {
+	var changed []string
+	for name, v := range f {
+		if p, ok := prev[name]; !ok || !bytes.Equal(p, v) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := f[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
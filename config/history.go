@@ -0,0 +1,189 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	box "github.com/getlantern/lantern-box"
+	singjson "github.com/sagernet/sing/common/json"
+
+	"github.com/getlantern/radiance/common/fileperm"
+)
+
+const defaultHistorySize = 5
+
+var (
+	// ErrNoPreviousConfig is returned by [ConfigHandler.Rollback] when there is no older config
+	// in the history to roll back to.
+	ErrNoPreviousConfig = errors.New("no previous config to roll back to")
+	// ErrConfigVersionNotFound is returned by [ConfigHandler.RollbackTo] for an unknown version.
+	ErrConfigVersionNotFound = errors.New("config version not found")
+)
+
+// ConfigVersion identifies a fetched config kept in the on-disk history.
+type ConfigVersion struct {
+	ID        string    `json:"id"`
+	FetchedAt time.Time `json:"fetched_at"`
+	// Current is true for the version that is currently applied.
+	Current bool `json:"current"`
+}
+
+// History returns the retained config versions, newest first.
+func (ch *ConfigHandler) History() ([]ConfigVersion, error) {
+	ids, err := ch.historyIDs()
+	if err != nil {
+		return nil, err
+	}
+	current := ch.currentVersion()
+	versions := make([]ConfigVersion, 0, len(ids))
+	for _, id := range ids {
+		nanos, _ := strconv.ParseInt(id, 10, 64)
+		versions = append(versions, ConfigVersion{
+			ID:        id,
+			FetchedAt: time.Unix(0, nanos),
+			Current:   id == current,
+		})
+	}
+	return versions, nil
+}
+
+// Rollback applies the config fetched immediately before the current one. It returns
+// [ErrNoPreviousConfig] if the current config is the oldest one retained.
+//
+// The rolled-back config stays in effect until the server publishes a config that differs from
+// the one last fetched.
+func (ch *ConfigHandler) Rollback() error {
+	ids, err := ch.historyIDs()
+	if err != nil {
+		return err
+	}
+	i := slices.Index(ids, ch.currentVersion())
+	if i < 0 || i+1 >= len(ids) {
+		return ErrNoPreviousConfig
+	}
+	return ch.RollbackTo(ids[i+1])
+}
+
+// RollbackTo applies the config with the given version ID from the history. A version that can't
+// be read or parsed is reported as an error and left in the history as it is.
+func (ch *ConfigHandler) RollbackTo(id string) error {
+	if ch.historyDir == "" || strings.ContainsAny(id, `/\`) {
+		return ErrConfigVersionNotFound
+	}
+	buf, err := ch.files().ReadFile(filepath.Join(ch.historyDir, id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrConfigVersionNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("reading config version %s: %w", id, err)
+	}
+	cfg, err := singjson.UnmarshalExtendedContext[*Config](box.BaseContext(), buf)
+	if err != nil {
+		return fmt.Errorf("parsing config version %s: %w", id, err)
+	}
+	ch.logger.Info("Rolling back config", "version", id)
+	if err := ch.setConfig(cfg); err != nil {
+		return err
+	}
+	ch.setCurrentVersion(id)
+	return nil
+}
+
+// recordHistory saves cfg as the newest history entry and prunes entries beyond historySize.
+// Entries hold the WireGuard key and server credentials, so only the user can read them.
+func (ch *ConfigHandler) recordHistory(cfg *Config) {
+	if ch.historyDir == "" {
+		return
+	}
+	if err := ch.files().MkdirAll(ch.historyDir, 0o700); err != nil {
+		ch.logger.Error("creating config history directory", "error", err)
+		return
+	}
+	id := strconv.FormatInt(ch.clock().Now().UnixNano(), 10)
+	if err := saveConfig(ch.files(), cfg, filepath.Join(ch.historyDir, id+".json")); err != nil {
+		ch.logger.Error("saving config history", "error", err)
+		return
+	}
+	ch.setCurrentVersion(id)
+
+	ids, err := ch.historyIDs()
+	if err != nil {
+		ch.logger.Error("listing config history", "error", err)
+		return
+	}
+	for _, old := range ids[min(len(ids), ch.historySize):] {
+		if err := ch.files().Remove(filepath.Join(ch.historyDir, old+".json")); err != nil {
+			ch.logger.Error("pruning config history", "version", old, "error", err)
+		}
+	}
+}
+
+// historyIDs returns the IDs of all retained versions, newest first.
+func (ch *ConfigHandler) historyIDs() ([]string, error) {
+	if ch.historyDir == "" {
+		return nil, nil
+	}
+	entries, err := ch.files().ReadDir(ch.historyDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config history: %w", err)
+	}
+	var ids []string
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	// IDs are nanosecond timestamps of equal width for the foreseeable future, so a reverse
+	// string sort is a reverse chronological sort.
+	slices.Sort(ids)
+	slices.Reverse(ids)
+	return ids, nil
+}
+
+// currentVersion returns the ID of the applied config. After a restart it is read back from
+// versionPath. Configs applied before that was kept have no record, so the newest history entry
+// is assumed to match config.json.
+func (ch *ConfigHandler) currentVersion() string {
+	if id, _ := ch.current.Load().(string); id != "" {
+		return id
+	}
+	if buf, err := ch.files().ReadFile(ch.versionPath()); err == nil {
+		if id := strings.TrimSpace(string(buf)); id != "" {
+			ch.current.Store(id)
+			return id
+		}
+	}
+	ids, _ := ch.historyIDs()
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[0]
+}
+
+// setCurrentVersion records id as the applied config, in memory and at versionPath, so a
+// rollback is still known to be in effect after a restart.
+func (ch *ConfigHandler) setCurrentVersion(id string) {
+	ch.current.Store(id)
+	if err := ch.files().WriteFile(ch.versionPath(), []byte(id), fileperm.File); err != nil {
+		ch.logger.Error("saving applied config version", "version", id, "error", err)
+	}
+}
+
+// versionPath is where the history ID of the applied config is kept, next to config.json.
+func (ch *ConfigHandler) versionPath() string {
+	return strings.TrimSuffix(ch.configPath, ".json") + "_version"
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/sagernet/sing-box/option"
+	singjson "github.com/sagernet/sing/common/json"
+
+	box "github.com/getlantern/lantern-box"
+
+	"github.com/getlantern/radiance/common"
+	"github.com/getlantern/radiance/common/settings"
+)
+
+// ErrNoPreview is returned by [ConfigHandler.ApplyPreview] if there is no previewed config, or
+// if the applied config changed after the preview was taken.
+var ErrNoPreview = errors.New("no previewed config to apply")
+
+// ConfigDiff describes how a fetched config differs from the applied one. Servers are identified
+// by outbound or endpoint tag.
+type ConfigDiff struct {
+	ServersAdded   []string `json:"servers_added,omitempty"`
+	ServersRemoved []string `json:"servers_removed,omitempty"`
+	ServersChanged []string `json:"servers_changed,omitempty"`
+	// OptionsChanged lists every other field that differs, e.g. "options.dns" or "features".
+	OptionsChanged []string `json:"options_changed,omitempty"`
+	// FlagsChanged lists the flags that would be added, removed, or change value.
+	FlagsChanged []string `json:"flags_changed,omitempty"`
+}
+
+// Empty reports whether the configs are the same.
+func (d *ConfigDiff) Empty() bool {
+	return len(d.ServersAdded) == 0 && len(d.ServersRemoved) == 0 &&
+		len(d.ServersChanged) == 0 && len(d.OptionsChanged) == 0 && len(d.FlagsChanged) == 0
+}
+
+// pendingConfig is a fetched config held back by PreviewUpdate.
+type pendingConfig struct {
+	cfg *Config
+	raw []byte
+	// appliedHash is the applied config's hash when the preview was taken.
+	appliedHash [sha256.Size]byte
+}
+
+// PreviewUpdate fetches the latest config and returns how it differs from the applied one without
+// applying it. Call [ConfigHandler.ApplyPreview] to apply it. The fetch is unconditional, but it
+// doesn't affect what later fetches request, so a previewed config that is never confirmed is
+// still applied by the next regular fetch if it is newer than the applied one.
+func (ch *ConfigHandler) PreviewUpdate(ctx context.Context) (*ConfigDiff, error) {
+	if settings.GetBool(settings.ConfigFetchDisabledKey) {
+		return nil, ErrConfigFetchDisabled
+	}
+	if !ch.started.Load() {
+		return nil, fmt.Errorf("config handler not started")
+	}
+	privateKey, err := ch.loadWGKey()
+	if err != nil {
+		return nil, fmt.Errorf("loading wg key: %w", err)
+	}
+	preferred := common.PreferredLocation{}
+	if err := settings.GetStruct(settings.PreferredLocationKey, &preferred); err != nil {
+		ch.logger.Error("failed to get preferred location from settings", "error", err)
+	}
+
+	ch.fetchRunMu.Lock()
+	defer ch.fetchRunMu.Unlock()
+	resp, err := ch.fetchUnconditionally(ctx, preferred, privateKey.PublicKey().String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFetchingConfig, err)
+	}
+	ch.preview = nil
+	if resp == nil {
+		return &ConfigDiff{}, nil
+	}
+	cfg, err := ch.prepareConfig(resp, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := diffConfigs(ch.config.Load(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("comparing configs: %w", err)
+	}
+	// Flags aren't part of Config, so they're compared separately from the raw response.
+	newFlags, err := parseFlags(resp)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config flags: %w", err)
+	}
+	diff.FlagsChanged = changedFlags(Flags(), newFlags)
+	if !diff.Empty() {
+		ch.preview = &pendingConfig{cfg: cfg, raw: resp, appliedHash: ch.appliedHash}
+	}
+	return diff, nil
+}
+
+// ApplyPreview applies the config fetched by the last call to [ConfigHandler.PreviewUpdate].
+func (ch *ConfigHandler) ApplyPreview() error {
+	ch.fetchRunMu.Lock()
+	defer ch.fetchRunMu.Unlock()
+	p := ch.preview
+	ch.preview = nil
+	if p == nil || p.appliedHash != ch.appliedHash {
+		return ErrNoPreview
+	}
+	ch.logger.Info("Applying previewed config")
+	return ch.applyFetched(p.cfg, p.raw)
+}
+
+// fetchUnconditionally fetches the full config and then restores the fetcher's conditional state
+// so regular fetches carry on as if this one never happened. Must be called with fetchRunMu held.
+func (ch *ConfigHandler) fetchUnconditionally(ctx context.Context, preferred common.PreferredLocation, wgPublicKey string) ([]byte, error) {
+	f, ok := ch.ftr.(*fetcher)
+	if !ok {
+		return ch.ftr.fetchConfig(ctx, preferred, wgPublicKey)
+	}
+	etag, lastModified, base, source := f.etag, f.lastModified, f.base, f.source
+	defer func() {
+		f.etag, f.lastModified, f.base, f.source = etag, lastModified, base, source
+		f.pending = nil
+	}()
+	f.etag = ""
+	f.lastModified = time.Time{}
+	f.base = nil
+	return f.fetchConfig(ctx, preferred, wgPublicKey)
+}
+
+func diffConfigs(old, new *Config) (*ConfigDiff, error) {
+	if old == nil {
+		old = &Config{}
+	}
+	diff := &ConfigDiff{}
+	oldServers, err := serverOptions(old.Options)
+	if err != nil {
+		return nil, err
+	}
+	newServers, err := serverOptions(new.Options)
+	if err != nil {
+		return nil, err
+	}
+	for tag, opts := range newServers {
+		prev, ok := oldServers[tag]
+		switch {
+		case !ok:
+			diff.ServersAdded = append(diff.ServersAdded, tag)
+		case string(prev) != string(opts):
+			diff.ServersChanged = append(diff.ServersChanged, tag)
+		}
+	}
+	for tag := range oldServers {
+		if _, ok := newServers[tag]; !ok {
+			diff.ServersRemoved = append(diff.ServersRemoved, tag)
+		}
+	}
+
+	oldFields, err := configFields(old)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := configFields(new)
+	if err != nil {
+		return nil, err
+	}
+	for name := range newFields {
+		if string(oldFields[name]) != string(newFields[name]) {
+			diff.OptionsChanged = append(diff.OptionsChanged, name)
+		}
+	}
+	for name := range oldFields {
+		if _, ok := newFields[name]; !ok {
+			diff.OptionsChanged = append(diff.OptionsChanged, name)
+		}
+	}
+
+	slices.Sort(diff.ServersAdded)
+	slices.Sort(diff.ServersRemoved)
+	slices.Sort(diff.ServersChanged)
+	slices.Sort(diff.OptionsChanged)
+	return diff, nil
+}
+
+// serverOptions returns the marshaled options of every outbound and endpoint, keyed by tag.
+func serverOptions(opts option.Options) (map[string][]byte, error) {
+	servers := make(map[string][]byte, len(opts.Outbounds)+len(opts.Endpoints))
+	ctx := box.BaseContext()
+	for _, out := range opts.Outbounds {
+		buf, err := singjson.MarshalContext(ctx, out)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling outbound %q: %w", out.Tag, err)
+		}
+		servers[out.Tag] = buf
+	}
+	for _, ep := range opts.Endpoints {
+		buf, err := singjson.MarshalContext(ctx, ep)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling endpoint %q: %w", ep.Tag, err)
+		}
+		servers[ep.Tag] = buf
+	}
+	return servers, nil
+}
+
+// configFields returns cfg's marshaled top-level fields, with the fields of "options" other than
+// outbounds and endpoints flattened to "options.<name>".
+func configFields(cfg *Config) (map[string]json.RawMessage, error) {
+	buf, err := singjson.MarshalContext(box.BaseContext(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling config: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(buf, &fields); err != nil {
+		return nil, err
+	}
+	if raw, ok := fields["options"]; ok {
+		delete(fields, "options")
+		var opts map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &opts); err != nil {
+			return nil, err
+		}
+		for name, v := range opts {
+			if name != "outbounds" && name != "endpoints" {
+				fields["options."+name] = v
+			}
+		}
+	}
+	return fields, nil
+}
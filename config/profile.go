@@ -0,0 +1,180 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/getlantern/radiance/common"
+	"github.com/getlantern/radiance/common/fileperm"
+	"github.com/getlantern/radiance/common/settings"
+)
+
+var (
+	// ErrProfileNotFound is returned for operations on a profile that doesn't exist.
+	ErrProfileNotFound = errors.New("profile not found")
+	// ErrProfileActive is returned by [ConfigHandler.DeleteProfile] for the active profile.
+	ErrProfileActive = errors.New("cannot delete the active profile")
+)
+
+// Profile is a named set of user preferences, such as "work" or "home", that can be switched
+// between as a unit.
+type Profile struct {
+	Name              string                   `json:"name"`
+	PreferredLocation common.PreferredLocation `json:"preferred_location"`
+	// Servers lists the tags of user-added servers available while this profile is active. If
+	// empty, all user-added servers are available. Lantern servers are always available.
+	Servers            []string `json:"servers,omitempty"`
+	SplitTunnelEnabled bool     `json:"split_tunnel_enabled"`
+	// SplitTunnel maps split tunnel filter types (e.g. "domain", "processName") to their items.
+	SplitTunnel map[string][]string `json:"split_tunnel,omitempty"`
+	// DNSServer overrides the locale-based local DNS server if set.
+	DNSServer string `json:"dns_server,omitempty"`
+}
+
+// HasServer reports whether the user-added server with the given tag is available in p.
+func (p *Profile) HasServer(tag string) bool {
+	return len(p.Servers) == 0 || slices.Contains(p.Servers, tag)
+}
+
+// Profiles returns all saved profiles sorted by name.
+func (ch *ConfigHandler) Profiles() ([]Profile, error) {
+	ch.profilesMu.Lock()
+	defer ch.profilesMu.Unlock()
+	profiles, err := ch.loadProfiles()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Profile, 0, len(profiles))
+	for _, p := range profiles {
+		list = append(list, p)
+	}
+	slices.SortFunc(list, func(a, b Profile) int { return strings.Compare(a.Name, b.Name) })
+	return list, nil
+}
+
+// ActiveProfile returns the active profile, or nil if no profile has been switched to.
+func (ch *ConfigHandler) ActiveProfile() (*Profile, error) {
+	name := settings.GetString(settings.ActiveProfileKey)
+	if name == "" {
+		return nil, nil
+	}
+	ch.profilesMu.Lock()
+	defer ch.profilesMu.Unlock()
+	profiles, err := ch.loadProfiles()
+	if err != nil {
+		return nil, err
+	}
+	p, ok := profiles[name]
+	if !ok {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// SaveProfile creates or replaces the profile with p.Name. Saving the active profile does not
+// apply it; call [ConfigHandler.SwitchProfile] for that.
+func (ch *ConfigHandler) SaveProfile(p Profile) error {
+	if p.Name == "" {
+		return errors.New("profile name is required")
+	}
+	ch.profilesMu.Lock()
+	defer ch.profilesMu.Unlock()
+	profiles, err := ch.loadProfiles()
+	if err != nil {
+		return err
+	}
+	profiles[p.Name] = p
+	return ch.saveProfiles(profiles)
+}
+
+// DeleteProfile removes the named profile. The active profile can't be deleted.
+func (ch *ConfigHandler) DeleteProfile(name string) error {
+	ch.profilesMu.Lock()
+	defer ch.profilesMu.Unlock()
+	// Checked under profilesMu, which SwitchProfile holds while changing the active profile, so
+	// a profile can't be deleted while it's being switched to.
+	if name == settings.GetString(settings.ActiveProfileKey) {
+		return ErrProfileActive
+	}
+	profiles, err := ch.loadProfiles()
+	if err != nil {
+		return err
+	}
+	if _, ok := profiles[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	delete(profiles, name)
+	return ch.saveProfiles(profiles)
+}
+
+// SwitchProfile makes the named profile active and applies its preferred location and DNS
+// server. If the preferred location changed, a config fetch is started in the background so the
+// new location takes effect without waiting for the next poll. Applying the profile's servers and
+// split tunnel rules is left to the caller, which owns them.
+func (ch *ConfigHandler) SwitchProfile(name string) (*Profile, error) {
+	ch.profilesMu.Lock()
+	defer ch.profilesMu.Unlock()
+	profiles, err := ch.loadProfiles()
+	if err != nil {
+		return nil, err
+	}
+	p, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+
+	var prev common.PreferredLocation
+	if err := settings.GetStruct(settings.PreferredLocationKey, &prev); err != nil {
+		ch.logger.Error("failed to get preferred location from settings", "error", err)
+	}
+	if err := settings.Patch(settings.Settings{
+		settings.ActiveProfileKey:     p.Name,
+		settings.PreferredLocationKey: &p.PreferredLocation,
+		settings.LocalDNSServerKey:    p.DNSServer,
+	}); err != nil {
+		return nil, fmt.Errorf("applying profile %s: %w", name, err)
+	}
+	ch.logger.Info("Switched profile", "profile", name)
+
+	if prev != p.PreferredLocation && ch.started.Load() {
+		go func() {
+			if err := ch.Fetch(); err != nil {
+				ch.logger.Error("fetching config after profile switch", "profile", name, "error", err)
+			}
+		}()
+	}
+	return &p, nil
+}
+
+// loadProfiles reads the profiles file. Must be called with profilesMu held.
+func (ch *ConfigHandler) loadProfiles() (map[string]Profile, error) {
+	buf, err := ch.files().ReadFile(ch.profilesPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(map[string]Profile), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading profiles: %w", err)
+	}
+	profiles := make(map[string]Profile)
+	if err := json.Unmarshal(buf, &profiles); err != nil {
+		return nil, fmt.Errorf("parsing profiles: %w", err)
+	}
+	return profiles, nil
+}
+
+// saveProfiles writes the profiles file. Profiles name the user's servers and DNS server, so only
+// the user can read it. Must be called with profilesMu held.
+func (ch *ConfigHandler) saveProfiles(profiles map[string]Profile) error {
+	buf, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling profiles: %w", err)
+	}
+	if err := ch.files().WriteFile(ch.profilesPath, buf, fileperm.Secret); err != nil {
+		return fmt.Errorf("writing profiles: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// The config API signs each response body with ed25519 and sends the detached signature, plus
+// the ID of the signing key, in response headers. This protects against a compromised fronting
+// CDN or domain handing the client arbitrary outbounds.
+const (
+	signatureHeader      = "X-Lantern-Config-Signature"
+	signatureKeyIDHeader = "X-Lantern-Config-Key-Id"
+)
+
+// ErrInvalidSignature is returned when a fetched config is unsigned, signed with an unknown key,
+// or fails verification. The previous config stays in place.
+var ErrInvalidSignature = errors.New("invalid config signature")
+
+// defaultConfigKeys maps key IDs to the public keys the config API may sign with. To rotate,
+// ship the new key alongside the old one, switch the server over, then drop the old key in a
+// later release once clients carrying only the old key have aged out.
+//
+// No key has been issued yet, so configs aren't verified until the first one is added here.
+var defaultConfigKeys = map[string]ed25519.PublicKey{}
+
+// signatureVerifier checks config signatures against a fixed set of trusted keys. With no keys,
+// configs are accepted as they are, signed or not, since there's nothing to verify them against.
+// Once there is a key, every config must carry a valid signature.
+type signatureVerifier struct {
+	keys map[string]ed25519.PublicKey
+}
+
+func newSignatureVerifier(keys map[string]ed25519.PublicKey) signatureVerifier {
+	if len(keys) == 0 {
+		slog.Warn("No config signing keys are configured; fetched configs will not be verified")
+	}
+	return signatureVerifier{keys: keys}
+}
+
+func (v signatureVerifier) verify(body []byte, header http.Header) error {
+	if len(v.keys) == 0 {
+		return nil
+	}
+	encoded := header.Get(signatureHeader)
+	if encoded == "" {
+		return fmt.Errorf("%w: response is not signed", ErrInvalidSignature)
+	}
+	keyID := header.Get(signatureKeyIDHeader)
+	key, ok := v.keys[keyID]
+	if !ok {
+		return fmt.Errorf("%w: unknown key %q", ErrInvalidSignature, keyID)
+	}
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: decoding signature: %w", ErrInvalidSignature, err)
+	}
+	if !ed25519.Verify(key, body, sig) {
+		return fmt.Errorf("%w: verification with key %q failed", ErrInvalidSignature, keyID)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/getlantern/radiance/common"
+	"github.com/getlantern/radiance/common/settings"
+)
+
+const (
+	minSourceBackoff = 30 * time.Second
+	maxSourceBackoff = 30 * time.Minute
+)
+
+// ErrInvalidSource is returned by [ConfigHandler.SetSources] for a source without an absolute
+// https URL.
+var ErrInvalidSource = errors.New("invalid config source")
+
+// ConfigSource is a config server the fetcher can use in addition to the Lantern API, such as a
+// mirror or an enterprise-hosted server. Sources must serve the same API as the Lantern API over
+// https. They're sent the same request as the Lantern API minus the user's ID, token and device ID,
+// so they can't act as the user.
+type ConfigSource struct {
+	// URL is the base URL of the server. Configs are requested from URL + "/config-new".
+	URL string `json:"url"`
+	// Priority determines the order sources are tried in, lowest first. The Lantern API has
+	// priority 0 and is tried before other sources with the same priority.
+	Priority int `json:"priority"`
+}
+
+// SourceStatus describes a config source and how its recent fetches went.
+type SourceStatus struct {
+	ConfigSource
+	// Default is true for the built-in Lantern API.
+	Default bool `json:"default,omitempty"`
+	// Failures is the number of consecutive failed fetches.
+	Failures    int       `json:"failures"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	// RetryAt is when a failing source is next tried ahead of healthy lower-priority ones.
+	RetryAt time.Time `json:"retry_at,omitzero"`
+}
+
+// Healthy reports whether the source is tried in its normal priority order.
+func (s *SourceStatus) Healthy(now time.Time) bool {
+	return !now.Before(s.RetryAt)
+}
+
+// Sources returns every config source in the order they're currently tried.
+func (ch *ConfigHandler) Sources() []SourceStatus {
+	if f, ok := ch.ftr.(*fetcher); ok {
+		return f.sources.ordered(f.baseURL)
+	}
+	var tracker sourceTracker
+	return tracker.ordered(common.GetBaseURL())
+}
+
+// SetSources replaces the additional config sources. The Lantern API is always used and doesn't
+// need to be included.
+func (ch *ConfigHandler) SetSources(sources []ConfigSource) error {
+	for _, src := range sources {
+		u, err := url.Parse(src.URL)
+		if err != nil || !u.IsAbs() || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("%w: %q", ErrInvalidSource, src.URL)
+		}
+	}
+	if err := settings.Set(settings.ConfigSourcesKey, sources); err != nil {
+		return fmt.Errorf("saving config sources: %w", err)
+	}
+	ch.logger.Info("Config sources changed", "sources", sources)
+	return nil
+}
+
+// configuredSources returns the additional sources from settings.
+func configuredSources() []ConfigSource {
+	var sources []ConfigSource
+	if err := settings.GetStruct(settings.ConfigSourcesKey, &sources); err != nil {
+		slog.Error("failed to get config sources from settings", "error", err)
+	}
+	return sources
+}
+
+// sourceTracker records the health of config sources. Sources that keep failing are backed off
+// exponentially and tried only after the healthy ones.
+type sourceTracker struct {
+	mu     sync.Mutex
+	health map[string]*SourceStatus
+}
+
+// ordered returns the default source and the configured ones with their health, healthy
+// sources first and each group sorted by priority.
+func (t *sourceTracker) ordered(defaultURL string) []SourceStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := []SourceStatus{{ConfigSource: ConfigSource{URL: defaultURL}, Default: true}}
+	for _, src := range configuredSources() {
+		if src.URL == defaultURL || slices.ContainsFunc(list, func(s SourceStatus) bool { return s.URL == src.URL }) {
+			continue
+		}
+		list = append(list, SourceStatus{ConfigSource: src})
+	}
+	for i := range list {
+		if h, ok := t.health[list[i].URL]; ok {
+			list[i].Failures = h.Failures
+			list[i].LastError = h.LastError
+			list[i].LastSuccess = h.LastSuccess
+			list[i].RetryAt = h.RetryAt
+		}
+	}
+	now := time.Now()
+	slices.SortStableFunc(list, func(a, b SourceStatus) int {
+		if ah, bh := a.Healthy(now), b.Healthy(now); ah != bh {
+			if ah {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.Priority, b.Priority)
+	})
+	return list
+}
+
+func (t *sourceTracker) succeeded(sourceURL string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.health == nil {
+		t.health = make(map[string]*SourceStatus)
+	}
+	t.health[sourceURL] = &SourceStatus{LastSuccess: time.Now()}
+}
+
+func (t *sourceTracker) failed(sourceURL string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.health == nil {
+		t.health = make(map[string]*SourceStatus)
+	}
+	h, ok := t.health[sourceURL]
+	if !ok {
+		h = &SourceStatus{}
+		t.health[sourceURL] = h
+	}
+	h.Failures++
+	h.LastError = err.Error()
+	backoff := min(minSourceBackoff<<min(h.Failures-1, 16), maxSourceBackoff)
+	h.RetryAt = time.Now().Add(backoff)
+}
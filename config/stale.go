@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/getlantern/radiance/common/fileperm"
+	"github.com/getlantern/radiance/common/settings"
+	"github.com/getlantern/radiance/events"
+)
+
+const (
+	defaultMaxConfigAge = 72 * time.Hour
+	// staleRetryInterval replaces the poll interval while the config is stale.
+	staleRetryInterval = time.Minute
+)
+
+// Freshness describes how recently the config server confirmed the applied config, either by
+// sending it or by reporting it unchanged.
+type Freshness struct {
+	// ConfirmedAt is zero if the config has never been confirmed.
+	ConfirmedAt time.Time `json:"confirmed_at,omitzero"`
+	// Stale is true once the config is older than the max age in settings (72h by default). While
+	// stale, fetches are retried every minute.
+	Stale bool `json:"stale"`
+	// Expired is true once the config is older than the expiry in settings, if one is set. Its
+	// server credentials should no longer be used.
+	Expired bool `json:"expired"`
+}
+
+// ConfigFreshnessEvent is emitted when the config becomes stale or expired, or stops being so.
+type ConfigFreshnessEvent struct {
+	events.Event
+	Old, New Freshness
+}
+
+// Freshness returns how fresh the applied config is.
+func (ch *ConfigHandler) Freshness() Freshness {
+	f := Freshness{}
+	if ns := ch.confirmedAt.Load(); ns != 0 {
+		f.ConfirmedAt = time.Unix(0, ns)
+	}
+	age := ch.clock().Now().Sub(f.ConfirmedAt)
+	maxAge := settings.GetDuration(settings.ConfigMaxAgeKey)
+	if maxAge <= 0 {
+		maxAge = defaultMaxConfigAge
+	}
+	f.Stale = f.ConfirmedAt.IsZero() || age > maxAge
+	if expiry := settings.GetDuration(settings.ConfigExpiryKey); expiry > 0 {
+		f.Expired = !f.ConfirmedAt.IsZero() && age > expiry
+	}
+	return f
+}
+
+// confirmation is the persisted record of when the server last confirmed the applied config.
+type confirmation struct {
+	// Hash is the hex SHA-256 of the raw config that was confirmed, so that the time isn't
+	// credited to a different one, e.g. if the raw config was replaced or restored by hand.
+	Hash        string    `json:"hash"`
+	ConfirmedAt time.Time `json:"confirmed_at"`
+}
+
+// confirm records that the config server just confirmed the applied config, and saves the time so
+// it survives restarts.
+func (ch *ConfigHandler) confirm() {
+	if ch.config.Load() == nil {
+		return
+	}
+	now := ch.clock().Now()
+	ch.confirmedAt.Store(now.UnixNano())
+	buf, err := json.Marshal(confirmation{Hash: hex.EncodeToString(ch.appliedHash[:]), ConfirmedAt: now})
+	if err == nil {
+		err = ch.files().WriteFile(ch.confirmationPath(), buf, fileperm.Secret)
+	}
+	if err != nil {
+		ch.logger.Debug("saving config confirmation time", "error", err)
+	}
+	ch.checkFreshness()
+}
+
+// loadConfirmedAt restores the confirmation time saved by confirm, if it was for the applied
+// config.
+func (ch *ConfigHandler) loadConfirmedAt() {
+	buf, err := ch.files().ReadFile(ch.confirmationPath())
+	if err != nil {
+		return
+	}
+	var c confirmation
+	if err := json.Unmarshal(buf, &c); err != nil {
+		ch.logger.Debug("reading config confirmation time", "error", err)
+		return
+	}
+	if c.Hash == hex.EncodeToString(ch.appliedHash[:]) {
+		ch.confirmedAt.Store(c.ConfirmedAt.UnixNano())
+	}
+}
+
+// confirmationPath is where confirm saves the confirmation time, next to config.json.
+func (ch *ConfigHandler) confirmationPath() string {
+	return strings.TrimSuffix(ch.configPath, ".json") + "_confirmed.json"
+}
+
+// checkFreshness emits a ConfigFreshnessEvent if the config became, or stopped being, stale or
+// expired since the last check.
+func (ch *ConfigHandler) checkFreshness() {
+	f := ch.Freshness()
+	ch.freshnessMu.Lock()
+	old := ch.lastFreshness
+	ch.lastFreshness = f
+	ch.freshnessMu.Unlock()
+	if old.Stale == f.Stale && old.Expired == f.Expired {
+		return
+	}
+	switch {
+	case f.Expired:
+		ch.logger.Warn("Config has expired", "confirmed_at", f.ConfirmedAt)
+	case f.Stale:
+		ch.logger.Warn("Config is stale", "confirmed_at", f.ConfirmedAt)
+	default:
+		ch.logger.Info("Config is fresh again")
+	}
+	events.Emit(ConfigFreshnessEvent{Old: old, New: f})
+}
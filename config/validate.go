@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/getlantern/radiance/events"
+)
+
+// ErrInvalidConfig matches any [*ValidationError] with errors.Is.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// reservedTags are outbound tags the VPN client adds itself when building the box options. A
+// config may reference them but must not rely on them being selectable.
+var reservedTags = []string{"auto", "manual", "direct", "block"}
+
+// nonProxyTypes are outbound types that can't carry user traffic to a proxy.
+var nonProxyTypes = []string{"direct", "block", "dns", "selector", "urltest"}
+
+// ValidationProblem is a single reason a config was rejected.
+type ValidationProblem struct {
+	// Path locates the problem in the config, e.g. "options.outbounds[3]".
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every problem found in a rejected config.
+type ValidationError struct {
+	Problems []ValidationProblem
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.Path + ": " + p.Message
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// ConfigRejectedEvent is emitted when a fetched config fails validation and is not applied.
+type ConfigRejectedEvent struct {
+	events.Event
+	Problems []ValidationProblem
+}
+
+// validateConfig checks a fetched config for problems that would otherwise only surface as an
+// opaque sing-box error when the tunnel starts. It returns a *ValidationError or nil.
+func validateConfig(cfg *Config) error {
+	var problems []ValidationProblem
+	add := func(path, format string, args ...any) {
+		problems = append(problems, ValidationProblem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// A config with no outbounds at all is valid; the user may only be using their own servers.
+	opts := cfg.Options
+	seen := make(map[string]string)
+	selectable := 0
+	checkTag := func(path, tag, typ string, hasOptions bool) {
+		switch {
+		case tag == "":
+			add(path, "missing tag")
+			return
+		case seen[tag] != "":
+			add(path, "duplicate tag %q (also %s)", tag, seen[tag])
+			return
+		}
+		seen[tag] = path
+		if !hasOptions {
+			add(path, "%s %q has no options", typ, tag)
+			return
+		}
+		if !slices.Contains(reservedTags, tag) && !slices.Contains(nonProxyTypes, typ) &&
+			!slices.Contains(cfg.NonSelectableOutbounds, tag) {
+			selectable++
+		}
+	}
+	for i, out := range opts.Outbounds {
+		checkTag(fmt.Sprintf("options.outbounds[%d]", i), out.Tag, out.Type, out.Options != nil)
+	}
+	for i, ep := range opts.Endpoints {
+		checkTag(fmt.Sprintf("options.endpoints[%d]", i), ep.Tag, ep.Type, ep.Options != nil)
+	}
+	if len(seen) > 0 && selectable == 0 {
+		add("options", "no selectable outbounds or endpoints")
+	}
+	for i, tag := range cfg.NonSelectableOutbounds {
+		if seen[tag] == "" {
+			add(fmt.Sprintf("non_selectable_outbounds[%d]", i), "unknown tag %q", tag)
+		}
+	}
+
+	if opts.DNS != nil {
+		validateDNS(opts.DNS, add)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// validateDNS checks the DNS section, which replaces the client's default DNS setup wholesale,
+// so an empty or dangling one breaks all name resolution.
+func validateDNS(dns *option.DNSOptions, add func(path, format string, args ...any)) {
+	if len(dns.Servers) == 0 {
+		add("options.dns", "no servers")
+		return
+	}
+	tags := make(map[string]bool, len(dns.Servers))
+	for i, srv := range dns.Servers {
+		path := fmt.Sprintf("options.dns.servers[%d]", i)
+		switch {
+		case srv.Tag == "":
+			add(path, "missing tag")
+		case tags[srv.Tag]:
+			add(path, "duplicate tag %q", srv.Tag)
+		default:
+			tags[srv.Tag] = true
+		}
+	}
+	if dns.Final != "" && !tags[dns.Final] {
+		add("options.dns.final", "unknown server %q", dns.Final)
+	}
+}
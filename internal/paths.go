@@ -10,8 +10,12 @@ const (
 	DebugBoxOptionsFileName    = "debug-box-options.json"
 	ConfigFileName             = "config.json"
 	ConfigInvalidFileName      = "config.invalid.json"
+	ConfigHistoryDirName       = "config-history"
+	ProfilesFileName           = "profiles.json"
 	ServersFileName            = "servers.json"
 	ServersInvalidFileName     = "servers.invalid.json"
+	ServersLockFileName        = "servers.json.lock"
+	ServerFingerprintsFileName = "trusted_server_fingerprints.json"
 	SplitTunnelFileName        = "split-tunnel.json"
 	SplitTunnelInvalidFileName = "split-tunnel.invalid.json"
 	LogFileName                = "lantern.log"
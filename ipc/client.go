@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -19,9 +20,13 @@ import (
 	box "github.com/getlantern/lantern-box"
 
 	"github.com/getlantern/radiance/account"
+	"github.com/getlantern/radiance/backend"
 	"github.com/getlantern/radiance/common"
+	"github.com/getlantern/radiance/common/env"
 	"github.com/getlantern/radiance/common/settings"
+	"github.com/getlantern/radiance/config"
 	"github.com/getlantern/radiance/issue"
+	"github.com/getlantern/radiance/kindling"
 	rlog "github.com/getlantern/radiance/log"
 	"github.com/getlantern/radiance/servers"
 	"github.com/getlantern/radiance/vpn"
@@ -30,14 +35,34 @@ import (
 )
 
 func newClient() *Client {
+	var transport http.RoundTripper = &http.Transport{
+		DialContext:       dialContext,
+		ForceAttemptHTTP2: true,
+		Protocols:         &protocols,
+	}
+	if token, _ := env.Get(env.IPCToken); token != "" {
+		transport = &tokenTransport{token: token, next: transport}
+	}
 	return &Client{
-		http: &http.Client{
-			Transport: &http.Transport{
-				DialContext:       dialContext,
-				ForceAttemptHTTP2: true,
-				Protocols:         &protocols,
-			},
-		},
+		http: &http.Client{Transport: transport},
+	}
+}
+
+// tokenTransport attaches the shared IPC token to every request.
+type tokenTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(tokenHeader, t.token)
+	return t.next.RoundTrip(req)
+}
+
+func (t *tokenTransport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
 	}
 }
 
@@ -87,6 +112,43 @@ func IsNotFound(err error) bool {
 	return errors.As(err, &e) && e.Status == http.StatusNotFound
 }
 
+///////////////////
+// API handshake //
+///////////////////
+
+// ErrIncompatibleAPI is returned by CheckAPI when the daemon speaks a different IPC API version.
+var ErrIncompatibleAPI = errors.New("incompatible IPC API version")
+
+// APIInfo returns the daemon's API version and capabilities. Daemons that predate the handshake
+// don't serve it; for those it returns an APIInfo with APIVersion 0 and no capabilities instead
+// of an error.
+func (c *Client) APIInfo(ctx context.Context) (APIInfo, error) {
+	var info APIInfo
+	err := c.doJSON(ctx, http.MethodGet, apiInfoEndpoint, nil, &info)
+	if IsNotFound(err) {
+		return APIInfo{}, nil
+	}
+	return info, err
+}
+
+// CheckAPI performs the version handshake and returns ErrIncompatibleAPI if the daemon's API
+// version differs from this client's. Pre-handshake daemons (version 0) serve the version 1
+// endpoints and are treated as compatible; use [APIInfo.Has] to check for optional features.
+func (c *Client) CheckAPI(ctx context.Context) (APIInfo, error) {
+	info, err := c.APIInfo(ctx)
+	if err != nil {
+		return info, err
+	}
+	v := info.APIVersion
+	if v == 0 {
+		v = 1
+	}
+	if v != APIVersion {
+		return info, fmt.Errorf("%w: daemon %d, client %d", ErrIncompatibleAPI, info.APIVersion, APIVersion)
+	}
+	return info, nil
+}
+
 /////////////
 //   VPN   //
 /////////////
@@ -149,6 +211,13 @@ func (c *Client) VPNThroughput(ctx context.Context) (vpn.ThroughputSnapshot, err
 	return s, err
 }
 
+// VPNMetrics returns a snapshot of tunnel traffic, outbound health, and daemon process metrics.
+func (c *Client) VPNMetrics(ctx context.Context) (backend.Metrics, error) {
+	var m backend.Metrics
+	err := c.doJSON(ctx, http.MethodGet, vpnMetricsEndpoint, nil, &m)
+	return m, err
+}
+
 // RunOfflineURLTests runs URL performance tests when offline (VPN disconnected) and caches the
 // results. This enables autoconnect to select the best server for the initial connection.
 func (c *Client) RunOfflineURLTests(ctx context.Context) error {
@@ -203,6 +272,123 @@ func (c *Client) UpdateConfig(ctx context.Context) error {
 	return err
 }
 
+// ForceRefreshConfig makes the daemon fetch and re-apply the latest config even if it hasn't
+// changed. Returns an error if config fetching is disabled.
+func (c *Client) ForceRefreshConfig(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodPost, configRefreshEndpoint, nil)
+	return err
+}
+
+// ConfigHistory returns the config versions retained for rollback, newest first.
+func (c *Client) ConfigHistory(ctx context.Context) ([]config.ConfigVersion, error) {
+	var versions []config.ConfigVersion
+	err := c.doJSON(ctx, http.MethodGet, configHistoryEndpoint, nil, &versions)
+	return versions, err
+}
+
+// RollbackConfig applies the config version with the given ID, or the version before the
+// current one if id is empty.
+func (c *Client) RollbackConfig(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodPost, configRollbackEndpoint, ConfigRollbackRequest{ID: id})
+	return err
+}
+
+// PreviewConfigUpdate fetches the latest config and returns how it differs from the applied one
+// without applying it.
+func (c *Client) PreviewConfigUpdate(ctx context.Context) (*config.ConfigDiff, error) {
+	var diff config.ConfigDiff
+	if err := c.doJSON(ctx, http.MethodPost, configPreviewEndpoint, nil, &diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// ApplyConfigPreview applies the config fetched by the last PreviewConfigUpdate.
+func (c *Client) ApplyConfigPreview(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodPost, configApplyEndpoint, nil)
+	return err
+}
+
+// CountryOverride returns the country code config fetches use instead of the IP-derived one, or
+// "" if there is none.
+func (c *Client) CountryOverride(ctx context.Context) (string, error) {
+	var resp ConfigCountryRequest
+	err := c.doJSON(ctx, http.MethodGet, configCountryEndpoint, nil, &resp)
+	return resp.Country, err
+}
+
+// SetCountryOverride makes config fetches ask for configs tuned for country and refreshes the
+// config. An empty country clears the override.
+func (c *Client) SetCountryOverride(ctx context.Context, country string) error {
+	_, err := c.do(ctx, http.MethodPost, configCountryEndpoint, ConfigCountryRequest{Country: country})
+	return err
+}
+
+// ConfigSources returns every config source in the order they're currently tried, with their
+// health.
+func (c *Client) ConfigSources(ctx context.Context) ([]config.SourceStatus, error) {
+	var sources []config.SourceStatus
+	if err := c.doJSON(ctx, http.MethodGet, configSourcesEndpoint, nil, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// SetConfigSources replaces the config sources used in addition to the Lantern API.
+func (c *Client) SetConfigSources(ctx context.Context, sources []config.ConfigSource) error {
+	_, err := c.do(ctx, http.MethodPost, configSourcesEndpoint, ConfigSourcesRequest{Sources: sources})
+	return err
+}
+
+// ConfigFreshness returns how recently the config server confirmed the applied config.
+func (c *Client) ConfigFreshness(ctx context.Context) (config.Freshness, error) {
+	var f config.Freshness
+	err := c.doJSON(ctx, http.MethodGet, configFreshnessEndpoint, nil, &f)
+	return f, err
+}
+
+// ConfigFetchHistory returns the most recent config fetch attempts, newest first.
+func (c *Client) ConfigFetchHistory(ctx context.Context) ([]config.FetchInfo, error) {
+	var fetches []config.FetchInfo
+	if err := c.doJSON(ctx, http.MethodGet, configFetchesEndpoint, nil, &fetches); err != nil {
+		return nil, err
+	}
+	return fetches, nil
+}
+
+// KindlingStatus returns which kindling transports served the most recent requests to Lantern's
+// API and config servers, and how each transport has fared.
+func (c *Client) KindlingStatus(ctx context.Context) (kindling.StatusReport, error) {
+	var status kindling.StatusReport
+	err := c.doJSON(ctx, http.MethodGet, kindlingStatusEndpoint, nil, &status)
+	return status, err
+}
+
+// Announcements returns the messages and surveys the server currently targets at the user,
+// including dismissed ones, which are marked as such.
+func (c *Client) Announcements(ctx context.Context) ([]config.Announcement, error) {
+	var announcements []config.Announcement
+	if err := c.doJSON(ctx, http.MethodGet, announcementsEndpoint, nil, &announcements); err != nil {
+		return nil, err
+	}
+	return announcements, nil
+}
+
+// DismissAnnouncement records that the user dismissed the announcement with the given ID.
+func (c *Client) DismissAnnouncement(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodPost, announcementsDismissEndpoint, DismissAnnouncementRequest{ID: id})
+	return err
+}
+
+// ConfigFlags returns the server-controlled flags from the latest config as raw JSON values.
+func (c *Client) ConfigFlags(ctx context.Context) (map[string]json.RawMessage, error) {
+	var flags map[string]json.RawMessage
+	if err := c.doJSON(ctx, http.MethodGet, configFlagsEndpoint, nil, &flags); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
 ///////////////////////
 // Server management //
 ///////////////////////
@@ -264,6 +450,22 @@ func (c *Client) AddServers(ctx context.Context, list servers.ServerList) error
 	return err
 }
 
+// AddServersBatch adds many servers at once, replacing or skipping those whose tag is already in
+// use, and reports what was done with each.
+func (c *Client) AddServersBatch(ctx context.Context, list servers.ServerList, replace bool) (servers.BatchResult, error) {
+	var res servers.BatchResult
+	body, err := sjson.MarshalContext(boxCtx, AddServersBatchRequest{Servers: list, Replace: replace})
+	if err != nil {
+		return res, fmt.Errorf("marshal add servers request: %w", err)
+	}
+	data, err := c.do(ctx, http.MethodPost, serversBatchEndpoint, body)
+	if err != nil {
+		return res, err
+	}
+	err = json.Unmarshal(data, &res)
+	return res, err
+}
+
 // RemoveServers removes servers by tag from the given group.
 func (c *Client) RemoveServers(ctx context.Context, tags []string) error {
 	_, err := c.do(ctx, http.MethodPost, serversRemoveEndpoint, RemoveServersRequest{Tags: tags})
@@ -310,6 +512,56 @@ func (c *Client) InviteToPrivateServer(ctx context.Context, ip string, port int,
 	return resp.Code, err
 }
 
+// ServerQRPayload returns the QR payload that shares the user server with the given tag.
+func (c *Client) ServerQRPayload(ctx context.Context, tag string) (string, error) {
+	var resp QRPayloadResponse
+	q := url.Values{"tag": {tag}}
+	err := c.doJSON(ctx, http.MethodGet, serversQREndpoint+"?"+q.Encode(), nil, &resp)
+	return resp.Payload, err
+}
+
+// AddServersByQRPayload adds the server or private server invite in a scanned QR payload and
+// returns the tags of the added servers.
+func (c *Client) AddServersByQRPayload(ctx context.Context, payload string, skipCertVerification bool) ([]string, error) {
+	var tags []string
+	err := c.doJSON(ctx, http.MethodPost, serversQREndpoint, QRPayloadRequest{Payload: payload, SkipCertVerification: skipCertVerification}, &tags)
+	return tags, err
+}
+
+// ExportServer returns the user server with the given tag in the given format.
+func (c *Client) ExportServer(ctx context.Context, tag string, format servers.ExportFormat) (string, error) {
+	var resp ServerExportResponse
+	q := url.Values{"tag": {tag}, "format": {string(format)}}
+	err := c.doJSON(ctx, http.MethodGet, serversExportEndpoint+"?"+q.Encode(), nil, &resp)
+	return resp.Data, err
+}
+
+// RenameServer changes the tag of a user server.
+func (c *Client) RenameServer(ctx context.Context, tag, newTag string) error {
+	_, err := c.do(ctx, http.MethodPost, serversRenameEndpoint, RenameServerRequest{Tag: tag, NewTag: newTag})
+	return err
+}
+
+// SetServerMetadata replaces the metadata of a user server.
+func (c *Client) SetServerMetadata(ctx context.Context, tag string, md servers.ServerMetadata) error {
+	_, err := c.do(ctx, http.MethodPost, serversMetadataEndpoint, ServerMetadataRequest{Tag: tag, Metadata: md})
+	return err
+}
+
+// SetServerDisabled disables or enables a user server.
+func (c *Client) SetServerDisabled(ctx context.Context, tag string, disabled bool) error {
+	_, err := c.do(ctx, http.MethodPost, serversDisabledEndpoint, ServerDisabledRequest{Tag: tag, Disabled: disabled})
+	return err
+}
+
+// ProbeServers checks whether the user servers with the given tags accept connections and returns
+// the results by tag. Servers that can't be probed are left out.
+func (c *Client) ProbeServers(ctx context.Context, tags []string) (map[string]servers.Reachability, error) {
+	var results map[string]servers.Reachability
+	err := c.doJSON(ctx, http.MethodPost, serversProbeEndpoint, ProbeServersRequest{Tags: tags}, &results)
+	return results, err
+}
+
 // RevokePrivateServerInvite revokes an invite for a private server.
 func (c *Client) RevokePrivateServerInvite(ctx context.Context, ip string, port int, accessToken, inviteName string) error {
 	_, err := c.do(ctx, http.MethodDelete, serversPrivateInviteEndpoint,
@@ -317,6 +569,33 @@ func (c *Client) RevokePrivateServerInvite(ctx context.Context, ip string, port
 	return err
 }
 
+// RemovePrivateServer removes the user servers of the private server at ip and forgets its
+// trusted certificate fingerprint.
+func (c *Client) RemovePrivateServer(ctx context.Context, ip string) error {
+	_, err := c.do(ctx, http.MethodPost, serversPrivateRemoveEndpoint, RemovePrivateServerRequest{IP: ip})
+	return err
+}
+
+// TrustedFingerprints returns the trusted private server certificate fingerprints.
+func (c *Client) TrustedFingerprints(ctx context.Context) ([]servers.TrustedFingerprint, error) {
+	var fps []servers.TrustedFingerprint
+	err := c.doJSON(ctx, http.MethodGet, serversFingerprintsEndpoint, nil, &fps)
+	return fps, err
+}
+
+// RemoveTrustedFingerprint forgets the trusted fingerprint of the private server at ip, so the
+// certificate it presents next is trusted.
+func (c *Client) RemoveTrustedFingerprint(ctx context.Context, ip string) error {
+	_, err := c.do(ctx, http.MethodDelete, serversFingerprintsEndpoint, TrustedFingerprintRequest{IP: ip})
+	return err
+}
+
+// ReplaceTrustedFingerprint trusts the given certificate fingerprint for the private server at ip.
+func (c *Client) ReplaceTrustedFingerprint(ctx context.Context, ip, fingerprint string) error {
+	_, err := c.do(ctx, http.MethodPost, serversFingerprintsEndpoint, TrustedFingerprintRequest{IP: ip, Fingerprint: fingerprint})
+	return err
+}
+
 //////////////
 // Settings //
 //////////////
@@ -412,6 +691,36 @@ func (c *Client) RemoveSplitTunnelItems(ctx context.Context, items vpn.SplitTunn
 	return err
 }
 
+//////////////
+// Profiles //
+//////////////
+
+// Profiles returns the saved profiles and the name of the active one.
+func (c *Client) Profiles(ctx context.Context) (ProfilesResponse, error) {
+	var resp ProfilesResponse
+	err := c.doJSON(ctx, http.MethodGet, profilesEndpoint, nil, &resp)
+	return resp, err
+}
+
+// SaveProfile saves the current settings as the named profile. servers lists the tags of
+// user-added servers available in the profile; if empty, all are available.
+func (c *Client) SaveProfile(ctx context.Context, name string, servers []string) error {
+	_, err := c.do(ctx, http.MethodPost, profilesSaveEndpoint, ProfileSaveRequest{Name: name, Servers: servers})
+	return err
+}
+
+// SwitchProfile makes the named profile active and applies it.
+func (c *Client) SwitchProfile(ctx context.Context, name string) error {
+	_, err := c.do(ctx, http.MethodPost, profilesSwitchEndpoint, ProfileRequest{Name: name})
+	return err
+}
+
+// DeleteProfile removes the named profile.
+func (c *Client) DeleteProfile(ctx context.Context, name string) error {
+	_, err := c.do(ctx, http.MethodPost, profilesDeleteEndpoint, ProfileRequest{Name: name})
+	return err
+}
+
 /////////////
 // Account //
 /////////////
@@ -431,11 +740,37 @@ func (c *Client) Login(ctx context.Context, email, password string) (*account.Us
 	err := c.doJSON(ctx, http.MethodPost, accountLoginEndpoint,
 		EmailPasswordRequest{Email: email, Password: password}, &userData)
 	if err != nil {
-		return nil, err
+		return nil, loginError(err)
+	}
+	return &userData, nil
+}
+
+// LoginAndReplaceDevice logs the user in, first removing deviceToRemove from the account if it has
+// reached its device limit.
+func (c *Client) LoginAndReplaceDevice(ctx context.Context, email, password, deviceToRemove string) (*account.UserData, error) {
+	var userData account.UserData
+	err := c.doJSON(ctx, http.MethodPost, accountLoginReplaceEndpoint,
+		LoginReplaceDeviceRequest{Email: email, Password: password, DeviceID: deviceToRemove}, &userData)
+	if err != nil {
+		return nil, loginError(err)
 	}
 	return &userData, nil
 }
 
+// loginError turns the conflict the server responds with when the account has reached its device
+// limit back into an [*account.TooManyDevicesError].
+func loginError(err error) error {
+	var e *Error
+	if !errors.As(err, &e) || e.Status != http.StatusConflict {
+		return err
+	}
+	var tooMany account.TooManyDevicesError
+	if json.Unmarshal([]byte(e.Message), &tooMany) != nil {
+		return err
+	}
+	return &tooMany
+}
+
 // Logout logs the user out.
 func (c *Client) Logout(ctx context.Context, email string) (*account.UserData, error) {
 	var userData account.UserData
@@ -455,6 +790,17 @@ func (c *Client) UserData(ctx context.Context) (*account.UserData, error) {
 	return c.userData(ctx, false)
 }
 
+// EnsureFreshUserData returns the locally cached user data, fetching it from the remote server
+// first if it's older than maxAge. The cached data is returned if the server can't be reached.
+func (c *Client) EnsureFreshUserData(ctx context.Context, maxAge time.Duration) (*account.UserData, error) {
+	var userData account.UserData
+	url := accountUserDataEndpoint + "?maxAge=" + url.QueryEscape(maxAge.String())
+	if err := c.doJSON(ctx, http.MethodGet, url, nil, &userData); err != nil {
+		return nil, err
+	}
+	return &userData, nil
+}
+
 func (c *Client) userData(ctx context.Context, fetch bool) (*account.UserData, error) {
 	var userData account.UserData
 	url := fmt.Sprintf("%s?fetch=%v", accountUserDataEndpoint, fetch)
@@ -480,6 +826,76 @@ func (c *Client) RemoveDevice(ctx context.Context, deviceID string) (*account.Li
 	return &resp, nil
 }
 
+// Entitlements returns what the user's plan allows.
+func (c *Client) Entitlements(ctx context.Context) (*account.Entitlements, error) {
+	var e account.Entitlements
+	if err := c.doJSON(ctx, http.MethodGet, accountEntitlementsEndpoint, nil, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Accounts returns the accounts stored on this device.
+func (c *Client) Accounts(ctx context.Context) ([]account.StoredAccount, error) {
+	var accounts []account.StoredAccount
+	if err := c.doJSON(ctx, http.MethodGet, accountAccountsEndpoint, nil, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// AddAccount stores the active account and replaces it with a new anonymous user, so the user can
+// log in to another account.
+func (c *Client) AddAccount(ctx context.Context) (*account.UserData, error) {
+	var userData account.UserData
+	if err := c.doJSON(ctx, http.MethodPost, accountAddEndpoint, nil, &userData); err != nil {
+		return nil, err
+	}
+	return &userData, nil
+}
+
+// SwitchAccount makes the stored account with the given user ID the active one.
+func (c *Client) SwitchAccount(ctx context.Context, userID string) error {
+	_, err := c.do(ctx, http.MethodPost, accountSwitchEndpoint, StoredAccountRequest{UserID: userID})
+	return err
+}
+
+// RemoveAccount forgets the stored account with the given user ID.
+func (c *Client) RemoveAccount(ctx context.Context, userID string) error {
+	_, err := c.do(ctx, http.MethodPost, accountRemoveEndpoint, StoredAccountRequest{UserID: userID})
+	return err
+}
+
+// AccountBreakerStates returns the state of the circuit breakers of the account backends, for
+// diagnosing why account requests fail.
+func (c *Client) AccountBreakerStates(ctx context.Context) ([]account.BreakerState, error) {
+	var states []account.BreakerState
+	if err := c.doJSON(ctx, http.MethodGet, accountBreakersEndpoint, nil, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// RequestLinkCode requests a code that links another device to the logged-in user's account.
+func (c *Client) RequestLinkCode(ctx context.Context) (*account.LinkCodeResponse, error) {
+	var resp account.LinkCodeResponse
+	if err := c.doJSON(ctx, http.MethodGet, accountLinkCodeEndpoint, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RedeemLinkCode links this device, under deviceName, to the account the code was requested for.
+func (c *Client) RedeemLinkCode(ctx context.Context, code, deviceName string) (*account.UserData, error) {
+	var userData account.UserData
+	err := c.doJSON(ctx, http.MethodPost, accountLinkCodeEndpoint,
+		RedeemLinkCodeRequest{Code: code, DeviceName: deviceName}, &userData)
+	if err != nil {
+		return nil, err
+	}
+	return &userData, nil
+}
+
 // SignUp creates a new account with the given email and password.
 func (c *Client) SignUp(ctx context.Context, email, password string) ([]byte, *account.SignupResponse, error) {
 	var resp SignupResponse
@@ -525,6 +941,18 @@ func (c *Client) CompleteChangeEmail(ctx context.Context, newEmail, password, co
 	return err
 }
 
+// ResendEmailVerification resends the code that verifies the logged-in user's email.
+func (c *Client) ResendEmailVerification(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodPost, accountEmailEndpoint+"/resend-verification", nil)
+	return err
+}
+
+// VerifyEmail verifies the logged-in user's email with the code sent to it.
+func (c *Client) VerifyEmail(ctx context.Context, code string) error {
+	_, err := c.do(ctx, http.MethodPost, accountEmailEndpoint+"/verify", VerifyEmailRequest{Code: code})
+	return err
+}
+
 // StartRecoveryByEmail initiates account recovery by email.
 func (c *Client) StartRecoveryByEmail(ctx context.Context, email string) error {
 	_, err := c.do(ctx, http.MethodPost, accountRecoveryEndpoint+"/start", EmailRequest{Email: email})
@@ -574,6 +1002,25 @@ func (c *Client) OAuthLoginCallback(ctx context.Context, oAuthToken string) (*ac
 	return &userData, nil
 }
 
+// StartOAuthLogin starts an OAuth login with provider that the daemon completes itself, and returns
+// the URL the user must open in a browser to sign in.
+func (c *Client) StartOAuthLogin(ctx context.Context, provider string) (string, error) {
+	var resp URLResponse
+	q := url.Values{"provider": {provider}}
+	err := c.doJSON(ctx, http.MethodPost, accountOAuthStartEndpoint+"?"+q.Encode(), nil, &resp)
+	return resp.URL, err
+}
+
+// CompleteOAuthLogin waits for the user to finish the OAuth login started with StartOAuthLogin and
+// returns their user data.
+func (c *Client) CompleteOAuthLogin(ctx context.Context) (*account.UserData, error) {
+	var userData account.UserData
+	if err := c.doJSON(ctx, http.MethodPost, accountOAuthCompleteEndpoint, nil, &userData); err != nil {
+		return nil, err
+	}
+	return &userData, nil
+}
+
 // DataCapInfo returns the current data cap information as a JSON string.
 func (c *Client) DataCapInfo(ctx context.Context) (*account.DataCapInfo, error) {
 	var resp account.DataCapInfo
@@ -615,6 +1062,25 @@ func (c *Client) ReferralAttach(ctx context.Context, code, channel string) (*acc
 	return &resp, nil
 }
 
+// ReferralInfo returns the user's referral code and the users who signed up with it.
+func (c *Client) ReferralInfo(ctx context.Context) (*account.ReferralInfo, error) {
+	var info account.ReferralInfo
+	if err := c.doJSON(ctx, http.MethodGet, subscriptionReferralEndpoint, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Subscription returns the current state of the user's subscription. If the user has no
+// subscription, the error satisfies [IsNotFound].
+func (c *Client) Subscription(ctx context.Context) (*account.SubscriptionData, error) {
+	var sub account.SubscriptionData
+	if err := c.doJSON(ctx, http.MethodGet, subscriptionStatusEndpoint, nil, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
 // StripeBillingPortalURL returns the Stripe billing portal URL.
 func (c *Client) StripeBillingPortalURL(ctx context.Context) (string, error) {
 	var resp URLResponse
@@ -644,6 +1110,17 @@ func (c *Client) SubscriptionPlans(ctx context.Context, channel string) (string,
 	return resp.Plans, err
 }
 
+// QuotePlanChange quotes switching to the plan with the given ID, as listed for the channel, in
+// currency, crediting the time left on the current subscription.
+func (c *Client) QuotePlanChange(ctx context.Context, channel, planID, currency string) (*account.PlanQuote, error) {
+	var quote account.PlanQuote
+	q := url.Values{"channel": {channel}, "plan": {planID}, "currency": {currency}}
+	if err := c.doJSON(ctx, http.MethodGet, subscriptionQuoteEndpoint+"?"+q.Encode(), nil, &quote); err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
 // VerifySubscription verifies a subscription purchase.
 func (c *Client) VerifySubscription(ctx context.Context, service account.SubscriptionService, data map[string]string) (string, error) {
 	var resp ResultResponse
@@ -670,25 +1147,74 @@ func (c *Client) RestoreSubscription(ctx context.Context, service account.Subscr
 
 // ReportIssue submits an issue report. additionalAttachments is a list of file paths for additional
 // files to include. Logs, diagnostics, and the config response are included automatically and do
-// not need to be specified. attachments contains screenshot files sent as first-class multipart
+// not need to be specified. If attachDiagnostics is true, the tunnel state and the config fetch and
+// account backend status are included too, with PII scrubbed. attachments contains screenshot files sent as first-class multipart
 // attachments; callers may include up to [issue.MaxFirstClassAttachmentCount] files with a
 // combined size of [issue.MaxFirstClassAttachmentBytes] bytes.
-func (c *Client) ReportIssue(ctx context.Context, issueType issue.IssueType, description, email string, additionalAttachments []string, attachments []*issue.Attachment) error {
+func (c *Client) ReportIssue(ctx context.Context, issueType issue.IssueType, description, email string, attachDiagnostics bool, additionalAttachments []string, attachments []*issue.Attachment) error {
 	_, err := c.do(ctx, http.MethodPost, issueEndpoint,
 		IssueReportRequest{
 			IssueType:             issueType,
 			Description:           description,
 			Email:                 email,
+			AttachDiagnostics:     attachDiagnostics,
 			AdditionalAttachments: additionalAttachments,
 			Attachments:           attachments,
 		})
 	return err
 }
 
+///////////
+// Debug //
+///////////
+
+// DebugProfile fetches the named pprof profile (e.g. heap, allocs, goroutine, profile, trace)
+// from the daemon. seconds applies to the sampling profiles (profile, trace) and to delta
+// profiles; 0 uses the pprof default. The daemon must be running with debug endpoints enabled,
+// otherwise the call fails with a 404.
+func (c *Client) DebugProfile(ctx context.Context, name string, seconds int) ([]byte, error) {
+	endpoint := debugPprofEndpoint + url.PathEscape(name)
+	if seconds > 0 {
+		endpoint = fmt.Sprintf("%s?seconds=%d", endpoint, seconds)
+	}
+	return c.do(ctx, http.MethodGet, endpoint, nil)
+}
+
+// DebugGoroutines returns a full stack dump of every goroutine in the daemon.
+func (c *Client) DebugGoroutines(ctx context.Context) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, debugGoroutinesEndpoint, nil)
+}
+
+// DebugVars returns the daemon's published expvar variables.
+func (c *Client) DebugVars(ctx context.Context) (map[string]json.RawMessage, error) {
+	var vars map[string]json.RawMessage
+	err := c.doJSON(ctx, http.MethodGet, debugVarsEndpoint, nil, &vars)
+	return vars, err
+}
+
 /////////////
 // streams //
 /////////////
 
+// eventsEndpointWithQuery builds the /events path with the type filter and throughput interval.
+func eventsEndpointWithQuery(types []StreamEventType, interval time.Duration) string {
+	q := url.Values{}
+	if len(types) > 0 {
+		names := make([]string, len(types))
+		for i, t := range types {
+			names[i] = string(t)
+		}
+		q.Set("types", strings.Join(names, ","))
+	}
+	if interval > 0 {
+		q.Set("interval", interval.String())
+	}
+	if len(q) == 0 {
+		return eventsEndpoint
+	}
+	return eventsEndpoint + "?" + q.Encode()
+}
+
 // sseRetryLoop runs sseStream in a retry loop until ctx is cancelled.
 func (c *Client) sseRetryLoop(ctx context.Context, endpoint string, handler func([]byte)) error {
 	bo := common.NewBackoff(30 * time.Second)
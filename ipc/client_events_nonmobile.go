@@ -5,6 +5,7 @@ package ipc
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/getlantern/radiance/account"
 	"github.com/getlantern/radiance/vpn"
@@ -52,3 +53,15 @@ func (c *Client) VPNStatusEvents(ctx context.Context, handler func(vpn.StatusUpd
 func (c *Client) DataCapStream(ctx context.Context, handler func(account.DataCapInfo)) error {
 	return c.dataCapStream(ctx, handler)
 }
+
+// Events streams the combined event feed: VPN status, auto-selection, URL tests, config updates,
+// and throughput ticks every interval while connected. types restricts the feed to the given kinds;
+// nil selects all of them. A zero interval uses the server default. Blocks until ctx is cancelled.
+func (c *Client) Events(ctx context.Context, types []StreamEventType, interval time.Duration, handler func(StreamEvent)) error {
+	return c.sseRetryLoop(ctx, eventsEndpointWithQuery(types, interval), func(data []byte) {
+		var evt StreamEvent
+		if err := json.Unmarshal(data, &evt); err == nil {
+			handler(evt)
+		}
+	})
+}
@@ -86,9 +86,14 @@ func getConnPeer(conn net.Conn) (p usr, err error) {
 	if err != nil {
 		return p, fmt.Errorf("lookup user id %v: %w", uid, err)
 	}
-	return usr{
+	p = usr{
 		uid:     uidStr,
 		uname:   u.Username,
-		isAdmin: canSudo(u.Username),
-	}, nil
+		inGroup: inIPCGroup(u),
+	}
+	// canSudo shells out, so skip it when group membership already grants access.
+	if !p.inGroup {
+		p.isAdmin = canSudo(u.Username)
+	}
+	return p, nil
 }
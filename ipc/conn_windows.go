@@ -22,13 +22,52 @@ const (
 	apiURL         = "http://pipe"
 	connectTimeout = 10 * time.Second
 
-	sddl = `D:P(A;;GA;;;SY)(A;;GRGW;;;BA)(A;;GRGW;;;IU)`
+	// sddl grants SYSTEM full access and administrators and interactive (console or RDP) users
+	// read/write. Network and anonymous logons are denied explicitly, ahead of the allow entries,
+	// so group policies that add them to broader groups can't open the pipe.
+	sddl = `D:P(D;;GA;;;NU)(D;;GA;;;AN)(A;;GA;;;SY)(A;;GRGW;;;BA)(A;;GRGW;;;IU)`
 )
 
 func dialContext(ctx context.Context, _, _ string) (net.Conn, error) {
 	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
 	defer cancel()
-	return winio.DialPipeAccessImpLevel(ctx, pipePath, windows.GENERIC_READ|windows.GENERIC_WRITE, winio.PipeImpLevelIdentification)
+	conn, err := winio.DialPipeAccessImpLevel(ctx, pipePath, windows.GENERIC_READ|windows.GENERIC_WRITE, winio.PipeImpLevelIdentification)
+	if err != nil {
+		return nil, err
+	}
+	if wc, ok := conn.(winioConn); ok {
+		if err := verifyPipeOwner(windows.Handle(wc.Fd())); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// verifyPipeOwner guards against pipe squatting: while the service is stopped, any local user
+// could create a pipe at pipePath and impersonate the daemon. The pipe is accepted only if it is
+// owned by SYSTEM, the administrators group (the owner an elevated daemon gets), or the calling
+// user.
+func verifyPipeOwner(h windows.Handle) error {
+	sd, err := windows.GetSecurityInfo(h, windows.SE_KERNEL_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return fmt.Errorf("get pipe security info: %w", err)
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return fmt.Errorf("get pipe owner: %w", err)
+	}
+	if owner.IsWellKnown(windows.WinLocalSystemSid) || owner.IsWellKnown(windows.WinBuiltinAdministratorsSid) {
+		return nil
+	}
+	self, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return fmt.Errorf("get current user: %w", err)
+	}
+	if windows.EqualSid(owner, self.User.Sid) {
+		return nil
+	}
+	return fmt.Errorf("IPC pipe is owned by untrusted account %s", owner)
 }
 
 // listen creates a named pipe listener at a predefined path.
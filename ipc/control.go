@@ -0,0 +1,215 @@
+package ipc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/getlantern/radiance/config"
+	"github.com/getlantern/radiance/events"
+	controlpb "github.com/getlantern/radiance/ipc/controlpb/v1"
+	"github.com/getlantern/radiance/vpn"
+)
+
+// controlPathPrefix is the HTTP path prefix gRPC uses for VPNControl methods.
+const controlPathPrefix = "/lantern.control.v1.VPNControl/"
+
+// controlService implements the gRPC VPNControl service on top of the same
+// backend snapshot the HTTP handlers use. It is mounted on the IPC mux, so it
+// shares the socket, auth, and logging middleware with the rest of the API.
+type controlService struct {
+	controlpb.UnimplementedVPNControlServer
+	api *localapi
+}
+
+func newControlServer(api *localapi) *grpc.Server {
+	gs := grpc.NewServer()
+	controlpb.RegisterVPNControlServer(gs, &controlService{api: api})
+	return gs
+}
+
+func (c *controlService) GetAPIVersion(context.Context, *controlpb.GetAPIVersionRequest) (*controlpb.GetAPIVersionResponse, error) {
+	info := c.api.apiInfo()
+	resp := &controlpb.GetAPIVersionResponse{
+		ApiVersion:    int32(info.APIVersion),
+		DaemonVersion: info.DaemonVersion,
+	}
+	for _, capability := range info.Capabilities {
+		resp.Capabilities = append(resp.Capabilities, string(capability))
+	}
+	return resp, nil
+}
+
+func (c *controlService) Connect(ctx context.Context, req *controlpb.ConnectRequest) (*controlpb.ConnectResponse, error) {
+	if err := c.api.backend(ctx).ConnectVPN(req.GetTag()); err != nil {
+		return nil, controlError(err)
+	}
+	return &controlpb.ConnectResponse{}, nil
+}
+
+func (c *controlService) Disconnect(ctx context.Context, _ *controlpb.DisconnectRequest) (*controlpb.DisconnectResponse, error) {
+	if err := c.api.backend(ctx).DisconnectVPN(); err != nil {
+		return nil, controlError(err)
+	}
+	return &controlpb.DisconnectResponse{}, nil
+}
+
+func (c *controlService) GetStatus(ctx context.Context, _ *controlpb.GetStatusRequest) (*controlpb.GetStatusResponse, error) {
+	b := c.api.backend(ctx)
+	resp := &controlpb.GetStatusResponse{Status: toPBStatus(b.VPNStatus())}
+	// Selection lookups fail while the tunnel is down; status is still useful without them.
+	if server, _, err := b.SelectedServer(); err == nil && server != nil {
+		resp.SelectedServer = server.Tag
+	}
+	if tag, err := b.CurrentAutoSelectedServer(); err == nil {
+		resp.AutoSelectedServer = tag
+	}
+	return resp, nil
+}
+
+func (c *controlService) GetMetrics(ctx context.Context, _ *controlpb.GetMetricsRequest) (*controlpb.GetMetricsResponse, error) {
+	b := c.api.backend(ctx)
+	tp, err := b.VPNThroughput()
+	if err != nil && !errors.Is(err, vpn.ErrTunnelNotConnected) {
+		return nil, controlError(err)
+	}
+	m := b.Metrics()
+	resp := &controlpb.GetMetricsResponse{
+		Throughput:        &controlpb.Throughput{Up: tp.Global.Up, Down: tp.Global.Down},
+		ActiveConnections: int64(tp.ActiveConnections),
+		BytesUp:           m.BytesUp,
+		BytesDown:         m.BytesDown,
+		DnsConnections:    m.DNSConnections,
+		UptimeSeconds:     m.UptimeSeconds,
+		ActiveOutbound:    m.ActiveOutbound,
+		HeapBytes:         m.HeapBytes,
+		Goroutines:        int64(m.Goroutines),
+	}
+	if m.LastError != nil {
+		resp.LastError = m.LastError.Error
+	}
+	if len(m.URLTestResults) > 0 {
+		resp.UrlTestResults = make(map[string]uint32, len(m.URLTestResults))
+		for tag, ms := range m.URLTestResults {
+			resp.UrlTestResults[tag] = uint32(ms)
+		}
+	}
+	for tag, t := range tp.PerOutbound {
+		resp.Outbounds = append(resp.Outbounds, &controlpb.OutboundMetrics{
+			Tag:               tag,
+			Throughput:        &controlpb.Throughput{Up: t.Up, Down: t.Down},
+			ActiveConnections: int64(tp.ActivePerOutbound[tag]),
+		})
+	}
+	return resp, nil
+}
+
+func (c *controlService) ListServers(ctx context.Context, _ *controlpb.ListServersRequest) (*controlpb.ListServersResponse, error) {
+	all := c.api.backend(ctx).AllServers()
+	resp := &controlpb.ListServersResponse{Servers: make([]*controlpb.Server, 0, len(all))}
+	for _, s := range all {
+		resp.Servers = append(resp.Servers, &controlpb.Server{
+			Tag:       s.Tag,
+			Type:      s.Type,
+			IsLantern: s.IsLantern,
+			Location: &controlpb.ServerLocation{
+				Country:     s.Location.Country,
+				CountryCode: s.Location.CountryCode,
+				City:        s.Location.City,
+				Latitude:    s.Location.Latitude,
+				Longitude:   s.Location.Longitude,
+			},
+		})
+	}
+	return resp, nil
+}
+
+func (c *controlService) StreamEvents(_ *controlpb.StreamEventsRequest, stream grpc.ServerStreamingServer[controlpb.Event]) error {
+	ctx := stream.Context()
+	ch := make(chan *controlpb.Event, 16)
+	push := func(evt *controlpb.Event) {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+	events.SubscribeContext(ctx, func(evt vpn.StatusUpdateEvent) {
+		push(&controlpb.Event{Event: &controlpb.Event_Status{Status: &controlpb.StatusEvent{
+			Status: toPBStatus(evt.Status),
+			Error:  evt.Error,
+		}}})
+	})
+	events.SubscribeContext(ctx, func(evt vpn.AutoSelectedEvent) {
+		push(&controlpb.Event{Event: &controlpb.Event_AutoSelected{AutoSelected: &controlpb.AutoSelectedEvent{
+			Selected: evt.Selected,
+		}}})
+	})
+	events.SubscribeContext(ctx, func(evt vpn.URLTestCompleteEvent) {
+		results := make(map[string]uint32, len(evt.Results))
+		for tag, ms := range evt.Results {
+			results[tag] = uint32(ms)
+		}
+		push(&controlpb.Event{Event: &controlpb.Event_UrlTestComplete{UrlTestComplete: &controlpb.URLTestCompleteEvent{
+			Source:  evt.Source,
+			Results: results,
+		}}})
+	})
+	events.SubscribeContext(ctx, func(evt config.NewConfigEvent) {
+		push(&controlpb.Event{Event: &controlpb.Event_ConfigUpdated{ConfigUpdated: &controlpb.ConfigUpdatedEvent{}}})
+	})
+
+	// events.Subscribe is forward-only; send the current status first so the
+	// client doesn't have to race a separate GetStatus call.
+	cur := &controlpb.Event{Event: &controlpb.Event_Status{Status: &controlpb.StatusEvent{
+		Status: toPBStatus(c.api.backend(ctx).VPNStatus()),
+	}}}
+	if err := stream.Send(cur); err != nil {
+		return err
+	}
+	for {
+		select {
+		case evt := <-ch:
+			if err := stream.Send(evt); err != nil {
+				slog.Debug("control: event stream closed", "error", err)
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func toPBStatus(s vpn.VPNStatus) controlpb.VPNStatus {
+	switch s {
+	case vpn.Connecting:
+		return controlpb.VPNStatus_VPN_STATUS_CONNECTING
+	case vpn.Connected:
+		return controlpb.VPNStatus_VPN_STATUS_CONNECTED
+	case vpn.Disconnecting:
+		return controlpb.VPNStatus_VPN_STATUS_DISCONNECTING
+	case vpn.Disconnected:
+		return controlpb.VPNStatus_VPN_STATUS_DISCONNECTED
+	case vpn.Restarting:
+		return controlpb.VPNStatus_VPN_STATUS_RESTARTING
+	case vpn.ErrorStatus:
+		return controlpb.VPNStatus_VPN_STATUS_ERROR
+	default:
+		return controlpb.VPNStatus_VPN_STATUS_UNSPECIFIED
+	}
+}
+
+// controlError maps backend errors to gRPC status codes.
+func controlError(err error) error {
+	switch {
+	case errors.Is(err, vpn.ErrTunnelNotConnected):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, vpn.ErrTunnelAlreadyConnected):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,131 @@
+package ipc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/getlantern/radiance/events"
+	controlpb "github.com/getlantern/radiance/ipc/controlpb/v1"
+	"github.com/getlantern/radiance/vpn"
+)
+
+// dialControl serves api over an in-memory listener the way the IPC server does, and returns a
+// VPNControl client connected to it.
+func dialControl(t *testing.T, api http.Handler) controlpb.VPNControlClient {
+	t.Helper()
+	l := bufconn.Listen(1 << 20)
+	srv := &http.Server{Handler: api, Protocols: &protocols}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+
+	conn, err := grpc.NewClient("passthrough:///ipc",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return controlpb.NewVPNControlClient(conn)
+}
+
+func TestControlService(t *testing.T) {
+	client := dialControl(t, newTestAPI(t, false))
+
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context) error
+		wantCode codes.Code
+	}{
+		{
+			name: "get api version",
+			call: func(ctx context.Context) error {
+				resp, err := client.GetAPIVersion(ctx, &controlpb.GetAPIVersionRequest{})
+				if err == nil {
+					assert.EqualValues(t, APIVersion, resp.GetApiVersion())
+					assert.Contains(t, resp.GetCapabilities(), string(CapabilityControlGRPC))
+				}
+				return err
+			},
+		},
+		{
+			name: "get status",
+			call: func(ctx context.Context) error {
+				resp, err := client.GetStatus(ctx, &controlpb.GetStatusRequest{})
+				if err == nil {
+					assert.Equal(t, controlpb.VPNStatus_VPN_STATUS_DISCONNECTED, resp.GetStatus())
+					assert.Empty(t, resp.GetSelectedServer(), "nothing is selected while disconnected")
+				}
+				return err
+			},
+		},
+		{
+			name: "connect to unknown server",
+			call: func(ctx context.Context) error {
+				_, err := client.Connect(ctx, &controlpb.ConnectRequest{Tag: "missing"})
+				return err
+			},
+			wantCode: codes.Internal,
+		},
+		{
+			name: "disconnect while disconnected",
+			call: func(ctx context.Context) error {
+				_, err := client.Disconnect(ctx, &controlpb.DisconnectRequest{})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(t.Context())
+			assert.Equal(t, tt.wantCode, status.Code(err), "error: %v", err)
+		})
+	}
+}
+
+func TestControlStreamEvents(t *testing.T) {
+	client := dialControl(t, newTestAPI(t, false))
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamEvents(ctx, &controlpb.StreamEventsRequest{})
+	require.NoError(t, err)
+	evt, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, controlpb.VPNStatus_VPN_STATUS_DISCONNECTED, evt.GetStatus().GetStatus(),
+		"the current status should be sent first")
+
+	// The subscriptions are in place once the first event has been sent.
+	events.Emit(vpn.StatusUpdateEvent{Status: vpn.Connecting})
+	evt, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, controlpb.VPNStatus_VPN_STATUS_CONNECTING, evt.GetStatus().GetStatus())
+}
+
+func TestControlError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{err: vpn.ErrTunnelNotConnected, want: codes.FailedPrecondition},
+		{err: vpn.ErrTunnelAlreadyConnected, want: codes.AlreadyExists},
+		{err: errors.New("boom"), want: codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.want, status.Code(controlError(tt.err)))
+		})
+	}
+}
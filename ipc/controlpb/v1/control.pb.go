@@ -0,0 +1,1493 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.28.3
+// source: ipc/controlpb/v1/control.proto
+
+package controlpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type VPNStatus int32
+
+const (
+	VPNStatus_VPN_STATUS_UNSPECIFIED   VPNStatus = 0
+	VPNStatus_VPN_STATUS_CONNECTING    VPNStatus = 1
+	VPNStatus_VPN_STATUS_CONNECTED     VPNStatus = 2
+	VPNStatus_VPN_STATUS_DISCONNECTING VPNStatus = 3
+	VPNStatus_VPN_STATUS_DISCONNECTED  VPNStatus = 4
+	VPNStatus_VPN_STATUS_RESTARTING    VPNStatus = 5
+	VPNStatus_VPN_STATUS_ERROR         VPNStatus = 6
+)
+
+// Enum value maps for VPNStatus.
+var (
+	VPNStatus_name = map[int32]string{
+		0: "VPN_STATUS_UNSPECIFIED",
+		1: "VPN_STATUS_CONNECTING",
+		2: "VPN_STATUS_CONNECTED",
+		3: "VPN_STATUS_DISCONNECTING",
+		4: "VPN_STATUS_DISCONNECTED",
+		5: "VPN_STATUS_RESTARTING",
+		6: "VPN_STATUS_ERROR",
+	}
+	VPNStatus_value = map[string]int32{
+		"VPN_STATUS_UNSPECIFIED":   0,
+		"VPN_STATUS_CONNECTING":    1,
+		"VPN_STATUS_CONNECTED":     2,
+		"VPN_STATUS_DISCONNECTING": 3,
+		"VPN_STATUS_DISCONNECTED":  4,
+		"VPN_STATUS_RESTARTING":    5,
+		"VPN_STATUS_ERROR":         6,
+	}
+)
+
+func (x VPNStatus) Enum() *VPNStatus {
+	p := new(VPNStatus)
+	*p = x
+	return p
+}
+
+func (x VPNStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (VPNStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_ipc_controlpb_v1_control_proto_enumTypes[0].Descriptor()
+}
+
+func (VPNStatus) Type() protoreflect.EnumType {
+	return &file_ipc_controlpb_v1_control_proto_enumTypes[0]
+}
+
+func (x VPNStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use VPNStatus.Descriptor instead.
+func (VPNStatus) EnumDescriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{0}
+}
+
+type GetAPIVersionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAPIVersionRequest) Reset() {
+	*x = GetAPIVersionRequest{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAPIVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAPIVersionRequest) ProtoMessage() {}
+
+func (x *GetAPIVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAPIVersionRequest.ProtoReflect.Descriptor instead.
+func (*GetAPIVersionRequest) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{0}
+}
+
+type GetAPIVersionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ApiVersion    int32                  `protobuf:"varint,1,opt,name=api_version,json=apiVersion,proto3" json:"api_version,omitempty"`
+	DaemonVersion string                 `protobuf:"bytes,2,opt,name=daemon_version,json=daemonVersion,proto3" json:"daemon_version,omitempty"`
+	Capabilities  []string               `protobuf:"bytes,3,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAPIVersionResponse) Reset() {
+	*x = GetAPIVersionResponse{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAPIVersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAPIVersionResponse) ProtoMessage() {}
+
+func (x *GetAPIVersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAPIVersionResponse.ProtoReflect.Descriptor instead.
+func (*GetAPIVersionResponse) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetAPIVersionResponse) GetApiVersion() int32 {
+	if x != nil {
+		return x.ApiVersion
+	}
+	return 0
+}
+
+func (x *GetAPIVersionResponse) GetDaemonVersion() string {
+	if x != nil {
+		return x.DaemonVersion
+	}
+	return ""
+}
+
+func (x *GetAPIVersionResponse) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type ConnectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectRequest) Reset() {
+	*x = ConnectRequest{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectRequest) ProtoMessage() {}
+
+func (x *ConnectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectRequest.ProtoReflect.Descriptor instead.
+func (*ConnectRequest) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ConnectRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+type ConnectResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectResponse) Reset() {
+	*x = ConnectResponse{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectResponse) ProtoMessage() {}
+
+func (x *ConnectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectResponse.ProtoReflect.Descriptor instead.
+func (*ConnectResponse) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{3}
+}
+
+type DisconnectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DisconnectRequest) Reset() {
+	*x = DisconnectRequest{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisconnectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisconnectRequest) ProtoMessage() {}
+
+func (x *DisconnectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisconnectRequest.ProtoReflect.Descriptor instead.
+func (*DisconnectRequest) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{4}
+}
+
+type DisconnectResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DisconnectResponse) Reset() {
+	*x = DisconnectResponse{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisconnectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisconnectResponse) ProtoMessage() {}
+
+func (x *DisconnectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisconnectResponse.ProtoReflect.Descriptor instead.
+func (*DisconnectResponse) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{5}
+}
+
+type GetStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{6}
+}
+
+type GetStatusResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status VPNStatus              `protobuf:"varint,1,opt,name=status,proto3,enum=lantern.control.v1.VPNStatus" json:"status,omitempty"`
+	// selected_server is the tag of the server the user selected, if any.
+	SelectedServer string `protobuf:"bytes,2,opt,name=selected_server,json=selectedServer,proto3" json:"selected_server,omitempty"`
+	// auto_selected_server is the tag the auto-select group is currently
+	// using. Empty when the tunnel is not running.
+	AutoSelectedServer string `protobuf:"bytes,3,opt,name=auto_selected_server,json=autoSelectedServer,proto3" json:"auto_selected_server,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *GetStatusResponse) Reset() {
+	*x = GetStatusResponse{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusResponse) ProtoMessage() {}
+
+func (x *GetStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetStatusResponse) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetStatusResponse) GetStatus() VPNStatus {
+	if x != nil {
+		return x.Status
+	}
+	return VPNStatus_VPN_STATUS_UNSPECIFIED
+}
+
+func (x *GetStatusResponse) GetSelectedServer() string {
+	if x != nil {
+		return x.SelectedServer
+	}
+	return ""
+}
+
+func (x *GetStatusResponse) GetAutoSelectedServer() string {
+	if x != nil {
+		return x.AutoSelectedServer
+	}
+	return ""
+}
+
+type GetMetricsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMetricsRequest) Reset() {
+	*x = GetMetricsRequest{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetricsRequest) ProtoMessage() {}
+
+func (x *GetMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMetricsRequest.ProtoReflect.Descriptor instead.
+func (*GetMetricsRequest) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{8}
+}
+
+type Throughput struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Bytes per second.
+	Up            int64 `protobuf:"varint,1,opt,name=up,proto3" json:"up,omitempty"`
+	Down          int64 `protobuf:"varint,2,opt,name=down,proto3" json:"down,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Throughput) Reset() {
+	*x = Throughput{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Throughput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Throughput) ProtoMessage() {}
+
+func (x *Throughput) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Throughput.ProtoReflect.Descriptor instead.
+func (*Throughput) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Throughput) GetUp() int64 {
+	if x != nil {
+		return x.Up
+	}
+	return 0
+}
+
+func (x *Throughput) GetDown() int64 {
+	if x != nil {
+		return x.Down
+	}
+	return 0
+}
+
+type OutboundMetrics struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Tag               string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Throughput        *Throughput            `protobuf:"bytes,2,opt,name=throughput,proto3" json:"throughput,omitempty"`
+	ActiveConnections int64                  `protobuf:"varint,3,opt,name=active_connections,json=activeConnections,proto3" json:"active_connections,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *OutboundMetrics) Reset() {
+	*x = OutboundMetrics{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutboundMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutboundMetrics) ProtoMessage() {}
+
+func (x *OutboundMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutboundMetrics.ProtoReflect.Descriptor instead.
+func (*OutboundMetrics) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *OutboundMetrics) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *OutboundMetrics) GetThroughput() *Throughput {
+	if x != nil {
+		return x.Throughput
+	}
+	return nil
+}
+
+func (x *OutboundMetrics) GetActiveConnections() int64 {
+	if x != nil {
+		return x.ActiveConnections
+	}
+	return 0
+}
+
+type GetMetricsResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Throughput        *Throughput            `protobuf:"bytes,1,opt,name=throughput,proto3" json:"throughput,omitempty"`
+	ActiveConnections int64                  `protobuf:"varint,2,opt,name=active_connections,json=activeConnections,proto3" json:"active_connections,omitempty"`
+	Outbounds         []*OutboundMetrics     `protobuf:"bytes,3,rep,name=outbounds,proto3" json:"outbounds,omitempty"`
+	// Cumulative bytes since the tunnel connected.
+	BytesUp   int64 `protobuf:"varint,4,opt,name=bytes_up,json=bytesUp,proto3" json:"bytes_up,omitempty"`
+	BytesDown int64 `protobuf:"varint,5,opt,name=bytes_down,json=bytesDown,proto3" json:"bytes_down,omitempty"`
+	// dns_connections counts routed connections sniffed as DNS.
+	DnsConnections int64  `protobuf:"varint,6,opt,name=dns_connections,json=dnsConnections,proto3" json:"dns_connections,omitempty"`
+	UptimeSeconds  int64  `protobuf:"varint,7,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	ActiveOutbound string `protobuf:"bytes,8,opt,name=active_outbound,json=activeOutbound,proto3" json:"active_outbound,omitempty"`
+	// url_test_results maps outbound tags to latency in milliseconds from the
+	// most recent URL test run.
+	UrlTestResults map[string]uint32 `protobuf:"bytes,9,rep,name=url_test_results,json=urlTestResults,proto3" json:"url_test_results,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	LastError      string            `protobuf:"bytes,10,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	HeapBytes      uint64            `protobuf:"varint,11,opt,name=heap_bytes,json=heapBytes,proto3" json:"heap_bytes,omitempty"`
+	Goroutines     int64             `protobuf:"varint,12,opt,name=goroutines,proto3" json:"goroutines,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetMetricsResponse) Reset() {
+	*x = GetMetricsResponse{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetricsResponse) ProtoMessage() {}
+
+func (x *GetMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMetricsResponse.ProtoReflect.Descriptor instead.
+func (*GetMetricsResponse) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetMetricsResponse) GetThroughput() *Throughput {
+	if x != nil {
+		return x.Throughput
+	}
+	return nil
+}
+
+func (x *GetMetricsResponse) GetActiveConnections() int64 {
+	if x != nil {
+		return x.ActiveConnections
+	}
+	return 0
+}
+
+func (x *GetMetricsResponse) GetOutbounds() []*OutboundMetrics {
+	if x != nil {
+		return x.Outbounds
+	}
+	return nil
+}
+
+func (x *GetMetricsResponse) GetBytesUp() int64 {
+	if x != nil {
+		return x.BytesUp
+	}
+	return 0
+}
+
+func (x *GetMetricsResponse) GetBytesDown() int64 {
+	if x != nil {
+		return x.BytesDown
+	}
+	return 0
+}
+
+func (x *GetMetricsResponse) GetDnsConnections() int64 {
+	if x != nil {
+		return x.DnsConnections
+	}
+	return 0
+}
+
+func (x *GetMetricsResponse) GetUptimeSeconds() int64 {
+	if x != nil {
+		return x.UptimeSeconds
+	}
+	return 0
+}
+
+func (x *GetMetricsResponse) GetActiveOutbound() string {
+	if x != nil {
+		return x.ActiveOutbound
+	}
+	return ""
+}
+
+func (x *GetMetricsResponse) GetUrlTestResults() map[string]uint32 {
+	if x != nil {
+		return x.UrlTestResults
+	}
+	return nil
+}
+
+func (x *GetMetricsResponse) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *GetMetricsResponse) GetHeapBytes() uint64 {
+	if x != nil {
+		return x.HeapBytes
+	}
+	return 0
+}
+
+func (x *GetMetricsResponse) GetGoroutines() int64 {
+	if x != nil {
+		return x.Goroutines
+	}
+	return 0
+}
+
+type ListServersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServersRequest) Reset() {
+	*x = ListServersRequest{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServersRequest) ProtoMessage() {}
+
+func (x *ListServersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServersRequest.ProtoReflect.Descriptor instead.
+func (*ListServersRequest) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{12}
+}
+
+type ServerLocation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Country       string                 `protobuf:"bytes,1,opt,name=country,proto3" json:"country,omitempty"`
+	CountryCode   string                 `protobuf:"bytes,2,opt,name=country_code,json=countryCode,proto3" json:"country_code,omitempty"`
+	City          string                 `protobuf:"bytes,3,opt,name=city,proto3" json:"city,omitempty"`
+	Latitude      float32                `protobuf:"fixed32,4,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     float32                `protobuf:"fixed32,5,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerLocation) Reset() {
+	*x = ServerLocation{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerLocation) ProtoMessage() {}
+
+func (x *ServerLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerLocation.ProtoReflect.Descriptor instead.
+func (*ServerLocation) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ServerLocation) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *ServerLocation) GetCountryCode() string {
+	if x != nil {
+		return x.CountryCode
+	}
+	return ""
+}
+
+func (x *ServerLocation) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *ServerLocation) GetLatitude() float32 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *ServerLocation) GetLongitude() float32 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+type Server struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	IsLantern     bool                   `protobuf:"varint,3,opt,name=is_lantern,json=isLantern,proto3" json:"is_lantern,omitempty"`
+	Location      *ServerLocation        `protobuf:"bytes,4,opt,name=location,proto3" json:"location,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Server) Reset() {
+	*x = Server{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Server) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Server) ProtoMessage() {}
+
+func (x *Server) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Server.ProtoReflect.Descriptor instead.
+func (*Server) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Server) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *Server) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Server) GetIsLantern() bool {
+	if x != nil {
+		return x.IsLantern
+	}
+	return false
+}
+
+func (x *Server) GetLocation() *ServerLocation {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+type ListServersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Servers       []*Server              `protobuf:"bytes,1,rep,name=servers,proto3" json:"servers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServersResponse) Reset() {
+	*x = ListServersResponse{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServersResponse) ProtoMessage() {}
+
+func (x *ListServersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServersResponse.ProtoReflect.Descriptor instead.
+func (*ListServersResponse) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListServersResponse) GetServers() []*Server {
+	if x != nil {
+		return x.Servers
+	}
+	return nil
+}
+
+type StreamEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEventsRequest) Reset() {
+	*x = StreamEventsRequest{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEventsRequest) ProtoMessage() {}
+
+func (x *StreamEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamEventsRequest) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{16}
+}
+
+type StatusEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        VPNStatus              `protobuf:"varint,1,opt,name=status,proto3,enum=lantern.control.v1.VPNStatus" json:"status,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusEvent) Reset() {
+	*x = StatusEvent{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusEvent) ProtoMessage() {}
+
+func (x *StatusEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusEvent.ProtoReflect.Descriptor instead.
+func (*StatusEvent) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *StatusEvent) GetStatus() VPNStatus {
+	if x != nil {
+		return x.Status
+	}
+	return VPNStatus_VPN_STATUS_UNSPECIFIED
+}
+
+func (x *StatusEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type AutoSelectedEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Selected      string                 `protobuf:"bytes,1,opt,name=selected,proto3" json:"selected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AutoSelectedEvent) Reset() {
+	*x = AutoSelectedEvent{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AutoSelectedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AutoSelectedEvent) ProtoMessage() {}
+
+func (x *AutoSelectedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AutoSelectedEvent.ProtoReflect.Descriptor instead.
+func (*AutoSelectedEvent) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *AutoSelectedEvent) GetSelected() string {
+	if x != nil {
+		return x.Selected
+	}
+	return ""
+}
+
+type URLTestCompleteEvent struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Source string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	// results maps each outbound tag to its latency in milliseconds.
+	Results       map[string]uint32 `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *URLTestCompleteEvent) Reset() {
+	*x = URLTestCompleteEvent{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *URLTestCompleteEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*URLTestCompleteEvent) ProtoMessage() {}
+
+func (x *URLTestCompleteEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use URLTestCompleteEvent.ProtoReflect.Descriptor instead.
+func (*URLTestCompleteEvent) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *URLTestCompleteEvent) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *URLTestCompleteEvent) GetResults() map[string]uint32 {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// ConfigUpdatedEvent signals that a new config was applied. It carries no
+// payload; clients re-fetch whatever state they display.
+type ConfigUpdatedEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigUpdatedEvent) Reset() {
+	*x = ConfigUpdatedEvent{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigUpdatedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigUpdatedEvent) ProtoMessage() {}
+
+func (x *ConfigUpdatedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigUpdatedEvent.ProtoReflect.Descriptor instead.
+func (*ConfigUpdatedEvent) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{20}
+}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*Event_Status
+	//	*Event_AutoSelected
+	//	*Event_UrlTestComplete
+	//	*Event_ConfigUpdated
+	Event         isEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_ipc_controlpb_v1_control_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_ipc_controlpb_v1_control_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Event) GetEvent() isEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *Event) GetStatus() *StatusEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Status); ok {
+			return x.Status
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetAutoSelected() *AutoSelectedEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_AutoSelected); ok {
+			return x.AutoSelected
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetUrlTestComplete() *URLTestCompleteEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_UrlTestComplete); ok {
+			return x.UrlTestComplete
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetConfigUpdated() *ConfigUpdatedEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_ConfigUpdated); ok {
+			return x.ConfigUpdated
+		}
+	}
+	return nil
+}
+
+type isEvent_Event interface {
+	isEvent_Event()
+}
+
+type Event_Status struct {
+	Status *StatusEvent `protobuf:"bytes,1,opt,name=status,proto3,oneof"`
+}
+
+type Event_AutoSelected struct {
+	AutoSelected *AutoSelectedEvent `protobuf:"bytes,2,opt,name=auto_selected,json=autoSelected,proto3,oneof"`
+}
+
+type Event_UrlTestComplete struct {
+	UrlTestComplete *URLTestCompleteEvent `protobuf:"bytes,3,opt,name=url_test_complete,json=urlTestComplete,proto3,oneof"`
+}
+
+type Event_ConfigUpdated struct {
+	ConfigUpdated *ConfigUpdatedEvent `protobuf:"bytes,4,opt,name=config_updated,json=configUpdated,proto3,oneof"`
+}
+
+func (*Event_Status) isEvent_Event() {}
+
+func (*Event_AutoSelected) isEvent_Event() {}
+
+func (*Event_UrlTestComplete) isEvent_Event() {}
+
+func (*Event_ConfigUpdated) isEvent_Event() {}
+
+var File_ipc_controlpb_v1_control_proto protoreflect.FileDescriptor
+
+const file_ipc_controlpb_v1_control_proto_rawDesc = "" +
+	"\n" +
+	"\x1eipc/controlpb/v1/control.proto\x12\x12lantern.control.v1\"\x16\n" +
+	"\x14GetAPIVersionRequest\"\x83\x01\n" +
+	"\x15GetAPIVersionResponse\x12\x1f\n" +
+	"\vapi_version\x18\x01 \x01(\x05R\n" +
+	"apiVersion\x12%\n" +
+	"\x0edaemon_version\x18\x02 \x01(\tR\rdaemonVersion\x12\"\n" +
+	"\fcapabilities\x18\x03 \x03(\tR\fcapabilities\"\"\n" +
+	"\x0eConnectRequest\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\"\x11\n" +
+	"\x0fConnectResponse\"\x13\n" +
+	"\x11DisconnectRequest\"\x14\n" +
+	"\x12DisconnectResponse\"\x12\n" +
+	"\x10GetStatusRequest\"\xa5\x01\n" +
+	"\x11GetStatusResponse\x125\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x1d.lantern.control.v1.VPNStatusR\x06status\x12'\n" +
+	"\x0fselected_server\x18\x02 \x01(\tR\x0eselectedServer\x120\n" +
+	"\x14auto_selected_server\x18\x03 \x01(\tR\x12autoSelectedServer\"\x13\n" +
+	"\x11GetMetricsRequest\"0\n" +
+	"\n" +
+	"Throughput\x12\x0e\n" +
+	"\x02up\x18\x01 \x01(\x03R\x02up\x12\x12\n" +
+	"\x04down\x18\x02 \x01(\x03R\x04down\"\x92\x01\n" +
+	"\x0fOutboundMetrics\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12>\n" +
+	"\n" +
+	"throughput\x18\x02 \x01(\v2\x1e.lantern.control.v1.ThroughputR\n" +
+	"throughput\x12-\n" +
+	"\x12active_connections\x18\x03 \x01(\x03R\x11activeConnections\"\x80\x05\n" +
+	"\x12GetMetricsResponse\x12>\n" +
+	"\n" +
+	"throughput\x18\x01 \x01(\v2\x1e.lantern.control.v1.ThroughputR\n" +
+	"throughput\x12-\n" +
+	"\x12active_connections\x18\x02 \x01(\x03R\x11activeConnections\x12A\n" +
+	"\toutbounds\x18\x03 \x03(\v2#.lantern.control.v1.OutboundMetricsR\toutbounds\x12\x19\n" +
+	"\bbytes_up\x18\x04 \x01(\x03R\abytesUp\x12\x1d\n" +
+	"\n" +
+	"bytes_down\x18\x05 \x01(\x03R\tbytesDown\x12'\n" +
+	"\x0fdns_connections\x18\x06 \x01(\x03R\x0ednsConnections\x12%\n" +
+	"\x0euptime_seconds\x18\a \x01(\x03R\ruptimeSeconds\x12'\n" +
+	"\x0factive_outbound\x18\b \x01(\tR\x0eactiveOutbound\x12d\n" +
+	"\x10url_test_results\x18\t \x03(\v2:.lantern.control.v1.GetMetricsResponse.UrlTestResultsEntryR\x0eurlTestResults\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\n" +
+	" \x01(\tR\tlastError\x12\x1d\n" +
+	"\n" +
+	"heap_bytes\x18\v \x01(\x04R\theapBytes\x12\x1e\n" +
+	"\n" +
+	"goroutines\x18\f \x01(\x03R\n" +
+	"goroutines\x1aA\n" +
+	"\x13UrlTestResultsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"\x14\n" +
+	"\x12ListServersRequest\"\x9b\x01\n" +
+	"\x0eServerLocation\x12\x18\n" +
+	"\acountry\x18\x01 \x01(\tR\acountry\x12!\n" +
+	"\fcountry_code\x18\x02 \x01(\tR\vcountryCode\x12\x12\n" +
+	"\x04city\x18\x03 \x01(\tR\x04city\x12\x1a\n" +
+	"\blatitude\x18\x04 \x01(\x02R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x05 \x01(\x02R\tlongitude\"\x8d\x01\n" +
+	"\x06Server\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1d\n" +
+	"\n" +
+	"is_lantern\x18\x03 \x01(\bR\tisLantern\x12>\n" +
+	"\blocation\x18\x04 \x01(\v2\".lantern.control.v1.ServerLocationR\blocation\"K\n" +
+	"\x13ListServersResponse\x124\n" +
+	"\aservers\x18\x01 \x03(\v2\x1a.lantern.control.v1.ServerR\aservers\"\x15\n" +
+	"\x13StreamEventsRequest\"Z\n" +
+	"\vStatusEvent\x125\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x1d.lantern.control.v1.VPNStatusR\x06status\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"/\n" +
+	"\x11AutoSelectedEvent\x12\x1a\n" +
+	"\bselected\x18\x01 \x01(\tR\bselected\"\xbb\x01\n" +
+	"\x14URLTestCompleteEvent\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12O\n" +
+	"\aresults\x18\x02 \x03(\v25.lantern.control.v1.URLTestCompleteEvent.ResultsEntryR\aresults\x1a:\n" +
+	"\fResultsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"\x14\n" +
+	"\x12ConfigUpdatedEvent\"\xc2\x02\n" +
+	"\x05Event\x129\n" +
+	"\x06status\x18\x01 \x01(\v2\x1f.lantern.control.v1.StatusEventH\x00R\x06status\x12L\n" +
+	"\rauto_selected\x18\x02 \x01(\v2%.lantern.control.v1.AutoSelectedEventH\x00R\fautoSelected\x12V\n" +
+	"\x11url_test_complete\x18\x03 \x01(\v2(.lantern.control.v1.URLTestCompleteEventH\x00R\x0furlTestComplete\x12O\n" +
+	"\x0econfig_updated\x18\x04 \x01(\v2&.lantern.control.v1.ConfigUpdatedEventH\x00R\rconfigUpdatedB\a\n" +
+	"\x05event*\xc8\x01\n" +
+	"\tVPNStatus\x12\x1a\n" +
+	"\x16VPN_STATUS_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15VPN_STATUS_CONNECTING\x10\x01\x12\x18\n" +
+	"\x14VPN_STATUS_CONNECTED\x10\x02\x12\x1c\n" +
+	"\x18VPN_STATUS_DISCONNECTING\x10\x03\x12\x1b\n" +
+	"\x17VPN_STATUS_DISCONNECTED\x10\x04\x12\x19\n" +
+	"\x15VPN_STATUS_RESTARTING\x10\x05\x12\x14\n" +
+	"\x10VPN_STATUS_ERROR\x10\x062\x90\x05\n" +
+	"\n" +
+	"VPNControl\x12d\n" +
+	"\rGetAPIVersion\x12(.lantern.control.v1.GetAPIVersionRequest\x1a).lantern.control.v1.GetAPIVersionResponse\x12R\n" +
+	"\aConnect\x12\".lantern.control.v1.ConnectRequest\x1a#.lantern.control.v1.ConnectResponse\x12[\n" +
+	"\n" +
+	"Disconnect\x12%.lantern.control.v1.DisconnectRequest\x1a&.lantern.control.v1.DisconnectResponse\x12X\n" +
+	"\tGetStatus\x12$.lantern.control.v1.GetStatusRequest\x1a%.lantern.control.v1.GetStatusResponse\x12[\n" +
+	"\n" +
+	"GetMetrics\x12%.lantern.control.v1.GetMetricsRequest\x1a&.lantern.control.v1.GetMetricsResponse\x12^\n" +
+	"\vListServers\x12&.lantern.control.v1.ListServersRequest\x1a'.lantern.control.v1.ListServersResponse\x12T\n" +
+	"\fStreamEvents\x12'.lantern.control.v1.StreamEventsRequest\x1a\x19.lantern.control.v1.Event0\x01B;Z9github.com/getlantern/radiance/ipc/controlpb/v1;controlpbb\x06proto3"
+
+var (
+	file_ipc_controlpb_v1_control_proto_rawDescOnce sync.Once
+	file_ipc_controlpb_v1_control_proto_rawDescData []byte
+)
+
+func file_ipc_controlpb_v1_control_proto_rawDescGZIP() []byte {
+	file_ipc_controlpb_v1_control_proto_rawDescOnce.Do(func() {
+		file_ipc_controlpb_v1_control_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ipc_controlpb_v1_control_proto_rawDesc), len(file_ipc_controlpb_v1_control_proto_rawDesc)))
+	})
+	return file_ipc_controlpb_v1_control_proto_rawDescData
+}
+
+var file_ipc_controlpb_v1_control_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_ipc_controlpb_v1_control_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
+var file_ipc_controlpb_v1_control_proto_goTypes = []any{
+	(VPNStatus)(0),                // 0: lantern.control.v1.VPNStatus
+	(*GetAPIVersionRequest)(nil),  // 1: lantern.control.v1.GetAPIVersionRequest
+	(*GetAPIVersionResponse)(nil), // 2: lantern.control.v1.GetAPIVersionResponse
+	(*ConnectRequest)(nil),        // 3: lantern.control.v1.ConnectRequest
+	(*ConnectResponse)(nil),       // 4: lantern.control.v1.ConnectResponse
+	(*DisconnectRequest)(nil),     // 5: lantern.control.v1.DisconnectRequest
+	(*DisconnectResponse)(nil),    // 6: lantern.control.v1.DisconnectResponse
+	(*GetStatusRequest)(nil),      // 7: lantern.control.v1.GetStatusRequest
+	(*GetStatusResponse)(nil),     // 8: lantern.control.v1.GetStatusResponse
+	(*GetMetricsRequest)(nil),     // 9: lantern.control.v1.GetMetricsRequest
+	(*Throughput)(nil),            // 10: lantern.control.v1.Throughput
+	(*OutboundMetrics)(nil),       // 11: lantern.control.v1.OutboundMetrics
+	(*GetMetricsResponse)(nil),    // 12: lantern.control.v1.GetMetricsResponse
+	(*ListServersRequest)(nil),    // 13: lantern.control.v1.ListServersRequest
+	(*ServerLocation)(nil),        // 14: lantern.control.v1.ServerLocation
+	(*Server)(nil),                // 15: lantern.control.v1.Server
+	(*ListServersResponse)(nil),   // 16: lantern.control.v1.ListServersResponse
+	(*StreamEventsRequest)(nil),   // 17: lantern.control.v1.StreamEventsRequest
+	(*StatusEvent)(nil),           // 18: lantern.control.v1.StatusEvent
+	(*AutoSelectedEvent)(nil),     // 19: lantern.control.v1.AutoSelectedEvent
+	(*URLTestCompleteEvent)(nil),  // 20: lantern.control.v1.URLTestCompleteEvent
+	(*ConfigUpdatedEvent)(nil),    // 21: lantern.control.v1.ConfigUpdatedEvent
+	(*Event)(nil),                 // 22: lantern.control.v1.Event
+	nil,                           // 23: lantern.control.v1.GetMetricsResponse.UrlTestResultsEntry
+	nil,                           // 24: lantern.control.v1.URLTestCompleteEvent.ResultsEntry
+}
+var file_ipc_controlpb_v1_control_proto_depIdxs = []int32{
+	0,  // 0: lantern.control.v1.GetStatusResponse.status:type_name -> lantern.control.v1.VPNStatus
+	10, // 1: lantern.control.v1.OutboundMetrics.throughput:type_name -> lantern.control.v1.Throughput
+	10, // 2: lantern.control.v1.GetMetricsResponse.throughput:type_name -> lantern.control.v1.Throughput
+	11, // 3: lantern.control.v1.GetMetricsResponse.outbounds:type_name -> lantern.control.v1.OutboundMetrics
+	23, // 4: lantern.control.v1.GetMetricsResponse.url_test_results:type_name -> lantern.control.v1.GetMetricsResponse.UrlTestResultsEntry
+	14, // 5: lantern.control.v1.Server.location:type_name -> lantern.control.v1.ServerLocation
+	15, // 6: lantern.control.v1.ListServersResponse.servers:type_name -> lantern.control.v1.Server
+	0,  // 7: lantern.control.v1.StatusEvent.status:type_name -> lantern.control.v1.VPNStatus
+	24, // 8: lantern.control.v1.URLTestCompleteEvent.results:type_name -> lantern.control.v1.URLTestCompleteEvent.ResultsEntry
+	18, // 9: lantern.control.v1.Event.status:type_name -> lantern.control.v1.StatusEvent
+	19, // 10: lantern.control.v1.Event.auto_selected:type_name -> lantern.control.v1.AutoSelectedEvent
+	20, // 11: lantern.control.v1.Event.url_test_complete:type_name -> lantern.control.v1.URLTestCompleteEvent
+	21, // 12: lantern.control.v1.Event.config_updated:type_name -> lantern.control.v1.ConfigUpdatedEvent
+	1,  // 13: lantern.control.v1.VPNControl.GetAPIVersion:input_type -> lantern.control.v1.GetAPIVersionRequest
+	3,  // 14: lantern.control.v1.VPNControl.Connect:input_type -> lantern.control.v1.ConnectRequest
+	5,  // 15: lantern.control.v1.VPNControl.Disconnect:input_type -> lantern.control.v1.DisconnectRequest
+	7,  // 16: lantern.control.v1.VPNControl.GetStatus:input_type -> lantern.control.v1.GetStatusRequest
+	9,  // 17: lantern.control.v1.VPNControl.GetMetrics:input_type -> lantern.control.v1.GetMetricsRequest
+	13, // 18: lantern.control.v1.VPNControl.ListServers:input_type -> lantern.control.v1.ListServersRequest
+	17, // 19: lantern.control.v1.VPNControl.StreamEvents:input_type -> lantern.control.v1.StreamEventsRequest
+	2,  // 20: lantern.control.v1.VPNControl.GetAPIVersion:output_type -> lantern.control.v1.GetAPIVersionResponse
+	4,  // 21: lantern.control.v1.VPNControl.Connect:output_type -> lantern.control.v1.ConnectResponse
+	6,  // 22: lantern.control.v1.VPNControl.Disconnect:output_type -> lantern.control.v1.DisconnectResponse
+	8,  // 23: lantern.control.v1.VPNControl.GetStatus:output_type -> lantern.control.v1.GetStatusResponse
+	12, // 24: lantern.control.v1.VPNControl.GetMetrics:output_type -> lantern.control.v1.GetMetricsResponse
+	16, // 25: lantern.control.v1.VPNControl.ListServers:output_type -> lantern.control.v1.ListServersResponse
+	22, // 26: lantern.control.v1.VPNControl.StreamEvents:output_type -> lantern.control.v1.Event
+	20, // [20:27] is the sub-list for method output_type
+	13, // [13:20] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_ipc_controlpb_v1_control_proto_init() }
+func file_ipc_controlpb_v1_control_proto_init() {
+	if File_ipc_controlpb_v1_control_proto != nil {
+		return
+	}
+	file_ipc_controlpb_v1_control_proto_msgTypes[21].OneofWrappers = []any{
+		(*Event_Status)(nil),
+		(*Event_AutoSelected)(nil),
+		(*Event_UrlTestComplete)(nil),
+		(*Event_ConfigUpdated)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ipc_controlpb_v1_control_proto_rawDesc), len(file_ipc_controlpb_v1_control_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   24,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ipc_controlpb_v1_control_proto_goTypes,
+		DependencyIndexes: file_ipc_controlpb_v1_control_proto_depIdxs,
+		EnumInfos:         file_ipc_controlpb_v1_control_proto_enumTypes,
+		MessageInfos:      file_ipc_controlpb_v1_control_proto_msgTypes,
+	}.Build()
+	File_ipc_controlpb_v1_control_proto = out.File
+	file_ipc_controlpb_v1_control_proto_goTypes = nil
+	file_ipc_controlpb_v1_control_proto_depIdxs = nil
+}
@@ -0,0 +1,48 @@
+package ipc
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+
+	"github.com/getlantern/radiance/common/env"
+)
+
+const (
+	debugPathPrefix         = "/debug/"
+	debugPprofEndpoint      = "/debug/pprof/"
+	debugVarsEndpoint       = "/debug/vars"
+	debugGoroutinesEndpoint = "/debug/goroutines"
+)
+
+// debugHandler serves pprof, expvar, and a full goroutine dump. The routes only respond when
+// RADIANCE_DEBUG_ENDPOINTS is true; the flag is read per request so it can be flipped through
+// the env endpoint on a running daemon without a restart.
+func debugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(debugPprofEndpoint, pprof.Index)
+	mux.HandleFunc(debugPprofEndpoint+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(debugPprofEndpoint+"profile", pprof.Profile)
+	mux.HandleFunc(debugPprofEndpoint+"symbol", pprof.Symbol)
+	mux.HandleFunc(debugPprofEndpoint+"trace", pprof.Trace)
+	mux.Handle(debugVarsEndpoint, expvar.Handler())
+	mux.HandleFunc(debugGoroutinesEndpoint, goroutineDumpHandler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !env.GetBool(env.DebugEndpoints) {
+			http.NotFound(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func goroutineDumpHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "goroutines: %d\n\n", runtime.NumGoroutine())
+	// debug=2 prints every goroutine with its full stack, in the same format as an unrecovered
+	// panic, which is what people already know how to read.
+	runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+}
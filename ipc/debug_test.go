@@ -0,0 +1,38 @@
+package ipc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/getlantern/radiance/common/env"
+)
+
+func TestDebugEndpoints(t *testing.T) {
+	api := newTestAPI(t, false)
+	paths := []string{debugPprofEndpoint, debugVarsEndpoint, debugGoroutinesEndpoint}
+
+	tests := []struct {
+		name    string
+		enabled string
+		want    int
+	}{
+		{name: "empty", want: http.StatusNotFound},
+		{name: "disabled", enabled: "false", want: http.StatusNotFound},
+		{name: "enabled", enabled: "true", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The OS env takes precedence over values set at runtime, so this also overrides
+			// anything set through the env endpoint.
+			t.Setenv(env.DebugEndpoints.String(), tt.enabled)
+			for _, path := range paths {
+				rec := httptest.NewRecorder()
+				api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				assert.Equal(t, tt.want, rec.Code, path)
+			}
+		})
+	}
+}
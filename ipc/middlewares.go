@@ -2,6 +2,7 @@ package ipc
 
 import (
 	"bytes"
+	"crypto/subtle"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -86,5 +87,22 @@ func authPeer(next http.Handler) http.Handler {
 }
 
 func peerCanAccess(peer usr) bool {
-	return peer.isAdmin
+	return peer.isAdmin || peer.inGroup
+}
+
+// tokenHeader carries the shared IPC token when one is configured.
+const tokenHeader = "X-Lantern-IPC-Token"
+
+// requireToken rejects requests that don't present the shared token. It runs in addition to the
+// peer-credential check, so a process running as an allowed user still needs the token.
+func requireToken(token string, next http.Handler) http.Handler {
+	want := []byte(token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get(tokenHeader))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			http.Error(w, "invalid IPC token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
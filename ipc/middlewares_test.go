@@ -0,0 +1,90 @@
+package ipc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getlantern/radiance/common/env"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestRequireToken(t *testing.T) {
+	const token = "s3cret-token"
+	handler := requireToken(token, okHandler)
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "missing", want: http.StatusUnauthorized},
+		// Same length as the token, so only the byte comparison can reject it.
+		{name: "wrong", header: "wr0ng-tokens", want: http.StatusUnauthorized},
+		{name: "prefix", header: token[:4], want: http.StatusUnauthorized},
+		{name: "longer", header: token + "x", want: http.StatusUnauthorized},
+		{name: "match", header: token, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, vpnStatusEndpoint, nil)
+			if tt.header != "" {
+				req.Header.Set(tokenHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestNewServerRequiresToken(t *testing.T) {
+	b, err := testBackend()
+	require.NoError(t, err)
+	t.Setenv(env.IPCToken.String(), "s3cret-token")
+	srv := httptest.NewServer(NewServer(b, false).svr.Handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + vpnStatusEndpoint)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+vpnStatusEndpoint, nil)
+	require.NoError(t, err)
+	req.Header.Set(tokenHeader, "s3cret-token")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestAuthPeer(t *testing.T) {
+	handler := authPeer(okHandler)
+
+	tests := []struct {
+		name string
+		peer usr
+		want int
+	}{
+		{name: "no credentials", want: http.StatusUnauthorized},
+		{name: "other user", peer: usr{uid: "1001", uname: "guest"}, want: http.StatusForbidden},
+		{name: "lantern group member", peer: usr{uid: "1002", uname: "desktop", inGroup: true}, want: http.StatusOK},
+		{name: "admin", peer: usr{uid: "0", uname: "root", isAdmin: true}, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, vpnStatusEndpoint, nil)
+			req = req.WithContext(contextWithUsr(req.Context(), tt.peer))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
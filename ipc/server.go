@@ -11,17 +11,21 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/getlantern/radiance/account"
 	"github.com/getlantern/radiance/backend"
+	"github.com/getlantern/radiance/common"
 	"github.com/getlantern/radiance/common/env"
 	"github.com/getlantern/radiance/common/settings"
 	"github.com/getlantern/radiance/config"
 	"github.com/getlantern/radiance/events"
 	rlog "github.com/getlantern/radiance/log"
+	"github.com/getlantern/radiance/servers"
 	"github.com/getlantern/radiance/vpn"
 
 	sjson "github.com/sagernet/sing/common/json"
@@ -30,6 +34,9 @@ import (
 const (
 	tracerName = "github.com/getlantern/radiance/ipc"
 
+	// API version/capabilities handshake
+	apiInfoEndpoint = "/api"
+
 	// VPN endpoints
 	vpnStatusEndpoint           = "/vpn/status"
 	vpnConnectEndpoint          = "/vpn/connect"
@@ -41,6 +48,7 @@ const (
 	vpnStatusEventsEndpoint     = "/vpn/status/events"
 	vpnSessionsEndpoint         = "/vpn/sessions"
 	vpnClearTunnelCacheEndpoint = "/vpn/cache/clear"
+	vpnMetricsEndpoint          = "/vpn/metrics"
 
 	// Server selection endpoints
 	serverSelectedEndpoint           = "/server/selected"
@@ -49,17 +57,43 @@ const (
 	serverURLTestEventsEndpoint      = "/server/url-test/events"
 
 	// Config endpoints
-	configEventsEndpoint = "/config/events"
-	configUpdateEndpoint = "/config/update"
+	configEventsEndpoint    = "/config/events"
+	configUpdateEndpoint    = "/config/update"
+	configRefreshEndpoint   = "/config/refresh"
+	configHistoryEndpoint   = "/config/history"
+	configRollbackEndpoint  = "/config/rollback"
+	configCountryEndpoint   = "/config/country"
+	configPreviewEndpoint   = "/config/preview"
+	configApplyEndpoint     = "/config/apply"
+	configSourcesEndpoint   = "/config/sources"
+	configFlagsEndpoint     = "/config/flags"
+	configFetchesEndpoint   = "/config/fetches"
+	configFreshnessEndpoint = "/config/freshness"
+
+	// Kindling endpoints
+	kindlingStatusEndpoint = "/kindling/status"
+
+	// Announcement endpoints
+	announcementsEndpoint        = "/announcements"
+	announcementsDismissEndpoint = "/announcements/dismiss"
 
 	// Server management endpoints
 	serversEndpoint              = "/servers"
 	serversAddEndpoint           = "/servers/add"
+	serversBatchEndpoint         = "/servers/batch"
 	serversRemoveEndpoint        = "/servers/remove"
 	serversFromJSONEndpoint      = "/servers/json"
 	serversFromURLsEndpoint      = "/servers/urls"
 	serversPrivateEndpoint       = "/servers/private"
 	serversPrivateInviteEndpoint = "/servers/private/invite"
+	serversFingerprintsEndpoint  = "/servers/private/fingerprints"
+	serversPrivateRemoveEndpoint = "/servers/private/remove"
+	serversQREndpoint            = "/servers/qr"
+	serversExportEndpoint        = "/servers/export"
+	serversRenameEndpoint        = "/servers/rename"
+	serversMetadataEndpoint      = "/servers/metadata"
+	serversProbeEndpoint         = "/servers/probe"
+	serversDisabledEndpoint      = "/servers/disabled"
 
 	// Settings endpoints
 	featuresEndpoint = "/settings/features"
@@ -68,20 +102,36 @@ const (
 	// Split tunnel endpoint
 	splitTunnelEndpoint = "/split-tunnel"
 
+	// Profile endpoints
+	profilesEndpoint       = "/profiles"
+	profilesSaveEndpoint   = "/profiles/save"
+	profilesSwitchEndpoint = "/profiles/switch"
+	profilesDeleteEndpoint = "/profiles/delete"
+
 	// Account endpoints
 	accountNewUserEndpoint        = "/account/new-user"
 	accountLoginEndpoint          = "/account/login"
+	accountLoginReplaceEndpoint   = "/account/login/replace-device"
 	accountLogoutEndpoint         = "/account/logout"
 	accountUserDataEndpoint       = "/account/user"
 	accountDevicesEndpoint        = "/account/devices/"
+	accountLinkCodeEndpoint       = "/account/link-code"
 	accountSignupEndpoint         = "/account/signup/"
 	accountVerifyPasswordEndpoint = "/account/verify-password"
 	accountEmailEndpoint          = "/account/email"
 	accountRecoveryEndpoint       = "/account/recovery"
 	accountDeleteEndpoint         = "/account/delete"
 	accountOAuthEndpoint          = "/account/oauth"
+	accountOAuthStartEndpoint     = "/account/oauth/start"
+	accountOAuthCompleteEndpoint  = "/account/oauth/complete"
 	accountDataCapEndpoint        = "/account/datacap"
 	accountDataCapStreamEndpoint  = "/account/datacap/stream"
+	accountBreakersEndpoint       = "/account/breakers"
+	accountEntitlementsEndpoint   = "/account/entitlements"
+	accountAccountsEndpoint       = "/account/accounts"
+	accountAddEndpoint            = "/account/accounts/add"
+	accountSwitchEndpoint         = "/account/accounts/switch"
+	accountRemoveEndpoint         = "/account/accounts/remove"
 
 	// Subscription endpoints
 	subscriptionActivationEndpoint         = "/subscription/activation"
@@ -91,8 +141,10 @@ const (
 	subscriptionBillingPortalEndpoint      = "/subscription/billing-portal"
 	subscriptionPaymentRedirectURLEndpoint = "/subscription/payment-redirect-url"
 	subscriptionPlansEndpoint              = "/subscription/plans"
+	subscriptionQuoteEndpoint              = "/subscription/quote"
 	subscriptionVerifyEndpoint             = "/subscription/verify"
 	subscriptionRestoreEndpoint            = "/subscription/restore"
+	subscriptionStatusEndpoint             = "/subscription/status"
 
 	// Issue endpoint
 	issueEndpoint = "/issue"
@@ -100,6 +152,9 @@ const (
 	// Logs endpoint
 	logsStreamEndpoint = "/logs/stream"
 
+	// Combined event stream endpoint
+	eventsEndpoint = "/events"
+
 	// Env endpoint (dev/testing)
 	envEndpoint = "/env"
 )
@@ -124,9 +179,16 @@ type Server struct {
 
 // NewServer returns an IPC server backed by b. When withAuth is true, the
 // server authenticates each connection; when false, it accepts all connections.
+//
+// If RADIANCE_IPC_TOKEN is set, every request must also carry that token. In-process loopback
+// clients don't go through the server, so the token only guards the socket.
 func NewServer(b *backend.LocalBackend, withAuth bool) *Server {
+	var handler http.Handler = newLocalAPI(b, withAuth)
+	if token, _ := env.Get(env.IPCToken); token != "" {
+		handler = requireToken(token, handler)
+	}
 	svr := &http.Server{
-		Handler:     newLocalAPI(b, withAuth),
+		Handler:     handler,
 		ReadTimeout: 5 * time.Second,
 		Protocols:   &protocols,
 	}
@@ -175,6 +237,20 @@ type backendKey struct{}
 type localapi struct {
 	be      atomic.Pointer[backend.LocalBackend]
 	handler http.Handler
+	// routes are the patterns of the endpoints served, advertised by the API handshake.
+	routes []string
+}
+
+// routeMux is an http.ServeMux that remembers the patterns registered with it, so the API
+// handshake advertises exactly the endpoints served without a list to keep in sync.
+type routeMux struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (m *routeMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.HandleFunc(pattern, handler)
 }
 
 // backend returns the LocalBackend snapshotted at the start of the request.
@@ -186,7 +262,7 @@ func newLocalAPI(b *backend.LocalBackend, withAuth bool) *localapi {
 	s := &localapi{}
 	s.be.Store(b)
 
-	mux := http.NewServeMux()
+	mux := &routeMux{ServeMux: http.NewServeMux()}
 
 	// traced wraps a handler with the tracer middleware.
 	traced := func(h http.HandlerFunc) http.HandlerFunc {
@@ -195,6 +271,9 @@ func newLocalAPI(b *backend.LocalBackend, withAuth bool) *localapi {
 		}
 	}
 
+	// API handshake
+	mux.HandleFunc("GET "+apiInfoEndpoint, traced(s.apiInfoHandler))
+
 	// VPN
 	mux.HandleFunc("GET "+vpnStatusEndpoint, traced(s.vpnStatusHandler))
 	mux.HandleFunc("POST "+vpnConnectEndpoint, traced(s.vpnConnectHandler))
@@ -205,6 +284,7 @@ func newLocalAPI(b *backend.LocalBackend, withAuth bool) *localapi {
 	mux.HandleFunc("POST "+vpnOfflineTestsEndpoint, traced(s.vpnOfflineTestsHandler))
 	mux.HandleFunc("GET "+vpnSessionsEndpoint, traced(s.vpnSessionsHandler))
 	mux.HandleFunc("POST "+vpnClearTunnelCacheEndpoint, traced(s.vpnClearTunnelCacheHandler))
+	mux.HandleFunc("GET "+vpnMetricsEndpoint, traced(s.vpnMetricsHandler))
 
 	// SSE routes skip the tracer middleware since it buffers the entire response body.
 	mux.HandleFunc("GET "+vpnStatusEventsEndpoint, s.vpnStatusEventsHandler)
@@ -216,15 +296,44 @@ func newLocalAPI(b *backend.LocalBackend, withAuth bool) *localapi {
 	mux.HandleFunc("GET "+serverURLTestEventsEndpoint, s.serverURLTestEventsHandler)
 	mux.HandleFunc("GET "+configEventsEndpoint, s.configEventsHandler)
 	mux.HandleFunc("POST "+configUpdateEndpoint, traced(s.configUpdateHandler))
+	mux.HandleFunc("POST "+configRefreshEndpoint, traced(s.configRefreshHandler))
+	mux.HandleFunc("GET "+configHistoryEndpoint, traced(s.configHistoryHandler))
+	mux.HandleFunc("POST "+configRollbackEndpoint, traced(s.configRollbackHandler))
+	mux.HandleFunc("GET "+configCountryEndpoint, traced(s.configCountryHandler))
+	mux.HandleFunc("POST "+configCountryEndpoint, traced(s.configSetCountryHandler))
+	mux.HandleFunc("GET "+configSourcesEndpoint, traced(s.configSourcesHandler))
+	mux.HandleFunc("POST "+configSourcesEndpoint, traced(s.configSetSourcesHandler))
+	mux.HandleFunc("GET "+configFlagsEndpoint, traced(s.configFlagsHandler))
+	mux.HandleFunc("GET "+configFetchesEndpoint, traced(s.configFetchesHandler))
+	mux.HandleFunc("GET "+configFreshnessEndpoint, traced(s.configFreshnessHandler))
+	mux.HandleFunc("POST "+configPreviewEndpoint, traced(s.configPreviewHandler))
+	mux.HandleFunc("POST "+configApplyEndpoint, traced(s.configApplyHandler))
+
+	// Kindling
+	mux.HandleFunc("GET "+kindlingStatusEndpoint, traced(s.kindlingStatusHandler))
+
+	// Announcements
+	mux.HandleFunc("GET "+announcementsEndpoint, traced(s.announcementsHandler))
+	mux.HandleFunc("POST "+announcementsDismissEndpoint, traced(s.announcementsDismissHandler))
 
 	// Server management
 	mux.HandleFunc("GET "+serversEndpoint, traced(s.serversHandler))
 	mux.HandleFunc("POST "+serversAddEndpoint, traced(s.serversAddHandler))
+	mux.HandleFunc("POST "+serversBatchEndpoint, traced(s.serversBatchHandler))
 	mux.HandleFunc("POST "+serversRemoveEndpoint, traced(s.serversRemoveHandler))
 	mux.HandleFunc("POST "+serversFromJSONEndpoint, traced(s.serversFromJSONHandler))
 	mux.HandleFunc("POST "+serversFromURLsEndpoint, traced(s.serversFromURLsHandler))
 	mux.HandleFunc("POST "+serversPrivateEndpoint, traced(s.serversPrivateAddHandler))
 	mux.HandleFunc(serversPrivateInviteEndpoint, traced(s.serversPrivateInviteHandler))
+	mux.HandleFunc(serversFingerprintsEndpoint, traced(s.serversFingerprintsHandler))
+	mux.HandleFunc("POST "+serversPrivateRemoveEndpoint, traced(s.serversPrivateRemoveHandler))
+	mux.HandleFunc("GET "+serversQREndpoint, traced(s.serversQRHandler))
+	mux.HandleFunc("POST "+serversQREndpoint, traced(s.serversAddQRHandler))
+	mux.HandleFunc("GET "+serversExportEndpoint, traced(s.serversExportHandler))
+	mux.HandleFunc("POST "+serversRenameEndpoint, traced(s.serversRenameHandler))
+	mux.HandleFunc("POST "+serversMetadataEndpoint, traced(s.serversMetadataHandler))
+	mux.HandleFunc("POST "+serversProbeEndpoint, traced(s.serversProbeHandler))
+	mux.HandleFunc("POST "+serversDisabledEndpoint, traced(s.serversDisabledHandler))
 
 	// Settings
 	mux.HandleFunc("GET "+featuresEndpoint, traced(s.featuresHandler))
@@ -233,18 +342,34 @@ func newLocalAPI(b *backend.LocalBackend, withAuth bool) *localapi {
 	// Split tunnel
 	mux.HandleFunc(splitTunnelEndpoint, traced(s.splitTunnelHandler))
 
+	// Profiles
+	mux.HandleFunc("GET "+profilesEndpoint, traced(s.profilesHandler))
+	mux.HandleFunc("POST "+profilesSaveEndpoint, traced(s.profilesSaveHandler))
+	mux.HandleFunc("POST "+profilesSwitchEndpoint, traced(s.profilesSwitchHandler))
+	mux.HandleFunc("POST "+profilesDeleteEndpoint, traced(s.profilesDeleteHandler))
+
 	// Account
 	mux.HandleFunc("POST "+accountNewUserEndpoint, traced(s.accountNewUserHandler))
 	mux.HandleFunc("POST "+accountLoginEndpoint, traced(s.accountLoginHandler))
+	mux.HandleFunc("POST "+accountLoginReplaceEndpoint, traced(s.accountLoginReplaceDeviceHandler))
 	mux.HandleFunc("POST "+accountLogoutEndpoint, traced(s.accountLogoutHandler))
 	mux.HandleFunc("GET "+accountUserDataEndpoint, traced(s.accountUserDataHandler))
 	mux.HandleFunc(accountDevicesEndpoint+"{deviceID...}", traced(s.accountDevicesHandler))
+	mux.HandleFunc(accountLinkCodeEndpoint, traced(s.accountLinkCodeHandler))
 	mux.HandleFunc("POST "+accountSignupEndpoint+"{action...}", traced(s.accountSignupHandler))
 	mux.HandleFunc("POST "+accountVerifyPasswordEndpoint, traced(s.accountVerifyPasswordHandler))
 	mux.HandleFunc("POST "+accountEmailEndpoint+"/{action}", traced(s.accountEmailHandler))
 	mux.HandleFunc("POST "+accountRecoveryEndpoint+"/{action}", traced(s.accountRecoveryHandler))
 	mux.HandleFunc("DELETE "+accountDeleteEndpoint, traced(s.accountDeleteHandler))
 	mux.HandleFunc(accountOAuthEndpoint, traced(s.accountOAuthHandler))
+	mux.HandleFunc("POST "+accountOAuthStartEndpoint, traced(s.accountOAuthStartHandler))
+	mux.HandleFunc("GET "+accountBreakersEndpoint, traced(s.accountBreakersHandler))
+	mux.HandleFunc("GET "+accountEntitlementsEndpoint, traced(s.accountEntitlementsHandler))
+	mux.HandleFunc("GET "+accountAccountsEndpoint, traced(s.accountAccountsHandler))
+	mux.HandleFunc("POST "+accountAddEndpoint, traced(s.accountAddHandler))
+	mux.HandleFunc("POST "+accountSwitchEndpoint, traced(s.accountSwitchHandler))
+	mux.HandleFunc("POST "+accountRemoveEndpoint, traced(s.accountRemoveHandler))
+	mux.HandleFunc("POST "+accountOAuthCompleteEndpoint, traced(s.accountOAuthCompleteHandler))
 	mux.HandleFunc("GET "+accountDataCapEndpoint, traced(s.accountDataCapHandler))
 
 	// SSE routes skip the tracer middleware since it buffers the entire response body.
@@ -255,11 +380,14 @@ func newLocalAPI(b *backend.LocalBackend, withAuth bool) *localapi {
 	mux.HandleFunc("POST "+subscriptionStripeEndpoint, traced(s.subscriptionStripeHandler))
 	mux.HandleFunc("POST "+subscriptionPaymentRedirectEndpoint, traced(s.subscriptionPaymentRedirectHandler))
 	mux.HandleFunc("POST "+subscriptionReferralEndpoint, traced(s.subscriptionReferralHandler))
+	mux.HandleFunc("GET "+subscriptionReferralEndpoint, traced(s.subscriptionReferralInfoHandler))
 	mux.HandleFunc("GET "+subscriptionBillingPortalEndpoint, traced(s.subscriptionBillingPortalHandler))
 	mux.HandleFunc("POST "+subscriptionPaymentRedirectURLEndpoint, traced(s.subscriptionPaymentRedirectURLHandler))
 	mux.HandleFunc("GET "+subscriptionPlansEndpoint, traced(s.subscriptionPlansHandler))
+	mux.HandleFunc("GET "+subscriptionQuoteEndpoint, traced(s.subscriptionQuoteHandler))
 	mux.HandleFunc("POST "+subscriptionVerifyEndpoint, traced(s.subscriptionVerifyHandler))
 	mux.HandleFunc("POST "+subscriptionRestoreEndpoint, traced(s.subscriptionRestoreHandler))
+	mux.HandleFunc("GET "+subscriptionStatusEndpoint, traced(s.subscriptionStatusHandler))
 
 	// Issue
 	mux.HandleFunc("POST "+issueEndpoint, traced(s.issueReportHandler))
@@ -267,9 +395,20 @@ func newLocalAPI(b *backend.LocalBackend, withAuth bool) *localapi {
 	// Logs (SSE, skip tracer)
 	mux.HandleFunc("GET "+logsStreamEndpoint, s.logsStreamHandler)
 
+	// Combined events (SSE, skip tracer)
+	mux.HandleFunc("GET "+eventsEndpoint, s.eventsHandler)
+
 	// Env (dev/testing)
 	mux.HandleFunc(envEndpoint, traced(s.envHandler))
 
+	// Debug (profiles stream for their full duration, skip tracer). Advertised by
+	// CapabilityDebug instead of as endpoints, since it's only enabled by env.DebugEndpoints.
+	mux.Handle(debugPathPrefix, debugHandler())
+
+	// gRPC control plane (streams, skip tracer). Advertised by CapabilityControlGRPC.
+	mux.Handle("POST "+controlPathPrefix, newControlServer(s))
+	s.routes = slices.Sorted(slices.Values(mux.patterns))
+
 	// Build the middleware chain: log -> (optional auth) -> mux
 	var handler http.Handler = mux
 	if withAuth {
@@ -411,6 +550,29 @@ func (s *localapi) vpnClearTunnelCacheHandler(w http.ResponseWriter, r *http.Req
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *localapi) apiInfoHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.apiInfo())
+}
+
+func (s *localapi) apiInfo() APIInfo {
+	caps := []Capability{CapabilityEvents, CapabilityControlGRPC, CapabilityMetrics}
+	if env.GetBool(env.DebugEndpoints) {
+		caps = append(caps, CapabilityDebug)
+	}
+	for _, route := range s.routes {
+		caps = append(caps, Capability(route))
+	}
+	return APIInfo{
+		APIVersion:    APIVersion,
+		DaemonVersion: common.Version,
+		Capabilities:  caps,
+	}
+}
+
+func (s *localapi) vpnMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.backend(r.Context()).Metrics())
+}
+
 func (s *localapi) vpnOfflineTestsHandler(w http.ResponseWriter, r *http.Request) {
 	if err := s.backend(r.Context()).RunOfflineURLTests(); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -572,6 +734,154 @@ func (s *localapi) configUpdateHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *localapi) configRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.backend(r.Context()).ForceRefreshConfig(r.Context()); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, config.ErrConfigFetchDisabled) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *localapi) configHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	versions, err := s.backend(r.Context()).ConfigHistory()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, versions)
+}
+
+func (s *localapi) configRollbackHandler(w http.ResponseWriter, r *http.Request) {
+	var req ConfigRollbackRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.backend(r.Context()).RollbackConfig(req.ID); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, config.ErrConfigVersionNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, config.ErrNoPreviousConfig):
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *localapi) configPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	diff, err := s.backend(r.Context()).PreviewConfigUpdate(r.Context())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, config.ErrConfigFetchDisabled) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, http.StatusOK, diff)
+}
+
+func (s *localapi) configApplyHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.backend(r.Context()).ApplyConfigPreview(); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, config.ErrNoPreview) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *localapi) configCountryHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ConfigCountryRequest{Country: s.backend(r.Context()).CountryOverride()})
+}
+
+// configSetCountryHandler sets the country override, or clears it if the country is empty.
+func (s *localapi) configSetCountryHandler(w http.ResponseWriter, r *http.Request) {
+	var req ConfigCountryRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.backend(r.Context()).SetCountryOverride(r.Context(), req.Country); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, config.ErrInvalidCountry) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// configSourcesHandler lists the config sources with their health.
+func (s *localapi) configSourcesHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.backend(r.Context()).ConfigSources())
+}
+
+// configSetSourcesHandler replaces the additional config sources.
+func (s *localapi) configSetSourcesHandler(w http.ResponseWriter, r *http.Request) {
+	var req ConfigSourcesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.backend(r.Context()).SetConfigSources(req.Sources); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, config.ErrInvalidSource) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *localapi) configFreshnessHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.backend(r.Context()).ConfigFreshness())
+}
+
+func (s *localapi) configFetchesHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.backend(r.Context()).ConfigFetchHistory())
+}
+
+func (s *localapi) kindlingStatusHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.backend(r.Context()).KindlingStatus())
+}
+
+func (s *localapi) configFlagsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.backend(r.Context()).ConfigFlags())
+}
+
+func (s *localapi) announcementsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.backend(r.Context()).Announcements())
+}
+
+func (s *localapi) announcementsDismissHandler(w http.ResponseWriter, r *http.Request) {
+	var req DismissAnnouncementRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	if err := s.backend(r.Context()).DismissAnnouncement(req.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 // configEventsHandler streams a notification on every config.NewConfigEvent.
 // The payload is always "{}" — subscribers only need to know a change
 // occurred and fetch fresh state through the other GET endpoints, so we don't
@@ -631,6 +941,20 @@ func (s *localapi) serversAddHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *localapi) serversBatchHandler(w http.ResponseWriter, r *http.Request) {
+	var req AddServersBatchRequest
+	if err := decodeSingJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := s.backend(r.Context()).AddServersBatch(req.Servers, req.Replace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
 func (s *localapi) serversRemoveHandler(w http.ResponseWriter, r *http.Request) {
 	var req RemoveServersRequest
 	if err := decodeJSON(r, &req); err != nil {
@@ -709,6 +1033,168 @@ func (s *localapi) serversPrivateInviteHandler(w http.ResponseWriter, r *http.Re
 	writeJSON(w, http.StatusOK, CodeResponse{Code: code})
 }
 
+func (s *localapi) serversPrivateRemoveHandler(w http.ResponseWriter, r *http.Request) {
+	var req RemovePrivateServerRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.backend(r.Context()).RemovePrivateServer(req.IP); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// serversFingerprintsHandler handles GET (list the trusted private server fingerprints), POST
+// (replace the fingerprint trusted for an IP) and DELETE (forget the fingerprint trusted for an IP)
+// on /servers/private/fingerprints.
+func (s *localapi) serversFingerprintsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		fps, err := s.backend(r.Context()).TrustedFingerprints()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, fps)
+		return
+	}
+	var req TrustedFingerprintRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		err = s.backend(r.Context()).ReplaceTrustedFingerprint(req.IP, req.Fingerprint)
+	case http.MethodDelete:
+		err = s.backend(r.Context()).RemoveTrustedFingerprint(req.IP)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch {
+	case errors.Is(err, servers.ErrFingerprintNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// serversQRHandler returns the QR payload sharing the server with the given tag.
+func (s *localapi) serversQRHandler(w http.ResponseWriter, r *http.Request) {
+	payload, err := s.backend(r.Context()).ServerQRPayload(r.URL.Query().Get("tag"))
+	switch {
+	case errors.Is(err, servers.ErrNotShareable):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, servers.ErrServerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, QRPayloadResponse{Payload: payload})
+	}
+}
+
+// serversAddQRHandler adds the server or private server invite in a scanned QR payload.
+func (s *localapi) serversAddQRHandler(w http.ResponseWriter, r *http.Request) {
+	var req QRPayloadRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tags, err := s.backend(r.Context()).AddServersByQRPayload(req.Payload, req.SkipCertVerification)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, tags)
+}
+
+func (s *localapi) serversExportHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := servers.ExportFormat(q.Get("format"))
+	data, err := s.backend(r.Context()).ExportServer(q.Get("tag"), format)
+	switch {
+	case errors.Is(err, servers.ErrNotShareable), errors.Is(err, servers.ErrUnsupportedExport):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, servers.ErrServerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, ServerExportResponse{Format: format, Data: data})
+	}
+}
+
+func (s *localapi) serversRenameHandler(w http.ResponseWriter, r *http.Request) {
+	var req RenameServerRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err := s.backend(r.Context()).RenameServer(req.Tag, req.NewTag)
+	switch {
+	case errors.Is(err, servers.ErrServerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *localapi) serversMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	var req ServerMetadataRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err := s.backend(r.Context()).SetServerMetadata(req.Tag, req.Metadata)
+	switch {
+	case errors.Is(err, servers.ErrServerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *localapi) serversProbeHandler(w http.ResponseWriter, r *http.Request) {
+	var req ProbeServersRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := s.backend(r.Context()).ProbeServers(req.Tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *localapi) serversDisabledHandler(w http.ResponseWriter, r *http.Request) {
+	var req ServerDisabledRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err := s.backend(r.Context()).SetServerDisabled(req.Tag, req.Disabled)
+	switch {
+	case errors.Is(err, servers.ErrServerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
 //////////////
 // Settings //
 //////////////
@@ -797,6 +1283,76 @@ func (s *localapi) splitTunnelHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+//////////////
+// Profiles //
+//////////////
+
+func (s *localapi) profilesHandler(w http.ResponseWriter, r *http.Request) {
+	profiles, err := s.backend(r.Context()).Profiles()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, ProfilesResponse{
+		Profiles: profiles,
+		Active:   settings.GetString(settings.ActiveProfileKey),
+	})
+}
+
+func (s *localapi) profilesSaveHandler(w http.ResponseWriter, r *http.Request) {
+	var req ProfileSaveRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if err := s.backend(r.Context()).SaveProfile(req.Name, req.Servers); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *localapi) profilesSwitchHandler(w http.ResponseWriter, r *http.Request) {
+	var req ProfileRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.backend(r.Context()).SwitchProfile(req.Name); err != nil {
+		http.Error(w, err.Error(), profileErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *localapi) profilesDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	var req ProfileRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.backend(r.Context()).DeleteProfile(req.Name); err != nil {
+		http.Error(w, err.Error(), profileErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func profileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, config.ErrProfileNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, config.ErrProfileActive):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 /////////////
 // Account //
 /////////////
@@ -818,12 +1374,37 @@ func (s *localapi) accountLoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	userData, err := s.backend(r.Context()).Login(r.Context(), req.Email, req.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeLoginError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, userData)
+}
+
+func (s *localapi) accountLoginReplaceDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	var req LoginReplaceDeviceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	userData, err := s.backend(r.Context()).LoginAndReplaceDevice(r.Context(), req.Email, req.Password, req.DeviceID)
+	if err != nil {
+		writeLoginError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, userData)
 }
 
+// writeLoginError responds to a failed login. Hitting the device limit is reported as a conflict
+// with the linked devices in the body, so the client can have the user pick one to remove.
+func writeLoginError(w http.ResponseWriter, err error) {
+	var tooMany *account.TooManyDevicesError
+	if errors.As(err, &tooMany) {
+		writeJSON(w, http.StatusConflict, tooMany)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusUnauthorized)
+}
+
 func (s *localapi) accountLogoutHandler(w http.ResponseWriter, r *http.Request) {
 	var req EmailRequest
 	if err := decodeJSON(r, &req); err != nil {
@@ -841,9 +1422,18 @@ func (s *localapi) accountLogoutHandler(w http.ResponseWriter, r *http.Request)
 func (s *localapi) accountUserDataHandler(w http.ResponseWriter, r *http.Request) {
 	var userData *account.UserData
 	var err error
-	if r.URL.Query().Get("fetch") == "true" {
+	query := r.URL.Query()
+	switch {
+	case query.Get("fetch") == "true":
 		userData, err = s.backend(r.Context()).FetchUserData(r.Context())
-	} else {
+	case query.Has("maxAge"):
+		maxAge, perr := time.ParseDuration(query.Get("maxAge"))
+		if perr != nil {
+			http.Error(w, "invalid maxAge: "+perr.Error(), http.StatusBadRequest)
+			return
+		}
+		userData, err = s.backend(r.Context()).EnsureFreshUserData(r.Context(), maxAge)
+	default:
 		userData, err = s.backend(r.Context()).UserData()
 	}
 	if err != nil {
@@ -926,7 +1516,8 @@ func (s *localapi) accountVerifyPasswordHandler(w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusOK)
 }
 
-// accountEmailHandler handles POST /account/email/{action} for start and complete.
+// accountEmailHandler handles POST /account/email/{action} for start and complete, which change
+// the email, and resend-verification and verify, which verify it.
 func (s *localapi) accountEmailHandler(w http.ResponseWriter, r *http.Request) {
 	var err error
 	switch r.PathValue("action") {
@@ -944,6 +1535,15 @@ func (s *localapi) accountEmailHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		err = s.backend(r.Context()).CompleteChangeEmail(r.Context(), req.NewEmail, req.Password, req.Code)
+	case "resend-verification":
+		err = s.backend(r.Context()).ResendEmailVerification(r.Context())
+	case "verify":
+		var req VerifyEmailRequest
+		if err = decodeJSON(r, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = s.backend(r.Context()).VerifyEmail(r.Context(), req.Code)
 	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -1028,6 +1628,117 @@ func (s *localapi) accountOAuthHandler(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, URLResponse{URL: u})
 }
 
+// accountLinkCodeHandler handles GET /account/link-code (request a code on the logged-in device)
+// and POST /account/link-code (redeem a code on the device being linked).
+func (s *localapi) accountLinkCodeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		var req RedeemLinkCodeRequest
+		if err := decodeJSON(r, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		userData, err := s.backend(r.Context()).RedeemLinkCode(r.Context(), req.Code, req.DeviceName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, userData)
+		return
+	}
+	code, err := s.backend(r.Context()).RequestLinkCode(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, code)
+}
+
+func (s *localapi) accountBreakersHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.backend(r.Context()).AccountBreakerStates())
+}
+
+func (s *localapi) accountEntitlementsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.backend(r.Context()).Entitlements())
+}
+
+func (s *localapi) accountAccountsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.backend(r.Context()).Accounts())
+}
+
+func (s *localapi) accountAddHandler(w http.ResponseWriter, r *http.Request) {
+	userData, err := s.backend(r.Context()).AddAccount(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, userData)
+}
+
+func (s *localapi) accountSwitchHandler(w http.ResponseWriter, r *http.Request) {
+	var req StoredAccountRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.backend(r.Context()).SwitchAccount(r.Context(), req.UserID); err != nil {
+		http.Error(w, err.Error(), storedAccountErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *localapi) accountRemoveHandler(w http.ResponseWriter, r *http.Request) {
+	var req StoredAccountRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.backend(r.Context()).RemoveAccount(req.UserID); err != nil {
+		http.Error(w, err.Error(), storedAccountErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func storedAccountErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, account.ErrUnknownAccount):
+		return http.StatusNotFound
+	case errors.Is(err, account.ErrActiveAccount):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (s *localapi) accountOAuthStartHandler(w http.ResponseWriter, r *http.Request) {
+	provider := r.URL.Query().Get("provider")
+	if provider == "" {
+		http.Error(w, "provider is required", http.StatusBadRequest)
+		return
+	}
+	u, err := s.backend(r.Context()).StartOAuthLogin(r.Context(), provider)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, URLResponse{URL: u})
+}
+
+// accountOAuthCompleteHandler blocks until the user finishes the OAuth login in their browser, or
+// the request is canceled.
+func (s *localapi) accountOAuthCompleteHandler(w http.ResponseWriter, r *http.Request) {
+	userData, err := s.backend(r.Context()).CompleteOAuthLogin(r.Context())
+	switch {
+	case errors.Is(err, account.ErrNoOAuthLogin):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, userData)
+	}
+}
+
 func (s *localapi) accountDataCapHandler(w http.ResponseWriter, r *http.Request) {
 	info, err := s.backend(r.Context()).DataCapInfo(r.Context())
 	if err != nil {
@@ -1127,6 +1838,27 @@ func (s *localapi) subscriptionBillingPortalHandler(w http.ResponseWriter, r *ht
 	writeJSON(w, http.StatusOK, URLResponse{URL: u})
 }
 
+func (s *localapi) subscriptionReferralInfoHandler(w http.ResponseWriter, r *http.Request) {
+	info, err := s.backend(r.Context()).ReferralInfo(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *localapi) subscriptionStatusHandler(w http.ResponseWriter, r *http.Request) {
+	sub, err := s.backend(r.Context()).Subscription(r.Context())
+	switch {
+	case errors.Is(err, account.ErrNoSubscription):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, sub)
+	}
+}
+
 func (s *localapi) subscriptionPaymentRedirectURLHandler(w http.ResponseWriter, r *http.Request) {
 	var req account.PaymentRedirectData
 	if err := decodeJSON(r, &req); err != nil {
@@ -1150,6 +1882,23 @@ func (s *localapi) subscriptionPlansHandler(w http.ResponseWriter, r *http.Reque
 	writeJSON(w, http.StatusOK, PlansResponse{Plans: plans})
 }
 
+func (s *localapi) subscriptionQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("plan") == "" {
+		http.Error(w, "plan is required", http.StatusBadRequest)
+		return
+	}
+	quote, err := s.backend(r.Context()).QuotePlanChange(r.Context(), q.Get("channel"), q.Get("plan"), q.Get("currency"))
+	switch {
+	case errors.Is(err, account.ErrUnknownPlan):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, quote)
+	}
+}
+
 func (s *localapi) subscriptionVerifyHandler(w http.ResponseWriter, r *http.Request) {
 	var req VerifySubscriptionRequest
 	if err := decodeJSON(r, &req); err != nil {
@@ -1188,7 +1937,7 @@ func (s *localapi) issueReportHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if err := s.backend(r.Context()).ReportIssue(req.IssueType, req.Description, req.Email, req.AdditionalAttachments, req.Attachments); err != nil {
+	if err := s.backend(r.Context()).ReportIssue(req.IssueType, req.Description, req.Email, req.AttachDiagnostics, req.AdditionalAttachments, req.Attachments); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1216,3 +1965,140 @@ func (s *localapi) logsStreamHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+////////////
+// Events //
+////////////
+
+const (
+	defaultThroughputTick = time.Second
+	minThroughputTick     = 250 * time.Millisecond
+)
+
+// eventsHandler handles GET /events, a single SSE stream carrying every event type a frontend
+// needs to stay in sync, so it doesn't have to hold one stream per type or poll. The optional
+// "types" query parameter is a comma-separated list of StreamEventTypes to include (all when
+// empty) and "interval" sets the throughput tick period. Throughput ticks are only sent while
+// the tunnel is connected.
+func (s *localapi) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	want, err := parseStreamEventTypes(r.URL.Query().Get("types"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interval := defaultThroughputTick
+	if v := r.URL.Query().Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			http.Error(w, "invalid interval: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		interval = max(d, minThroughputTick)
+	}
+	flusher := sseWriter(w)
+	if flusher == nil {
+		return
+	}
+
+	ctx := r.Context()
+	ch := make(chan StreamEvent, 32)
+	push := func(typ StreamEventType, v any) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		select {
+		case ch <- StreamEvent{Type: typ, Data: data}:
+		default:
+		}
+	}
+	if want[StreamEventVPNStatus] {
+		events.SubscribeContext(ctx, func(evt vpn.StatusUpdateEvent) { push(StreamEventVPNStatus, evt) })
+	}
+	if want[StreamEventAutoSelected] {
+		events.SubscribeContext(ctx, func(evt vpn.AutoSelectedEvent) { push(StreamEventAutoSelected, evt) })
+	}
+	if want[StreamEventURLTest] {
+		events.SubscribeContext(ctx, func(evt vpn.URLTestCompleteEvent) { push(StreamEventURLTest, evt) })
+	}
+	if want[StreamEventConfig] {
+		events.SubscribeContext(ctx, func(config.NewConfigEvent) { push(StreamEventConfig, struct{}{}) })
+	}
+	if want[StreamEventConfigFreshness] {
+		events.SubscribeContext(ctx, func(evt config.ConfigFreshnessEvent) { push(StreamEventConfigFreshness, evt.New) })
+	}
+	if want[StreamEventFlags] {
+		events.SubscribeContext(ctx, func(evt config.FlagsChangedEvent) { push(StreamEventFlags, evt) })
+	}
+	if want[StreamEventServersDisabled] {
+		events.SubscribeContext(ctx, func(evt servers.ServersDisabledEvent) { push(StreamEventServersDisabled, evt) })
+	}
+	if want[StreamEventFingerprintChanged] {
+		events.SubscribeContext(ctx, func(evt servers.FingerprintChangedEvent) { push(StreamEventFingerprintChanged, evt) })
+	}
+	if want[StreamEventDataCapThreshold] {
+		events.SubscribeContext(ctx, func(evt account.DataCapThresholdEvent) { push(StreamEventDataCapThreshold, evt) })
+	}
+	if want[StreamEventAuthExpired] {
+		events.SubscribeContext(ctx, func(account.AuthExpiredEvent) { push(StreamEventAuthExpired, struct{}{}) })
+	}
+
+	write := func(evt StreamEvent) {
+		data, err := json.Marshal(evt)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	// events.Subscribe is forward-only; replay the current status so the client starts in sync.
+	if want[StreamEventVPNStatus] {
+		if data, err := json.Marshal(vpn.StatusUpdateEvent{Status: s.backend(ctx).VPNStatus()}); err == nil {
+			write(StreamEvent{Type: StreamEventVPNStatus, Data: data})
+		}
+	}
+
+	var tick <-chan time.Time
+	if want[StreamEventThroughput] {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case evt := <-ch:
+			write(evt)
+		case <-tick:
+			tp, err := s.backend(ctx).VPNThroughput()
+			if err != nil {
+				continue
+			}
+			if data, err := json.Marshal(tp); err == nil {
+				write(StreamEvent{Type: StreamEventThroughput, Data: data})
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// parseStreamEventTypes parses a comma-separated list of event types. An empty list selects
+// every type.
+func parseStreamEventTypes(s string) (map[StreamEventType]bool, error) {
+	set := make(map[StreamEventType]bool)
+	if s == "" {
+		for _, t := range allStreamEventTypes {
+			set[t] = true
+		}
+		return set, nil
+	}
+	for _, name := range strings.Split(s, ",") {
+		t := StreamEventType(strings.TrimSpace(name))
+		if !slices.Contains(allStreamEventTypes, t) {
+			return nil, fmt.Errorf("unknown event type %q", t)
+		}
+		set[t] = true
+	}
+	return set, nil
+}
@@ -0,0 +1,93 @@
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getlantern/radiance/backend"
+	"github.com/getlantern/radiance/common/env"
+)
+
+// testBackend is shared by the package's tests, since a backend's global initialization only
+// happens once per process.
+var testBackend = sync.OnceValues(func() (*backend.LocalBackend, error) {
+	dir, err := os.MkdirTemp("", "ipc-test")
+	if err != nil {
+		return nil, err
+	}
+	return backend.NewLocalBackend(context.Background(), backend.Options{
+		DataDir:  filepath.Join(dir, "data"),
+		LogDir:   filepath.Join(dir, "logs"),
+		LogLevel: "error",
+	})
+})
+
+// newTestAPI returns the local API over the shared test backend.
+func newTestAPI(t *testing.T, withAuth bool) *localapi {
+	t.Helper()
+	b, err := testBackend()
+	require.NoError(t, err)
+	return newLocalAPI(b, withAuth)
+}
+
+func TestAPIInfo(t *testing.T) {
+	api := newTestAPI(t, false)
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	getInfo := func(t *testing.T) APIInfo {
+		t.Helper()
+		resp, err := http.Get(srv.URL + apiInfoEndpoint)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var info APIInfo
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+		return info
+	}
+
+	info := getInfo(t)
+	assert.Equal(t, APIVersion, info.APIVersion)
+	var endpoints []string
+	for _, c := range info.Capabilities {
+		if c.IsEndpoint() {
+			endpoints = append(endpoints, string(c))
+		}
+	}
+	assert.ElementsMatch(t, api.routes, endpoints, "every served route should be advertised, and nothing else")
+
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{method: http.MethodGet, path: vpnStatusEndpoint, want: true},
+		{method: http.MethodPost, path: vpnStatusEndpoint},
+		{method: http.MethodGet, path: configCountryEndpoint, want: true},
+		{method: http.MethodPost, path: configCountryEndpoint, want: true},
+		{method: http.MethodPut, path: settingsEndpoint, want: true},
+		{method: http.MethodGet, path: "/config/unknown"},
+		{method: http.MethodGet, path: debugVarsEndpoint},
+		{method: http.MethodPost, path: controlPathPrefix},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, info.Serves(tt.method, tt.path))
+		})
+	}
+
+	for _, c := range info.Capabilities {
+		assert.False(t, strings.HasPrefix(string(c), debugPathPrefix), "debug routes are advertised by %q", CapabilityDebug)
+	}
+	assert.False(t, info.Has(CapabilityDebug))
+	t.Setenv(env.DebugEndpoints.String(), "true")
+	assert.True(t, getInfo(t).Has(CapabilityDebug))
+}
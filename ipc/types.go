@@ -1,9 +1,14 @@
 package ipc
 
 import (
+	"encoding/json"
+	"slices"
+	"strings"
+
 	"github.com/getlantern/common"
 
 	"github.com/getlantern/radiance/account"
+	"github.com/getlantern/radiance/config"
 	"github.com/getlantern/radiance/issue"
 	"github.com/getlantern/radiance/servers"
 )
@@ -23,6 +28,21 @@ type EmailPasswordRequest struct {
 	Password string `json:"password"`
 }
 
+type LoginReplaceDeviceRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	DeviceID string `json:"deviceId"`
+}
+
+type RedeemLinkCodeRequest struct {
+	Code       string `json:"code"`
+	DeviceName string `json:"deviceName"`
+}
+
+type VerifyEmailRequest struct {
+	Code string `json:"code"`
+}
+
 type EmailCodeRequest struct {
 	Email string `json:"email"`
 	Code  string `json:"code"`
@@ -37,6 +57,50 @@ type CodeRequest struct {
 	Channel string `json:"channel"`
 }
 
+// ConfigRollbackRequest selects the config version to roll back to. An empty ID means the
+// version before the current one.
+type ConfigRollbackRequest struct {
+	ID string `json:"id"`
+}
+
+// ConfigCountryRequest carries the country code config fetches use instead of the IP-derived
+// one. An empty Country means no override.
+type ConfigCountryRequest struct {
+	Country string `json:"country"`
+}
+
+// ConfigSourcesRequest replaces the config sources used in addition to the Lantern API.
+type ConfigSourcesRequest struct {
+	Sources []config.ConfigSource `json:"sources"`
+}
+
+// StoredAccountRequest carries the user ID of an account stored on the device.
+type StoredAccountRequest struct {
+	UserID string `json:"userId"`
+}
+
+// DismissAnnouncementRequest carries the ID of the announcement the user dismissed.
+type DismissAnnouncementRequest struct {
+	ID string `json:"id"`
+}
+
+// ProfilesResponse lists the saved profiles and the name of the active one, if any.
+type ProfilesResponse struct {
+	Profiles []config.Profile `json:"profiles"`
+	Active   string           `json:"active"`
+}
+
+// ProfileSaveRequest saves the current settings as the named profile. Servers lists the tags of
+// user-added servers available in the profile; if empty, all are available.
+type ProfileSaveRequest struct {
+	Name    string   `json:"name"`
+	Servers []string `json:"servers,omitempty"`
+}
+
+type ProfileRequest struct {
+	Name string `json:"name"`
+}
+
 type JSONConfigRequest struct {
 	Config string `json:"config"`
 }
@@ -45,10 +109,21 @@ type AddServersRequest struct {
 	Servers servers.ServerList `json:"servers"`
 }
 
+// AddServersBatchRequest adds many servers at once. Servers whose tag is already in use are
+// replaced if Replace is true and skipped otherwise.
+type AddServersBatchRequest struct {
+	Servers servers.ServerList `json:"servers"`
+	Replace bool               `json:"replace"`
+}
+
 type RemoveServersRequest struct {
 	Tags []string `json:"tags"`
 }
 
+type ProbeServersRequest struct {
+	Tags []string `json:"tags"`
+}
+
 type URLsRequest struct {
 	URLs                 []string `json:"urls"`
 	SkipCertVerification bool     `json:"skipCertVerification"`
@@ -63,6 +138,10 @@ type PrivateServerRequest struct {
 	Joined      bool                  `json:"joined"`
 }
 
+type RemovePrivateServerRequest struct {
+	IP string `json:"ip"`
+}
+
 type PrivateServerInviteRequest struct {
 	IP          string `json:"ip"`
 	Port        int    `json:"port"`
@@ -70,6 +149,43 @@ type PrivateServerInviteRequest struct {
 	InviteName  string `json:"inviteName"`
 }
 
+// QRPayloadRequest adds the server or private server invite in a scanned QR payload.
+type QRPayloadRequest struct {
+	Payload              string `json:"payload"`
+	SkipCertVerification bool   `json:"skipCertVerification"`
+}
+
+type QRPayloadResponse struct {
+	Payload string `json:"payload"`
+}
+
+type ServerExportResponse struct {
+	Format servers.ExportFormat `json:"format"`
+	Data   string               `json:"data"`
+}
+
+type RenameServerRequest struct {
+	Tag    string `json:"tag"`
+	NewTag string `json:"newTag"`
+}
+
+type ServerDisabledRequest struct {
+	Tag      string `json:"tag"`
+	Disabled bool   `json:"disabled"`
+}
+
+// TrustedFingerprintRequest identifies the private server whose trusted fingerprint is removed or
+// replaced. Fingerprint is only used when replacing.
+type TrustedFingerprintRequest struct {
+	IP          string `json:"ip"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+type ServerMetadataRequest struct {
+	Tag      string                 `json:"tag"`
+	Metadata servers.ServerMetadata `json:"metadata"`
+}
+
 type ChangeEmailStartRequest struct {
 	NewEmail string `json:"newEmail"`
 	Password string `json:"password"`
@@ -112,6 +228,7 @@ type IssueReportRequest struct {
 	IssueType             issue.IssueType     `json:"issueType"`
 	Description           string              `json:"description"`
 	Email                 string              `json:"email"`
+	AttachDiagnostics     bool                `json:"attachDiagnostics"`
 	AdditionalAttachments []string            `json:"additionalAttachments"`
 	Attachments           []*issue.Attachment `json:"attachments"`
 }
@@ -151,3 +268,99 @@ type PlansResponse struct {
 type ResultResponse struct {
 	Result string `json:"result"`
 }
+
+// StreamEventType names the kind of event carried by a StreamEvent.
+type StreamEventType string
+
+const (
+	// StreamEventVPNStatus carries a vpn.StatusUpdateEvent.
+	StreamEventVPNStatus StreamEventType = "vpn-status"
+	// StreamEventAutoSelected carries a vpn.AutoSelectedEvent.
+	StreamEventAutoSelected StreamEventType = "auto-selected"
+	// StreamEventURLTest carries a vpn.URLTestCompleteEvent.
+	StreamEventURLTest StreamEventType = "url-test"
+	// StreamEventConfig signals a config update. Data is always "{}".
+	StreamEventConfig StreamEventType = "config"
+	// StreamEventThroughput carries a periodic vpn.ThroughputSnapshot.
+	StreamEventThroughput StreamEventType = "throughput"
+	// StreamEventConfigFreshness carries the new config.Freshness when the config becomes, or
+	// stops being, stale or expired.
+	StreamEventConfigFreshness StreamEventType = "config-freshness"
+	// StreamEventFlags carries a config.FlagsChangedEvent.
+	StreamEventFlags StreamEventType = "flags"
+	// StreamEventServersDisabled carries a servers.ServersDisabledEvent.
+	StreamEventServersDisabled StreamEventType = "servers-disabled"
+	// StreamEventFingerprintChanged carries a servers.FingerprintChangedEvent.
+	StreamEventFingerprintChanged StreamEventType = "fingerprint-changed"
+	// StreamEventDataCapThreshold carries an account.DataCapThresholdEvent.
+	StreamEventDataCapThreshold StreamEventType = "datacap-threshold"
+	// StreamEventAuthExpired signals that the user's credentials were rejected and they must log
+	// in again. Data is always "{}".
+	StreamEventAuthExpired StreamEventType = "auth-expired"
+)
+
+var allStreamEventTypes = []StreamEventType{
+	StreamEventVPNStatus,
+	StreamEventAutoSelected,
+	StreamEventURLTest,
+	StreamEventConfig,
+	StreamEventThroughput,
+	StreamEventConfigFreshness,
+	StreamEventFlags,
+	StreamEventServersDisabled,
+	StreamEventFingerprintChanged,
+	StreamEventDataCapThreshold,
+	StreamEventAuthExpired,
+}
+
+// StreamEvent is a single entry on the combined event stream. Data is the JSON encoding of the
+// event identified by Type.
+type StreamEvent struct {
+	Type StreamEventType `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// APIVersion is the IPC protocol version served by this build. It only changes when an existing
+// endpoint changes incompatibly; additive features are advertised as a Capability instead, so
+// clients can keep working against older and newer daemons alike.
+const APIVersion = 1
+
+// Capability names an optional IPC feature a daemon may support. Besides the named features
+// below, every endpoint the daemon serves is advertised as a capability named by its route
+// pattern, e.g. "POST /config/preview", so clients can check for one with [APIInfo.Serves].
+type Capability string
+
+// IsEndpoint reports whether c advertises an endpoint rather than a named feature.
+func (c Capability) IsEndpoint() bool {
+	return strings.Contains(string(c), "/")
+}
+
+const (
+	// CapabilityEvents is the combined /events stream.
+	CapabilityEvents Capability = "events"
+	// CapabilityControlGRPC is the gRPC VPNControl service.
+	CapabilityControlGRPC Capability = "grpc-control"
+	// CapabilityMetrics is the /vpn/metrics endpoint.
+	CapabilityMetrics Capability = "metrics"
+	// CapabilityDebug means the /debug endpoints are currently enabled.
+	CapabilityDebug Capability = "debug"
+)
+
+// APIInfo describes the daemon's IPC protocol so clients can detect mismatches up front instead
+// of failing on unknown endpoints.
+type APIInfo struct {
+	APIVersion    int          `json:"apiVersion"`
+	DaemonVersion string       `json:"daemonVersion"`
+	Capabilities  []Capability `json:"capabilities"`
+}
+
+// Has reports whether the daemon advertised capability c.
+func (i APIInfo) Has(c Capability) bool {
+	return slices.Contains(i.Capabilities, c)
+}
+
+// Serves reports whether the daemon serves method requests to path. Routes registered without
+// a method serve every method.
+func (i APIInfo) Serves(method, path string) bool {
+	return i.Has(Capability(method+" "+path)) || i.Has(Capability(path))
+}
@@ -4,10 +4,16 @@ import (
 	"context"
 	"os"
 	"os/exec"
+	"os/user"
 	"runtime"
+	"slices"
 	"time"
 )
 
+// ipcGroup is the Unix group whose members may control the daemon without sudo rights, so
+// administrators can grant access to a desktop user without giving them root.
+const ipcGroup = "lantern"
+
 var executable string
 
 func init() {
@@ -26,6 +32,8 @@ type usr struct {
 	uid     string
 	uname   string
 	isAdmin bool
+	// inGroup reports whether the peer belongs to ipcGroup.
+	inGroup bool
 }
 
 func contextWithUsr(ctx context.Context, u usr) context.Context {
@@ -51,3 +59,20 @@ func canSudo(usr string) bool {
 	}
 	return true
 }
+
+// inIPCGroup reports whether u is a member of ipcGroup, either as its primary group or as a
+// supplementary one.
+func inIPCGroup(u *user.User) bool {
+	g, err := user.LookupGroup(ipcGroup)
+	if err != nil {
+		return false
+	}
+	if u.Gid == g.Gid {
+		return true
+	}
+	gids, err := u.GroupIds()
+	if err != nil {
+		return false
+	}
+	return slices.Contains(gids, g.Gid)
+}
@@ -23,6 +23,9 @@ const (
 	// filenames: "<log-name>-<timestamp>.log.gz".
 	backupTimeFormat = "2006-01-02T15-04-05.000"
 
+	// diagnosticsName is the name of the diagnostics attachment in the archive.
+	diagnosticsName = "diagnostics.json"
+
 	logExt    = ".log"
 	backupExt = ".log.gz"
 )
@@ -30,9 +33,9 @@ const (
 // buildIssueArchive creates a zip archive containing all .log files found in
 // logDir plus additional attachment files. The primary log (lantern.log) is
 // given truncation priority; secondary log files and attachments are included
-// greedily if space permits. The total compressed archive size will not exceed
-// maxSize bytes.
-func buildIssueArchive(logDir string, additionalFiles []string, maxSize int64) ([]byte, error) {
+// greedily if space permits, diagnostics first if not empty. The total
+// compressed archive size will not exceed maxSize bytes.
+func buildIssueArchive(logDir string, diagnostics []byte, additionalFiles []string, maxSize int64) ([]byte, error) {
 	logFiles := globFiles(logDir, "*.log")
 
 	var primaryLogData []byte
@@ -57,7 +60,11 @@ func buildIssueArchive(logDir string, additionalFiles []string, maxSize int64) (
 		}
 	}
 
-	attachments := readExtraFiles(additionalFiles)
+	var attachments []extraFile
+	if len(diagnostics) > 0 {
+		attachments = append(attachments, extraFile{name: diagnosticsName, data: diagnostics})
+	}
+	attachments = append(attachments, readExtraFiles(additionalFiles)...)
 
 	primaryPath := filepath.Join(logDir, logArchiveName)
 	primaryLogData = prependMostRecentBackup(primaryPath, primaryLogData, maxSize)
@@ -342,7 +342,7 @@ func TestBuildIssueArchive(t *testing.T) {
 		extra := filepath.Join(dir, "extra.txt")
 		require.NoError(t, os.WriteFile(extra, []byte("extra content"), 0644))
 
-		result, err := buildIssueArchive(dir, []string{extra}, 1024*1024)
+		result, err := buildIssueArchive(dir, nil, []string{extra}, 1024*1024)
 		require.NoError(t, err)
 		require.NotNil(t, result)
 
@@ -353,12 +353,27 @@ func TestBuildIssueArchive(t *testing.T) {
 		assert.Equal(t, "attachments/extra.txt", entries[1].name)
 	})
 
+	t.Run("diagnostics precede extras", func(t *testing.T) {
+		dir := t.TempDir()
+		extra := filepath.Join(dir, "extra.txt")
+		require.NoError(t, os.WriteFile(extra, []byte("extra content"), 0644))
+
+		result, err := buildIssueArchive(dir, []byte(`{"vpn":"connected"}`), []string{extra}, 1024*1024)
+		require.NoError(t, err)
+
+		entries := readZipEntries(t, result)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "attachments/"+diagnosticsName, entries[0].name)
+		assert.Equal(t, `{"vpn":"connected"}`, entries[0].content)
+		assert.Equal(t, "attachments/extra.txt", entries[1].name)
+	})
+
 	t.Run("includes all log files in directory", func(t *testing.T) {
 		dir := t.TempDir()
 		require.NoError(t, os.WriteFile(filepath.Join(dir, "lantern.log"), []byte("main log"), 0644))
 		require.NoError(t, os.WriteFile(filepath.Join(dir, "lantern-crash.log"), []byte("crash log"), 0644))
 
-		result, err := buildIssueArchive(dir, nil, 1024*1024)
+		result, err := buildIssueArchive(dir, nil, nil, 1024*1024)
 		require.NoError(t, err)
 		require.NotNil(t, result)
 
@@ -377,7 +392,7 @@ func TestBuildIssueArchive(t *testing.T) {
 		extra := filepath.Join(dir, "extra.txt")
 		require.NoError(t, os.WriteFile(extra, []byte("data"), 0644))
 
-		result, err := buildIssueArchive(filepath.Join(dir, "nonexistent"), []string{extra}, 1024*1024)
+		result, err := buildIssueArchive(filepath.Join(dir, "nonexistent"), nil, []string{extra}, 1024*1024)
 		require.NoError(t, err)
 		require.NotNil(t, result)
 
@@ -393,7 +408,7 @@ func TestBuildIssueArchive(t *testing.T) {
 		require.NoError(t, os.WriteFile(filepath.Join(dir, "lantern.log"), logData, 0644))
 
 		maxSize := int64(512 * 1024)
-		result, err := buildIssueArchive(dir, nil, maxSize)
+		result, err := buildIssueArchive(dir, nil, nil, maxSize)
 		require.NoError(t, err)
 		assert.LessOrEqual(t, int64(len(result)), maxSize)
 
@@ -556,7 +571,7 @@ func TestBuildIssueArchiveIncludesCompressedBackup(t *testing.T) {
 		require.NoError(t, os.WriteFile(filepath.Join(dir, "lantern.log"), []byte("current line\n"), 0644))
 		writeGzipFile(t, filepath.Join(dir, "lantern-2026-06-15T15-31-02.000.log.gz"), []byte("rotated line\n"))
 
-		result, err := buildIssueArchive(dir, nil, 1024*1024)
+		result, err := buildIssueArchive(dir, nil, nil, 1024*1024)
 		require.NoError(t, err)
 		assert.Equal(t, "rotated line\ncurrent line\n", primaryContent(t, result))
 	})
@@ -566,7 +581,7 @@ func TestBuildIssueArchiveIncludesCompressedBackup(t *testing.T) {
 		require.NoError(t, os.WriteFile(filepath.Join(dir, "lantern.log"), []byte("current line\n"), 0644))
 		writeGzipFile(t, filepath.Join(dir, "lantern-2026-06-15T15-31-02.000.log.gz"), []byte("rotated line"))
 
-		result, err := buildIssueArchive(dir, nil, 1024*1024)
+		result, err := buildIssueArchive(dir, nil, nil, 1024*1024)
 		require.NoError(t, err)
 		assert.Equal(t, "rotated line\ncurrent line\n", primaryContent(t, result))
 	})
@@ -576,7 +591,7 @@ func TestBuildIssueArchiveIncludesCompressedBackup(t *testing.T) {
 		require.NoError(t, os.WriteFile(filepath.Join(dir, "lantern.log"), []byte("current line\n"), 0644))
 		require.NoError(t, os.WriteFile(filepath.Join(dir, "lantern-2026-06-15T15-31-02.000.log.gz"), []byte("not gzip"), 0644))
 
-		result, err := buildIssueArchive(dir, nil, 1024*1024)
+		result, err := buildIssueArchive(dir, nil, nil, 1024*1024)
 		require.NoError(t, err)
 		assert.Equal(t, "current line\n", primaryContent(t, result))
 	})
@@ -586,7 +601,7 @@ func TestBuildIssueArchiveIncludesCompressedBackup(t *testing.T) {
 		require.NoError(t, os.WriteFile(filepath.Join(dir, "lantern.log"), []byte("current line\n"), 0644))
 		writeGzipFile(t, filepath.Join(dir, "lantern-2026-06-15T15-31-02.000.log.gz"), nil)
 
-		result, err := buildIssueArchive(dir, nil, 1024*1024)
+		result, err := buildIssueArchive(dir, nil, nil, 1024*1024)
 		require.NoError(t, err)
 		assert.Equal(t, "current line\n", primaryContent(t, result))
 	})
@@ -604,7 +619,7 @@ func TestBuildIssueArchiveIncludesCompressedBackup(t *testing.T) {
 		// 200 KiB current + 400 KiB backup (incompressible) exceed the 384 KiB
 		// budget, so the tail-trim must drop the prepended backup (oldest) and
 		// keep the current log (newest).
-		result, err := buildIssueArchive(dir, nil, 384*1024)
+		result, err := buildIssueArchive(dir, nil, nil, 384*1024)
 		require.NoError(t, err)
 		primary := primaryContent(t, result)
 		assert.Contains(t, primary, "CURRENTTAILMARKER")
@@ -93,6 +93,10 @@ type IssueReport struct {
 	// AdditionalAttachments is a list of additional files to be attached. The log file will be
 	// automatically included.
 	AdditionalAttachments []string
+	// Diagnostics is a snapshot of the client's state, such as its config fetch status and
+	// tunnel state, that's included in the archive with the logs. PII is scrubbed from it before
+	// it's sent.
+	Diagnostics []byte
 }
 
 // Report sends an issue report to lantern-cloud/issue, which is then forwarded to ticket system via API
@@ -149,7 +153,7 @@ func (ir *IssueReporter) Report(ctx context.Context, report IssueReport) error {
 	archiveBudget = max(archiveBudget, 0)
 
 	logDir := settings.GetString(settings.LogPathKey)
-	archive, err := buildIssueArchive(logDir, report.AdditionalAttachments, archiveBudget)
+	archive, err := buildIssueArchive(logDir, scrubPII(report.Diagnostics), report.AdditionalAttachments, archiveBudget)
 	if err != nil {
 		slog.Error("failed to build issue archive", "error", err)
 	}
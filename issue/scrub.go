@@ -0,0 +1,31 @@
+package issue
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// addrPattern matches candidate IP addresses, which are then parsed to tell them apart from
+	// version numbers, times, and the like.
+	addrPattern = regexp.MustCompile(`[0-9A-Fa-f]*[.:][0-9A-Fa-f.:]*[0-9A-Fa-f]`)
+)
+
+// scrubPII replaces email and IP addresses in data with placeholders.
+func scrubPII(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+	data = emailPattern.ReplaceAll(data, []byte("[email]"))
+	return addrPattern.ReplaceAllFunc(data, func(m []byte) []byte {
+		if _, err := netip.ParseAddr(string(m)); err == nil {
+			return []byte("[ip]")
+		}
+		if ap, err := netip.ParseAddrPort(string(m)); err == nil {
+			return fmt.Appendf(nil, "[ip]:%d", ap.Port())
+		}
+		return m
+	})
+}
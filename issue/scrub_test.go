@@ -0,0 +1,22 @@
+package issue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrubPII(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"user jane.doe+vpn@example.co.uk signed in", "user [email] signed in"},
+		{`{"ip":"203.0.113.7","proxy":"198.51.100.1:443"}`, `{"ip":"[ip]","proxy":"[ip]:443"}`},
+		{"dial [2001:db8::1]:8443 via fe80::1", "dial [[ip]]:8443 via [ip]"},
+		{"version 9.0.12 at 12:30:45 on 2026-10-17", "version 9.0.12 at 12:30:45 on 2026-10-17"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, string(scrubPII([]byte(tt.in))))
+	}
+}
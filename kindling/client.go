@@ -1,15 +1,18 @@
 // Package kindling provides a wrapper around the kindling library to create an HTTP client with
-// various transports (domain fronting, AMP, DNS tunneling, proxyless) from a shared kindling instance.
+// various transports (domain fronting, AMP, DNS tunneling, Snowflake, Shadowsocks, ECH, proxyless) from a shared kindling instance.
 package kindling
 
 import (
 	"context"
 	"errors"
 	"log/slog"
+	"maps"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/getlantern/kindling"
 	"go.opentelemetry.io/otel"
@@ -21,8 +24,11 @@ import (
 	"github.com/getlantern/radiance/common/reporting"
 	"github.com/getlantern/radiance/common/settings"
 	"github.com/getlantern/radiance/kindling/dnstt"
+	"github.com/getlantern/radiance/kindling/ech"
 	"github.com/getlantern/radiance/kindling/fronted"
+	"github.com/getlantern/radiance/kindling/shadowsocks"
 	radiancesmart "github.com/getlantern/radiance/kindling/smart"
+	"github.com/getlantern/radiance/kindling/snowflake"
 	"github.com/getlantern/radiance/traces"
 )
 
@@ -36,6 +42,14 @@ const (
 	TransportDomainfront = kindling.TransportDomainfront
 	TransportDNSTunnel   = kindling.TransportDNSTunnel
 	TransportSmart       = kindling.TransportSmart
+	// TransportSnowflake isn't built into the upstream library; NewKindling adds it as a custom
+	// transport.
+	TransportSnowflake TransportName = snowflake.Name
+	// TransportShadowsocks connects through the user's Shadowsocks servers, once SetServerSource
+	// has said where to find them.
+	TransportShadowsocks TransportName = shadowsocks.Name
+	// TransportECH connects directly with a browser's TLS fingerprint and Encrypted Client Hello.
+	TransportECH TransportName = ech.Name
 )
 
 var (
@@ -49,7 +63,19 @@ var (
 		kindling.TransportAMP:         true,
 		kindling.TransportSmart:       true,
 		kindling.TransportDomainfront: true,
+		TransportSnowflake:            false,
+		TransportShadowsocks:          true,
+		TransportECH:                  true,
 	}
+	// DNSTTProbePolicy bounds the probing the DNS tunnel transport does to find working
+	// tunnels. Like EnabledTransports, a change takes effect on the next rebuild.
+	DNSTTProbePolicy dnstt.ProbePolicy
+	// DNSTTConfigMirrors are URLs serving copies of the dnstt config, tried in order when
+	// dnstt.ConfigURL can't be reached directly. Like EnabledTransports, a change takes effect
+	// on the next rebuild.
+	DNSTTConfigMirrors []string
+	serverSource       shadowsocks.ServerSource
+
 	defaultTransportClone = http.DefaultTransport.(*http.Transport).Clone()
 
 	transport http.RoundTripper
@@ -67,6 +93,21 @@ func EnableTransport(transport TransportName, enable bool) bool {
 	return true
 }
 
+// SetServerSource sets where the Shadowsocks transport finds the servers to connect through.
+// Call it before Init; until then, the transport is left out.
+func SetServerSource(src shadowsocks.ServerSource) {
+	mu.Lock()
+	defer mu.Unlock()
+	serverSource = src
+}
+
+// SetOnBattery sets whether the device is running on battery, so the DNS tunnel transport can
+// probe for working tunnels less often. Unlike DNSTTProbePolicy, it takes effect without a
+// rebuild.
+func SetOnBattery(onBattery bool) {
+	dnstt.SetOnBattery(onBattery)
+}
+
 func initKindling() {
 	newK, err := NewKindling(settings.GetString(settings.DataPathKey))
 	if err != nil {
@@ -74,7 +115,7 @@ func initKindling() {
 	}
 	if newK != nil {
 		k = newK
-		transport = traces.NewRoundTripper(traces.NewHeaderAnnotatingRoundTripper(newK.NewHTTPClient().Transport))
+		transport = traces.NewRoundTripper(traces.NewHeaderAnnotatingRoundTripper(statsRoundTripper{newK.NewHTTPClient().Transport}))
 	} else {
 		slog.Warn("kindling unavailable, using default transport clone")
 		transport = traces.NewRoundTripper(traces.NewHeaderAnnotatingRoundTripper(defaultTransportClone))
@@ -185,7 +226,10 @@ func NewKindling(dataDir string) (*Client, error) {
 		return &Client{Kindling: newK}, nil
 	}
 
+	stats.load(filepath.Join(dataDir, transportStatsFile))
 	var closers []func() error
+	// newRTs holds each transport's round tripper generator, for wrapTransports.
+	newRTs := make(map[TransportName]newRoundTripperFunc)
 	kindlingOptions := []kindling.Option{
 		kindling.WithPanicListener(reporting.PanicListener),
 		kindling.WithLogWriter(logger),
@@ -202,6 +246,7 @@ func NewKindling(dataDir string) (*Client, error) {
 		}
 		if f != nil {
 			closers = append(closers, func() error { f.Close(); return nil })
+			newRTs[TransportDomainfront] = f.NewConnectedRoundTripper
 			kindlingOptions = append(kindlingOptions, kindling.WithDomainFronting(f))
 		}
 	}
@@ -213,6 +258,9 @@ func NewKindling(dataDir string) (*Client, error) {
 			span.RecordError(err)
 		}
 		if ampClient != nil {
+			newRTs[TransportAMP] = func(ctx context.Context, addr string) (http.RoundTripper, error) {
+				return ampClient.RoundTripper()
+			}
 			kindlingOptions = append(kindlingOptions, kindling.WithAMPCache(ampClient))
 		}
 	}
@@ -220,22 +268,75 @@ func NewKindling(dataDir string) (*Client, error) {
 	if enabled := EnabledTransports[kindling.TransportSmart]; enabled {
 		// "pro-server" calls still target api.getiantem.org; everything
 		// else uses df.iantem.io.
-		kindlingOptions = append(kindlingOptions, kindling.WithProxyless("df.iantem.io", "api.getiantem.org"))
+		smart, err := kindling.NewSmartHTTPTransportWithConfig(logger, radiancesmart.DialerConfig, bypass.StreamDialer(), nil, "df.iantem.io", "api.getiantem.org")
+		if err != nil {
+			slog.Error("failed to create smart transport", slog.Any("error", err))
+			span.RecordError(err)
+		} else {
+			t := proxylessTransport{smart: smart}
+			newRTs[TransportSmart] = t.NewRoundTripper
+			kindlingOptions = append(kindlingOptions, kindling.WithTransport(t))
+		}
 	}
 
+	// self lets config updaters started while building kindling fetch through it once it's built.
+	self := &lateRoundTripper{}
 	if enabled := EnabledTransports[kindling.TransportDNSTunnel]; enabled {
-		dnsttOptions, err := dnstt.DNSTTOptions(updaterCtx, filepath.Join(dataDir, "dnstt.yml.gz"), logger)
+		mirrors := make([]radiancesmart.Mirror, 0, len(DNSTTConfigMirrors)+1)
+		for _, u := range DNSTTConfigMirrors {
+			c, err := radiancesmart.NewHTTPClientWithSmartTransport(logger, u)
+			if err != nil {
+				slog.Warn("skipping dnstt config mirror", slog.String("url", u), slog.Any("error", err))
+				continue
+			}
+			mirrors = append(mirrors, radiancesmart.Mirror{URL: u, Client: c})
+		}
+		// Kindling itself is tried last, as it's the slowest way to reach the config.
+		mirrors = append(mirrors, radiancesmart.Mirror{URL: dnstt.ConfigURL, Client: &http.Client{Transport: self}})
+		dnsttOptions, err := dnstt.DNSTTOptions(updaterCtx, filepath.Join(dataDir, "dnstt.yml.gz"), logger,
+			dnstt.WithProbePolicy(DNSTTProbePolicy),
+			dnstt.WithConfigMirrors(mirrors...),
+		)
 		if err != nil {
 			slog.Error("failed to create or load dnstt kindling options", slog.Any("error", err))
 			span.RecordError(err)
 		}
 		if dnsttOptions != nil {
 			closers = append(closers, dnsttOptions.Close)
+			newRTs[TransportDNSTunnel] = dnsttOptions.NewRoundTripper
 			kindlingOptions = append(kindlingOptions, kindling.WithDNSTunnel(dnsttOptions))
 		}
 	}
 
+	if enabled := EnabledTransports[TransportSnowflake]; enabled {
+		sfTransport, err := snowflake.NewTransport(updaterCtx, filepath.Join(dataDir, "snowflake.yml.gz"), logger)
+		if err != nil {
+			slog.Error("failed to create snowflake transport", slog.Any("error", err))
+			span.RecordError(err)
+		}
+		if sfTransport != nil {
+			newRTs[TransportSnowflake] = sfTransport.NewRoundTripper
+			kindlingOptions = append(kindlingOptions, kindling.WithTransport(sfTransport))
+		}
+	}
+
+	if enabled := EnabledTransports[TransportECH]; enabled {
+		echTransport := ech.NewTransport()
+		newRTs[TransportECH] = echTransport.NewRoundTripper
+		kindlingOptions = append(kindlingOptions, kindling.WithTransport(echTransport))
+	}
+
+	if enabled := EnabledTransports[TransportShadowsocks]; enabled && serverSource != nil {
+		ssTransport := shadowsocks.NewTransport(serverSource)
+		newRTs[TransportShadowsocks] = ssTransport.NewRoundTripper
+		kindlingOptions = append(kindlingOptions, kindling.WithTransport(ssTransport))
+	}
+
 	newK, err := kindling.NewKindling("radiance", kindlingOptions...)
+	if err == nil && len(newRTs) == 0 {
+		// Every enabled transport failed to build, so fall back to the default transport.
+		err = errors.New("no kindling transports available")
+	}
 	if err != nil {
 		errs := []error{err}
 		cancel()
@@ -244,9 +345,49 @@ func NewKindling(dataDir string) (*Client, error) {
 		}
 		return nil, errors.Join(errs...)
 	}
+	// With no stats yet for this network, every transport races on equal terms, and the
+	// winners are recorded for next time.
+	var preferred TransportName
+	if len(newRTs) > 1 {
+		if preferred = stats.preferred(slices.Collect(maps.Keys(newRTs))); preferred != "" {
+			slog.Debug("giving preferred kindling transport a head start", slog.String("transport", string(preferred)))
+			span.SetAttributes(attribute.String("preferred_transport", string(preferred)))
+		}
+	}
+	wrapTransports(newK, preferred, newRTs)
+	self.set(newK.NewHTTPClient().Transport)
+
+	routingPath := filepath.Join(dataDir, routingConfigFile)
+	loadRoutingRules(routingPath)
+	routingMirrors := make([]radiancesmart.Mirror, 0, 2)
+	if c, err := radiancesmart.NewHTTPClientWithSmartTransport(logger, routingConfigURL); err != nil {
+		slog.Warn("couldn't create http client for fetching routing rules", slog.Any("error", err))
+	} else {
+		routingMirrors = append(routingMirrors, radiancesmart.Mirror{URL: routingConfigURL, Client: c})
+	}
+	routingMirrors = append(routingMirrors, radiancesmart.Mirror{URL: routingConfigURL, Client: &http.Client{Transport: self}})
+	routingRulesUpdate(updaterCtx, routingPath, routingMirrors)
 	return &Client{Kindling: newK, cancel: cancel, closers: closers}, nil
 }
 
+// lateRoundTripper sends requests through a round tripper that is set after it's handed out,
+// failing them until then.
+type lateRoundTripper struct {
+	rt atomic.Pointer[http.RoundTripper]
+}
+
+func (l *lateRoundTripper) set(rt http.RoundTripper) {
+	l.rt.Store(&rt)
+}
+
+func (l *lateRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := l.rt.Load()
+	if rt == nil {
+		return nil, errors.New("kindling is not ready")
+	}
+	return (*rt).RoundTrip(req)
+}
+
 type slogWriter struct {
 	*slog.Logger
 }
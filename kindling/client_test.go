@@ -19,6 +19,21 @@ func TestEnableTransport(t *testing.T) {
 	assert.False(t, EnableTransport(kindling.TransportAMP, false), "setting the same value reports no change")
 }
 
+func TestLateRoundTripper(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://df.iantem.io/", http.NoBody)
+	require.NoError(t, err)
+	late := &lateRoundTripper{}
+	_, err = late.RoundTrip(req)
+	assert.Error(t, err, "requests fail until the round tripper is set")
+
+	late.set(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	}))
+	resp, err := late.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
 func TestNewClient(t *testing.T) {
 	transports := []kindling.TransportName{
 		kindling.TransportDomainfront,
@@ -32,6 +47,7 @@ func TestNewClient(t *testing.T) {
 				EnabledTransports[name] = false
 			}
 			EnabledTransports[kindling.TransportDNSTunnel] = false
+			EnabledTransports[TransportECH] = false
 			EnabledTransports[tr] = true
 
 			Close()
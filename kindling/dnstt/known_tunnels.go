@@ -0,0 +1,139 @@
+package dnstt
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/getlantern/radiance/common/atomicfile"
+	"github.com/getlantern/radiance/common/fileperm"
+)
+
+const (
+	knownTunnelsFile = "dnstt_tunnels.json"
+	// knownTunnelTTL is how long a tunnel is remembered after it last worked. Resolvers that
+	// worked a week ago are still more likely to work than ones never seen working, but much
+	// older entries mostly reflect networks the device is no longer on.
+	knownTunnelTTL = 7 * 24 * time.Hour
+	// knownTunnelSaveInterval limits how often a tunnel that keeps working is rewritten to disk.
+	knownTunnelSaveInterval = time.Hour
+)
+
+type knownTunnel struct {
+	Domain        string    `json:"domain"`
+	Resolver      string    `json:"resolver"`
+	LastSucceeded time.Time `json:"lastSucceeded"`
+}
+
+// knownTunnels remembers the domain/resolver combinations that last worked, so they can be
+// probed first after a restart instead of rediscovering them from scratch. A nil *knownTunnels
+// remembers nothing.
+type knownTunnels struct {
+	mu      sync.Mutex
+	path    string
+	tunnels []knownTunnel
+}
+
+// loadKnownTunnels reads the tunnels persisted at path, dropping any that haven't worked within
+// knownTunnelTTL. If path is empty, tunnels are remembered only for the life of the process.
+func loadKnownTunnels(path string, now time.Time) *knownTunnels {
+	k := &knownTunnels{path: path}
+	if path == "" {
+		return k
+	}
+	data, err := atomicfile.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to read known dnstt tunnels", slog.Any("error", err))
+		}
+		return k
+	}
+	if err := json.Unmarshal(data, &k.tunnels); err != nil {
+		slog.Warn("failed to parse known dnstt tunnels", slog.Any("error", err))
+		k.tunnels = nil
+		return k
+	}
+	k.tunnels = slices.DeleteFunc(k.tunnels, func(t knownTunnel) bool {
+		return now.Sub(t.LastSucceeded) > knownTunnelTTL
+	})
+	return k
+}
+
+// save persists the tunnels. Must be called with mu held.
+func (k *knownTunnels) save() {
+	if k.path == "" {
+		return
+	}
+	data, err := json.Marshal(k.tunnels)
+	if err != nil {
+		slog.Error("failed to marshal known dnstt tunnels", slog.Any("error", err))
+		return
+	}
+	if err := atomicfile.WriteFile(k.path, data, fileperm.File); err != nil {
+		slog.Error("failed to write known dnstt tunnels", slog.Any("error", err))
+	}
+}
+
+func (k *knownTunnels) index(domain, resolver string) int {
+	return slices.IndexFunc(k.tunnels, func(t knownTunnel) bool {
+		return t.Domain == domain && t.Resolver == resolver
+	})
+}
+
+// succeeded records that the tunnel through resolver to domain worked at now.
+func (k *knownTunnels) succeeded(domain, resolver string, now time.Time) {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	i := k.index(domain, resolver)
+	if i < 0 {
+		k.tunnels = append(k.tunnels, knownTunnel{Domain: domain, Resolver: resolver, LastSucceeded: now})
+		k.save()
+		return
+	}
+	last := k.tunnels[i].LastSucceeded
+	k.tunnels[i].LastSucceeded = now
+	if now.Sub(last) >= knownTunnelSaveInterval {
+		k.save()
+	}
+}
+
+// forget drops the tunnel through resolver to domain, once it has stopped working.
+func (k *knownTunnels) forget(domain, resolver string) {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if i := k.index(domain, resolver); i >= 0 {
+		k.tunnels = slices.Delete(k.tunnels, i, i+1)
+		k.save()
+	}
+}
+
+// order returns configs with the known tunnels first, most recently working first, and the
+// rest in their original order.
+func (k *knownTunnels) order(configs []dnsttConfig) []dnsttConfig {
+	if k == nil {
+		return configs
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	lastSucceeded := func(cfg dnsttConfig) time.Time {
+		if i := k.index(cfg.Domain, cfgResolver(cfg)); i >= 0 {
+			return k.tunnels[i].LastSucceeded
+		}
+		return time.Time{}
+	}
+	ordered := slices.Clone(configs)
+	slices.SortStableFunc(ordered, func(a, b dnsttConfig) int {
+		return lastSucceeded(b).Compare(lastSucceeded(a))
+	})
+	return ordered
+}
@@ -0,0 +1,61 @@
+package dnstt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnownTunnels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), knownTunnelsFile)
+	cloudflare, google, quad9 := "https://cloudflare-dns.com/dns-query", "https://dns.google/dns-query", "https://dns.quad9.net/dns-query"
+	configs := []dnsttConfig{
+		{Domain: "t.iantem.io", DoHResolver: &cloudflare},
+		{Domain: "t.iantem.io", DoHResolver: &google},
+		{Domain: "t.iantem.io", DoHResolver: &quad9},
+	}
+	now := time.Now()
+
+	k := loadKnownTunnels(path, now)
+	assert.Equal(t, configs, k.order(configs), "nothing known yet")
+
+	k.succeeded("t.iantem.io", quad9, now.Add(-knownTunnelTTL-time.Minute))
+	k.succeeded("t.iantem.io", cloudflare, now.Add(-time.Hour))
+	k.succeeded("t.iantem.io", google, now)
+	ordered := k.order(configs)
+	assert.Equal(t, []string{google, cloudflare, quad9}, []string{cfgResolver(ordered[0]), cfgResolver(ordered[1]), cfgResolver(ordered[2])})
+
+	reloaded := loadKnownTunnels(path, now)
+	assert.Len(t, reloaded.tunnels, 2, "tunnels that haven't worked in a while are forgotten")
+	ordered = reloaded.order(configs)
+	assert.Equal(t, google, cfgResolver(ordered[0]))
+
+	reloaded.forget("t.iantem.io", google)
+	ordered = loadKnownTunnels(path, now).order(configs)
+	assert.Equal(t, []string{cloudflare, google, quad9}, []string{cfgResolver(ordered[0]), cfgResolver(ordered[1]), cfgResolver(ordered[2])})
+}
+
+func TestKnownTunnelsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), knownTunnelsFile)
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+	k := loadKnownTunnels(path, time.Now())
+	assert.Empty(t, k.tunnels)
+	k.succeeded("t.iantem.io", "https://dns.google/dns-query", time.Now())
+	assert.Len(t, loadKnownTunnels(path, time.Now()).tunnels, 1, "a corrupt file is replaced")
+}
+
+func TestDNSTunnelKnownTunnels(t *testing.T) {
+	k := loadKnownTunnels("", time.Now())
+	tun := &dnsTunnel{domain: "t.iantem.io", resolver: "https://dns.google/dns-query", known: k}
+	tun.markSucceeded()
+	assert.Len(t, k.tunnels, 1)
+	for range maxTunnelFailures {
+		tun.recordFailure()
+	}
+	assert.Empty(t, k.tunnels, "a discarded tunnel isn't tried first after a restart")
+}
@@ -0,0 +1,22 @@
+package dnstt
+
+import "github.com/getlantern/radiance/kindling/smart"
+
+// Option configures the transport returned by [DNSTTOptions].
+type Option func(*multipleDNSTTTransport)
+
+// WithConfigMirrors sets where the config is fetched from, in order, when [ConfigURL] can't be
+// reached with the smart dialer. A mirror can serve a copy of the config from another host, or
+// fetch ConfigURL itself through a client that routes around the block.
+func WithConfigMirrors(mirrors ...smart.Mirror) Option {
+	return func(m *multipleDNSTTTransport) {
+		m.configMirrors = mirrors
+	}
+}
+
+// WithProbePolicy sets how the transport probes for working tunnels.
+func WithProbePolicy(p ProbePolicy) Option {
+	return func(m *multipleDNSTTTransport) {
+		m.policy = p.withDefaults()
+	}
+}
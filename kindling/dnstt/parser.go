@@ -9,7 +9,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
-	"os"
+	"path/filepath"
 	"runtime/debug"
 	"sync"
 	"sync/atomic"
@@ -48,15 +48,18 @@ var embeddedConfig []byte
 
 var localConfigMutex sync.Mutex
 
-const dnsttConfigURL = "https://raw.githubusercontent.com/getlantern/radiance/main/kindling/dnstt/dnstt.yml.gz"
+// ConfigURL is where the dnstt config is published. It's fetched with the smart dialer first,
+// then from any mirrors given by [WithConfigMirrors].
+const ConfigURL = "https://raw.githubusercontent.com/getlantern/radiance/main/kindling/dnstt/dnstt.yml.gz"
 const pollInterval = 12 * time.Hour
 const tracerName = "github.com/getlantern/radiance/kindling/dnstt"
 
 // DNSTTOptions load the embedded DNSTT config and return kindling options so
 // it can be used as one of the transport options. If the local config filepath
 // is provided and exists, this config will be loaded and if successfully
-// parsed, will be returned instead of the embedded config.
-func DNSTTOptions(ctx context.Context, localConfigFilepath string, logger io.Writer) (dnstt.DNSTT, error) {
+// parsed, will be returned instead of the embedded config. Tunnels that worked
+// are remembered in the same directory and probed first on the next start.
+func DNSTTOptions(ctx context.Context, localConfigFilepath string, logger io.Writer, opts ...Option) (dnstt.DNSTT, error) {
 	ctx, span := otel.Tracer(tracerName).Start(
 		ctx,
 		"DNSTTOptions",
@@ -72,7 +75,7 @@ func DNSTTOptions(ctx context.Context, localConfigFilepath string, logger io.Wri
 	if localConfigFilepath != "" {
 		localConfigMutex.Lock()
 		defer localConfigMutex.Unlock()
-		if config, err := os.ReadFile(localConfigFilepath); err == nil {
+		if config, err := atomicfile.ReadFile(localConfigFilepath); err == nil {
 			opts, err := parseDNSTTConfigs(config)
 			if err != nil {
 				span.RecordError(err)
@@ -86,11 +89,21 @@ func DNSTTOptions(ctx context.Context, localConfigFilepath string, logger io.Wri
 			slog.Warn("failed to read local dnstt config file", slog.Any("error", err), slog.String("filepath", localConfigFilepath))
 		}
 	}
+	knownTunnelsPath := ""
+	if localConfigFilepath != "" {
+		knownTunnelsPath = filepath.Join(filepath.Dir(localConfigFilepath), knownTunnelsFile)
+	}
+	known := loadKnownTunnels(knownTunnelsPath, time.Now())
 	m := &multipleDNSTTTransport{
 		tunChan:  make(chan *dnsTunnel, maxWorkingTunnels),
 		stopChan: make(chan struct{}),
 		probeCh:  make(chan struct{}, 1),
-		configs:  options,
+		configs:  known.order(options),
+		known:    known,
+		policy:   ProbePolicy{}.withDefaults(),
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
 	m.crawlOnce.Do(func() {
 		go func() {
@@ -104,13 +117,17 @@ func DNSTTOptions(ctx context.Context, localConfigFilepath string, logger io.Wri
 	})
 
 	// starting config updater/fetcher
-	client, err := smart.NewHTTPClientWithSmartTransport(logger, dnsttConfigURL)
+	var mirrors []smart.Mirror
+	client, err := smart.NewHTTPClientWithSmartTransport(logger, ConfigURL)
 	if err != nil {
 		span.RecordError(err)
 		slog.Error("couldn't create http client for fetching dnstt configs", slog.Any("error", err))
+	} else {
+		mirrors = append(mirrors, smart.Mirror{URL: ConfigURL, Client: client})
 	}
+	mirrors = append(mirrors, m.configMirrors...)
 
-	dnsttConfigUpdate(ctx, localConfigFilepath, client)
+	dnsttConfigUpdate(ctx, localConfigFilepath, mirrors)
 	return m, nil
 }
 
@@ -146,13 +163,15 @@ func dnsttConfigValidator() func([]byte) error {
 	}
 }
 
-func dnsttConfigUpdate(ctx context.Context, localConfigPath string, httpClient *http.Client) {
-	if httpClient == nil || localConfigPath == "" {
-		slog.Warn("missing http client or local config path parameters, required for updating dnstt configuration")
+// dnsttConfigUpdate keeps the config at localConfigPath current, fetching it from the first of
+// mirrors that responds.
+func dnsttConfigUpdate(ctx context.Context, localConfigPath string, mirrors []smart.Mirror) {
+	if len(mirrors) == 0 || localConfigPath == "" {
+		slog.Warn("missing config mirrors or local config path parameters, required for updating dnstt configuration")
 		return
 	}
-	slog.Debug("Updating dnstt configuration", slog.String("url", dnsttConfigURL))
-	source := keepcurrent.FromWebWithClient(dnsttConfigURL, httpClient)
+	slog.Debug("Updating dnstt configuration", slog.Int("mirrors", len(mirrors)))
+	source := smart.NewMirrorSource(mirrors...)
 	chDB := make(chan []byte)
 	dest := keepcurrent.ToChannel(chDB)
 	runner := keepcurrent.NewWithValidator(
@@ -261,14 +280,20 @@ var waitFor = 5 * time.Minute
 
 func (m *multipleDNSTTTransport) findWorkingDNSTunnels() {
 	go m.tryAllDNSTunnels()
-	ticker := time.NewTicker(probeInterval)
-	defer ticker.Stop()
+	timer := time.NewTimer(m.policy.interval())
+	defer timer.Stop()
 	for {
 		select {
 		case <-m.stopChan:
 			slog.Debug("stopping parallel dialing dns tunnels")
 			return
-		case <-ticker.C:
+		case <-timer.C:
+			// The interval is picked anew each time, as the device may have gone on or off battery.
+			timer.Reset(m.policy.interval())
+			if m.policy.PauseWhileHealthy && len(m.tunChan) > 0 {
+				slog.Debug("dns tunnel pool is healthy, skipping scheduled probe", slog.Int("working", len(m.tunChan)))
+				continue
+			}
 			m.tryAllDNSTunnels()
 		case <-m.probeCh:
 			m.tryAllDNSTunnels()
@@ -303,16 +328,13 @@ func (m *multipleDNSTTTransport) tryAllDNSTunnels() {
 
 	slog.Debug("selecting dnstt options with active probing", slog.Int("options", len(m.configs)))
 
-	pondCtx, cancel := context.WithTimeout(context.Background(), waitFor)
+	pondCtx, cancel := context.WithTimeout(context.Background(), m.policy.Timeout)
 	m.probeCancelMx.Lock()
 	m.probeCancelFn = cancel
 	m.probeCancelMx.Unlock()
 	defer cancel()
 
-	poolSize := 10
-	if len(m.configs) < poolSize {
-		poolSize = len(m.configs)
-	}
+	poolSize := min(m.policy.MaxConcurrentProbes, len(m.configs))
 
 	pool := pond.New(poolSize, 10, pond.Context(pondCtx))
 	start := m.probeCursor
@@ -336,7 +358,7 @@ func (m *multipleDNSTTTransport) tryAllDNSTunnels() {
 				slog.Debug("failed to create dnstt instance", slog.String("domain", cfg.Domain), slog.String("resolver", resolver), slog.Any("error", err))
 				return
 			}
-			tun := &dnsTunnel{DNSTT: dnstImpl, domain: cfg.Domain, resolver: resolver}
+			tun := &dnsTunnel{DNSTT: dnstImpl, domain: cfg.Domain, resolver: resolver, known: m.known}
 
 			rt, err := tun.NewRoundTripper(pondCtx, "")
 			if err != nil {
@@ -380,7 +402,7 @@ func (m *multipleDNSTTTransport) tryAllDNSTunnels() {
 		})
 	}
 	m.probeCursor = (start + tested) % len(m.configs)
-	pool.StopAndWaitFor(waitFor)
+	pool.StopAndWaitFor(m.policy.Timeout)
 }
 
 const probeInterval = 5 * time.Minute
@@ -431,6 +453,15 @@ type multipleDNSTTTransport struct {
 	// successive cycles spread probing across all configs instead of always
 	// re-testing the same prefix. Guarded by probing.
 	probeCursor int
+
+	// known holds the tunnels that worked before. configs is ordered with
+	// them first, so the first probe cycle after a restart tries them first.
+	known *knownTunnels
+
+	policy ProbePolicy
+
+	// configMirrors are where the config is fetched from when ConfigURL can't be reached.
+	configMirrors []smart.Mirror
 }
 
 // maxWorkingTunnels caps how many established tunnels tunChan retains. Once
@@ -448,6 +479,7 @@ type dnsTunnel struct {
 	dnstt.DNSTT
 	domain   string
 	resolver string
+	known    *knownTunnels
 
 	lastSucceeded       time.Time
 	consecutiveFailures int
@@ -455,19 +487,25 @@ type dnsTunnel struct {
 }
 
 func (t *dnsTunnel) markSucceeded() {
+	now := time.Now()
 	t.mx.Lock()
-	defer t.mx.Unlock()
-	t.lastSucceeded = time.Now()
+	t.lastSucceeded = now
 	t.consecutiveFailures = 0
+	t.mx.Unlock()
+	t.known.succeeded(t.domain, t.resolver, now)
 }
 
 func (t *dnsTunnel) recordFailure() {
 	t.mx.Lock()
-	defer t.mx.Unlock()
 	t.consecutiveFailures++
+	discarded := t.consecutiveFailures == maxTunnelFailures
 	if t.consecutiveFailures >= maxTunnelFailures {
 		t.lastSucceeded = time.Time{}
 	}
+	t.mx.Unlock()
+	if discarded {
+		t.known.forget(t.domain, t.resolver)
+	}
 }
 
 func (t *dnsTunnel) isSucceeding() bool {
@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/getlantern/radiance/events"
+	"github.com/getlantern/radiance/kindling/smart"
 )
 
 type roundTripperFunc func(*http.Request) (*http.Response, error)
@@ -99,7 +100,7 @@ dnsttConfigs:
 			client := &http.Client{Transport: rt}
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			dnsttConfigUpdate(ctx, filepath.Join(t.TempDir(), "dnstt.yml.gz"), client)
+			dnsttConfigUpdate(ctx, filepath.Join(t.TempDir(), "dnstt.yml.gz"), []smart.Mirror{{URL: ConfigURL, Client: client}})
 			if tt.expectUpdate {
 				assert.Eventually(t, func() bool {
 					_, ok := <-updated
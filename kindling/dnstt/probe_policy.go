@@ -0,0 +1,58 @@
+package dnstt
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ProbePolicy bounds how much work the transport spends looking for working tunnels. Each probe
+// runs a DNSTT session over DoH/DoT, which is costly on metered and battery-powered devices.
+// Zero fields take the defaults.
+type ProbePolicy struct {
+	// MaxConcurrentProbes is how many tunnels are probed at once. Defaults to 10.
+	MaxConcurrentProbes int
+	// Interval is how often probing resumes to replace tunnels that stopped working. Defaults
+	// to 5 minutes.
+	Interval time.Duration
+	// Timeout bounds a single probe cycle. Defaults to 5 minutes.
+	Timeout time.Duration
+	// BatteryBackoff multiplies Interval while the device is on battery (see [SetOnBattery]).
+	// Defaults to 4; 1 disables it.
+	BatteryBackoff int
+	// PauseWhileHealthy skips scheduled probe cycles while the pool holds a working tunnel,
+	// rather than topping the pool up. Running out of tunnels still triggers a probe.
+	PauseWhileHealthy bool
+}
+
+func (p ProbePolicy) withDefaults() ProbePolicy {
+	if p.MaxConcurrentProbes <= 0 {
+		p.MaxConcurrentProbes = 10
+	}
+	if p.Interval <= 0 {
+		p.Interval = probeInterval
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = waitFor
+	}
+	if p.BatteryBackoff <= 0 {
+		p.BatteryBackoff = 4
+	}
+	return p
+}
+
+// interval returns how long to wait before the next scheduled probe cycle.
+func (p ProbePolicy) interval() time.Duration {
+	if onBattery.Load() {
+		return p.Interval * time.Duration(p.BatteryBackoff)
+	}
+	return p.Interval
+}
+
+var onBattery atomic.Bool
+
+// SetOnBattery sets whether the device is running on battery, which stretches the interval
+// between scheduled probe cycles by [ProbePolicy.BatteryBackoff]. Platforms that can tell
+// should call it whenever the power source changes. It takes effect from the next cycle.
+func SetOnBattery(battery bool) {
+	onBattery.Store(battery)
+}
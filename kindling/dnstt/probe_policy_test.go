@@ -0,0 +1,24 @@
+package dnstt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbePolicy(t *testing.T) {
+	t.Cleanup(func() { SetOnBattery(false) })
+
+	m := &multipleDNSTTTransport{}
+	WithProbePolicy(ProbePolicy{MaxConcurrentProbes: 2, Interval: time.Minute})(m)
+	assert.Equal(t, 2, m.policy.MaxConcurrentProbes)
+	assert.Equal(t, waitFor, m.policy.Timeout, "unset fields take the defaults")
+	assert.Equal(t, time.Minute, m.policy.interval())
+
+	SetOnBattery(true)
+	assert.Equal(t, 4*time.Minute, m.policy.interval(), "probing backs off on battery")
+
+	WithProbePolicy(ProbePolicy{Interval: time.Minute, BatteryBackoff: 1})(m)
+	assert.Equal(t, time.Minute, m.policy.interval())
+}
@@ -0,0 +1,122 @@
+// Package ech provides a kindling transport that connects directly to origins with a TLS
+// ClientHello that mimics a browser's. Where the origin publishes an ECH config in DNS, the
+// ClientHello is also encrypted with Encrypted Client Hello, so the only server name on the wire
+// is its provider's public name. In many regions that alone gets past SNI filtering, at little
+// more cost than a plain connection.
+package ech
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	utls "github.com/refraction-networking/utls"
+	"golang.org/x/net/http2"
+
+	"github.com/getlantern/radiance/bypass"
+)
+
+// Name identifies the ECH transport to kindling.
+const Name = "ech"
+
+// Transport is a kindling transport that connects directly to origins with uTLS, using ECH
+// where the origin supports it.
+type Transport struct {
+	dial    func(ctx context.Context, network, addr string) (net.Conn, error)
+	configs *configCache
+	helloID utls.ClientHelloID
+	// rootCAs are the CAs server certificates are verified against, or nil for the system's.
+	rootCAs *x509.CertPool
+}
+
+// NewTransport returns a transport that looks up ECH configs with the given DNS-over-HTTPS
+// resolvers, tried in order, or with well-known public resolvers if none are given. Origins and
+// resolvers are dialed outside the VPN tunnel.
+func NewTransport(resolvers ...string) *Transport {
+	if len(resolvers) == 0 {
+		resolvers = defaultResolvers
+	}
+	return &Transport{
+		dial:    bypass.DialContext,
+		configs: newConfigCache(newDoHResolver(resolvers).lookupECH),
+		helloID: utls.HelloChrome_Auto,
+	}
+}
+
+// Name returns [Name].
+func (t *Transport) Name() string { return Name }
+
+// MaxLength returns 0, as a direct connection has no request size limit.
+func (t *Transport) MaxLength() int { return 0 }
+
+// IsStreamable returns true.
+func (t *Transport) IsStreamable() bool { return true }
+
+// RequestTimeout returns 0 to use kindling's default.
+func (t *Transport) RequestTimeout() time.Duration { return 0 }
+
+// NewRoundTripper connects to addr and returns a round tripper that sends requests over that
+// connection. The connection uses ECH if the origin publishes a config; if the origin rejects
+// the config but offers another, the handshake is retried once with that one.
+func (t *Transport) NewRoundTripper(ctx context.Context, addr string) (http.RoundTripper, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	conn, err := t.handshake(ctx, addr, host, t.configs.get(ctx, host))
+	var rejection *utls.ECHRejectionError
+	if errors.As(err, &rejection) && len(rejection.RetryConfigList) > 0 {
+		t.configs.put(host, rejection.RetryConfigList, minTTL)
+		conn, err = t.handshake(ctx, addr, host, rejection.RetryConfigList)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return roundTripper(conn)
+}
+
+func (t *Transport) handshake(ctx context.Context, addr, host string, echConfigs []byte) (*utls.UConn, error) {
+	raw, err := t.dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dialing %s: %w", addr, err)
+	}
+	cfg := &utls.Config{
+		ServerName:                     host,
+		RootCAs:                        t.rootCAs,
+		EncryptedClientHelloConfigList: echConfigs,
+	}
+	if echConfigs != nil {
+		cfg.MinVersion = utls.VersionTLS13
+	}
+	conn := utls.UClient(raw, cfg, t.helloID)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("tls handshake with %s: %w", host, err)
+	}
+	return conn, nil
+}
+
+// roundTripper returns a round tripper that sends requests over conn, using HTTP/2 if the
+// server chose it. The browser fingerprint offers both HTTP/2 and HTTP/1.1, and net/http can't
+// tell which was negotiated on a connection that isn't a *tls.Conn.
+func roundTripper(conn *utls.UConn) (http.RoundTripper, error) {
+	if conn.ConnectionState().NegotiatedProtocol == http2.NextProtoTLS {
+		cc, err := (&http2.Transport{}).NewClientConn(conn)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("starting http2 connection: %w", err)
+		}
+		return cc, nil
+	}
+	return &http.Transport{
+		DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return conn, nil
+		},
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: 4 * time.Second,
+	}, nil
+}
@@ -0,0 +1,108 @@
+package ech
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	utls "github.com/refraction-networking/utls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// newECHKey returns a server ECH key and the ECHConfigList a client needs to use it.
+func newECHKey(t *testing.T, id uint8) (tls.EncryptedClientHelloKey, []byte) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	var b cryptobyte.Builder
+	b.AddUint16(0xfe0d) // version
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint8(id)
+		b.AddUint16(0x0020) // DHKEM(X25519, HKDF-SHA256)
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(priv.PublicKey().Bytes()) })
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddUint16(0x0001) // HKDF-SHA256
+			b.AddUint16(0x0001) // AES-128-GCM
+		})
+		b.AddUint8(0) // maximum name length
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes([]byte("public.example")) })
+		b.AddUint16(0) // extensions
+	})
+	config := b.BytesOrPanic()
+	var list cryptobyte.Builder
+	list.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(config) })
+	return tls.EncryptedClientHelloKey{Config: config, PrivateKey: priv.Bytes(), SendAsRetry: true}, list.BytesOrPanic()
+}
+
+// newServer starts a TLS server with ECH keys that reports the protocol and whether ECH was
+// accepted, and a transport that connects to it whatever the address.
+func newServer(t *testing.T, http2 bool, keys []tls.EncryptedClientHelloKey, lookup lookupFunc) *Transport {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s ech=%t", r.Proto, r.TLS.ECHAccepted)
+	}))
+	srv.EnableHTTP2 = http2
+	srv.TLS = &tls.Config{EncryptedClientHelloKeys: keys}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	return &Transport{
+		dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+		},
+		configs: newConfigCache(lookup),
+		helloID: utls.HelloChrome_Auto,
+		rootCAs: roots,
+	}
+}
+
+func get(t *testing.T, tr *Transport) string {
+	rt, err := tr.NewRoundTripper(context.Background(), "example.com:443")
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestTransport(t *testing.T) {
+	key, configs := newECHKey(t, 1)
+	_, staleConfigs := newECHKey(t, 2)
+
+	t.Run("ECH", func(t *testing.T) {
+		tr := newServer(t, true, []tls.EncryptedClientHelloKey{key}, func(context.Context, string) ([]byte, time.Duration, error) {
+			return configs, time.Hour, nil
+		})
+		assert.Equal(t, "HTTP/2.0 ech=true", get(t, tr))
+	})
+
+	t.Run("retries with the config the server offers", func(t *testing.T) {
+		tr := newServer(t, false, []tls.EncryptedClientHelloKey{key}, func(context.Context, string) ([]byte, time.Duration, error) {
+			return staleConfigs, time.Hour, nil
+		})
+		assert.Equal(t, "HTTP/1.1 ech=true", get(t, tr))
+		assert.Equal(t, configs, tr.configs.get(context.Background(), "example.com"), "the offered config replaces the stale one")
+	})
+
+	t.Run("without ECH", func(t *testing.T) {
+		tr := newServer(t, true, []tls.EncryptedClientHelloKey{key}, func(context.Context, string) ([]byte, time.Duration, error) {
+			return nil, 0, nil
+		})
+		assert.Equal(t, "HTTP/2.0 ech=false", get(t, tr))
+	})
+}
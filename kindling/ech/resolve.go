@@ -0,0 +1,172 @@
+package ech
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/getlantern/radiance/bypass"
+)
+
+// defaultResolvers are queried for ECH configs when NewTransport is given none. The system
+// resolver isn't used, as it's the one most likely to be tampered with.
+var defaultResolvers = []string{
+	"https://cloudflare-dns.com/dns-query",
+	"https://dns.google/dns-query",
+}
+
+const (
+	// minTTL and maxTTL bound how long a looked up config is cached, whatever its record's TTL.
+	minTTL = time.Minute
+	maxTTL = time.Hour
+	// missTTL is how long to wait before looking up a config again after finding none, so that
+	// origins without ECH, or a blocked resolver, don't cost a lookup on every connection.
+	missTTL = 10 * time.Minute
+	// lookupTimeout bounds a lookup across all resolvers.
+	lookupTimeout = 5 * time.Second
+)
+
+type lookupFunc func(ctx context.Context, host string) (configs []byte, ttl time.Duration, err error)
+
+type cacheEntry struct {
+	configs []byte
+	expires time.Time
+}
+
+// configCache caches the ECH config lists of hosts, including the absence of one.
+type configCache struct {
+	lookup  lookupFunc
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func newConfigCache(lookup lookupFunc) *configCache {
+	return &configCache{lookup: lookup, entries: make(map[string]cacheEntry)}
+}
+
+// get returns the ECH config list for host, or nil if it has none or it couldn't be looked up.
+func (c *configCache) get(ctx context.Context, host string) []byte {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.configs
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	configs, ttl, err := c.lookup(ctx, host)
+	if err != nil {
+		slog.Debug("failed to look up ECH config, connecting without ECH", slog.String("host", host), slog.Any("error", err))
+	}
+	if configs == nil {
+		ttl = missTTL
+	}
+	c.put(host, configs, ttl)
+	return configs
+}
+
+func (c *configCache) put(host string, configs []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[host] = cacheEntry{configs: configs, expires: time.Now().Add(min(max(ttl, minTTL), maxTTL))}
+}
+
+// dohResolver looks up ECH configs in HTTPS records with DNS over HTTPS.
+type dohResolver struct {
+	urls   []string
+	client *http.Client
+}
+
+func newDoHResolver(urls []string) *dohResolver {
+	return &dohResolver{
+		urls: urls,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext:       bypass.DialContext,
+				ForceAttemptHTTP2: true,
+				IdleConnTimeout:   90 * time.Second,
+			},
+		},
+	}
+}
+
+// lookupECH returns the ECH config list published in host's HTTPS records, trying each resolver
+// in turn until one answers. It returns nil configs and no error if host publishes none.
+func (r *dohResolver) lookupECH(ctx context.Context, host string) ([]byte, time.Duration, error) {
+	var errs []error
+	for _, url := range r.urls {
+		msg, err := r.query(ctx, url, host)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		configs, ttl := echFromAnswer(msg)
+		return configs, ttl, nil
+	}
+	return nil, 0, errors.Join(errs...)
+}
+
+func (r *dohResolver) query(ctx context.Context, url, host string) (*dns.Msg, error) {
+	q := new(dns.Msg)
+	q.SetQuestion(dns.Fqdn(host), dns.TypeHTTPS)
+	// RFC 8484 recommends an ID of 0 so responses can be cached.
+	q.Id = 0
+	packed, err := q.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("packing query: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	msg := new(dns.Msg)
+	if err := msg.Unpack(body); err != nil {
+		return nil, fmt.Errorf("unpacking response: %w", err)
+	}
+	if msg.Rcode != dns.RcodeSuccess && msg.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("resolver answered %s", dns.RcodeToString[msg.Rcode])
+	}
+	return msg, nil
+}
+
+// echFromAnswer returns the first ECH config list in msg's HTTPS records, with the record's TTL.
+func echFromAnswer(msg *dns.Msg) ([]byte, time.Duration) {
+	for _, rr := range msg.Answer {
+		https, ok := rr.(*dns.HTTPS)
+		if !ok {
+			continue
+		}
+		for _, kv := range https.Value {
+			if ech, ok := kv.(*dns.SVCBECHConfig); ok && len(ech.ECH) > 0 {
+				return ech.ECH, time.Duration(https.Hdr.Ttl) * time.Second
+			}
+		}
+	}
+	return nil, 0
+}
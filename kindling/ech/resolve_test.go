@@ -0,0 +1,72 @@
+package ech
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoHResolver(t *testing.T) {
+	echConfigs := []byte{0x00, 0x01, 0x02}
+	doh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		q := new(dns.Msg)
+		require.NoError(t, q.Unpack(body))
+		resp := new(dns.Msg)
+		resp.SetReply(q)
+		if q.Question[0].Name == "ech.example.com." {
+			resp.Answer = append(resp.Answer, &dns.HTTPS{SVCB: dns.SVCB{
+				Hdr:      dns.RR_Header{Name: q.Question[0].Name, Rrtype: dns.TypeHTTPS, Class: dns.ClassINET, Ttl: 300},
+				Priority: 1,
+				Target:   ".",
+				Value:    []dns.SVCBKeyValue{&dns.SVCBAlpn{Alpn: []string{"h2"}}, &dns.SVCBECHConfig{ECH: echConfigs}},
+			}})
+		}
+		packed, err := resp.Pack()
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(packed)
+	}))
+	defer doh.Close()
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer blocked.Close()
+
+	r := &dohResolver{urls: []string{blocked.URL, doh.URL}, client: doh.Client()}
+	configs, ttl, err := r.lookupECH(context.Background(), "ech.example.com")
+	require.NoError(t, err, "falls through to the next resolver")
+	assert.Equal(t, echConfigs, configs)
+	assert.Equal(t, 300*time.Second, ttl)
+
+	configs, _, err = r.lookupECH(context.Background(), "plain.example.com")
+	require.NoError(t, err)
+	assert.Nil(t, configs)
+
+	_, _, err = (&dohResolver{urls: []string{blocked.URL}, client: blocked.Client()}).lookupECH(context.Background(), "ech.example.com")
+	assert.Error(t, err)
+}
+
+func TestConfigCache(t *testing.T) {
+	lookups := 0
+	c := newConfigCache(func(ctx context.Context, host string) ([]byte, time.Duration, error) {
+		lookups++
+		return nil, 0, errors.New("resolver blocked")
+	})
+	assert.Nil(t, c.get(context.Background(), "df.iantem.io"))
+	assert.Nil(t, c.get(context.Background(), "df.iantem.io"))
+	assert.Equal(t, 1, lookups, "a failed lookup isn't repeated right away")
+
+	c.put("df.iantem.io", []byte{1}, time.Second)
+	assert.Equal(t, []byte{1}, c.get(context.Background(), "df.iantem.io"))
+	assert.Equal(t, 1, lookups)
+}
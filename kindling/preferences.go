@@ -0,0 +1,278 @@
+package kindling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/getlantern/radiance/common/atomicfile"
+	"github.com/getlantern/radiance/common/fileperm"
+)
+
+const (
+	transportStatsFile = "transport_stats.json"
+	// kindlingMethodHeader is set by kindling on the request sent over the transport that won the
+	// race.
+	kindlingMethodHeader = "X-Kindling-Method"
+
+	// recentWins is how many race winners are kept per network. Only recent races count, so a
+	// transport that gets blocked stops being preferred once others win in its place.
+	recentWins = 20
+	// minWins is how many of the recent races a transport must have won to be preferred.
+	minWins = 3
+	// maxNetworks bounds the networks remembered; the least recently seen is forgotten first.
+	maxNetworks = 32
+	// headStart is how long the other transports wait before connecting when one is preferred.
+	// It's short so that a preferred transport that has since been blocked costs little.
+	headStart = 2 * time.Second
+)
+
+// newRoundTripperFunc creates a pre-connected round tripper to addr, as kindling transports do.
+type newRoundTripperFunc = func(ctx context.Context, addr string) (http.RoundTripper, error)
+
+type networkStats struct {
+	// Winners are the transports that won the most recent races, oldest first.
+	Winners []string `json:"winners"`
+	// LatencyMs is a moving average of each transport's time to a response in the races it won.
+	LatencyMs map[string]float64 `json:"latencyMs"`
+	LastSeen  time.Time          `json:"lastSeen"`
+}
+
+// transportStats records which transport wins kindling's race on each network, so that the one
+// that has been fastest there can be given a head start when kindling is next built.
+type transportStats struct {
+	mu   sync.Mutex
+	path string
+	// Network is the network the device was last seen on, which is assumed to be the current one
+	// until [SetNetwork] says otherwise.
+	Network  string                   `json:"network"`
+	Networks map[string]*networkStats `json:"networks"`
+}
+
+var stats = &transportStats{}
+
+// load reads the stats persisted at path, unless they're already loaded.
+func (s *transportStats) load(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == path {
+		return
+	}
+	s.path = path
+	s.Network = ""
+	s.Networks = nil
+	data, err := atomicfile.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to read transport stats", slog.Any("error", err))
+		}
+		return
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		slog.Warn("failed to parse transport stats", slog.Any("error", err))
+	}
+}
+
+// save persists the stats. Must be called with mu held.
+func (s *transportStats) save() {
+	if s.path == "" {
+		return
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		slog.Error("failed to marshal transport stats", slog.Any("error", err))
+		return
+	}
+	if err := atomicfile.WriteFile(s.path, data, fileperm.File); err != nil {
+		slog.Error("failed to write transport stats", slog.Any("error", err))
+	}
+}
+
+func (s *transportStats) setNetwork(network string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Network == network {
+		return
+	}
+	s.Network = network
+	s.save()
+}
+
+// record notes that transport won a race on the current network, getting a response after
+// latency.
+func (s *transportStats) record(transport string, latency time.Duration, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Networks == nil {
+		s.Networks = make(map[string]*networkStats)
+	}
+	ns := s.Networks[s.Network]
+	if ns == nil {
+		if len(s.Networks) >= maxNetworks {
+			oldest := ""
+			for network, n := range s.Networks {
+				if oldest == "" || n.LastSeen.Before(s.Networks[oldest].LastSeen) {
+					oldest = network
+				}
+			}
+			delete(s.Networks, oldest)
+		}
+		ns = &networkStats{LatencyMs: make(map[string]float64)}
+		s.Networks[s.Network] = ns
+	}
+	ns.LastSeen = now
+	ns.Winners = append(ns.Winners, transport)
+	if len(ns.Winners) > recentWins {
+		ns.Winners = slices.Delete(ns.Winners, 0, len(ns.Winners)-recentWins)
+	}
+	ms := float64(latency.Milliseconds())
+	if avg, ok := ns.LatencyMs[transport]; ok {
+		ms = 0.7*avg + 0.3*ms
+	}
+	ns.LatencyMs[transport] = ms
+	s.save()
+}
+
+// preferred returns the enabled transport that has won the most recent races on the current
+// network, with the lower average latency breaking ties, or "" if none has won enough of them.
+func (s *transportStats) preferred(enabled []TransportName) TransportName {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ns := s.Networks[s.Network]
+	if ns == nil {
+		return ""
+	}
+	wins := make(map[TransportName]int)
+	for _, w := range ns.Winners {
+		if name := TransportName(w); slices.Contains(enabled, name) {
+			wins[name]++
+		}
+	}
+	var best TransportName
+	for _, name := range slices.Sorted(maps.Keys(wins)) {
+		if wins[name] < minWins {
+			continue
+		}
+		if best == "" || wins[name] > wins[best] ||
+			(wins[name] == wins[best] && ns.LatencyMs[string(name)] < ns.LatencyMs[string(best)]) {
+			best = name
+		}
+	}
+	return best
+}
+
+// SetNetwork sets the network the device is on, which the transport stats are kept by. Platforms
+// that know the Wi-Fi SSID can pass it; otherwise [NetworkForIP] derives one from the public IP.
+// A change takes effect the next time kindling is built.
+func SetNetwork(network string) {
+	stats.setNetwork(network)
+}
+
+// NetworkForIP identifies the network with the given public IP by its /24 (IPv4) or /48 (IPv6)
+// prefix, which stands in for the ASN as that's not known on the device. It returns "" if ip
+// can't be parsed.
+func NetworkForIP(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	bits := 48
+	if addr.Unmap().Is4() {
+		addr, bits = addr.Unmap(), 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
+
+// statsRoundTripper records the transport that served each request it sends, for the transport
+// stats and [Status].
+type statsRoundTripper struct {
+	rt http.RoundTripper
+}
+
+func (s statsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := s.rt.RoundTrip(req)
+	info := newRequestInfo(req, start, resp, err)
+	status.recordRequest(info)
+	if info.Transport != "" && resp.StatusCode < 500 {
+		stats.record(info.Transport, time.Since(start), time.Now())
+	}
+	return resp, err
+}
+
+type transportReplacer interface {
+	ReplaceTransport(name TransportName, rt newRoundTripperFunc) error
+}
+
+// wrapTransports replaces the round tripper generators of the transports in k, given in newRTs,
+// with ones that record their handshakes for [Status] and follow the routing rules. If preferred
+// is set, the other transports wait for it to have a head start.
+func wrapTransports(k transportReplacer, preferred TransportName, newRTs map[TransportName]newRoundTripperFunc) {
+	status.setPreferred(string(preferred))
+	enabled := slices.Collect(maps.Keys(newRTs))
+	for name, newRT := range newRTs {
+		newRT = instrumented(name, newRT)
+		if preferred != "" && name != preferred {
+			newRT = behind(preferred, enabled, newRT)
+		}
+		newRT = routed(name, enabled, newRT)
+		if err := k.ReplaceTransport(name, newRT); err != nil {
+			slog.Error("failed to wrap transport", slog.String("transport", string(name)), slog.Any("error", err))
+		}
+	}
+}
+
+func delayed(newRT newRoundTripperFunc, delay time.Duration) newRoundTripperFunc {
+	return func(ctx context.Context, addr string) (http.RoundTripper, error) {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+		return newRT(ctx, addr)
+	}
+}
+
+// proxylessTransport connects directly with the smart dialer. It's what kindling's
+// WithProxyless adds, built here so that its generator can be wrapped by wrapTransports.
+type proxylessTransport struct {
+	smart *http.Transport
+}
+
+func (t proxylessTransport) Name() string                  { return string(TransportSmart) }
+func (t proxylessTransport) MaxLength() int                { return 0 }
+func (t proxylessTransport) IsStreamable() bool            { return true }
+func (t proxylessTransport) RequestTimeout() time.Duration { return 0 }
+
+func (t proxylessTransport) NewRoundTripper(ctx context.Context, addr string) (http.RoundTripper, error) {
+	conn, err := t.smart.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("smart dial: %w", err)
+	}
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return conn, nil
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   20 * time.Second,
+		ExpectContinueTimeout: 4 * time.Second,
+	}, nil
+}
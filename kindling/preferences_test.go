@@ -0,0 +1,131 @@
+package kindling
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportStatsPreferred(t *testing.T) {
+	path := filepath.Join(t.TempDir(), transportStatsFile)
+	s := &transportStats{}
+	s.load(path)
+	s.setNetwork("203.0.113.0/24")
+	enabled := []TransportName{TransportDomainfront, TransportSmart, TransportAMP}
+	now := time.Now()
+
+	assert.Empty(t, s.preferred(enabled), "no races yet")
+	for range minWins - 1 {
+		s.record(string(TransportSmart), time.Second, now)
+	}
+	assert.Empty(t, s.preferred(enabled), "too few wins")
+
+	s.record(string(TransportSmart), time.Second, now)
+	for range minWins {
+		s.record(string(TransportDomainfront), 100*time.Millisecond, now)
+	}
+	assert.Equal(t, TransportDomainfront, s.preferred(enabled), "ties go to the faster transport")
+	assert.Equal(t, TransportSmart, s.preferred([]TransportName{TransportSmart, TransportAMP}), "only enabled transports are preferred")
+
+	for range recentWins {
+		s.record(string(TransportAMP), time.Second, now)
+	}
+	assert.Equal(t, TransportAMP, s.preferred(enabled), "only recent races count")
+
+	s.setNetwork("198.51.100.0/24")
+	assert.Empty(t, s.preferred(enabled), "stats are kept per network")
+
+	reloaded := &transportStats{}
+	reloaded.load(path)
+	assert.Equal(t, "198.51.100.0/24", reloaded.Network)
+	reloaded.setNetwork("203.0.113.0/24")
+	assert.Equal(t, TransportAMP, reloaded.preferred(enabled))
+}
+
+func TestTransportStatsEviction(t *testing.T) {
+	s := &transportStats{}
+	start := time.Now()
+	for i := range maxNetworks + 1 {
+		s.setNetwork(NetworkForIP(fmt.Sprintf("10.0.%d.1", i)))
+		s.record(string(TransportSmart), time.Second, start.Add(time.Duration(i)*time.Minute))
+	}
+	assert.Len(t, s.Networks, maxNetworks)
+	assert.NotContains(t, s.Networks, "10.0.0.0/24", "the least recently seen network is forgotten")
+}
+
+func TestNetworkForIP(t *testing.T) {
+	assert.Equal(t, "203.0.113.0/24", NetworkForIP("203.0.113.7"))
+	assert.Equal(t, "203.0.113.0/24", NetworkForIP("::ffff:203.0.113.7"))
+	assert.Equal(t, "2001:db8:1::/48", NetworkForIP("2001:db8:1:2::3"))
+	assert.Empty(t, NetworkForIP(""))
+}
+
+type fakeReplacer map[TransportName]newRoundTripperFunc
+
+func (f fakeReplacer) ReplaceTransport(name TransportName, rt newRoundTripperFunc) error {
+	f[name] = rt
+	return nil
+}
+
+func TestWrapTransports(t *testing.T) {
+	prev := status
+	t.Cleanup(func() { status = prev })
+	status = &telemetry{}
+
+	newRT := func(ctx context.Context, addr string) (http.RoundTripper, error) {
+		return http.DefaultTransport, nil
+	}
+	replaced := fakeReplacer{}
+	wrapTransports(replaced, TransportSmart, map[TransportName]newRoundTripperFunc{
+		TransportSmart:       newRT,
+		TransportDomainfront: newRT,
+	})
+	require.Contains(t, replaced, TransportSmart)
+	require.Contains(t, replaced, TransportDomainfront)
+
+	_, err := replaced[TransportSmart](context.Background(), "df.iantem.io:443")
+	require.NoError(t, err, "the preferred transport connects right away")
+	ctx, cancel := context.WithTimeout(context.Background(), headStart/2)
+	defer cancel()
+	_, err = replaced[TransportDomainfront](ctx, "df.iantem.io:443")
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "other transports wait out the head start")
+
+	report := Status()
+	assert.Equal(t, string(TransportSmart), report.Preferred)
+	require.Len(t, report.Transports, 1, "a handshake abandoned with the race isn't counted")
+	assert.Equal(t, 1, report.Transports[0].Handshakes)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestStatsRoundTripper(t *testing.T) {
+	prev := stats
+	t.Cleanup(func() { stats = prev })
+	stats = &transportStats{}
+
+	code := http.StatusOK
+	rt := statsRoundTripper{roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		clone := req.Clone(req.Context())
+		clone.Header.Set(kindlingMethodHeader, string(TransportAMP))
+		return &http.Response{StatusCode: code, Body: http.NoBody, Request: clone}, nil
+	})}
+	req, err := http.NewRequest(http.MethodGet, "https://df.iantem.io/", nil)
+	require.NoError(t, err)
+
+	_, err = rt.RoundTrip(req)
+	require.NoError(t, err)
+	code = http.StatusBadGateway
+	_, err = rt.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{string(TransportAMP)}, stats.Networks[""].Winners, "only usable responses count as wins")
+}
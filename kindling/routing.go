@@ -0,0 +1,220 @@
+package kindling
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getlantern/keepcurrent"
+	"github.com/goccy/go-yaml"
+
+	"github.com/getlantern/radiance/common/atomicfile"
+	"github.com/getlantern/radiance/common/fileperm"
+	radiancesmart "github.com/getlantern/radiance/kindling/smart"
+)
+
+const (
+	// routingConfigURL serves the routing rules every client applies. The config fetched from
+	// the API has no place for them, so they're kept current like the transport configs are.
+	routingConfigURL    = "https://raw.githubusercontent.com/getlantern/radiance/main/kindling/routing.yml.gz"
+	routingConfigFile   = "routing.yml.gz"
+	routingPollInterval = time.Hour
+)
+
+// RoutingRule restricts the transports kindling may use for requests to some domains, e.g. so
+// that requests to a payment provider only go through domain fronting.
+type RoutingRule struct {
+	// Domains are the domains the rule applies to, each including its subdomains.
+	Domains []string `yaml:"domains"`
+	// Transports are the only transports that may be used for requests to Domains.
+	Transports []TransportName `yaml:"transports"`
+}
+
+func (r RoutingRule) matches(host string) bool {
+	return slices.ContainsFunc(r.Domains, func(domain string) bool {
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	})
+}
+
+var (
+	// localRoutes are the rules set with SetRoutingRules, which take precedence over
+	// remoteRoutes, the ones fetched from routingConfigURL.
+	localRoutes  atomic.Pointer[[]RoutingRule]
+	remoteRoutes atomic.Pointer[[]RoutingRule]
+
+	routingConfigMutex sync.Mutex
+)
+
+// SetRoutingRules sets rules for which transports may be used for requests to which domains.
+// For each request, the first rule that matches its host applies, ahead of any fetched from the
+// remote routing config. A host no rule matches may use any transport. Unlike EnabledTransports,
+// the rules take effect without a rebuild.
+func SetRoutingRules(rules []RoutingRule) {
+	localRoutes.Store(normalizeRules(rules))
+}
+
+func normalizeRules(rules []RoutingRule) *[]RoutingRule {
+	normalized := make([]RoutingRule, 0, len(rules))
+	for _, r := range rules {
+		rule := RoutingRule{Transports: r.Transports}
+		for _, domain := range r.Domains {
+			if domain = strings.Trim(strings.ToLower(domain), "."); domain != "" {
+				rule.Domains = append(rule.Domains, domain)
+			}
+		}
+		normalized = append(normalized, rule)
+	}
+	return &normalized
+}
+
+// allowedTransports returns the transports that may be used for requests to host, or nil if
+// any may. A rule none of whose transports is enabled is passed over, so that a domain can't be
+// left with no way to be reached.
+func allowedTransports(host string, enabled []TransportName) []TransportName {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, rules := range []*[]RoutingRule{localRoutes.Load(), remoteRoutes.Load()} {
+		if rules == nil {
+			continue
+		}
+		for _, r := range *rules {
+			if r.matches(host) && slices.ContainsFunc(r.Transports, func(t TransportName) bool {
+				return slices.Contains(enabled, t)
+			}) {
+				return r.Transports
+			}
+		}
+	}
+	return nil
+}
+
+// routedTo reports whether the routing rules let the named transport connect to addr.
+func routedTo(name TransportName, addr string, enabled []TransportName) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	allowed := allowedTransports(host, enabled)
+	return allowed == nil || slices.Contains(allowed, name)
+}
+
+var errNotRouted = errors.New("not allowed for this domain by the routing rules")
+
+// routed makes newRT refuse the connections the routing rules don't let the named transport make,
+// so that kindling's race is left to the transports they do.
+func routed(name TransportName, enabled []TransportName, newRT newRoundTripperFunc) newRoundTripperFunc {
+	return func(ctx context.Context, addr string) (http.RoundTripper, error) {
+		if !routedTo(name, addr, enabled) {
+			return nil, fmt.Errorf("%s: %w", name, errNotRouted)
+		}
+		return newRT(ctx, addr)
+	}
+}
+
+// behind makes newRT give the preferred transport a head start on the connections it may make.
+func behind(preferred TransportName, enabled []TransportName, newRT newRoundTripperFunc) newRoundTripperFunc {
+	late := delayed(newRT, headStart)
+	return func(ctx context.Context, addr string) (http.RoundTripper, error) {
+		if routedTo(preferred, addr, enabled) {
+			return late(ctx, addr)
+		}
+		return newRT(ctx, addr)
+	}
+}
+
+func parseRoutingRules(gzippedYML []byte) ([]RoutingRule, error) {
+	r, err := gzip.NewReader(bytes.NewReader(gzippedYML))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer r.Close()
+	yml, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gzipped file: %w", err)
+	}
+	var cfg struct {
+		Routes []RoutingRule `yaml:"routes"`
+	}
+	if err := yaml.Unmarshal(yml, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	for i, r := range cfg.Routes {
+		if len(r.Domains) == 0 || len(r.Transports) == 0 {
+			return nil, fmt.Errorf("route %d: missing domains or transports", i)
+		}
+	}
+	return cfg.Routes, nil
+}
+
+// loadRoutingRules applies the remote routing rules saved at path by an earlier update, if any.
+func loadRoutingRules(path string) {
+	routingConfigMutex.Lock()
+	data, err := atomicfile.ReadFile(path)
+	routingConfigMutex.Unlock()
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to read routing rules", slog.Any("error", err))
+		}
+		return
+	}
+	rules, err := parseRoutingRules(data)
+	if err != nil {
+		slog.Warn("failed to parse routing rules", slog.Any("error", err))
+		return
+	}
+	remoteRoutes.Store(normalizeRules(rules))
+}
+
+// routingRulesUpdate keeps the remote routing rules current until ctx is done, applying each new
+// version as it's fetched and saving it to localConfigPath.
+func routingRulesUpdate(ctx context.Context, localConfigPath string, mirrors []radiancesmart.Mirror) {
+	chDB := make(chan []byte)
+	runner := keepcurrent.NewWithValidator(
+		func(data []byte) error {
+			if _, err := parseRoutingRules(data); err != nil {
+				slog.Error("failed to validate routing rules", "error", err)
+				return err
+			}
+			return nil
+		},
+		radiancesmart.NewMirrorSource(mirrors...),
+		keepcurrent.ToChannel(chDB),
+	)
+	stopRunner := runner.Start(routingPollInterval)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				stopRunner()
+				return
+			case data, ok := <-chDB:
+				if !ok {
+					return
+				}
+				rules, err := parseRoutingRules(data)
+				if err != nil {
+					continue
+				}
+				slog.Debug("received new routing rules", slog.Int("rules", len(rules)))
+				remoteRoutes.Store(normalizeRules(rules))
+				routingConfigMutex.Lock()
+				err = atomicfile.WriteFile(localConfigPath, data, fileperm.File)
+				routingConfigMutex.Unlock()
+				if err != nil {
+					slog.Error("failed to save routing rules", "error", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,96 @@
+package kindling
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setRoutes(t *testing.T, local, remote []RoutingRule) {
+	t.Cleanup(func() {
+		localRoutes.Store(nil)
+		remoteRoutes.Store(nil)
+	})
+	SetRoutingRules(local)
+	remoteRoutes.Store(normalizeRules(remote))
+}
+
+func TestAllowedTransports(t *testing.T) {
+	enabled := []TransportName{TransportDomainfront, TransportAMP, TransportDNSTunnel, TransportSmart}
+	setRoutes(t,
+		[]RoutingRule{
+			{Domains: []string{"Stripe.com."}, Transports: []TransportName{TransportDomainfront}},
+			{Domains: []string{"paypal.com"}, Transports: []TransportName{TransportSnowflake}},
+		},
+		[]RoutingRule{
+			{Domains: []string{"stripe.com", "paypal.com"}, Transports: []TransportName{TransportAMP}},
+			{Domains: []string{"iantem.io"}, Transports: []TransportName{TransportDNSTunnel, TransportSmart}},
+		},
+	)
+
+	assert.Equal(t, []TransportName{TransportDomainfront}, allowedTransports("api.stripe.com", enabled), "local rules come first")
+	assert.Equal(t, []TransportName{TransportAMP}, allowedTransports("paypal.com", enabled), "rules with no enabled transport are passed over")
+	assert.Equal(t, []TransportName{TransportDNSTunnel, TransportSmart}, allowedTransports("df.iantem.io", enabled))
+	assert.Nil(t, allowedTransports("notstripe.com", enabled), "only subdomains match")
+	assert.Nil(t, allowedTransports("stripe.com", []TransportName{TransportSmart}), "no rule applies without an enabled transport")
+
+	assert.True(t, routedTo(TransportDomainfront, "stripe.com:443", enabled))
+	assert.False(t, routedTo(TransportSmart, "stripe.com:443", enabled))
+	assert.True(t, routedTo(TransportSmart, "example.com:443", enabled))
+}
+
+func TestWrapTransportsRouting(t *testing.T) {
+	prev := status
+	t.Cleanup(func() { status = prev })
+	status = &telemetry{}
+	setRoutes(t, []RoutingRule{{Domains: []string{"stripe.com"}, Transports: []TransportName{TransportDomainfront}}}, nil)
+
+	newRT := func(ctx context.Context, addr string) (http.RoundTripper, error) {
+		return http.DefaultTransport, nil
+	}
+	replaced := fakeReplacer{}
+	wrapTransports(replaced, TransportSmart, map[TransportName]newRoundTripperFunc{
+		TransportSmart:       newRT,
+		TransportDomainfront: newRT,
+	})
+
+	_, err := replaced[TransportSmart](context.Background(), "stripe.com:443")
+	assert.ErrorIs(t, err, errNotRouted)
+	ctx, cancel := context.WithTimeout(context.Background(), headStart/2)
+	defer cancel()
+	_, err = replaced[TransportDomainfront](ctx, "stripe.com:443")
+	require.NoError(t, err, "no head start is given to a transport the rules refuse")
+
+	report := Status()
+	require.Len(t, report.Transports, 1, "refused connections aren't counted as handshakes")
+	assert.Equal(t, string(TransportDomainfront), report.Transports[0].Name)
+}
+
+func TestParseRoutingRules(t *testing.T) {
+	shipped, err := os.ReadFile("routing.yml.gz")
+	require.NoError(t, err)
+	rules, err := parseRoutingRules(shipped)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	gzipped := func(yml string) []byte {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write([]byte(yml))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+	rules, err = parseRoutingRules(gzipped("routes:\n  - domains: [stripe.com]\n    transports: [domainfront, amp]\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []RoutingRule{{Domains: []string{"stripe.com"}, Transports: []TransportName{TransportDomainfront, TransportAMP}}}, rules)
+
+	_, err = parseRoutingRules(gzipped("routes:\n  - domains: [stripe.com]\n"))
+	assert.Error(t, err, "a route needs transports")
+}
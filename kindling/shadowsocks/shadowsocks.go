@@ -0,0 +1,148 @@
+// Package shadowsocks provides a kindling transport that reaches origins through one of the
+// Shadowsocks servers the user has configured, so that a server that works can bootstrap config
+// and auth requests when fronting is blocked. Connections are made with the same Shadowsocks
+// implementation sing-box uses for its outbounds.
+package shadowsocks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	ss "github.com/sagernet/sing-shadowsocks2"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/getlantern/radiance/bypass"
+	"github.com/getlantern/radiance/servers"
+)
+
+// Name identifies the Shadowsocks transport to kindling.
+const Name = "shadowsocks"
+
+// maxAttempts bounds how many servers a single connection tries, so that a long list of dead
+// servers doesn't hold up the race.
+const maxAttempts = 3
+
+// ServerSource lists the servers the transport can connect through. *servers.Manager implements
+// it.
+type ServerSource interface {
+	AllServers() []*servers.Server
+}
+
+// Transport is a kindling transport that connects to origins through Shadowsocks servers. The
+// servers are listed anew for each connection, so servers added or removed take effect right
+// away.
+type Transport struct {
+	servers ServerSource
+	dial    func(ctx context.Context, network, addr string) (net.Conn, error)
+	next    atomic.Uint32
+}
+
+// NewTransport returns a transport that connects through the Shadowsocks servers in src. Servers
+// are dialed outside the VPN tunnel.
+func NewTransport(src ServerSource) *Transport {
+	return &Transport{servers: src, dial: bypass.DialContext}
+}
+
+// Name returns [Name].
+func (t *Transport) Name() string { return Name }
+
+// MaxLength returns 0, as Shadowsocks has no request size limit.
+func (t *Transport) MaxLength() int { return 0 }
+
+// IsStreamable returns true.
+func (t *Transport) IsStreamable() bool { return true }
+
+// RequestTimeout returns 0 to use kindling's default, as a proxy adds little latency.
+func (t *Transport) RequestTimeout() time.Duration { return 0 }
+
+// NewRoundTripper connects to addr through one of the Shadowsocks servers, trying the next if a
+// server can't be reached, and returns a round tripper that sends requests over that
+// connection.
+func (t *Transport) NewRoundTripper(ctx context.Context, addr string) (http.RoundTripper, error) {
+	candidates := usableServers(t.servers.AllServers())
+	if len(candidates) == 0 {
+		return nil, errors.New("no usable shadowsocks servers configured")
+	}
+	dest := M.ParseSocksaddr(addr)
+	if !dest.IsValid() {
+		return nil, fmt.Errorf("invalid address %q", addr)
+	}
+	start := int(t.next.Add(1))
+	var errs []error
+	for i := range min(maxAttempts, len(candidates)) {
+		srv := candidates[(start+i)%len(candidates)]
+		conn, err := t.connect(ctx, srv.opts, dest)
+		if err == nil {
+			return preconnected(conn), nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", srv.tag, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (t *Transport) connect(ctx context.Context, opts *option.ShadowsocksOutboundOptions, dest M.Socksaddr) (net.Conn, error) {
+	method, err := ss.CreateMethod(ctx, opts.Method, ss.MethodOptions{Password: opts.Password})
+	if err != nil {
+		return nil, fmt.Errorf("creating %s cipher: %w", opts.Method, err)
+	}
+	conn, err := t.dial(ctx, "tcp", net.JoinHostPort(opts.Server, strconv.Itoa(int(opts.ServerPort))))
+	if err != nil {
+		return nil, fmt.Errorf("dialing shadowsocks server: %w", err)
+	}
+	// The request header goes out with the first write, saving a round trip.
+	return method.DialEarlyConn(conn, dest), nil
+}
+
+type server struct {
+	tag  string
+	opts *option.ShadowsocksOutboundOptions
+}
+
+// usableServers returns the enabled Shadowsocks servers in list that carry TCP and don't need a
+// plugin, which the transport doesn't run.
+func usableServers(list []*servers.Server) []server {
+	var usable []server
+	for _, srv := range list {
+		if srv.Disabled || srv.Type != constant.TypeShadowsocks {
+			continue
+		}
+		out, ok := srv.Options.(option.Outbound)
+		if !ok {
+			continue
+		}
+		opts, ok := out.Options.(*option.ShadowsocksOutboundOptions)
+		if !ok || opts.Plugin != "" || opts.Server == "" || opts.ServerPort == 0 {
+			continue
+		}
+		if !slices.Contains(opts.Network.Build(), N.NetworkTCP) {
+			continue
+		}
+		usable = append(usable, server{tag: srv.Tag, opts: opts})
+	}
+	return usable
+}
+
+func preconnected(conn net.Conn) *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return conn, nil
+		},
+		ForceAttemptHTTP2:     true,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   20 * time.Second,
+		ExpectContinueTimeout: 4 * time.Second,
+	}
+}
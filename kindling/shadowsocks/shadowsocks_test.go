@@ -0,0 +1,104 @@
+package shadowsocks
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getlantern/radiance/servers"
+)
+
+type serverList []*servers.Server
+
+func (l serverList) AllServers() []*servers.Server { return l }
+
+func ssServer(tag string, port int, configure func(*option.ShadowsocksOutboundOptions)) *servers.Server {
+	opts := &option.ShadowsocksOutboundOptions{
+		ServerOptions: option.ServerOptions{Server: "127.0.0.1", ServerPort: uint16(port)},
+		Method:        "none",
+	}
+	if configure != nil {
+		configure(opts)
+	}
+	return &servers.Server{
+		Tag:     tag,
+		Type:    constant.TypeShadowsocks,
+		Options: option.Outbound{Type: constant.TypeShadowsocks, Tag: tag, Options: opts},
+	}
+}
+
+func TestUsableServers(t *testing.T) {
+	disabled := ssServer("disabled", 8388, nil)
+	disabled.Disabled = true
+	list := []*servers.Server{
+		ssServer("ok", 8388, nil),
+		disabled,
+		ssServer("plugin", 8388, func(o *option.ShadowsocksOutboundOptions) { o.Plugin = "obfs-local" }),
+		ssServer("udp", 8388, func(o *option.ShadowsocksOutboundOptions) { o.Network = "udp" }),
+		{Tag: "vmess", Type: constant.TypeVMess, Options: option.Outbound{Type: constant.TypeVMess}},
+	}
+	usable := usableServers(list)
+	require.Len(t, usable, 1)
+	assert.Equal(t, "ok", usable[0].tag)
+}
+
+func TestTransport(t *testing.T) {
+	// A server using the "none" cipher, which sends the destination address in the clear ahead
+	// of the payload.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	dests := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		dest, err := M.SocksaddrSerializer.ReadAddrPort(conn)
+		if err != nil {
+			return
+		}
+		dests <- dest.String()
+		if _, err := http.ReadRequest(bufio.NewReader(conn)); err != nil {
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 204 No Content\r\n\r\n"))
+	}()
+
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadPort := dead.Addr().(*net.TCPAddr).Port
+	dead.Close()
+
+	tr := &Transport{
+		servers: serverList{
+			ssServer("dead", deadPort, nil),
+			ssServer("live", ln.Addr().(*net.TCPAddr).Port, nil),
+		},
+		dial: (&net.Dialer{}).DialContext,
+	}
+	// Start with the dead server, so the transport has to move on to the live one.
+	tr.next.Store(1)
+	rt, err := tr.NewRoundTripper(context.Background(), "df.iantem.io:80")
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "http://df.iantem.io/", nil)
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "df.iantem.io:80", <-dests)
+
+	_, err = (&Transport{servers: serverList{}}).NewRoundTripper(context.Background(), "df.iantem.io:443")
+	assert.Error(t, err)
+}
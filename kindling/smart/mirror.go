@@ -0,0 +1,64 @@
+package smart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/getlantern/keepcurrent"
+)
+
+// Mirror is a URL a config can be fetched from, with the client to fetch it through.
+type Mirror struct {
+	URL    string
+	Client *http.Client
+}
+
+// mirrorSource fetches from the first of its mirrors that responds, so that a config stays
+// current while some of the mirrors are blocked.
+type mirrorSource struct {
+	mirrors []Mirror
+	sources []keepcurrent.Source
+
+	mu sync.Mutex
+	// last is the mirror that last responded, which is tried first next time.
+	last int
+}
+
+// NewMirrorSource returns a source that tries mirrors in order until one responds, starting
+// from the one that responded last time.
+func NewMirrorSource(mirrors ...Mirror) keepcurrent.Source {
+	s := &mirrorSource{mirrors: mirrors}
+	for _, m := range mirrors {
+		s.sources = append(s.sources, keepcurrent.FromWebWithClient(m.URL, m.Client))
+	}
+	return s
+}
+
+func (s *mirrorSource) Fetch(ifNewerThan time.Time) (io.ReadCloser, error) {
+	if len(s.sources) == 0 {
+		return nil, errors.New("no config mirrors")
+	}
+	s.mu.Lock()
+	start := s.last
+	s.mu.Unlock()
+	var errs []error
+	for i := range s.sources {
+		idx := (start + i) % len(s.sources)
+		rc, err := s.sources[idx].Fetch(ifNewerThan)
+		// keepcurrent compares against ErrUnmodified directly, so it must not be wrapped.
+		if err == nil || errors.Is(err, keepcurrent.ErrUnmodified) {
+			s.mu.Lock()
+			s.last = idx
+			s.mu.Unlock()
+			return rc, err
+		}
+		slog.Debug("config mirror failed, trying the next", slog.String("url", s.mirrors[idx].URL), slog.Any("error", err))
+		errs = append(errs, fmt.Errorf("%s: %w", s.mirrors[idx].URL, err))
+	}
+	return nil, errors.Join(errs...)
+}
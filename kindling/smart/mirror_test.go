@@ -0,0 +1,56 @@
+package smart
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getlantern/keepcurrent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorSource(t *testing.T) {
+	var blockedHits atomic.Int32
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		blockedHits.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer blocked.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("config"))
+	}))
+	defer mirror.Close()
+
+	source := NewMirrorSource(
+		Mirror{URL: blocked.URL, Client: blocked.Client()},
+		Mirror{URL: mirror.URL, Client: mirror.Client()},
+	)
+	rc, err := source.Fetch(time.Time{})
+	require.NoError(t, err, "falls through to the next mirror")
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	rc.Close()
+	assert.Equal(t, "config", string(data))
+	assert.EqualValues(t, 1, blockedHits.Load())
+
+	_, err = source.Fetch(time.Time{})
+	assert.Equal(t, keepcurrent.ErrUnmodified, err)
+	assert.EqualValues(t, 1, blockedHits.Load(), "the mirror that responded last is tried first")
+
+	mirror.Close()
+	_, err = source.Fetch(time.Time{})
+	assert.Error(t, err, "fails once every mirror has")
+	assert.EqualValues(t, 2, blockedHits.Load())
+
+	_, err = NewMirrorSource().Fetch(time.Time{})
+	assert.Error(t, err)
+}
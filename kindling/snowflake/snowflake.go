@@ -0,0 +1,344 @@
+// Package snowflake provides a kindling transport that reaches origins through Snowflake, which
+// relays traffic over WebRTC via volunteer proxies. The proxies are assigned by a broker, whose
+// configuration is embedded and kept current from the radiance repository like the dnstt config.
+package snowflake
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	_ "embed"
+
+	"github.com/getlantern/keepcurrent"
+	"github.com/goccy/go-yaml"
+	sf "gitlab.torproject.org/tpo/anti-censorship/pluggable-transports/snowflake/v2/client/lib"
+	"go.opentelemetry.io/otel"
+
+	"github.com/getlantern/radiance/common/atomicfile"
+	"github.com/getlantern/radiance/common/fileperm"
+	"github.com/getlantern/radiance/events"
+	"github.com/getlantern/radiance/kindling/smart"
+	"github.com/getlantern/radiance/traces"
+)
+
+// Name identifies the Snowflake transport to kindling.
+const Name = "snowflake"
+
+type snowflakeConfig struct {
+	BrokerURL string `yaml:"brokerURL"`
+	// AmpCacheURL, if set, reaches the broker through the AMP cache rather than directly.
+	AmpCacheURL string `yaml:"ampCacheURL,omitempty"`
+	// FrontDomains are domains the broker is reached through, one picked at random per request.
+	FrontDomains []string `yaml:"frontDomains,omitempty"`
+	// ICEServers are the STUN URLs used for NAT traversal.
+	ICEServers []string `yaml:"iceServers"`
+	// BridgeFingerprint tells the broker which Snowflake bridge to match proxies for.
+	BridgeFingerprint string  `yaml:"bridgeFingerprint"`
+	UTLSClientID      *string `yaml:"utlsClientID,omitempty"`
+	// MaxPeers is the number of proxies to connect to at once, defaulting to 1.
+	MaxPeers int `yaml:"maxPeers,omitempty"`
+}
+
+//go:embed snowflake.yml.gz
+var embeddedConfig []byte
+
+var localConfigMutex sync.Mutex
+
+const snowflakeConfigURL = "https://raw.githubusercontent.com/getlantern/radiance/main/kindling/snowflake/snowflake.yml.gz"
+const pollInterval = 12 * time.Hour
+const tracerName = "github.com/getlantern/radiance/kindling/snowflake"
+
+// NewTransport loads the embedded Snowflake config, or the local one at localConfigFilepath if it
+// exists and parses, and returns a kindling transport using its brokers. The config is kept
+// current in the background until ctx is done; updates take effect the next time the transport
+// is created.
+func NewTransport(ctx context.Context, localConfigFilepath string, logger io.Writer) (*Transport, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "NewTransport")
+	defer span.End()
+
+	configs, err := parseSnowflakeConfigs(embeddedConfig)
+	if err != nil {
+		return nil, traces.RecordError(ctx, fmt.Errorf("failed to parse snowflake embedded config: %w", err))
+	}
+	if localConfigFilepath != "" {
+		localConfigMutex.Lock()
+		if config, err := atomicfile.ReadFile(localConfigFilepath); err == nil {
+			cfgs, err := parseSnowflakeConfigs(config)
+			if err != nil {
+				span.RecordError(err)
+				slog.Warn("failed to parse local snowflake config, using embedded snowflake config", slog.Any("error", err))
+			} else {
+				configs = cfgs
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			span.RecordError(err)
+			slog.Warn("failed to read local snowflake config file", slog.Any("error", err), slog.String("filepath", localConfigFilepath))
+		}
+		localConfigMutex.Unlock()
+	}
+
+	// The updater runs even when no broker is usable yet, so that one configured later is picked
+	// up on the next start.
+	client, err := smart.NewHTTPClientWithSmartTransport(logger, snowflakeConfigURL)
+	if err != nil {
+		span.RecordError(err)
+		slog.Error("couldn't create http client for fetching snowflake configs", slog.Any("error", err))
+	}
+	snowflakeConfigUpdate(ctx, localConfigFilepath, client)
+
+	t := &Transport{}
+	for _, cfg := range configs {
+		c, err := newSnowflakeClient(cfg)
+		if err != nil {
+			span.RecordError(err)
+			slog.Warn("failed to create snowflake client", slog.String("broker", cfg.BrokerURL), slog.Any("error", err))
+			continue
+		}
+		t.clients = append(t.clients, c)
+	}
+	if len(t.clients) == 0 {
+		return nil, traces.RecordError(ctx, errors.New("no usable snowflake brokers configured"))
+	}
+	return t, nil
+}
+
+func newSnowflakeClient(cfg snowflakeConfig) (*sf.Transport, error) {
+	config := sf.ClientConfig{
+		BrokerURL:         cfg.BrokerURL,
+		AmpCacheURL:       cfg.AmpCacheURL,
+		FrontDomains:      cfg.FrontDomains,
+		ICEAddresses:      cfg.ICEServers,
+		BridgeFingerprint: cfg.BridgeFingerprint,
+		Max:               cfg.MaxPeers,
+	}
+	if cfg.UTLSClientID != nil {
+		config.UTLSClientID = *cfg.UTLSClientID
+	}
+	return sf.NewSnowflakeClient(config)
+}
+
+func processYaml(gzippedYaml []byte) ([]snowflakeConfig, error) {
+	r, err := gzip.NewReader(bytes.NewReader(gzippedYaml))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer r.Close()
+	yml, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gzipped file: %w", err)
+	}
+	path, err := yaml.PathString("$.snowflakeConfigs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config path: %w", err)
+	}
+	var cfg []snowflakeConfig
+	if err = path.Read(bytes.NewReader(yml), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return cfg, nil
+}
+
+func parseSnowflakeConfigs(gzipyml []byte) ([]snowflakeConfig, error) {
+	cfgs, err := processYaml(gzipyml)
+	if err != nil {
+		return nil, err
+	}
+	for _, cfg := range cfgs {
+		if cfg.BrokerURL == "" || cfg.BridgeFingerprint == "" {
+			return nil, errors.New("missing required parameters")
+		}
+		if len(cfg.ICEServers) == 0 {
+			return nil, errors.New("missing ICE servers")
+		}
+	}
+	return cfgs, nil
+}
+
+func snowflakeConfigValidator() func([]byte) error {
+	return func(data []byte) error {
+		if _, err := parseSnowflakeConfigs(data); err != nil {
+			slog.Error("failed to validate snowflake configuration", "error", err)
+			return err
+		}
+		return nil
+	}
+}
+
+func snowflakeConfigUpdate(ctx context.Context, localConfigPath string, httpClient *http.Client) {
+	if httpClient == nil || localConfigPath == "" {
+		slog.Warn("missing http client or local config path parameters, required for updating snowflake configuration")
+		return
+	}
+	slog.Debug("Updating snowflake configuration", slog.String("url", snowflakeConfigURL))
+	source := keepcurrent.FromWebWithClient(snowflakeConfigURL, httpClient)
+	chDB := make(chan []byte)
+	dest := keepcurrent.ToChannel(chDB)
+	runner := keepcurrent.NewWithValidator(
+		snowflakeConfigValidator(),
+		source,
+		dest,
+	)
+	stopRunner := runner.Start(pollInterval)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				stopRunner()
+				return
+			case data, ok := <-chDB:
+				if !ok {
+					return
+				}
+				if err := onNewSnowflakeConfig(localConfigPath, data); err != nil {
+					slog.Error("failed to handle new snowflake configuration", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// SnowflakeUpdateEvent is emitted with the gzipped YAML of each new Snowflake config fetched.
+type SnowflakeUpdateEvent struct {
+	events.Event
+	YML string
+}
+
+func onNewSnowflakeConfig(configFilepath string, gzippedYML []byte) error {
+	slog.Debug("received new snowflake configs")
+	events.Emit(SnowflakeUpdateEvent{
+		YML: string(gzippedYML),
+	})
+
+	localConfigMutex.Lock()
+	defer localConfigMutex.Unlock()
+	return atomicfile.WriteFile(configFilepath, gzippedYML, fileperm.File)
+}
+
+// Transport is a kindling transport that tunnels each connection through a Snowflake proxy to the
+// bridge, which is asked to connect it on to the origin with an HTTP CONNECT request.
+type Transport struct {
+	clients []*sf.Transport
+	next    atomic.Uint32
+}
+
+// Name returns [Name].
+func (t *Transport) Name() string { return Name }
+
+// MaxLength returns 0, as Snowflake has no request size limit.
+func (t *Transport) MaxLength() int { return 0 }
+
+// IsStreamable returns true.
+func (t *Transport) IsStreamable() bool { return true }
+
+// RequestTimeout allows for the WebRTC handshake with a proxy, which the broker may take tens of
+// seconds to match, on top of the request itself.
+func (t *Transport) RequestTimeout() time.Duration { return 2 * time.Minute }
+
+// Priority races Snowflake after the direct and fronted transports have failed, but ahead of DNS
+// tunneling, which is slower still.
+func (t *Transport) Priority() int { return 50 }
+
+// NewRoundTripper connects to addr through a Snowflake proxy and returns a round tripper that
+// sends requests over that connection.
+func (t *Transport) NewRoundTripper(ctx context.Context, addr string) (http.RoundTripper, error) {
+	client := t.clients[int(t.next.Add(1))%len(t.clients)]
+	conn, err := dial(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("snowflake dial: %w", err)
+	}
+	if conn, err = connect(ctx, conn, addr); err != nil {
+		return nil, err
+	}
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return conn, nil
+		},
+		ForceAttemptHTTP2:     true,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   20 * time.Second,
+		ExpectContinueTimeout: 4 * time.Second,
+	}, nil
+}
+
+// dial opens a connection to the bridge, abandoning it if ctx is done first since the Snowflake
+// client can't cancel a dial.
+func dial(ctx context.Context, client *sf.Transport) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		conn, err := client.Dial()
+		ch <- result{conn, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.conn, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-ch; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// connect asks the bridge at the other end of conn to connect it to addr. conn is closed on
+// failure.
+func connect(ctx context.Context, conn net.Conn, addr string) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("sending CONNECT to snowflake bridge: %w", err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading CONNECT response from snowflake bridge: %w", err)
+	}
+	// The body of a successful CONNECT response is the tunnel itself, so it's left unread, as
+	// net/http does for proxies.
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("snowflake bridge refused CONNECT to %s: %s", addr, resp.Status)
+	}
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection with data already read into r.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,109 @@
+package snowflake
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipYAML(yaml []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write(yaml)
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestParseSnowflakeConfigs(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			yaml: `
+snowflakeConfigs:
+  - brokerURL: https://broker.example.com/
+    frontDomains: [front.example.com]
+    iceServers: [stun:stun.example.com:3478]
+    bridgeFingerprint: 0123456789ABCDEF0123456789ABCDEF01234567
+`,
+			want: 1,
+		},
+		{
+			name: "missing fingerprint",
+			yaml: `
+snowflakeConfigs:
+  - brokerURL: https://broker.example.com/
+    iceServers: [stun:stun.example.com:3478]
+`,
+			wantErr: true,
+		},
+		{
+			name: "missing ICE servers",
+			yaml: `
+snowflakeConfigs:
+  - brokerURL: https://broker.example.com/
+    bridgeFingerprint: 0123456789ABCDEF0123456789ABCDEF01234567
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfgs, err := parseSnowflakeConfigs(gzipYAML([]byte(tt.yaml)))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, cfgs, tt.want)
+		})
+	}
+
+	_, err := parseSnowflakeConfigs(embeddedConfig)
+	assert.NoError(t, err, "embedded config")
+}
+
+func TestConnect(t *testing.T) {
+	// serve reads the CONNECT request from the client end of a pipe and replies with response,
+	// followed by the origin's first bytes.
+	serve := func(t *testing.T, response string) net.Conn {
+		client, bridge := net.Pipe()
+		go func() {
+			defer bridge.Close()
+			req, err := http.ReadRequest(bufio.NewReader(bridge))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, http.MethodConnect, req.Method)
+			assert.Equal(t, "api.example.com:443", req.Host)
+			_, _ = io.WriteString(bridge, response+"hello")
+		}()
+		return client
+	}
+
+	t.Run("accepted", func(t *testing.T) {
+		conn, err := connect(context.Background(), serve(t, "HTTP/1.1 200 OK\r\n\r\n"), "api.example.com:443")
+		require.NoError(t, err)
+		defer conn.Close()
+		got, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(got), "bytes read along with the response aren't lost")
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		_, err := connect(context.Background(), serve(t, "HTTP/1.1 403 Forbidden\r\nContent-Length: 0\r\n\r\n"), "api.example.com:443")
+		assert.ErrorContains(t, err, "403")
+	})
+}
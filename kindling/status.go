@@ -0,0 +1,183 @@
+package kindling
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/getlantern/radiance/events"
+)
+
+// recentRequests is how many requests [Status] reports.
+const recentRequests = 20
+
+// RequestInfo describes a request sent through kindling.
+type RequestInfo struct {
+	Time       time.Time `json:"time"`
+	Host       string    `json:"host"`
+	DurationMs int64     `json:"duration_ms"`
+	// Transport is the transport that served the request, or empty if none did.
+	Transport  string `json:"transport,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// TransportInfo is how a transport has fared since the process started. A transport connects
+// anew for every request it races for, so its handshakes include races it lost.
+type TransportInfo struct {
+	Name string `json:"name"`
+	// Served is the number of requests that got their response over the transport.
+	Served     int `json:"served"`
+	Handshakes int `json:"handshakes"`
+	Failures   int `json:"failures"`
+	// AvgHandshakeMs is a moving average of the time successful handshakes took.
+	AvgHandshakeMs  float64   `json:"avg_handshake_ms"`
+	LastHandshakeMs int64     `json:"last_handshake_ms,omitempty"`
+	LastError       string    `json:"last_error,omitempty"`
+	LastErrorAt     time.Time `json:"last_error_at,omitzero"`
+}
+
+// StatusReport is what [Status] returns.
+type StatusReport struct {
+	// Current is the transport that served the most recent request that got a response.
+	Current string `json:"current,omitempty"`
+	// Preferred is the transport given a head start on the current network, if any.
+	Preferred string `json:"preferred,omitempty"`
+	// Requests are the most recent requests, newest first.
+	Requests   []RequestInfo   `json:"requests"`
+	Transports []TransportInfo `json:"transports"`
+}
+
+// TransportSwitchEvent is emitted when a request is served over a different transport than the
+// previous one.
+type TransportSwitchEvent struct {
+	events.Event
+	From string
+	To   string
+}
+
+type telemetry struct {
+	mu         sync.Mutex
+	current    string
+	preferred  string
+	requests   []RequestInfo
+	transports map[string]*TransportInfo
+}
+
+var status = &telemetry{}
+
+// Status returns which transports served the most recent kindling requests and how each
+// transport's handshakes have fared.
+func Status() StatusReport {
+	return status.report()
+}
+
+func (t *telemetry) report() StatusReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := StatusReport{
+		Current:    t.current,
+		Preferred:  t.preferred,
+		Requests:   slices.Clone(t.requests),
+		Transports: make([]TransportInfo, 0, len(t.transports)),
+	}
+	slices.Reverse(r.Requests)
+	for _, name := range slices.Sorted(maps.Keys(t.transports)) {
+		r.Transports = append(r.Transports, *t.transports[name])
+	}
+	return r
+}
+
+// transport returns the info for the named transport. Must be called with mu held.
+func (t *telemetry) transport(name string) *TransportInfo {
+	if t.transports == nil {
+		t.transports = make(map[string]*TransportInfo)
+	}
+	info := t.transports[name]
+	if info == nil {
+		info = &TransportInfo{Name: name}
+		t.transports[name] = info
+	}
+	return info
+}
+
+func (t *telemetry) setPreferred(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.preferred = name
+}
+
+func (t *telemetry) recordHandshake(name string, took time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	info := t.transport(name)
+	if err != nil {
+		info.Failures++
+		info.LastError = err.Error()
+		info.LastErrorAt = time.Now()
+		return
+	}
+	ms := took.Milliseconds()
+	if info.Handshakes == 0 {
+		info.AvgHandshakeMs = float64(ms)
+	} else {
+		info.AvgHandshakeMs = 0.7*info.AvgHandshakeMs + 0.3*float64(ms)
+	}
+	info.Handshakes++
+	info.LastHandshakeMs = ms
+}
+
+func (t *telemetry) recordRequest(req RequestInfo) {
+	t.mu.Lock()
+	if len(t.requests) == recentRequests {
+		t.requests = slices.Delete(t.requests, 0, 1)
+	}
+	t.requests = append(t.requests, req)
+	if req.Transport == "" {
+		t.mu.Unlock()
+		return
+	}
+	t.transport(req.Transport).Served++
+	prev := t.current
+	t.current = req.Transport
+	t.mu.Unlock()
+	if prev != "" && prev != req.Transport {
+		events.Emit(TransportSwitchEvent{From: prev, To: req.Transport})
+	}
+}
+
+// instrumented records the outcome and duration of each handshake newRT makes for the named
+// transport.
+func instrumented(name TransportName, newRT newRoundTripperFunc) newRoundTripperFunc {
+	return func(ctx context.Context, addr string) (http.RoundTripper, error) {
+		start := time.Now()
+		rt, err := newRT(ctx, addr)
+		// A handshake cut short because another transport won the race says nothing about this
+		// one.
+		if err == nil || ctx.Err() == nil {
+			status.recordHandshake(string(name), time.Since(start), err)
+		}
+		return rt, err
+	}
+}
+
+// newRequestInfo describes a request that was sent at start and ended with resp and err.
+func newRequestInfo(req *http.Request, start time.Time, resp *http.Response, err error) RequestInfo {
+	info := RequestInfo{
+		Time:       start,
+		Host:       req.URL.Hostname(),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.StatusCode = resp.StatusCode
+	if resp.Request != nil {
+		info.Transport = resp.Request.Header.Get(kindlingMethodHeader)
+	}
+	return info
+}
@@ -0,0 +1,53 @@
+package kindling
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getlantern/radiance/events"
+)
+
+func TestTelemetry(t *testing.T) {
+	tel := &telemetry{}
+	switches := make(chan TransportSwitchEvent, 2)
+	sub := events.Subscribe(func(evt TransportSwitchEvent) { switches <- evt })
+	t.Cleanup(sub.Unsubscribe)
+
+	tel.recordHandshake(string(TransportDNSTunnel), 4*time.Second, nil)
+	tel.recordHandshake(string(TransportDNSTunnel), 0, errors.New("no working dnstt tunnels available"))
+	tel.recordRequest(RequestInfo{Host: "df.iantem.io", Transport: string(TransportDNSTunnel), StatusCode: http.StatusOK})
+	tel.recordRequest(RequestInfo{Host: "df.iantem.io", Error: "all transports failed"})
+	tel.recordRequest(RequestInfo{Host: "df.iantem.io", Transport: string(TransportSmart), StatusCode: http.StatusOK})
+
+	select {
+	case evt := <-switches:
+		assert.Equal(t, string(TransportDNSTunnel), evt.From)
+		assert.Equal(t, string(TransportSmart), evt.To)
+	case <-time.After(time.Second):
+		t.Fatal("no switch event")
+	}
+
+	report := tel.report()
+	assert.Equal(t, string(TransportSmart), report.Current)
+	require.Len(t, report.Requests, 3)
+	assert.Equal(t, string(TransportSmart), report.Requests[0].Transport, "newest first")
+	require.Len(t, report.Transports, 2)
+	dnstt := report.Transports[0]
+	assert.Equal(t, string(TransportDNSTunnel), dnstt.Name)
+	assert.Equal(t, 1, dnstt.Served)
+	assert.Equal(t, 1, dnstt.Handshakes)
+	assert.Equal(t, 1, dnstt.Failures)
+	assert.Equal(t, float64(4000), dnstt.AvgHandshakeMs)
+	assert.Equal(t, "no working dnstt tunnels available", dnstt.LastError)
+
+	for range recentRequests {
+		tel.recordRequest(RequestInfo{Host: "api.getiantem.org", Transport: string(TransportSmart)})
+	}
+	assert.Len(t, tel.report().Requests, recentRequests)
+	assert.Empty(t, switches, "no switch while the transport stays the same")
+}
@@ -0,0 +1,50 @@
+package servers
+
+import "github.com/getlantern/radiance/events"
+
+// ServerAddedEvent is emitted when a server is added to the Manager, including when a server is
+// renamed, under its new tag.
+type ServerAddedEvent struct {
+	events.Event
+	Server *Server `json:"server"`
+	// Replaced is true if the server replaced one with the same tag.
+	Replaced bool `json:"replaced,omitempty"`
+}
+
+// ServerRemovedEvent is emitted when a server is removed from the Manager, including when a
+// server is renamed, under its old tag.
+type ServerRemovedEvent struct {
+	events.Event
+	Server *Server `json:"server"`
+}
+
+// ServersReplacedEvent is emitted when the Manager's servers are replaced wholesale, which happens
+// when another process changed servers.json. Servers holds all the servers after the change.
+type ServersReplacedEvent struct {
+	events.Event
+	Servers []*Server `json:"servers"`
+}
+
+// serverChanges collects the servers added and removed by an update, to be emitted once the
+// update has been saved.
+type serverChanges struct {
+	added   []ServerAddedEvent
+	removed []ServerRemovedEvent
+}
+
+func (c *serverChanges) add(srv *Server, replaced bool) {
+	c.added = append(c.added, ServerAddedEvent{Server: srv.Clone(), Replaced: replaced})
+}
+
+func (c *serverChanges) remove(srv *Server) {
+	c.removed = append(c.removed, ServerRemovedEvent{Server: srv.Clone()})
+}
+
+func (c *serverChanges) emit() {
+	for _, evt := range c.removed {
+		events.Emit(evt)
+	}
+	for _, evt := range c.added {
+		events.Emit(evt)
+	}
+}
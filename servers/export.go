@@ -0,0 +1,281 @@
+package servers
+
+import (
+	"bytes"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	box "github.com/getlantern/lantern-box"
+	"github.com/goccy/go-yaml"
+	"github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json"
+)
+
+// ExportFormat is a format user servers can be exported in.
+type ExportFormat string
+
+const (
+	// ExportSingBox is a sing-box config with the server's outbound or endpoint, as accepted by
+	// AddServersByJSON.
+	ExportSingBox ExportFormat = "sing-box"
+	// ExportShadowsocksURL is an ss:// access key. Only Shadowsocks servers can be exported as one.
+	ExportShadowsocksURL ExportFormat = "ss"
+	// ExportClash is a Clash (mihomo) "proxies" snippet.
+	ExportClash ExportFormat = "clash"
+)
+
+// ErrUnsupportedExport is returned by [Manager.ExportServer] for a format that can't represent
+// the server.
+var ErrUnsupportedExport = errors.New("server can't be exported in this format")
+
+// ExportServer returns the user server with the given tag in the given format, so it can be
+// moved to another device or client. Like [Manager.ServerQRPayload], it refuses Lantern and
+// private servers.
+func (m *Manager) ExportServer(tag string, format ExportFormat) (string, error) {
+	srv, ok := m.GetServerByTag(tag)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrServerNotFound, tag)
+	}
+	if !shareable(srv) {
+		return "", fmt.Errorf("%w: %q", ErrNotShareable, tag)
+	}
+	switch format {
+	case ExportSingBox:
+		return exportSingBox(srv)
+	case ExportShadowsocksURL:
+		out, ok := srv.Options.(option.Outbound)
+		if !ok || out.Type != constant.TypeShadowsocks {
+			return "", fmt.Errorf("%w: %q is not a Shadowsocks server", ErrUnsupportedExport, tag)
+		}
+		return shadowsocksURL(out)
+	case ExportClash:
+		out, ok := srv.Options.(option.Outbound)
+		if !ok {
+			return "", fmt.Errorf("%w: %s endpoints aren't supported by Clash", ErrUnsupportedExport, srv.Type)
+		}
+		return exportClash(out)
+	default:
+		return "", fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func exportSingBox(srv *Server) (string, error) {
+	var cfg singboxConfig
+	switch opts := srv.Options.(type) {
+	case option.Outbound:
+		cfg.Outbounds = []option.Outbound{opts}
+	case option.Endpoint:
+		cfg.Endpoints = []option.Endpoint{opts}
+	default:
+		return "", fmt.Errorf("%w: %q has no options", ErrUnsupportedExport, srv.Tag)
+	}
+	data, err := json.MarshalContext(box.BaseContext(), cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal server: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := stdjson.Indent(&buf, data, "", "  "); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// shadowsocksURL returns the SIP002 ss:// URL for a Shadowsocks outbound. The user info is base64
+// encoded, except for 2022 ciphers, whose keys SIP002 requires to be percent-encoded instead.
+func shadowsocksURL(out option.Outbound) (string, error) {
+	opts, ok := out.Options.(*option.ShadowsocksOutboundOptions)
+	if !ok {
+		return "", fmt.Errorf("%w: invalid Shadowsocks options", ErrUnsupportedExport)
+	}
+	u := url.URL{
+		Scheme:   "ss",
+		Host:     net.JoinHostPort(opts.Server, strconv.Itoa(int(opts.ServerPort))),
+		Fragment: out.Tag,
+	}
+	if strings.HasPrefix(opts.Method, "2022-") {
+		u.User = url.UserPassword(opts.Method, opts.Password)
+	} else {
+		u.User = url.User(base64.RawURLEncoding.EncodeToString([]byte(opts.Method + ":" + opts.Password)))
+	}
+	if opts.Plugin != "" {
+		plugin := opts.Plugin
+		if opts.PluginOptions != "" {
+			plugin += ";" + opts.PluginOptions
+		}
+		u.Path = "/"
+		u.RawQuery = url.Values{"plugin": {plugin}}.Encode()
+	}
+	return u.String(), nil
+}
+
+// clashProxy is a proxy in a Clash config. Only the fields the exported protocols use are
+// included.
+type clashProxy struct {
+	Name              string         `yaml:"name"`
+	Type              string         `yaml:"type"`
+	Server            string         `yaml:"server"`
+	Port              uint16         `yaml:"port"`
+	Ports             string         `yaml:"ports,omitempty"`
+	Cipher            string         `yaml:"cipher,omitempty"`
+	Password          string         `yaml:"password,omitempty"`
+	UUID              string         `yaml:"uuid,omitempty"`
+	AlterID           *int           `yaml:"alterId,omitempty"`
+	Flow              string         `yaml:"flow,omitempty"`
+	Plugin            string         `yaml:"plugin,omitempty"`
+	PluginOpts        map[string]any `yaml:"plugin-opts,omitempty"`
+	Obfs              string         `yaml:"obfs,omitempty"`
+	ObfsPassword      string         `yaml:"obfs-password,omitempty"`
+	TLS               bool           `yaml:"tls,omitempty"`
+	SNI               string         `yaml:"sni,omitempty"`
+	ServerName        string         `yaml:"servername,omitempty"`
+	SkipCertVerify    bool           `yaml:"skip-cert-verify,omitempty"`
+	ALPN              []string       `yaml:"alpn,omitempty"`
+	ClientFingerprint string         `yaml:"client-fingerprint,omitempty"`
+	RealityOpts       map[string]any `yaml:"reality-opts,omitempty"`
+	Network           string         `yaml:"network,omitempty"`
+	WSOpts            map[string]any `yaml:"ws-opts,omitempty"`
+	GRPCOpts          map[string]any `yaml:"grpc-opts,omitempty"`
+	H2Opts            map[string]any `yaml:"h2-opts,omitempty"`
+	UDP               bool           `yaml:"udp,omitempty"`
+}
+
+// exportClash returns a Clash "proxies" snippet with the outbound.
+func exportClash(out option.Outbound) (string, error) {
+	p, err := clashProxyFor(out)
+	if err != nil {
+		return "", err
+	}
+	data, err := yaml.Marshal(map[string][]clashProxy{"proxies": {p}})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal Clash config: %w", err)
+	}
+	return string(data), nil
+}
+
+func clashProxyFor(out option.Outbound) (clashProxy, error) {
+	p := clashProxy{Name: out.Tag}
+	var tls *option.OutboundTLSOptions
+	var transport *option.V2RayTransportOptions
+	switch opts := out.Options.(type) {
+	case *option.ShadowsocksOutboundOptions:
+		p.Type, p.Server, p.Port = "ss", opts.Server, opts.ServerPort
+		p.Cipher, p.Password, p.UDP = opts.Method, opts.Password, true
+		if opts.Plugin != "" {
+			name, pluginOpts, err := clashPlugin(opts.Plugin, opts.PluginOptions)
+			if err != nil {
+				return clashProxy{}, err
+			}
+			p.Plugin, p.PluginOpts = name, pluginOpts
+		}
+	case *option.VMessOutboundOptions:
+		p.Type, p.Server, p.Port = "vmess", opts.Server, opts.ServerPort
+		p.UUID, p.Cipher, p.AlterID, p.UDP = opts.UUID, opts.Security, &opts.AlterId, true
+		tls, transport = opts.TLS, opts.Transport
+	case *option.VLESSOutboundOptions:
+		p.Type, p.Server, p.Port = "vless", opts.Server, opts.ServerPort
+		p.UUID, p.Flow, p.UDP = opts.UUID, opts.Flow, true
+		tls, transport = opts.TLS, opts.Transport
+	case *option.TrojanOutboundOptions:
+		p.Type, p.Server, p.Port = "trojan", opts.Server, opts.ServerPort
+		p.Password, p.UDP = opts.Password, true
+		tls, transport = opts.TLS, opts.Transport
+	case *option.Hysteria2OutboundOptions:
+		p.Type, p.Server, p.Port = "hysteria2", opts.Server, opts.ServerPort
+		p.Password = opts.Password
+		if len(opts.ServerPorts) > 0 {
+			p.Ports = strings.ReplaceAll(strings.Join(opts.ServerPorts, ","), ":", "-")
+		}
+		if opts.Obfs != nil {
+			p.Obfs, p.ObfsPassword = opts.Obfs.Type, opts.Obfs.Password
+		}
+		tls = opts.TLS
+	default:
+		return clashProxy{}, fmt.Errorf("%w: %s servers aren't supported by Clash", ErrUnsupportedExport, out.Type)
+	}
+
+	if tls != nil && tls.Enabled {
+		// Trojan and hysteria2 name the server name "sni"; the V2Ray protocols "servername".
+		p.TLS = p.Type == "vmess" || p.Type == "vless"
+		if p.TLS {
+			p.ServerName = tls.ServerName
+		} else {
+			p.SNI = tls.ServerName
+		}
+		p.SkipCertVerify = tls.Insecure
+		p.ALPN = tls.ALPN
+		if tls.UTLS != nil && tls.UTLS.Enabled {
+			p.ClientFingerprint = tls.UTLS.Fingerprint
+		}
+		if tls.Reality != nil && tls.Reality.Enabled {
+			p.RealityOpts = map[string]any{"public-key": tls.Reality.PublicKey, "short-id": tls.Reality.ShortID}
+		}
+	}
+	if transport != nil {
+		switch transport.Type {
+		case constant.V2RayTransportTypeWebsocket:
+			ws := transport.WebsocketOptions
+			p.Network = "ws"
+			p.WSOpts = map[string]any{"path": ws.Path}
+			if len(ws.Headers) > 0 {
+				headers := make(map[string]string, len(ws.Headers))
+				for k, v := range ws.Headers {
+					headers[k] = strings.Join(v, ",")
+				}
+				p.WSOpts["headers"] = headers
+			}
+			if ws.MaxEarlyData > 0 {
+				p.WSOpts["max-early-data"] = ws.MaxEarlyData
+				p.WSOpts["early-data-header-name"] = ws.EarlyDataHeaderName
+			}
+		case constant.V2RayTransportTypeGRPC:
+			p.Network = "grpc"
+			p.GRPCOpts = map[string]any{"grpc-service-name": transport.GRPCOptions.ServiceName}
+		case constant.V2RayTransportTypeHTTP:
+			p.Network = "h2"
+			p.H2Opts = map[string]any{"host": []string(transport.HTTPOptions.Host), "path": transport.HTTPOptions.Path}
+		default:
+			return clashProxy{}, fmt.Errorf("%w: Clash doesn't support the %s transport", ErrUnsupportedExport, transport.Type)
+		}
+	}
+	return p, nil
+}
+
+// clashPlugin converts SIP003 plugin options, e.g. "obfs=http;obfs-host=example.com", to Clash's
+// plugin-opts.
+func clashPlugin(plugin, pluginOpts string) (string, map[string]any, error) {
+	opts := make(map[string]any)
+	for kv := range strings.SplitSeq(pluginOpts, ";") {
+		if kv == "" {
+			continue
+		}
+		k, v, hasValue := strings.Cut(kv, "=")
+		switch {
+		case plugin == "obfs-local" && k == "obfs":
+			opts["mode"] = v
+		case plugin == "obfs-local" && k == "obfs-host":
+			opts["host"] = v
+		case plugin == "v2ray-plugin" && !hasValue:
+			opts[k] = true
+		case plugin == "v2ray-plugin":
+			opts[k] = v
+		}
+	}
+	switch plugin {
+	case "obfs-local":
+		return "obfs", opts, nil
+	case "v2ray-plugin":
+		if opts["mode"] == nil {
+			opts["mode"] = "websocket"
+		}
+		return "v2ray-plugin", opts, nil
+	default:
+		return "", nil, fmt.Errorf("%w: Clash doesn't support the %s plugin", ErrUnsupportedExport, plugin)
+	}
+}
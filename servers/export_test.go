@@ -0,0 +1,54 @@
+package servers
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportShadowsocksURL(t *testing.T) {
+	for _, link := range []string{
+		"ss://YWVzLTEyOC1nY206dGVzdA@192.168.100.1:8888/?plugin=obfs-local%3Bobfs%3Dhttp%3Bobfs-host%3Dexample.com#obfs",
+		"ss://2022-blake3-aes-128-gcm:YctPZ6U7xPPcU+gp3u+0tx%2FtRizJN9K8y+uKlW2qjlI=@[2001:db8::1]:8888#ipv6",
+	} {
+		out, err := parseShadowsocksURL(link)
+		require.NoError(t, err)
+		exported, err := shadowsocksURL(out)
+		require.NoError(t, err)
+		assert.Equal(t, link, exported)
+	}
+}
+
+func TestExportClash(t *testing.T) {
+	out, err := parseShareLink("vless://b831381d-6324-4d53-ad4f-8cda48b30811@example.com:443?security=tls&sni=sni.example.com&type=ws&host=cdn.example.com&path=/ws#vless")
+	require.NoError(t, err)
+	snippet, err := exportClash(out)
+	require.NoError(t, err)
+	assert.Equal(t, `proxies:
+- name: vless
+  type: vless
+  server: example.com
+  port: 443
+  uuid: b831381d-6324-4d53-ad4f-8cda48b30811
+  tls: true
+  servername: sni.example.com
+  network: ws
+  ws-opts:
+    headers:
+      Host: cdn.example.com
+    path: /ws
+  udp: true
+`, snippet)
+
+	out, err = parseShadowsocksURL("ss://YWVzLTEyOC1nY206dGVzdA@192.168.100.1:8888/?plugin=v2ray-plugin%3Btls%3Bhost%3Dexample.com#v2ray")
+	require.NoError(t, err)
+	p, err := clashProxyFor(out)
+	require.NoError(t, err)
+	assert.Equal(t, "v2ray-plugin", p.Plugin)
+	assert.Equal(t, map[string]any{"mode": "websocket", "tls": true, "host": "example.com"}, p.PluginOpts)
+
+	_, err = clashProxyFor(option.Outbound{Type: "direct", Tag: "direct", Options: &option.DirectOutboundOptions{}})
+	assert.ErrorIs(t, err, ErrUnsupportedExport)
+}
@@ -0,0 +1,289 @@
+package servers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+
+	"github.com/getlantern/radiance/common/atomicfile"
+	"github.com/getlantern/radiance/common/fileperm"
+	"github.com/getlantern/radiance/events"
+)
+
+// Private server managers reached by IP usually present self-signed certificates. Their
+// certificates are verified against the system roots first and, failing that, against a
+// fingerprint pinned ahead of time: the one carried in the invite the server was joined with, or
+// one the user trusted with [Manager.ReplaceTrustedFingerprint]. A certificate is never trusted
+// just because it's the first one a server presents. Servers reached by domain name are expected
+// to have a publicly trusted certificate, such as one from Let's Encrypt, and are verified the
+// usual way instead.
+
+// TrustedFingerprint is the trusted certificate fingerprint of the private server at IP.
+type TrustedFingerprint struct {
+	IP string `json:"ip"`
+	// Fingerprint is the hex-encoded SHA-256 hash of the server's DER-encoded certificate.
+	Fingerprint string    `json:"fingerprint"`
+	TrustedAt   time.Time `json:"trusted_at"`
+}
+
+var (
+	// ErrFingerprintMismatch is returned when a private server presents a certificate that
+	// doesn't match its trusted fingerprint.
+	ErrFingerprintMismatch = errors.New("private server certificate doesn't match the trusted fingerprint")
+	// ErrFingerprintNotFound is returned for an IP with no trusted fingerprint, including when
+	// connecting to a private server whose certificate isn't publicly trusted.
+	ErrFingerprintNotFound = errors.New("no trusted fingerprint for private server")
+)
+
+// FingerprintChangedEvent is emitted when a private server presents a certificate that doesn't
+// match its trusted fingerprint. The connection is refused; if the change is expected, e.g. the
+// server was reinstalled, the user can trust the new fingerprint with
+// [Manager.ReplaceTrustedFingerprint] or [Manager.RemoveTrustedFingerprint].
+type FingerprintChangedEvent struct {
+	events.Event
+	IP        string `json:"ip"`
+	Trusted   string `json:"trusted"`
+	Presented string `json:"presented"`
+}
+
+// fingerprintStore holds the trusted fingerprints in trusted_server_fingerprints.json. The file is
+// read on every access, as the GUI and the daemon may both change it.
+type fingerprintStore struct {
+	mu     sync.Mutex
+	path   string
+	logger *slog.Logger
+	// roots are the CAs certificates are verified against before fingerprints. Nil means the
+	// system roots.
+	roots *x509.CertPool
+}
+
+func newFingerprintStore(path string, logger *slog.Logger) *fingerprintStore {
+	return &fingerprintStore{path: path, logger: logger}
+}
+
+func (s *fingerprintStore) load() (map[string]TrustedFingerprint, error) {
+	entries := make(map[string]TrustedFingerprint)
+	buf, err := atomicfile.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read trusted fingerprints: %w", err)
+	}
+	var list []TrustedFingerprint
+	if err := stdjson.Unmarshal(buf, &list); err != nil {
+		return nil, fmt.Errorf("parse trusted fingerprints: %w", err)
+	}
+	for _, fp := range list {
+		entries[fp.IP] = fp
+	}
+	return entries, nil
+}
+
+func (s *fingerprintStore) save(entries map[string]TrustedFingerprint) error {
+	list := sortedFingerprints(entries)
+	buf, err := stdjson.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, buf, fileperm.File)
+}
+
+// update loads the fingerprints, applies fn, and saves them.
+func (s *fingerprintStore) update(fn func(entries map[string]TrustedFingerprint) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(entries); err != nil {
+		return err
+	}
+	return s.save(entries)
+}
+
+// verify checks the certificate presented by the private server at ip against the system roots
+// and, if it isn't publicly trusted, against the server's trusted fingerprint.
+func (s *fingerprintStore) verify(ip string, rawCerts [][]byte) error {
+	if len(rawCerts) == 0 {
+		return errors.New("private server presented no certificate")
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("parse private server certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	opts := x509.VerifyOptions{DNSName: ip, Roots: s.roots, Intermediates: x509.NewCertPool()}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(opts); err == nil {
+		return nil
+	}
+
+	sum := sha256.Sum256(rawCerts[0])
+	presented := hex.EncodeToString(sum[:])
+	s.mu.Lock()
+	entries, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	fp, ok := entries[ip]
+	if !ok {
+		return fmt.Errorf("%w: %s presented %s", ErrFingerprintNotFound, ip, presented)
+	}
+	if fp.Fingerprint == presented {
+		return nil
+	}
+	s.logger.Warn("Private server certificate changed", "ip", ip, "trusted", fp.Fingerprint, "presented", presented)
+	events.Emit(FingerprintChangedEvent{IP: ip, Trusted: fp.Fingerprint, Presented: presented})
+	return fmt.Errorf("%w: %s presented %s", ErrFingerprintMismatch, ip, presented)
+}
+
+func sortedFingerprints(entries map[string]TrustedFingerprint) []TrustedFingerprint {
+	return slices.SortedFunc(maps.Values(entries), func(a, b TrustedFingerprint) int {
+		return strings.Compare(a.IP, b.IP)
+	})
+}
+
+// normalizeFingerprint accepts a SHA-256 fingerprint in hex, optionally colon-separated as most
+// tools print it, and returns it in the form stored.
+func normalizeFingerprint(fp string) (string, error) {
+	fp = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fp), ":", ""))
+	if b, err := hex.DecodeString(fp); err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("invalid fingerprint %q: must be a hex-encoded SHA-256 hash", fp)
+	}
+	return fp, nil
+}
+
+// TrustedFingerprints returns the trusted private server fingerprints, ordered by IP.
+func (m *Manager) TrustedFingerprints() ([]TrustedFingerprint, error) {
+	m.fingerprints.mu.Lock()
+	defer m.fingerprints.mu.Unlock()
+	entries, err := m.fingerprints.load()
+	if err != nil {
+		return nil, err
+	}
+	return sortedFingerprints(entries), nil
+}
+
+// RemoveTrustedFingerprint forgets the trusted fingerprint of the private server at ip. Unless
+// its certificate is publicly trusted, the server can't be reached until a fingerprint is trusted
+// again.
+func (m *Manager) RemoveTrustedFingerprint(ip string) error {
+	return m.fingerprints.update(func(entries map[string]TrustedFingerprint) error {
+		if _, ok := entries[ip]; !ok {
+			return fmt.Errorf("%w: %s", ErrFingerprintNotFound, ip)
+		}
+		delete(entries, ip)
+		return nil
+	})
+}
+
+// ReplaceTrustedFingerprint trusts the given certificate fingerprint for the private server at
+// ip, replacing any fingerprint trusted before.
+func (m *Manager) ReplaceTrustedFingerprint(ip, fingerprint string) error {
+	if net.ParseIP(ip) == nil {
+		return fmt.Errorf("invalid IP address %q", ip)
+	}
+	fp, err := normalizeFingerprint(fingerprint)
+	if err != nil {
+		return err
+	}
+	return m.fingerprints.update(func(entries map[string]TrustedFingerprint) error {
+		entries[ip] = TrustedFingerprint{IP: ip, Fingerprint: fp, TrustedAt: time.Now()}
+		return nil
+	})
+}
+
+// PinTrustedFingerprint trusts the given certificate fingerprint for the private server at ip, as
+// when joining it with an invite that carries one. Unlike [Manager.ReplaceTrustedFingerprint], it
+// refuses to replace a different fingerprint that is already trusted.
+func (m *Manager) PinTrustedFingerprint(ip, fingerprint string) error {
+	if net.ParseIP(ip) == nil {
+		return fmt.Errorf("invalid IP address %q", ip)
+	}
+	fp, err := normalizeFingerprint(fingerprint)
+	if err != nil {
+		return err
+	}
+	err = m.fingerprints.update(func(entries map[string]TrustedFingerprint) error {
+		if trusted, ok := entries[ip]; ok {
+			if trusted.Fingerprint == fp {
+				return errUnchanged
+			}
+			return fmt.Errorf("%w: %s already has fingerprint %s", ErrFingerprintMismatch, ip, trusted.Fingerprint)
+		}
+		entries[ip] = TrustedFingerprint{IP: ip, Fingerprint: fp, TrustedAt: time.Now()}
+		return nil
+	})
+	if errors.Is(err, errUnchanged) {
+		return nil
+	}
+	return err
+}
+
+// privateServerHTTPClient returns the client used to talk to private server managers, which
+// checks the certificates of those reached by IP against the system roots and then the trusted
+// fingerprints.
+func (m *Manager) privateServerHTTPClient() *http.Client {
+	client := retryableHTTPClient(m.logger)
+	transport := client.HTTPClient.Transport.(*http.Transport)
+	// A proxy would make the transport do the TLS handshake itself, skipping the fingerprint check.
+	transport.Proxy = nil
+	transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := m.dialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		cfg := &tls.Config{ServerName: host}
+		if net.ParseIP(host) != nil {
+			// The certificate is usually self-signed, which the standard verification would
+			// reject before a fingerprint could be checked.
+			cfg = &tls.Config{
+				InsecureSkipVerify: true,
+				VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+					return m.fingerprints.verify(host, rawCerts)
+				},
+			}
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+	client.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		if errors.Is(err, ErrFingerprintMismatch) || errors.Is(err, ErrFingerprintNotFound) {
+			return false, err
+		}
+		return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+	}
+	return client.StandardClient()
+}
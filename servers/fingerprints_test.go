@@ -0,0 +1,108 @@
+package servers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getlantern/radiance/events"
+	"github.com/getlantern/radiance/internal"
+	"github.com/getlantern/radiance/log"
+)
+
+func TestPrivateServerFingerprints(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	// each request gets a new connection, and so a new certificate check
+	srv.Config.SetKeepAlivesEnabled(false)
+	srv.StartTLS()
+	defer srv.Close()
+
+	m := testManager(t)
+	m.dialContext = (&net.Dialer{}).DialContext
+	m.fingerprints = newFingerprintStore(filepath.Join(t.TempDir(), internal.ServerFingerprintsFileName), log.NoOpLogger())
+	client := m.privateServerHTTPClient()
+
+	_, err := client.Get(srv.URL)
+	require.ErrorIs(t, err, ErrFingerprintNotFound, "the first certificate presented must not be trusted")
+	fps, err := m.TrustedFingerprints()
+	require.NoError(t, err)
+	assert.Empty(t, fps)
+
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	presented := hex.EncodeToString(sum[:])
+	require.NoError(t, m.PinTrustedFingerprint("127.0.0.1", presented))
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err, "the pinned certificate should be trusted")
+	resp.Body.Close()
+	fps, err = m.TrustedFingerprints()
+	require.NoError(t, err)
+	require.Len(t, fps, 1)
+	assert.Equal(t, "127.0.0.1", fps[0].IP)
+	assert.Equal(t, presented, fps[0].Fingerprint)
+
+	other := strings.Repeat("AB:", sha256.Size-1) + "AB"
+	require.ErrorIs(t, m.PinTrustedFingerprint("127.0.0.1", other), ErrFingerprintMismatch,
+		"pinning must not replace a different trusted fingerprint")
+	require.NoError(t, m.ReplaceTrustedFingerprint("127.0.0.1", other))
+	changed := make(chan FingerprintChangedEvent, 1)
+	sub := events.Subscribe(func(evt FingerprintChangedEvent) { changed <- evt })
+	defer sub.Unsubscribe()
+	_, err = client.Get(srv.URL)
+	require.ErrorIs(t, err, ErrFingerprintMismatch)
+	select {
+	case evt := <-changed:
+		assert.Equal(t, strings.Repeat("ab", sha256.Size), evt.Trusted)
+		assert.Equal(t, presented, evt.Presented)
+	case <-time.After(time.Second):
+		t.Fatal("FingerprintChangedEvent was not emitted")
+	}
+
+	require.NoError(t, m.RemoveTrustedFingerprint("127.0.0.1"))
+	assert.ErrorIs(t, m.RemoveTrustedFingerprint("127.0.0.1"), ErrFingerprintNotFound)
+	_, err = client.Get(srv.URL)
+	require.ErrorIs(t, err, ErrFingerprintNotFound, "the certificate must not be trusted once the fingerprint is removed")
+
+	m.fingerprints.roots = x509.NewCertPool()
+	m.fingerprints.roots.AddCert(srv.Certificate())
+	resp, err = client.Get(srv.URL)
+	require.NoError(t, err, "a trusted certificate needs no fingerprint")
+	resp.Body.Close()
+
+	assert.Error(t, m.ReplaceTrustedFingerprint("127.0.0.1", "not-a-fingerprint"))
+	assert.Error(t, m.ReplaceTrustedFingerprint("example.com", other))
+}
+
+func TestPrivateServerDomainNameIsVerified(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	m := testManager(t)
+	// the test server's certificate is for example.com
+	m.dialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	m.fingerprints = newFingerprintStore(filepath.Join(t.TempDir(), internal.ServerFingerprintsFileName), log.NoOpLogger())
+	client := m.privateServerHTTPClient()
+
+	// the test server's certificate isn't publicly trusted, and a domain's has no fingerprint
+	_, err := client.Get("https://example.com")
+	var unknownAuthority x509.UnknownAuthorityError
+	require.ErrorAs(t, err, &unknownAuthority)
+	fps, err := m.TrustedFingerprints()
+	require.NoError(t, err)
+	assert.Empty(t, fps)
+}
@@ -0,0 +1,133 @@
+package servers
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/getlantern/radiance/events"
+)
+
+// minFailuresToDisable is the number of failed checks in a row a user server needs before
+// [Manager.DisableDeadServers] disables it, so a server that failed once and then wasn't checked
+// again for a while isn't taken for dead.
+const minFailuresToDisable = 3
+
+// ServerHealth tracks whether a user server has been working, from the tunnel's checks of the
+// server and from reachability probes.
+type ServerHealth struct {
+	// LastWorkingAt is when the server last worked. It's zero if the server never has.
+	LastWorkingAt time.Time `json:"last_working_at"`
+	// FailingSince is when the current failure streak started. It's zero if the server is working.
+	FailingSince time.Time `json:"failing_since"`
+	// Failures is the number of failed checks in the current streak.
+	Failures int `json:"failures,omitempty"`
+	// LastCheckedAt is the time of the last check counted, so a check reported more than once is
+	// only counted once.
+	LastCheckedAt time.Time `json:"last_checked_at"`
+}
+
+// ServersDisabledEvent is emitted when [Manager.DisableDeadServers] disables user servers that
+// haven't worked in a while, so the UI can offer to remove them.
+type ServersDisabledEvent struct {
+	events.Event
+	Tags []string `json:"tags"`
+}
+
+// recordCheck adds the outcome of a check of srv at the given time to its health. It must be
+// called with m.access held.
+func recordCheck(srv *Server, working bool, at time.Time) {
+	if srv.IsLantern || at.IsZero() {
+		return
+	}
+	h := srv.Health
+	if h == nil {
+		h = &ServerHealth{}
+		srv.Health = h
+	}
+	if !at.After(h.LastCheckedAt) {
+		return
+	}
+	h.LastCheckedAt = at
+	if working {
+		h.LastWorkingAt = at
+		h.FailingSince = time.Time{}
+		h.Failures = 0
+		return
+	}
+	if h.FailingSince.IsZero() {
+		h.FailingSince = at
+	}
+	h.Failures++
+}
+
+// selectionHistoryCheck returns the outcome of the latest check in a selection history, and false
+// if the history has no outcome.
+func selectionHistoryCheck(h SelectionHistory) (working bool, at time.Time, ok bool) {
+	switch {
+	case h.LastOutcomeAt.IsZero():
+		return false, time.Time{}, false
+	case h.ConsecutiveFailures > 0:
+		return false, h.LastOutcomeAt, true
+	case h.LastSuccessDelayMs > 0:
+		return true, h.LastOutcomeAt, true
+	default:
+		return false, time.Time{}, false
+	}
+}
+
+// DisableDeadServers disables the user servers that have been failing for at least maxAge and
+// returns their tags. Disabled servers are kept, but aren't used until they're enabled again with
+// [Manager.SetServerDisabled]. A [ServersDisabledEvent] is emitted if any servers are disabled.
+func (m *Manager) DisableDeadServers(maxAge time.Duration) ([]string, error) {
+	now := time.Now()
+	var disabled []string
+	err := m.update(func() error {
+		for tag, srv := range m.servers {
+			h := srv.Health
+			if srv.IsLantern || srv.Disabled || h == nil || h.FailingSince.IsZero() {
+				continue
+			}
+			if h.Failures < minFailuresToDisable || now.Sub(h.FailingSince) < maxAge {
+				continue
+			}
+			srv = srv.Clone()
+			srv.Disabled = true
+			m.servers[tag] = srv
+			disabled = append(disabled, tag)
+		}
+		if len(disabled) == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	if err != nil || len(disabled) == 0 {
+		return nil, err
+	}
+	slices.Sort(disabled)
+	m.logger.Info("Disabled user servers that haven't worked recently", "tags", disabled, "max_age", maxAge)
+	events.Emit(ServersDisabledEvent{Tags: disabled})
+	return disabled, nil
+}
+
+// SetServerDisabled disables or enables the user server with the given tag. Enabling a server
+// clears its failure streak, so it isn't disabled again before it's been checked.
+func (m *Manager) SetServerDisabled(tag string, disabled bool) error {
+	return m.update(func() error {
+		srv, exists := m.servers[tag]
+		if !exists {
+			return fmt.Errorf("%w: %q", ErrServerNotFound, tag)
+		}
+		if srv.IsLantern {
+			return fmt.Errorf("server %q is a Lantern server and can't be disabled", tag)
+		}
+		srv = srv.Clone()
+		srv.Disabled = disabled
+		if !disabled && srv.Health != nil {
+			srv.Health.FailingSince = time.Time{}
+			srv.Health.Failures = 0
+		}
+		m.servers[tag] = srv
+		return nil
+	})
+}
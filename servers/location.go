@@ -0,0 +1,143 @@
+package servers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+
+	C "github.com/getlantern/common"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Locator resolves the location of an IP address.
+type Locator interface {
+	Locate(ctx context.Context, ip netip.Addr) (C.ServerLocation, error)
+}
+
+// MMDBLocator resolves locations from a local MaxMind database, such as GeoLite2-City or
+// GeoLite2-Country, so the addresses of the user's servers aren't sent to a lookup service.
+type MMDBLocator struct {
+	reader *maxminddb.Reader
+}
+
+// OpenMMDBLocator opens the MaxMind database at path. The locator must be closed when done.
+func OpenMMDBLocator(path string) (*MMDBLocator, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open GeoIP database: %w", err)
+	}
+	return &MMDBLocator{reader: reader}, nil
+}
+
+// mmdbRecord is the part of a GeoLite2-City or GeoLite2-Country record used for server locations.
+type mmdbRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+func (l *MMDBLocator) Locate(_ context.Context, ip netip.Addr) (C.ServerLocation, error) {
+	var rec mmdbRecord
+	if err := l.reader.Lookup(ip.AsSlice(), &rec); err != nil {
+		return C.ServerLocation{}, err
+	}
+	if rec.Country.ISOCode == "" {
+		return C.ServerLocation{}, fmt.Errorf("no location for %s", ip)
+	}
+	return C.ServerLocation{
+		Country:     rec.Country.Names["en"],
+		CountryCode: rec.Country.ISOCode,
+		City:        rec.City.Names["en"],
+		Latitude:    float32(rec.Location.Latitude),
+		Longitude:   float32(rec.Location.Longitude),
+	}, nil
+}
+
+func (l *MMDBLocator) Close() error {
+	return l.reader.Close()
+}
+
+// LocateServers sets the location of each of the user servers with the given tags that has none,
+// using loc, and returns the tags of the servers located. Servers addressed by hostname are
+// located by the first address the hostname resolves to.
+func (m *Manager) LocateServers(ctx context.Context, loc Locator, tags []string) ([]string, error) {
+	hosts := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		srv, ok := m.GetServerByTag(tag)
+		if !ok || srv.IsLantern || srv.Location.CountryCode != "" || srv.Location.Country != "" {
+			continue
+		}
+		if opts, ok := serverOptions(srv); ok {
+			hosts[tag] = opts.Server
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+
+	locations := make(map[string]C.ServerLocation, len(hosts))
+	var errs []error
+	for tag, host := range hosts {
+		var l C.ServerLocation
+		ip, err := resolveHost(ctx, host)
+		if err == nil {
+			l, err = loc.Locate(ctx, ip)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("locate %q: %w", tag, err))
+			continue
+		}
+		locations[tag] = l
+	}
+
+	var located []string
+	if err := m.update(func() error {
+		for tag, l := range locations {
+			srv, exists := m.servers[tag]
+			if !exists || srv.Location.CountryCode != "" || srv.Location.Country != "" {
+				// removed, or given a location, while it was being located
+				continue
+			}
+			srv = srv.Clone()
+			srv.Location = l
+			m.servers[tag] = srv
+			located = append(located, tag)
+		}
+		if len(located) == 0 {
+			return errUnchanged
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	slices.Sort(located)
+	for _, tag := range located {
+		m.logger.Info("Located server", "tag", tag, "location", locations[tag])
+	}
+	return located, errors.Join(errs...)
+}
+
+func resolveHost(ctx context.Context, host string) (netip.Addr, error) {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return ip, nil
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if len(ips) == 0 {
+		return netip.Addr{}, fmt.Errorf("no addresses for %s", host)
+	}
+	return ips[0].Unmap(), nil
+}
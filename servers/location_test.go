@@ -0,0 +1,65 @@
+package servers
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	C "github.com/getlantern/common"
+	"github.com/sagernet/sing-box/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapLocator map[netip.Addr]C.ServerLocation
+
+func (l mapLocator) Locate(_ context.Context, ip netip.Addr) (C.ServerLocation, error) {
+	loc, ok := l[ip]
+	if !ok {
+		return C.ServerLocation{}, fmt.Errorf("no location for %s", ip)
+	}
+	return loc, nil
+}
+
+func TestLocateServers(t *testing.T) {
+	m := testManager(t)
+	server := func(tag, ip string, loc C.ServerLocation) *Server {
+		return &Server{
+			Tag:  tag,
+			Type: "shadowsocks",
+			Options: option.Outbound{
+				Tag:  tag,
+				Type: "shadowsocks",
+				Options: &option.ShadowsocksOutboundOptions{
+					ServerOptions: option.ServerOptions{Server: ip, ServerPort: 443},
+					Method:        "chacha20-ietf-poly1305",
+					Password:      "pw",
+				},
+			},
+			Location: loc,
+		}
+	}
+	paris := C.ServerLocation{Country: "France", CountryCode: "FR", City: "Paris"}
+	tokyo := C.ServerLocation{Country: "Japan", CountryCode: "JP", City: "Tokyo"}
+	require.NoError(t, m.AddServers(ServerList{Servers: []*Server{
+		server("unlocated", "192.0.2.1", C.ServerLocation{}),
+		server("located", "192.0.2.2", tokyo),
+		server("unknown", "192.0.2.3", C.ServerLocation{}),
+	}}, false))
+	locator := mapLocator{
+		netip.MustParseAddr("192.0.2.1"): paris,
+		netip.MustParseAddr("192.0.2.2"): paris,
+	}
+
+	located, err := m.LocateServers(context.Background(), locator, []string{"unlocated", "located", "unknown"})
+	assert.Error(t, err, "the server the locator has no location for should be reported")
+	assert.Equal(t, []string{"unlocated"}, located)
+
+	srv, _ := m.GetServerByTag("unlocated")
+	assert.Equal(t, paris, srv.Location)
+	srv, _ = m.GetServerByTag("located")
+	assert.Equal(t, tokyo, srv.Location, "a location set by the user should be kept")
+	srv, _ = m.GetServerByTag("unknown")
+	assert.Empty(t, srv.Location)
+}
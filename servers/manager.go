@@ -6,6 +6,7 @@ package servers
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	stdjson "encoding/json"
 	"errors"
 	"fmt"
@@ -32,7 +33,9 @@ import (
 
 	"github.com/getlantern/radiance/bypass"
 	"github.com/getlantern/radiance/common/atomicfile"
+	"github.com/getlantern/radiance/common/filelock"
 	"github.com/getlantern/radiance/common/fileperm"
+	"github.com/getlantern/radiance/events"
 	"github.com/getlantern/radiance/internal"
 	"github.com/getlantern/radiance/log"
 	"github.com/getlantern/radiance/traces"
@@ -80,6 +83,9 @@ func dumpAllGoroutines() string {
 
 const tracerName = "github.com/getlantern/radiance/servers"
 
+// ErrServerNotFound is returned when there is no server with the given tag.
+var ErrServerNotFound = errors.New("server not found")
+
 // ServerCredentials holds the access token and invite status for a private server.
 type ServerCredentials struct {
 	AccessToken string `json:"access_token,omitempty"`
@@ -87,6 +93,14 @@ type ServerCredentials struct {
 	IsJoined    bool   `json:"is_joined,omitempty"` // whether the user has joined the server (i.e. accepted the invite)
 }
 
+// ServerMetadata is information the user attached to a server, for display only.
+type ServerMetadata struct {
+	Labels []string `json:"labels,omitempty"`
+	Notes  string   `json:"notes,omitempty"`
+	// Color is a display color chosen by the user, such as "#ff8800".
+	Color string `json:"color,omitempty"`
+}
+
 type Server struct {
 	Tag              string             `json:"tag"`
 	Type             string             `json:"type"`
@@ -95,6 +109,10 @@ type Server struct {
 	Location         C.ServerLocation   `json:"location,omitempty"`
 	Credentials      *ServerCredentials `json:"credentials,omitempty"`
 	SelectionHistory *SelectionHistory  `json:"selection_history,omitempty"`
+	Metadata         *ServerMetadata    `json:"metadata,omitempty"`
+	Reachability     *Reachability      `json:"reachability,omitempty"`
+	Health           *ServerHealth      `json:"health,omitempty"`
+	Disabled         bool               `json:"disabled,omitempty"`
 }
 
 // serverJSON is the on-wire representation of a Server. The Options field is split into
@@ -109,6 +127,10 @@ type serverJSON struct {
 	Location         C.ServerLocation   `json:"location,omitempty"`
 	Credentials      *ServerCredentials `json:"credentials,omitempty"`
 	SelectionHistory *SelectionHistory  `json:"selection_history,omitempty"`
+	Metadata         *ServerMetadata    `json:"metadata,omitempty"`
+	Reachability     *Reachability      `json:"reachability,omitempty"`
+	Health           *ServerHealth      `json:"health,omitempty"`
+	Disabled         bool               `json:"disabled,omitempty"`
 }
 
 func (s Server) MarshalJSON() ([]byte, error) {
@@ -119,6 +141,10 @@ func (s Server) MarshalJSON() ([]byte, error) {
 		Location:         s.Location,
 		Credentials:      s.Credentials,
 		SelectionHistory: s.SelectionHistory,
+		Metadata:         s.Metadata,
+		Reachability:     s.Reachability,
+		Health:           s.Health,
+		Disabled:         s.Disabled,
 	}
 	switch opts := s.Options.(type) {
 	case option.Outbound:
@@ -140,6 +166,10 @@ func (s *Server) UnmarshalJSON(data []byte) error {
 	s.Location = sj.Location
 	s.Credentials = sj.Credentials
 	s.SelectionHistory = sj.SelectionHistory
+	s.Metadata = sj.Metadata
+	s.Reachability = sj.Reachability
+	s.Health = sj.Health
+	s.Disabled = sj.Disabled
 	if sj.Outbound != nil {
 		s.Options = *sj.Outbound
 	} else if sj.Endpoint != nil {
@@ -161,6 +191,19 @@ func (s *Server) Clone() *Server {
 		}
 		cp.SelectionHistory = &h
 	}
+	if s.Metadata != nil {
+		md := *s.Metadata
+		md.Labels = slices.Clone(md.Labels)
+		cp.Metadata = &md
+	}
+	if s.Reachability != nil {
+		r := *s.Reachability
+		cp.Reachability = &r
+	}
+	if s.Health != nil {
+		h := *s.Health
+		cp.Health = &h
+	}
 	return &cp
 }
 
@@ -208,9 +251,20 @@ type Manager struct {
 	// during the brief JSON marshalling step.
 	saveMu sync.Mutex
 
+	// fileMu and the lock file next to serversFile serialize updates of the servers file, within
+	// this process and between processes, such as the GUI and the daemon. fileVersion, guarded by
+	// them, is the hash of the file's contents as this Manager last read or wrote them, used to
+	// tell whether another process has written it since.
+	fileMu      sync.Mutex
+	fileVersion [sha256.Size]byte
+
 	logger      *slog.Logger
 	serversFile string
 	httpClient  *http.Client
+	// dialContext opens the connections used to probe server reachability and to reach private
+	// servers.
+	dialContext  func(ctx context.Context, network, addr string) (net.Conn, error)
+	fingerprints *fingerprintStore
 }
 
 // NewManager creates a new Manager instance, loading server options from disk.
@@ -225,9 +279,11 @@ func NewManager(dataPath string, logger *slog.Logger) (*Manager, error) {
 		serversFile: filepath.Join(dataPath, internal.ServersFileName),
 		logger:      logger,
 		// Use the bypass proxy dialer to route requests outside the VPN tunnel.
-		// This client is only used to access private servers the user has created.
-		httpClient: retryableHTTPClient(logger).StandardClient(),
+		dialContext:  bypass.DialContext,
+		fingerprints: newFingerprintStore(filepath.Join(dataPath, internal.ServerFingerprintsFileName), logger),
 	}
+	// This client is only used to access private servers the user has created.
+	mgr.httpClient = mgr.privateServerHTTPClient()
 
 	mgr.logger.Debug("Loading servers", "file", mgr.serversFile)
 	if err := mgr.loadServers(); err != nil {
@@ -280,17 +336,18 @@ type SelectionHistory = lbA.TagHistory
 // UpdateSelectionHistory updates the selection history for servers
 // matching the provided tags and persists the change to disk.
 func (m *Manager) UpdateSelectionHistory(results map[string]SelectionHistory) error {
-	func() {
-		m.access.Lock()
-		defer m.access.Unlock()
+	return m.update(func() error {
 		for tag, result := range results {
 			if srv, exists := m.servers[tag]; exists {
 				r := result
 				srv.SelectionHistory = &r
+				if working, at, ok := selectionHistoryCheck(r); ok {
+					recordCheck(srv, working, at)
+				}
 			}
 		}
-	}()
-	return m.saveServers()
+		return nil
+	})
 }
 
 // GetServerByTag returns the server configuration for a given tag and a boolean indicating whether
@@ -330,12 +387,8 @@ func (m *Manager) AddServers(list ServerList, force bool) error {
 		return nil
 	}
 
-	// Perform the in-memory mutation under the write lock, then release it
-	// before saving to disk (saveServers acquires its own locks). Scoped
-	// in a closure so defer Unlock is robust against future early returns.
-	if err := func() error {
-		m.access.Lock()
-		defer m.access.Unlock()
+	var changes serverChanges
+	if err := m.update(func() error {
 		if !force {
 			for _, srv := range list.Servers {
 				if _, exists := m.servers[srv.Tag]; exists {
@@ -344,14 +397,61 @@ func (m *Manager) AddServers(list ServerList, force bool) error {
 			}
 		}
 		for _, srv := range list.Servers {
+			_, exists := m.servers[srv.Tag]
 			m.servers[srv.Tag] = srv.Clone()
+			changes.add(srv, exists)
 		}
 		return nil
-	}(); err != nil {
+	}); err != nil {
 		return err
 	}
-	// saveServers acquires its own locks; don't hold the write lock across it.
-	return m.saveServers()
+	changes.emit()
+	return nil
+}
+
+// BatchResult reports what [Manager.AddServersBatch] did with each server.
+type BatchResult struct {
+	Added    []string `json:"added"`
+	Replaced []string `json:"replaced,omitempty"`
+	// Skipped lists servers whose tag was already in use and weren't replaced.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// AddServersBatch adds many servers at once, such as those from a subscription, saving them with
+// a single write. Unlike AddServers, a tag that's already in use doesn't fail the batch: the
+// existing server is replaced if replace is true and the new one is skipped otherwise. Lantern
+// servers are never replaced by user servers.
+func (m *Manager) AddServersBatch(list ServerList, replace bool) (BatchResult, error) {
+	var (
+		res     BatchResult
+		changes serverChanges
+	)
+	err := m.update(func() error {
+		for _, srv := range list.Servers {
+			existing, exists := m.servers[srv.Tag]
+			switch {
+			case !exists:
+				res.Added = append(res.Added, srv.Tag)
+			case replace && (!existing.IsLantern || srv.IsLantern):
+				res.Replaced = append(res.Replaced, srv.Tag)
+			default:
+				res.Skipped = append(res.Skipped, srv.Tag)
+				continue
+			}
+			m.servers[srv.Tag] = srv.Clone()
+			changes.add(srv, exists)
+		}
+		if len(res.Added)+len(res.Replaced) == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	if err != nil || len(res.Added)+len(res.Replaced) == 0 {
+		return res, err
+	}
+	m.logger.Info("Added servers in batch", "added", len(res.Added), "replaced", len(res.Replaced), "skipped", len(res.Skipped))
+	changes.emit()
+	return res, nil
 }
 
 // RemoveServer removes a server config by its tag.
@@ -362,28 +462,91 @@ func (m *Manager) RemoveServer(tag string) error {
 
 // RemoveServers removes multiple server configs by their tags and returns the removed servers.
 func (m *Manager) RemoveServers(tags []string) ([]*Server, error) {
-	// Perform the in-memory mutation under the write lock, then release it
-	// before saving to disk (saveServers acquires its own locks). Scoped in
-	// a closure so defer Unlock is robust against future early returns.
-	removed := func() []*Server {
-		m.access.Lock()
-		defer m.access.Unlock()
-		r := make([]*Server, 0, len(tags))
+	removed := make([]*Server, 0, len(tags))
+	var changes serverChanges
+	if err := m.update(func() error {
 		for _, tag := range tags {
 			if srv, exists := m.servers[tag]; exists {
-				r = append(r, srv)
+				removed = append(removed, srv)
 				delete(m.servers, tag)
+				changes.remove(srv)
 			}
 		}
-		return r
-	}()
-	// saveServers acquires its own locks; don't hold the write lock across it.
-	if err := m.saveServers(); err != nil {
-		return nil, fmt.Errorf("failed to save servers: %w", err)
+		return nil
+	}); err != nil {
+		return nil, err
 	}
+	changes.emit()
 	return removed, nil
 }
 
+// RenameServer changes the tag of the user server oldTag to newTag and returns the renamed
+// server. The caller is responsible for replacing the server's outbound in a running tunnel.
+func (m *Manager) RenameServer(oldTag, newTag string) (*Server, error) {
+	if newTag == "" {
+		return nil, errors.New("new tag is empty")
+	}
+	var (
+		renamed *Server
+		changes serverChanges
+	)
+	if err := m.update(func() error {
+		srv, exists := m.servers[oldTag]
+		if !exists {
+			return fmt.Errorf("%w: %q", ErrServerNotFound, oldTag)
+		}
+		if srv.IsLantern {
+			return fmt.Errorf("server %q is a Lantern server and can't be renamed", oldTag)
+		}
+		if _, exists := m.servers[newTag]; exists {
+			return fmt.Errorf("server %q already exists", newTag)
+		}
+		srv = srv.Clone()
+		srv.Tag = newTag
+		switch opts := srv.Options.(type) {
+		case option.Outbound:
+			opts.Tag = newTag
+			srv.Options = opts
+		case option.Endpoint:
+			opts.Tag = newTag
+			srv.Options = opts
+		}
+		changes.remove(m.servers[oldTag])
+		delete(m.servers, oldTag)
+		m.servers[newTag] = srv
+		changes.add(srv, false)
+		renamed = srv.Clone()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	m.logger.Info("Renamed server", "old_tag", oldTag, "new_tag", newTag)
+	changes.emit()
+	return renamed, nil
+}
+
+// SetServerMetadata replaces the metadata of the user server with the given tag. A zero md
+// clears it.
+func (m *Manager) SetServerMetadata(tag string, md ServerMetadata) error {
+	return m.update(func() error {
+		srv, exists := m.servers[tag]
+		if !exists {
+			return fmt.Errorf("%w: %q", ErrServerNotFound, tag)
+		}
+		if srv.IsLantern {
+			return fmt.Errorf("server %q is a Lantern server and can't have metadata", tag)
+		}
+		srv = srv.Clone()
+		srv.Metadata = nil
+		if len(md.Labels) > 0 || md.Notes != "" || md.Color != "" {
+			md.Labels = slices.Clone(md.Labels)
+			srv.Metadata = &md
+		}
+		m.servers[tag] = srv
+		return nil
+	})
+}
+
 // saveServers marshals the current server state to JSON and writes it to disk.
 //
 // The access write lock is NOT held across this function; only a brief RLock
@@ -420,8 +583,11 @@ func (m *Manager) saveServers() error {
 	}
 
 	writeStart := time.Now()
-	werr := atomicfile.WriteFile(m.serversFile, buf, fileperm.File)
+	werr := atomicfile.WriteFile(m.serversFile, buf, fileperm.Secret)
 	writeDur := time.Since(writeStart)
+	if werr == nil {
+		m.fileVersion = sha256.Sum256(buf)
+	}
 
 	total := time.Since(start)
 	slog.Log(nil, log.LevelTrace, "saveServers timing",
@@ -458,6 +624,85 @@ func (m *Manager) saveServers() error {
 	return werr
 }
 
+// errUnchanged is returned by an update function to skip saving the servers.
+var errUnchanged = errors.New("servers unchanged")
+
+// update runs fn with the access write lock held, then saves the servers unless fn returns an
+// error. The servers file lock is held throughout, and the servers are first reloaded if another
+// process has written the file since this Manager last read or wrote it, so that the other
+// process's changes are kept rather than overwritten.
+func (m *Manager) update(fn func() error) error {
+	unlock, err := m.lockServersFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	m.reloadIfChanged()
+
+	err = func() error {
+		m.access.Lock()
+		defer m.access.Unlock()
+		return fn()
+	}()
+	switch {
+	case errors.Is(err, errUnchanged):
+		return nil
+	case err != nil:
+		return err
+	}
+	// saveServers acquires its own locks; don't hold the write lock across it.
+	if err := m.saveServers(); err != nil {
+		return fmt.Errorf("failed to save servers: %w", err)
+	}
+	return nil
+}
+
+// lockServersFile acquires the servers file lock and returns a function that releases it.
+func (m *Manager) lockServersFile() (unlock func(), err error) {
+	m.fileMu.Lock()
+	lock, err := filelock.Acquire(filepath.Join(filepath.Dir(m.serversFile), internal.ServersLockFileName))
+	if err != nil {
+		m.fileMu.Unlock()
+		return nil, err
+	}
+	return func() {
+		if err := lock.Release(); err != nil {
+			m.logger.Warn("Failed to release servers file lock", "error", err)
+		}
+		m.fileMu.Unlock()
+	}, nil
+}
+
+// reloadIfChanged replaces the in-memory servers with those in the servers file if another
+// process has written it since this Manager last read or wrote it. The servers file lock must be
+// held. If the file can't be read, the in-memory servers are kept.
+func (m *Manager) reloadIfChanged() {
+	buf, err := atomicfile.ReadFile(m.serversFile)
+	if err != nil || sha256.Sum256(buf) == m.fileVersion {
+		return
+	}
+	m.logger.Info("Servers file was changed by another process, reloading", "file", m.serversFile)
+	fresh := &Manager{
+		servers:     make(map[string]*Server),
+		serversFile: m.serversFile,
+		logger:      m.logger,
+	}
+	if err := fresh.readServers(); err != nil {
+		m.logger.Warn("Failed to reload servers file, keeping servers in memory", "error", err)
+		return
+	}
+	m.access.Lock()
+	m.servers = fresh.servers
+	m.access.Unlock()
+	m.fileVersion = fresh.fileVersion
+
+	all := make([]*Server, 0, len(fresh.servers))
+	for _, srv := range fresh.servers {
+		all = append(all, srv.Clone())
+	}
+	events.Emit(ServersReplacedEvent{Servers: all})
+}
+
 const (
 	modeLantern = "lantern"
 	modeUser    = "user"
@@ -471,6 +716,16 @@ const (
 // It returns a non-nil error enumerating any skipped entries (or a wholly
 // unparseable file); the in-memory state is still valid when it does.
 func (m *Manager) loadServers() error {
+	unlock, err := m.lockServersFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return m.readServers()
+}
+
+// readServers does the work of loadServers. The servers file lock must be held.
+func (m *Manager) readServers() error {
 	rawServersFile, err := atomicfile.ReadFile(m.serversFile)
 	if errors.Is(err, os.ErrNotExist) {
 		return nil // file doesn't exist
@@ -478,6 +733,7 @@ func (m *Manager) loadServers() error {
 	if err != nil {
 		return fmt.Errorf("read servers file %q: %w", m.serversFile, err)
 	}
+	m.fileVersion = sha256.Sum256(rawServersFile)
 	rawServersFile = bytes.TrimSpace(rawServersFile)
 	if len(rawServersFile) == 0 {
 		return nil
@@ -587,7 +843,7 @@ func serverTag(raw []byte) string {
 // by this build remain available to a later re-upgrade.
 func (m *Manager) quarantineInvalidServers(buf []byte) {
 	invalidPath := filepath.Join(filepath.Dir(m.serversFile), internal.ServersInvalidFileName)
-	if err := atomicfile.WriteFile(invalidPath, buf, fileperm.File); err != nil {
+	if err := atomicfile.WriteFile(invalidPath, buf, fileperm.Secret); err != nil {
 		m.logger.Error("Writing invalid servers copy", "path", invalidPath, "error", err)
 		return
 	}
@@ -690,18 +946,24 @@ func (m *Manager) RevokePrivateServerInvite(ip string, port int, accessToken str
 	return nil
 }
 
+// singboxConfig is the part of a sing-box config that defines servers.
+type singboxConfig struct {
+	Outbounds []option.Outbound `json:"outbounds,omitempty"`
+	Endpoints []option.Endpoint `json:"endpoints,omitempty"`
+}
+
 // AddServersByJSON adds any outbounds and endpoints defined in the provided sing-box JSON config.
 func (m *Manager) AddServersByJSON(ctx context.Context, config []byte) (*ServerList, error) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "Manager.AddServerBySingboxJSON")
 	defer span.End()
-	type singboxConfig struct {
-		Outbounds []option.Outbound `json:"outbounds,omitempty"`
-		Endpoints []option.Endpoint `json:"endpoints,omitempty"`
-	}
 	cfg, err := json.UnmarshalExtendedContext[singboxConfig](box.BaseContext(), config)
 	if err != nil {
 		return nil, traces.RecordError(ctx, fmt.Errorf("failed to parse config: %w", err))
 	}
+	return m.addSingboxConfig(ctx, cfg)
+}
+
+func (m *Manager) addSingboxConfig(ctx context.Context, cfg singboxConfig) (*ServerList, error) {
 	if len(cfg.Endpoints) == 0 && len(cfg.Outbounds) == 0 {
 		return nil, traces.RecordError(ctx, fmt.Errorf("no endpoints or outbounds found in the provided configuration"))
 	}
@@ -726,20 +988,63 @@ func (m *Manager) AddServersByJSON(ctx context.Context, config []byte) (*ServerL
 }
 
 // AddServersByURL adds a server(s) by downloading and parsing the config from a list of URLs.
+// Share links (ss://, vmess://, vless://, trojan://, and hysteria2://) and server QR payloads are
+// parsed directly; see parseShareLink and ServerQRPayload. Other URLs are parsed by pluriconfig.
 func (m *Manager) AddServersByURL(ctx context.Context, urls []string, skipCertVerification bool) (*ServerList, error) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "Manager.AddServerByURLs")
 	defer span.End()
+	var cfg singboxConfig
+	var otherURLs []string
+	for _, u := range urls {
+		if isServerQRPayload(u) {
+			shared, err := parseServerQRPayload(u)
+			if err != nil {
+				return nil, traces.RecordError(ctx, err)
+			}
+			cfg.Outbounds = append(cfg.Outbounds, shared.Outbounds...)
+			cfg.Endpoints = append(cfg.Endpoints, shared.Endpoints...)
+			continue
+		}
+		if _, ok := shareLinkScheme(u); !ok {
+			otherURLs = append(otherURLs, u)
+			continue
+		}
+		out, err := parseShareLink(u)
+		if err != nil {
+			return nil, traces.RecordError(ctx, fmt.Errorf("failed to parse share link: %w", err))
+		}
+		if skipCertVerification {
+			if w, ok := out.Options.(option.OutboundTLSOptionsWrapper); ok && w.TakeOutboundTLSOptions() != nil {
+				w.TakeOutboundTLSOptions().Insecure = true
+			}
+		}
+		cfg.Outbounds = append(cfg.Outbounds, out)
+	}
+	if len(otherURLs) > 0 || len(cfg.Outbounds)+len(cfg.Endpoints) == 0 {
+		parsed, err := parseURLs(ctx, otherURLs, skipCertVerification)
+		if err != nil {
+			return nil, traces.RecordError(ctx, err)
+		}
+		cfg.Outbounds = append(cfg.Outbounds, parsed.Outbounds...)
+		cfg.Endpoints = append(cfg.Endpoints, parsed.Endpoints...)
+	}
+	m.logger.Info("Added servers based on URLs", "serverCount", len(urls), "skipCertVerification", skipCertVerification)
+	return m.addSingboxConfig(ctx, cfg)
+}
+
+// parseURLs converts proxy URLs into sing-box outbounds and endpoints using pluriconfig.
+func parseURLs(ctx context.Context, urls []string, skipCertVerification bool) (singboxConfig, error) {
 	urlProvider, loaded := pluriconfig.GetProvider(string(model.ProviderURL))
 	if !loaded {
-		return nil, traces.RecordError(ctx, fmt.Errorf("URL config provider not loaded"))
+		return singboxConfig{}, fmt.Errorf("URL config provider not loaded")
 	}
 	cfg, err := urlProvider.Parse(ctx, []byte(strings.Join(urls, "\n")))
 	if err != nil {
-		return nil, traces.RecordError(ctx, fmt.Errorf("failed to parse URLs: %w", err))
+		return singboxConfig{}, fmt.Errorf("failed to parse URLs: %w", err)
 	}
 	cfgURLs, ok := cfg.Options.([]url.URL)
 	if !ok || len(cfgURLs) == 0 {
-		return nil, traces.RecordError(ctx, fmt.Errorf("no valid URLs found in the provided configuration"))
+		return singboxConfig{}, fmt.Errorf("no valid URLs found in the provided configuration")
 	}
 
 	if skipCertVerification {
@@ -755,12 +1060,15 @@ func (m *Manager) AddServersByURL(ctx context.Context, urls []string, skipCertVe
 
 	singBoxProvider, loaded := pluriconfig.GetProvider(string(model.ProviderSingBox))
 	if !loaded {
-		return nil, traces.RecordError(ctx, fmt.Errorf("singbox config provider not loaded"))
+		return singboxConfig{}, fmt.Errorf("singbox config provider not loaded")
 	}
 	singBoxCfg, err := singBoxProvider.Serialize(ctx, cfg)
 	if err != nil {
-		return nil, traces.RecordError(ctx, fmt.Errorf("failed to serialize sing-box config: %w", err))
+		return singboxConfig{}, fmt.Errorf("failed to serialize sing-box config: %w", err)
+	}
+	parsed, err := json.UnmarshalExtendedContext[singboxConfig](box.BaseContext(), singBoxCfg)
+	if err != nil {
+		return singboxConfig{}, fmt.Errorf("failed to parse sing-box config: %w", err)
 	}
-	m.logger.Info("Added servers based on URLs", "serverCount", len(cfgURLs), "skipCertVerification", skipCertVerification)
-	return m.AddServersByJSON(ctx, singBoxCfg)
+	return parsed, nil
 }
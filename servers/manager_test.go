@@ -1,8 +1,10 @@
 package servers
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -11,11 +13,13 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	C "github.com/getlantern/common"
 	box "github.com/getlantern/lantern-box"
 
 	_ "github.com/getlantern/radiance/common"
+	"github.com/getlantern/radiance/events"
 	"github.com/getlantern/radiance/internal"
 	"github.com/getlantern/radiance/log"
 
@@ -203,6 +207,18 @@ func TestAddServersByURL(t *testing.T) {
 		require.NotNil(t, trojanOpts.TLS)
 		assert.True(t, trojanOpts.TLS.Insecure, "TLS.Insecure should be true")
 	})
+	t.Run("shadowsocks access keys", func(t *testing.T) {
+		m := testManager(t)
+		ssURL := "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpzM2NyM3Q@203.0.113.1:8388/?outline=1#Outline+Server"
+		list, err := m.AddServersByURL(t.Context(), append([]string{ssURL}, urls...), false)
+		require.NoError(t, err)
+		assert.Len(t, list.Tags(), 3)
+		server, exists := m.GetServerByTag("Outline+Server")
+		require.True(t, exists, "Shadowsocks server should be added")
+		options := server.Options.(option.Outbound).Options
+		require.IsType(t, &option.ShadowsocksOutboundOptions{}, options)
+		assert.Equal(t, "chacha20-ietf-poly1305", options.(*option.ShadowsocksOutboundOptions).Method)
+	})
 	t.Run("empty urls", func(t *testing.T) {
 		m := testManager(t)
 		_, err := m.AddServersByURL(t.Context(), []string{}, false)
@@ -360,3 +376,252 @@ func testManager(t *testing.T) *Manager {
 		logger:      log.NoOpLogger(),
 	}
 }
+
+func TestRenameServer(t *testing.T) {
+	m := testManager(t)
+	require.NoError(t, m.AddServers(ServerList{Servers: []*Server{
+		{Tag: "old", Type: "direct", Options: option.Outbound{Tag: "old", Type: "direct"}},
+		{Tag: "taken", Type: "direct", Options: option.Outbound{Tag: "taken", Type: "direct"}},
+		{Tag: "lantern", Type: "direct", IsLantern: true, Options: option.Outbound{Tag: "lantern", Type: "direct"}},
+	}}, false))
+
+	renamed, err := m.RenameServer("old", "new")
+	require.NoError(t, err)
+	assert.Equal(t, "new", renamed.Tag)
+	assert.Equal(t, "new", renamed.Options.(option.Outbound).Tag, "outbound tag should follow the server tag")
+	_, exists := m.GetServerByTag("old")
+	assert.False(t, exists)
+
+	reloaded := &Manager{servers: make(map[string]*Server), serversFile: m.serversFile, logger: log.NoOpLogger()}
+	require.NoError(t, reloaded.loadServers())
+	srv, exists := reloaded.GetServerByTag("new")
+	require.True(t, exists, "rename should be persisted")
+	assert.Equal(t, "new", srv.Options.(option.Outbound).Tag)
+
+	_, err = m.RenameServer("missing", "other")
+	assert.ErrorIs(t, err, ErrServerNotFound)
+	_, err = m.RenameServer("new", "taken")
+	assert.Error(t, err)
+	_, err = m.RenameServer("lantern", "mine")
+	assert.Error(t, err)
+}
+
+func TestSetServerMetadata(t *testing.T) {
+	m := testManager(t)
+	require.NoError(t, m.AddServers(ServerList{Servers: []*Server{
+		{Tag: "mine", Type: "direct", Options: option.Outbound{Tag: "mine", Type: "direct"}},
+	}}, false))
+
+	md := ServerMetadata{Labels: []string{"home", "fast"}, Notes: "router in the closet", Color: "#ff8800"}
+	require.NoError(t, m.SetServerMetadata("mine", md))
+
+	reloaded := &Manager{servers: make(map[string]*Server), serversFile: m.serversFile, logger: log.NoOpLogger()}
+	require.NoError(t, reloaded.loadServers())
+	srv, exists := reloaded.GetServerByTag("mine")
+	require.True(t, exists)
+	require.NotNil(t, srv.Metadata)
+	assert.Equal(t, md, *srv.Metadata)
+
+	require.NoError(t, m.SetServerMetadata("mine", ServerMetadata{}))
+	srv, _ = m.GetServerByTag("mine")
+	assert.Nil(t, srv.Metadata, "zero metadata should clear it")
+
+	assert.ErrorIs(t, m.SetServerMetadata("missing", md), ErrServerNotFound)
+}
+
+func TestAddServersBatch(t *testing.T) {
+	direct := func(tag string, lantern bool) *Server {
+		return &Server{Tag: tag, Type: "direct", IsLantern: lantern, Options: option.Outbound{Tag: tag, Type: "direct"}}
+	}
+	m := testManager(t)
+	require.NoError(t, m.AddServers(ServerList{Servers: []*Server{direct("a", false), direct("lantern", true)}}, false))
+
+	batch := ServerList{Servers: []*Server{direct("a", false), direct("b", false), direct("lantern", false)}}
+	res, err := m.AddServersBatch(batch, false)
+	require.NoError(t, err)
+	assert.Equal(t, BatchResult{Added: []string{"b"}, Skipped: []string{"a", "lantern"}}, res)
+
+	res, err = m.AddServersBatch(batch, true)
+	require.NoError(t, err)
+	assert.Equal(t, BatchResult{Replaced: []string{"a", "b"}, Skipped: []string{"lantern"}}, res)
+	srv, _ := m.GetServerByTag("lantern")
+	assert.True(t, srv.IsLantern, "a Lantern server must not be replaced by a user server")
+
+	reloaded := &Manager{servers: make(map[string]*Server), serversFile: m.serversFile, logger: log.NoOpLogger()}
+	require.NoError(t, reloaded.loadServers())
+	assert.Len(t, reloaded.AllServers(), 3)
+}
+
+func TestProbeServers(t *testing.T) {
+	up, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer up.Close()
+	down, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	down.Close()
+
+	socks := func(tag string, addr net.Addr) *Server {
+		tcpAddr := addr.(*net.TCPAddr)
+		return &Server{Tag: tag, Type: "socks", Options: option.Outbound{
+			Tag:  tag,
+			Type: "socks",
+			Options: &option.SOCKSOutboundOptions{
+				ServerOptions: option.ServerOptions{Server: "127.0.0.1", ServerPort: uint16(tcpAddr.Port)},
+			},
+		}}
+	}
+	m := testManager(t)
+	m.dialContext = (&net.Dialer{}).DialContext
+	require.NoError(t, m.AddServers(ServerList{Servers: []*Server{
+		socks("up", up.Addr()),
+		socks("down", down.Addr()),
+		{Tag: "hy2", Type: "hysteria2", Options: option.Outbound{
+			Tag:     "hy2",
+			Type:    "hysteria2",
+			Options: &option.Hysteria2OutboundOptions{ServerOptions: option.ServerOptions{Server: "127.0.0.1", ServerPort: 443}},
+		}},
+	}}, false))
+
+	results, err := m.ProbeServers(context.Background(), []string{"up", "down", "hy2", "missing"})
+	require.NoError(t, err)
+	require.Len(t, results, 2, "UDP-only and unknown servers should not be probed")
+	assert.True(t, results["up"].Reachable)
+	assert.False(t, results["down"].Reachable)
+	assert.NotEmpty(t, results["down"].Error)
+
+	reloaded := &Manager{servers: make(map[string]*Server), serversFile: m.serversFile, logger: log.NoOpLogger()}
+	require.NoError(t, reloaded.loadServers())
+	srv, _ := reloaded.GetServerByTag("down")
+	require.NotNil(t, srv.Reachability)
+	assert.False(t, srv.Reachability.Reachable)
+	srv, _ = reloaded.GetServerByTag("hy2")
+	assert.Nil(t, srv.Reachability)
+}
+
+func TestDisableDeadServers(t *testing.T) {
+	m := testManager(t)
+	require.NoError(t, m.AddServers(ServerList{Servers: []*Server{
+		{Tag: "dead", Type: "direct", Options: option.Outbound{Tag: "dead", Type: "direct"}},
+		{Tag: "flaky", Type: "direct", Options: option.Outbound{Tag: "flaky", Type: "direct"}},
+		{Tag: "lantern", Type: "direct", IsLantern: true, Options: option.Outbound{Tag: "lantern", Type: "direct"}},
+	}}, false))
+
+	start := time.Now().Add(-10 * 24 * time.Hour)
+	for i := range 3 {
+		at := start.Add(time.Duration(i) * time.Hour)
+		failed := SelectionHistory{ConsecutiveFailures: uint32(i + 1), LastOutcomeAt: at, UpdatedAt: at}
+		require.NoError(t, m.UpdateSelectionHistory(map[string]SelectionHistory{"dead": failed, "flaky": failed, "lantern": failed}))
+	}
+	// a history reported again must not add to the streak
+	srv, _ := m.GetServerByTag("dead")
+	require.NoError(t, m.UpdateSelectionHistory(map[string]SelectionHistory{"dead": *srv.SelectionHistory}))
+	srv, _ = m.GetServerByTag("dead")
+	require.NotNil(t, srv.Health)
+	assert.Equal(t, 3, srv.Health.Failures)
+	assert.True(t, start.Equal(srv.Health.FailingSince))
+
+	recovered := time.Now()
+	require.NoError(t, m.UpdateSelectionHistory(map[string]SelectionHistory{
+		"flaky": {LastSuccessDelayMs: 120, LastOutcomeAt: recovered, UpdatedAt: recovered},
+	}))
+
+	emitted := make(chan []string, 1)
+	sub := events.Subscribe(func(evt ServersDisabledEvent) { emitted <- evt.Tags })
+	defer sub.Unsubscribe()
+
+	disabled, err := m.DisableDeadServers(7 * 24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dead"}, disabled)
+	select {
+	case tags := <-emitted:
+		assert.Equal(t, []string{"dead"}, tags)
+	case <-time.After(time.Second):
+		t.Fatal("ServersDisabledEvent was not emitted")
+	}
+	srv, _ = m.GetServerByTag("dead")
+	assert.True(t, srv.Disabled)
+	srv, _ = m.GetServerByTag("lantern")
+	assert.False(t, srv.Disabled, "Lantern servers must not be disabled")
+
+	require.NoError(t, m.SetServerDisabled("dead", false))
+	disabled, err = m.DisableDeadServers(7 * 24 * time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, disabled, "enabling a server should reset its failure streak")
+}
+
+func TestManagersSharingServersFile(t *testing.T) {
+	direct := func(tag string) *Server {
+		return &Server{Tag: tag, Type: "direct", Options: option.Outbound{Tag: tag, Type: "direct"}}
+	}
+	gui := testManager(t)
+	daemon := &Manager{servers: make(map[string]*Server), serversFile: gui.serversFile, logger: log.NoOpLogger()}
+	require.NoError(t, gui.loadServers())
+	require.NoError(t, daemon.loadServers())
+
+	require.NoError(t, gui.AddServers(ServerList{Servers: []*Server{direct("from-gui")}}, false))
+	require.NoError(t, daemon.AddServers(ServerList{Servers: []*Server{direct("from-daemon")}}, false))
+	_, err := gui.RemoveServers([]string{"from-daemon"})
+	require.NoError(t, err)
+	require.NoError(t, daemon.SetServerMetadata("from-gui", ServerMetadata{Notes: "daemon note"}))
+
+	reloaded := &Manager{servers: make(map[string]*Server), serversFile: gui.serversFile, logger: log.NoOpLogger()}
+	require.NoError(t, reloaded.loadServers())
+	_, exists := reloaded.GetServerByTag("from-daemon")
+	assert.False(t, exists, "the GUI's removal should not be undone by the daemon")
+	srv, exists := reloaded.GetServerByTag("from-gui")
+	require.True(t, exists, "the daemon should not clobber the GUI's server")
+	require.NotNil(t, srv.Metadata)
+	assert.Equal(t, "daemon note", srv.Metadata.Notes)
+}
+
+func TestServerChangeEvents(t *testing.T) {
+	direct := func(tag string) *Server {
+		return &Server{Tag: tag, Type: "direct", Options: option.Outbound{Tag: tag, Type: "direct"}}
+	}
+	changes := make(chan string, 10)
+	added := events.Subscribe(func(evt ServerAddedEvent) {
+		changes <- fmt.Sprintf("added %s replaced=%t", evt.Server.Tag, evt.Replaced)
+	})
+	defer added.Unsubscribe()
+	removed := events.Subscribe(func(evt ServerRemovedEvent) { changes <- "removed " + evt.Server.Tag })
+	defer removed.Unsubscribe()
+	replaced := events.Subscribe(func(evt ServersReplacedEvent) {
+		changes <- fmt.Sprintf("replaced %d", len(evt.Servers))
+	})
+	defer replaced.Unsubscribe()
+	expect := func(want ...string) {
+		t.Helper()
+		var got []string
+		for range want {
+			select {
+			case c := <-changes:
+				got = append(got, c)
+			case <-time.After(time.Second):
+				t.Fatalf("timed out waiting for %v, got %v", want, got)
+			}
+		}
+		assert.ElementsMatch(t, want, got)
+	}
+
+	m := testManager(t)
+	require.NoError(t, m.AddServers(ServerList{Servers: []*Server{direct("a"), direct("b")}}, false))
+	expect("added a replaced=false", "added b replaced=false")
+
+	require.NoError(t, m.AddServers(ServerList{Servers: []*Server{direct("a")}}, true))
+	expect("added a replaced=true")
+
+	_, err := m.RenameServer("b", "c")
+	require.NoError(t, err)
+	expect("removed b", "added c replaced=false")
+
+	_, err = m.RemoveServers([]string{"a", "missing"})
+	require.NoError(t, err)
+	expect("removed a")
+
+	other := &Manager{servers: make(map[string]*Server), serversFile: m.serversFile, logger: log.NoOpLogger()}
+	require.NoError(t, other.loadServers())
+	require.NoError(t, other.AddServers(ServerList{Servers: []*Server{direct("d")}}, false))
+	expect("added d replaced=false")
+	require.NoError(t, m.SetServerMetadata("c", ServerMetadata{Notes: "note"}))
+	expect("replaced 2")
+}
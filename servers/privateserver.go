@@ -0,0 +1,39 @@
+package servers
+
+import "errors"
+
+// RemovePrivateServer removes the user servers of the private server at ip and forgets its trusted
+// certificate fingerprint, so a new server later given the same address isn't taken for a changed
+// one. It returns the removed servers. The server itself isn't affected.
+func (m *Manager) RemovePrivateServer(ip string) ([]*Server, error) {
+	var (
+		removed []*Server
+		changes serverChanges
+	)
+	err := m.update(func() error {
+		for tag, srv := range m.servers {
+			if srv.Credentials == nil {
+				continue
+			}
+			if opts, ok := serverOptions(srv); !ok || opts.Server != ip {
+				continue
+			}
+			delete(m.servers, tag)
+			removed = append(removed, srv)
+			changes.remove(srv)
+		}
+		if len(removed) == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	changes.emit()
+	if err := m.RemoveTrustedFingerprint(ip); err != nil && !errors.Is(err, ErrFingerprintNotFound) {
+		return removed, err
+	}
+	m.logger.Info("Removed private server", "ip", ip, "servers", len(removed))
+	return removed, nil
+}
@@ -0,0 +1,61 @@
+package servers
+
+import (
+	"crypto/sha256"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sagernet/sing-box/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getlantern/radiance/internal"
+	"github.com/getlantern/radiance/log"
+)
+
+// testPrivateServer returns a user server for the private server at ip, with the credentials for
+// its server manager on port.
+func testPrivateServer(tag, ip string, port int) *Server {
+	return &Server{
+		Tag:  tag,
+		Type: "shadowsocks",
+		Options: option.Outbound{
+			Tag:  tag,
+			Type: "shadowsocks",
+			Options: &option.ShadowsocksOutboundOptions{
+				ServerOptions: option.ServerOptions{Server: ip, ServerPort: 8443},
+				Method:        "chacha20-ietf-poly1305",
+				Password:      "pw",
+			},
+		},
+		Credentials: &ServerCredentials{AccessToken: "secret", Port: port},
+	}
+}
+
+func TestRemovePrivateServer(t *testing.T) {
+	m := testManager(t)
+	m.fingerprints = newFingerprintStore(filepath.Join(t.TempDir(), internal.ServerFingerprintsFileName), log.NoOpLogger())
+	require.NoError(t, m.AddServers(ServerList{Servers: []*Server{
+		testPrivateServer("owned", "192.0.2.1", 8080),
+		testPrivateServer("joined", "192.0.2.1", 8080),
+		testPrivateServer("other", "192.0.2.2", 8080),
+		{Tag: "direct", Type: "direct", Options: option.Outbound{Tag: "direct", Type: "direct"}},
+	}}, false))
+	require.NoError(t, m.ReplaceTrustedFingerprint("192.0.2.1", strings.Repeat("ab", sha256.Size)))
+
+	removed, err := m.RemovePrivateServer("192.0.2.1")
+	require.NoError(t, err)
+	var tags []string
+	for _, srv := range removed {
+		tags = append(tags, srv.Tag)
+	}
+	assert.ElementsMatch(t, []string{"owned", "joined"}, tags)
+	_, exists := m.GetServerByTag("other")
+	assert.True(t, exists)
+	_, exists = m.GetServerByTag("direct")
+	assert.True(t, exists)
+	fps, err := m.TrustedFingerprints()
+	require.NoError(t, err)
+	assert.Empty(t, fps)
+}
@@ -0,0 +1,162 @@
+package servers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	box "github.com/getlantern/lantern-box"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json"
+)
+
+// QR payloads are the text encoded in the QR codes used to share servers between devices. The
+// app draws and scans the codes; this file only produces and reads their contents. A user
+// server is shared as
+//
+//	lantern://server?config=<base64url sing-box JSON>
+//
+// which AddServersByURL accepts like any share link, and a private server invite as
+//
+//	lantern://private-server?ip=<ip>&port=<port>&token=<invite token>&fp=<fingerprint>&tag=<tag>
+//
+// which is read with ParsePrivateServerInvite and passed to AddPrivateServer, after pinning the
+// certificate fingerprint with PinTrustedFingerprint.
+const (
+	qrPayloadPrefix = "lantern://"
+	qrServerHost    = "server"
+	qrInviteHost    = "private-server"
+)
+
+// ErrNotShareable is returned by [Manager.ServerQRPayload] and [Manager.ExportServer] for servers
+// that can't be shared: Lantern servers, and private servers, which are shared with invites
+// instead so that their access tokens never leave the device.
+var ErrNotShareable = errors.New("server can't be shared")
+
+// shareable reports whether srv may be shared with other devices or clients.
+func shareable(srv *Server) bool {
+	return !srv.IsLantern && srv.Credentials == nil
+}
+
+// PrivateServerInvite is a private server invite read from a QR payload.
+type PrivateServerInvite struct {
+	Tag   string `json:"tag"`
+	IP    string `json:"ip"`
+	Port  int    `json:"port"`
+	Token string `json:"token"`
+	// Fingerprint is the SHA-256 fingerprint of the server's certificate, as trusted by the
+	// inviter. It's empty if the inviter had none, e.g. because the certificate is publicly
+	// trusted.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// ServerQRPayload returns the QR payload that shares the user server with the given tag.
+func (m *Manager) ServerQRPayload(tag string) (string, error) {
+	srv, ok := m.GetServerByTag(tag)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrServerNotFound, tag)
+	}
+	if !shareable(srv) {
+		return "", fmt.Errorf("%w: %q", ErrNotShareable, tag)
+	}
+	var cfg singboxConfig
+	switch opts := srv.Options.(type) {
+	case option.Outbound:
+		cfg.Outbounds = []option.Outbound{opts}
+	case option.Endpoint:
+		cfg.Endpoints = []option.Endpoint{opts}
+	default:
+		return "", fmt.Errorf("%w: %q has no options", ErrNotShareable, tag)
+	}
+	data, err := json.MarshalContext(box.BaseContext(), cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal server: %w", err)
+	}
+	q := url.Values{"config": {base64.RawURLEncoding.EncodeToString(data)}}
+	return qrPayloadPrefix + qrServerHost + "?" + q.Encode(), nil
+}
+
+// PrivateServerInviteQRPayload returns the QR payload for an invite to the private server at
+// ip:port, as created by [Manager.InviteToPrivateServer]. fingerprint is the server's trusted
+// certificate fingerprint, if it has one, so the invitee can verify the server. The invitee's
+// server gets the given tag, or the IP if it's empty.
+func PrivateServerInviteQRPayload(ip string, port int, inviteToken, fingerprint, tag string) string {
+	q := url.Values{
+		"ip":    {ip},
+		"port":  {strconv.Itoa(port)},
+		"token": {inviteToken},
+		"tag":   {tag},
+	}
+	if fingerprint != "" {
+		q.Set("fp", fingerprint)
+	}
+	return qrPayloadPrefix + qrInviteHost + "?" + q.Encode()
+}
+
+// ParsePrivateServerInvite reads a private server invite from a scanned QR payload. It returns
+// false if the payload isn't an invite, in which case it can be passed to
+// [Manager.AddServersByURL].
+func ParsePrivateServerInvite(payload string) (PrivateServerInvite, bool, error) {
+	u, ok := parseQRPayload(payload, qrInviteHost)
+	if !ok {
+		return PrivateServerInvite{}, false, nil
+	}
+	q := u.Query()
+	inv := PrivateServerInvite{
+		Tag:   q.Get("tag"),
+		IP:    q.Get("ip"),
+		Token: q.Get("token"),
+	}
+	port, err := strconv.Atoi(q.Get("port"))
+	if err != nil || port <= 0 || port > 65535 || inv.IP == "" || inv.Token == "" {
+		return PrivateServerInvite{}, true, fmt.Errorf("invalid private server invite")
+	}
+	inv.Port = port
+	if fp := q.Get("fp"); fp != "" {
+		if inv.Fingerprint, err = normalizeFingerprint(fp); err != nil {
+			return PrivateServerInvite{}, true, fmt.Errorf("invalid private server invite: %w", err)
+		}
+	}
+	if inv.Tag == "" {
+		inv.Tag = inv.IP
+	}
+	return inv, true, nil
+}
+
+// isServerQRPayload reports whether raw is a QR payload made by [Manager.ServerQRPayload].
+func isServerQRPayload(raw string) bool {
+	_, ok := parseQRPayload(raw, qrServerHost)
+	return ok
+}
+
+// parseServerQRPayload returns the servers in a QR payload made by [Manager.ServerQRPayload].
+func parseServerQRPayload(raw string) (singboxConfig, error) {
+	u, ok := parseQRPayload(raw, qrServerHost)
+	if !ok {
+		return singboxConfig{}, fmt.Errorf("not a server QR payload")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(u.Query().Get("config"))
+	if err != nil {
+		return singboxConfig{}, fmt.Errorf("invalid server QR payload: %w", err)
+	}
+	cfg, err := json.UnmarshalExtendedContext[singboxConfig](box.BaseContext(), data)
+	if err != nil {
+		return singboxConfig{}, fmt.Errorf("invalid server QR payload: %w", err)
+	}
+	return cfg, nil
+}
+
+func parseQRPayload(raw, host string) (*url.URL, bool) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(strings.ToLower(raw), qrPayloadPrefix) {
+		return nil, false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || !strings.EqualFold(u.Host, host) {
+		return nil, false
+	}
+	return u, true
+}
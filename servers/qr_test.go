@@ -0,0 +1,73 @@
+package servers
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/sagernet/sing-box/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerQRPayload(t *testing.T) {
+	src := testManager(t)
+	_, err := src.AddServersByURL(t.Context(), []string{"trojan://password@host:443?security=tls&sni=example.com#Trojan+with+TLS"}, false)
+	require.NoError(t, err)
+	payload, err := src.ServerQRPayload("Trojan+with+TLS")
+	require.NoError(t, err)
+
+	_, isInvite, err := ParsePrivateServerInvite(payload)
+	require.NoError(t, err)
+	assert.False(t, isInvite)
+
+	dst := testManager(t)
+	list, err := dst.AddServersByURL(t.Context(), []string{payload}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Trojan+with+TLS"}, list.Tags())
+	server, exists := dst.GetServerByTag("Trojan+with+TLS")
+	require.True(t, exists)
+	opts := server.Options.(option.Outbound).Options
+	require.IsType(t, &option.TrojanOutboundOptions{}, opts)
+	assert.Equal(t, "password", opts.(*option.TrojanOutboundOptions).Password)
+
+	t.Run("not shareable", func(t *testing.T) {
+		require.NoError(t, src.AddServers(ServerList{Servers: []*Server{
+			{Tag: "lantern", Type: "direct", IsLantern: true, Options: option.Outbound{Tag: "lantern", Type: "direct"}},
+		}}, false))
+		_, err := src.ServerQRPayload("lantern")
+		assert.ErrorIs(t, err, ErrNotShareable)
+
+		require.NoError(t, src.AddServers(ServerList{Servers: []*Server{
+			{Tag: "private", Type: "direct", Credentials: &ServerCredentials{AccessToken: "secret"}, Options: option.Outbound{Tag: "private", Type: "direct"}},
+		}}, false))
+		_, err = src.ServerQRPayload("private")
+		assert.ErrorIs(t, err, ErrNotShareable)
+		_, err = src.ExportServer("private", ExportSingBox)
+		assert.ErrorIs(t, err, ErrNotShareable, "private servers' credentials must not be exported")
+	})
+}
+
+func TestPrivateServerInviteQRPayload(t *testing.T) {
+	payload := PrivateServerInviteQRPayload("203.0.113.1", 8443, "invite-token", "", "")
+	inv, isInvite, err := ParsePrivateServerInvite(payload)
+	require.NoError(t, err)
+	require.True(t, isInvite)
+	assert.Equal(t, PrivateServerInvite{Tag: "203.0.113.1", IP: "203.0.113.1", Port: 8443, Token: "invite-token"}, inv)
+
+	fp := strings.Repeat("ab", sha256.Size)
+	inv, _, err = ParsePrivateServerInvite(PrivateServerInviteQRPayload("203.0.113.1", 8443, "invite-token", fp, "mine"))
+	require.NoError(t, err)
+	assert.Equal(t, PrivateServerInvite{Tag: "mine", IP: "203.0.113.1", Port: 8443, Token: "invite-token", Fingerprint: fp}, inv)
+
+	_, _, err = ParsePrivateServerInvite(PrivateServerInviteQRPayload("203.0.113.1", 8443, "invite-token", "not-a-fingerprint", ""))
+	assert.Error(t, err)
+
+	_, isInvite, err = ParsePrivateServerInvite("lantern://private-server?ip=203.0.113.1")
+	assert.True(t, isInvite)
+	assert.Error(t, err)
+
+	_, isInvite, err = ParsePrivateServerInvite("trojan://password@host:443")
+	assert.False(t, isInvite)
+	assert.NoError(t, err)
+}
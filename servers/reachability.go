@@ -0,0 +1,132 @@
+package servers
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+// probeTimeout bounds each reachability probe. Probes run when servers are added, so a server
+// that can't be reached shouldn't hold up the add for long.
+const probeTimeout = 5 * time.Second
+
+// Reachability is the result of the last connectivity probe of a server, which lets the UI warn
+// that a server appears unreachable before the user selects it.
+type Reachability struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Reachable bool      `json:"reachable"`
+	// LatencyMs is the time taken to open a connection to the server.
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ProbeServers checks whether each of the user servers with the given tags accepts connections
+// and stores the results with the servers. The probe only opens a TCP connection to the server's
+// address; it doesn't test the proxy protocol. Servers that only use UDP, such as Hysteria2 and
+// WireGuard servers, can't be probed this way and are left out of the results, as are unknown tags
+// and Lantern servers.
+func (m *Manager) ProbeServers(ctx context.Context, tags []string) (map[string]Reachability, error) {
+	addrs := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		srv, ok := m.GetServerByTag(tag)
+		if !ok || srv.IsLantern {
+			continue
+		}
+		if addr, ok := probeAddress(srv); ok {
+			addrs[tag] = addr
+		}
+	}
+	if len(addrs) == 0 {
+		return map[string]Reachability{}, nil
+	}
+
+	results := make(map[string]Reachability, len(addrs))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for tag, addr := range addrs {
+		wg.Go(func() {
+			r := m.probe(ctx, addr)
+			mu.Lock()
+			results[tag] = r
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	if err := m.update(func() error {
+		for tag, r := range results {
+			srv, exists := m.servers[tag]
+			if !exists {
+				// removed while it was being probed
+				delete(results, tag)
+				continue
+			}
+			srv = srv.Clone()
+			srv.Reachability = &r
+			recordCheck(srv, r.Reachable, r.CheckedAt)
+			m.servers[tag] = srv
+		}
+		return nil
+	}); err != nil {
+		return results, err
+	}
+	for tag, r := range results {
+		m.logger.Info("Probed server", "tag", tag, "reachable", r.Reachable, "latency_ms", r.LatencyMs, "error", r.Error)
+	}
+	return results, nil
+}
+
+func (m *Manager) probe(ctx context.Context, addr string) Reachability {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := m.dialContext(ctx, "tcp", addr)
+	r := Reachability{CheckedAt: start}
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	conn.Close()
+	r.Reachable = true
+	r.LatencyMs = time.Since(start).Milliseconds()
+	return r
+}
+
+// probeAddress returns the address a TCP probe of srv should connect to, or false if the server
+// can't be probed over TCP.
+func probeAddress(srv *Server) (string, bool) {
+	out, ok := srv.Options.(option.Outbound)
+	if !ok {
+		return "", false
+	}
+	switch out.Type {
+	case constant.TypeHysteria, constant.TypeHysteria2, constant.TypeTUIC, constant.TypeWireGuard:
+		return "", false
+	}
+	opts, ok := serverOptions(srv)
+	if !ok || opts.ServerPort == 0 {
+		return "", false
+	}
+	return net.JoinHostPort(opts.Server, strconv.Itoa(int(opts.ServerPort))), true
+}
+
+// serverOptions returns the address options of srv, or false if it has none.
+func serverOptions(srv *Server) (option.ServerOptions, bool) {
+	out, ok := srv.Options.(option.Outbound)
+	if !ok {
+		return option.ServerOptions{}, false
+	}
+	wrapper, ok := out.Options.(option.ServerOptionsWrapper)
+	if !ok {
+		return option.ServerOptions{}, false
+	}
+	opts := wrapper.TakeServerOptions()
+	return opts, opts.Server != ""
+}
@@ -0,0 +1,153 @@
+package servers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+)
+
+const shadowsocksScheme = "ss://"
+
+// supportedSSPlugins are the SIP003 plugins sing-box implements itself.
+var supportedSSPlugins = []string{"obfs-local", "v2ray-plugin"}
+
+// isShadowsocksURL reports whether raw is an ss:// access key.
+func isShadowsocksURL(raw string) bool {
+	return len(raw) >= len(shadowsocksScheme) && strings.EqualFold(raw[:len(shadowsocksScheme)], shadowsocksScheme)
+}
+
+// parseShadowsocksURL parses a Shadowsocks access key, as shared by Outline and most other
+// Shadowsocks clients, into a sing-box outbound. Both the SIP002 form
+//
+//	ss://base64url(method:password)@host:port/?plugin=obfs-local%3Bobfs%3Dhttp#tag
+//
+// with the user info optionally percent-encoded instead of base64 encoded, and the legacy form
+//
+//	ss://base64(method:password@host:port)#tag
+//
+// are accepted. The tag defaults to host:port if the key has none. Outline's "prefix"
+// parameter is ignored, since servers accept connections without it.
+//
+// The key is parsed by hand rather than with [url.Parse], as standard base64 user info may
+// contain '/' and would be taken for a path.
+func parseShadowsocksURL(raw string) (option.Outbound, error) {
+	raw = strings.TrimSpace(raw)
+	if !isShadowsocksURL(raw) {
+		return option.Outbound{}, fmt.Errorf("not an ss:// URL")
+	}
+	rest := raw[len(shadowsocksScheme):]
+	rest, fragment, _ := strings.Cut(rest, "#")
+	tag, err := url.PathUnescape(fragment)
+	if err != nil {
+		return option.Outbound{}, fmt.Errorf("invalid ss:// URL tag: %w", err)
+	}
+	rest, rawQuery, _ := strings.Cut(rest, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return option.Outbound{}, fmt.Errorf("invalid ss:// URL query: %w", err)
+	}
+	rest = strings.TrimSuffix(rest, "/")
+
+	var method, password, hostPort string
+	if userInfo, hp, ok := cutLast(rest, "@"); ok {
+		// SIP002
+		hostPort = hp
+		userInfo, err := url.PathUnescape(userInfo)
+		if err != nil {
+			return option.Outbound{}, fmt.Errorf("invalid ss:// URL user info: %w", err)
+		}
+		if decoded, err := decodeBase64(userInfo); err == nil {
+			userInfo = string(decoded)
+		}
+		var found bool
+		if method, password, found = strings.Cut(userInfo, ":"); !found {
+			return option.Outbound{}, fmt.Errorf("ss:// URL user info is neither base64 nor method:password")
+		}
+	} else {
+		decoded, err := decodeBase64(rest)
+		if err != nil {
+			return option.Outbound{}, fmt.Errorf("invalid legacy ss:// URL: %w", err)
+		}
+		creds, hp, ok := cutLast(string(decoded), "@")
+		if !ok {
+			return option.Outbound{}, fmt.Errorf("legacy ss:// URL is missing the server address")
+		}
+		hostPort = hp
+		var found bool
+		if method, password, found = strings.Cut(creds, ":"); !found {
+			return option.Outbound{}, fmt.Errorf("legacy ss:// URL is missing the method or password")
+		}
+	}
+	if method == "" || (password == "" && method != "none") {
+		return option.Outbound{}, fmt.Errorf("ss:// URL is missing the method or password")
+	}
+
+	host, portStr, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return option.Outbound{}, fmt.Errorf("invalid ss:// URL server address %q: %w", hostPort, err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || port == 0 || host == "" {
+		return option.Outbound{}, fmt.Errorf("invalid ss:// URL server address %q", hostPort)
+	}
+
+	opts := &option.ShadowsocksOutboundOptions{
+		ServerOptions: option.ServerOptions{
+			Server:     host,
+			ServerPort: uint16(port),
+		},
+		Method:   strings.ToLower(method),
+		Password: password,
+	}
+	if plugin := query.Get("plugin"); plugin != "" {
+		name, pluginOpts, _ := strings.Cut(plugin, ";")
+		if name == "simple-obfs" {
+			name = "obfs-local"
+		}
+		switch {
+		case name == "none":
+		case !slices.Contains(supportedSSPlugins, name):
+			return option.Outbound{}, fmt.Errorf("unsupported Shadowsocks plugin %q", name)
+		default:
+			opts.Plugin = name
+			opts.PluginOptions = pluginOpts
+		}
+	}
+	if query.Has("prefix") {
+		slog.Warn("Ignoring the connection prefix of a Shadowsocks access key", "server", hostPort)
+	}
+
+	if tag == "" {
+		tag = net.JoinHostPort(host, portStr)
+	}
+	return option.Outbound{
+		Type:    constant.TypeShadowsocks,
+		Tag:     tag,
+		Options: opts,
+	}, nil
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// decodeBase64 decodes standard or URL-safe base64, with or without padding.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
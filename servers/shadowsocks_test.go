@@ -0,0 +1,92 @@
+package servers
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/sagernet/sing-box/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShadowsocksURL(t *testing.T) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	tests := []struct {
+		name    string
+		url     string
+		tag     string
+		want    option.ShadowsocksOutboundOptions
+		wantErr bool
+	}{
+		{
+			name: "outline key",
+			url:  "ss://" + b64([]byte("chacha20-ietf-poly1305:s3cr3t")) + "@203.0.113.1:8388/?outline=1",
+			tag:  "203.0.113.1:8388",
+			want: option.ShadowsocksOutboundOptions{
+				ServerOptions: option.ServerOptions{Server: "203.0.113.1", ServerPort: 8388},
+				Method:        "chacha20-ietf-poly1305",
+				Password:      "s3cr3t",
+			},
+		},
+		{
+			name: "padded standard base64 with prefix",
+			url:  "ss://" + base64.StdEncoding.EncodeToString([]byte("aes-256-gcm:pa:ss")) + "@example.com:443?prefix=%16%03%01#My%20Server",
+			tag:  "My Server",
+			want: option.ShadowsocksOutboundOptions{
+				ServerOptions: option.ServerOptions{Server: "example.com", ServerPort: 443},
+				Method:        "aes-256-gcm",
+				Password:      "pa:ss",
+			},
+		},
+		{
+			name: "percent-encoded user info",
+			url:  "ss://2022-blake3-aes-128-gcm:YctPZ6U7xPPcU%2Bgp3u%2B0tx%2FtRizJN9K8y%2BuKlW2qjlI%3D@[2001:db8::1]:8888#ipv6",
+			tag:  "ipv6",
+			want: option.ShadowsocksOutboundOptions{
+				ServerOptions: option.ServerOptions{Server: "2001:db8::1", ServerPort: 8888},
+				Method:        "2022-blake3-aes-128-gcm",
+				Password:      "YctPZ6U7xPPcU+gp3u+0tx/tRizJN9K8y+uKlW2qjlI=",
+			},
+		},
+		{
+			name: "plugin",
+			url:  "ss://" + b64([]byte("aes-128-gcm:test")) + "@192.168.100.1:8888/?plugin=simple-obfs%3Bobfs%3Dhttp%3Bobfs-host%3Dexample.com#obfs",
+			tag:  "obfs",
+			want: option.ShadowsocksOutboundOptions{
+				ServerOptions: option.ServerOptions{Server: "192.168.100.1", ServerPort: 8888},
+				Method:        "aes-128-gcm",
+				Password:      "test",
+				Plugin:        "obfs-local",
+				PluginOptions: "obfs=http;obfs-host=example.com",
+			},
+		},
+		{
+			name: "legacy",
+			url:  "ss://" + base64.StdEncoding.EncodeToString([]byte("bf-cfb:test/!@#:@192.168.100.1:8888")) + "#legacy",
+			tag:  "legacy",
+			want: option.ShadowsocksOutboundOptions{
+				ServerOptions: option.ServerOptions{Server: "192.168.100.1", ServerPort: 8888},
+				Method:        "bf-cfb",
+				Password:      "test/!@#:",
+			},
+		},
+		{name: "missing port", url: "ss://" + b64([]byte("aes-128-gcm:test")) + "@example.com", wantErr: true},
+		{name: "missing password", url: "ss://" + b64([]byte("aes-128-gcm")) + "@example.com:443", wantErr: true},
+		{name: "unsupported plugin", url: "ss://" + b64([]byte("aes-128-gcm:test")) + "@example.com:443/?plugin=kcptun", wantErr: true},
+		{name: "garbage", url: "ss://not base64!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := parseShadowsocksURL(tt.url)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "shadowsocks", out.Type)
+			assert.Equal(t, tt.tag, out.Tag)
+			require.IsType(t, &option.ShadowsocksOutboundOptions{}, out.Options)
+			assert.Equal(t, tt.want, *out.Options.(*option.ShadowsocksOutboundOptions))
+		})
+	}
+}
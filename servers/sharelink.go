@@ -0,0 +1,423 @@
+package servers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+// Share links are the proxy URLs other clients let users copy and paste or scan, in the format
+// documented by Xray ("VMessAEAD / VLESS share link standard") and, for hysteria2, by Hysteria.
+// They're parsed here rather than by pluriconfig so that a link using a feature sing-box lacks is
+// rejected with an error naming it, instead of being imported as a server that can't connect.
+
+// shareLinkScheme returns the lowercased scheme of raw if it's a link parseShareLink handles.
+func shareLinkScheme(raw string) (string, bool) {
+	scheme, _, found := strings.Cut(strings.TrimSpace(raw), "://")
+	if !found {
+		return "", false
+	}
+	scheme = strings.ToLower(scheme)
+	switch scheme {
+	case "ss", "vmess", "vless", "trojan", "hysteria2", "hy2":
+		return scheme, true
+	}
+	return "", false
+}
+
+// parseShareLink parses an ss://, vmess://, vless://, trojan://, or hysteria2:// share link into
+// a sing-box outbound.
+func parseShareLink(raw string) (option.Outbound, error) {
+	raw = strings.TrimSpace(raw)
+	scheme, ok := shareLinkScheme(raw)
+	if !ok {
+		return option.Outbound{}, fmt.Errorf("not a share link")
+	}
+	if scheme == "ss" {
+		return parseShadowsocksURL(raw)
+	}
+	if body, _, _ := strings.Cut(raw, "#"); scheme == "vmess" && !strings.Contains(body, "@") {
+		return parseV2RayNVMessLink(raw)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return option.Outbound{}, fmt.Errorf("invalid %s link: %w", scheme, err)
+	}
+	var out option.Outbound
+	switch scheme {
+	case "vmess":
+		out, err = parseVMessLink(u)
+	case "vless":
+		out, err = parseVLESSLink(u)
+	case "trojan":
+		out, err = parseTrojanLink(u)
+	case "hysteria2", "hy2":
+		out, err = parseHysteria2Link(u)
+	}
+	if err != nil {
+		return option.Outbound{}, fmt.Errorf("invalid %s link: %w", scheme, err)
+	}
+	return out, nil
+}
+
+func parseVLESSLink(u *url.URL) (option.Outbound, error) {
+	server, port, err := linkServer(u)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	q := u.Query()
+	if enc := q.Get("encryption"); enc != "" && enc != "none" {
+		return option.Outbound{}, fmt.Errorf("unsupported encryption %q", enc)
+	}
+	opts := &option.VLESSOutboundOptions{
+		ServerOptions: option.ServerOptions{Server: server, ServerPort: port},
+		UUID:          u.User.Username(),
+	}
+	if opts.UUID == "" {
+		return option.Outbound{}, fmt.Errorf("missing UUID")
+	}
+	switch flow := q.Get("flow"); flow {
+	case "", "none":
+	case "xtls-rprx-vision":
+		opts.Flow = flow
+	default:
+		return option.Outbound{}, fmt.Errorf("unsupported flow %q", flow)
+	}
+	if pe := q.Get("packetEncoding"); pe != "" {
+		opts.PacketEncoding = &pe
+	}
+	if opts.TLS, err = linkTLS(q, false); err != nil {
+		return option.Outbound{}, err
+	}
+	if opts.Transport, err = linkTransport(q); err != nil {
+		return option.Outbound{}, err
+	}
+	return linkOutbound(constant.TypeVLESS, u, opts), nil
+}
+
+// parseVMessLink parses a vmess:// link in the same format as a vless:// one.
+func parseVMessLink(u *url.URL) (option.Outbound, error) {
+	server, port, err := linkServer(u)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	q := u.Query()
+	opts := &option.VMessOutboundOptions{
+		ServerOptions: option.ServerOptions{Server: server, ServerPort: port},
+		UUID:          u.User.Username(),
+		Security:      q.Get("encryption"),
+	}
+	if opts.UUID == "" {
+		return option.Outbound{}, fmt.Errorf("missing UUID")
+	}
+	if opts.Security == "" {
+		opts.Security = "auto"
+	}
+	if opts.TLS, err = linkTLS(q, false); err != nil {
+		return option.Outbound{}, err
+	}
+	if opts.Transport, err = linkTransport(q); err != nil {
+		return option.Outbound{}, err
+	}
+	return linkOutbound(constant.TypeVMess, u, opts), nil
+}
+
+// looseNumber is a JSON number that some clients write as a string, possibly an empty one.
+type looseNumber string
+
+func (n *looseNumber) UnmarshalJSON(data []byte) error {
+	*n = looseNumber(strings.Trim(string(data), `"`))
+	return nil
+}
+
+func (n looseNumber) int() int {
+	i, _ := strconv.Atoi(string(n))
+	return i
+}
+
+// v2rayNVMess is the JSON encoded in a v2rayN style vmess:// link.
+type v2rayNVMess struct {
+	Name        string      `json:"ps"`
+	Address     string      `json:"add"`
+	Port        looseNumber `json:"port"`
+	ID          string      `json:"id"`
+	AlterID     looseNumber `json:"aid"`
+	Security    string      `json:"scy"`
+	Network     string      `json:"net"`
+	HeaderType  string      `json:"type"`
+	Host        string      `json:"host"`
+	Path        string      `json:"path"`
+	TLS         string      `json:"tls"`
+	SNI         string      `json:"sni"`
+	ALPN        string      `json:"alpn"`
+	Fingerprint string      `json:"fp"`
+	Insecure    looseNumber `json:"allowInsecure"`
+}
+
+// parseV2RayNVMessLink parses a vmess:// link whose body is base64 encoded JSON, as written by
+// v2rayN and most clients derived from it.
+func parseV2RayNVMessLink(raw string) (option.Outbound, error) {
+	body := raw[len("vmess://"):]
+	body, _, _ = strings.Cut(body, "#")
+	decoded, err := decodeBase64(strings.TrimSpace(body))
+	if err != nil {
+		return option.Outbound{}, fmt.Errorf("invalid vmess link: %w", err)
+	}
+	var v v2rayNVMess
+	if err := json.Unmarshal(decoded, &v); err != nil {
+		return option.Outbound{}, fmt.Errorf("invalid vmess link: %w", err)
+	}
+	port, err := strconv.ParseUint(string(v.Port), 10, 16)
+	if err != nil || port == 0 || v.Address == "" {
+		return option.Outbound{}, fmt.Errorf("invalid vmess link: invalid server address %q", net.JoinHostPort(v.Address, string(v.Port)))
+	}
+	if v.ID == "" {
+		return option.Outbound{}, fmt.Errorf("invalid vmess link: missing UUID")
+	}
+	opts := &option.VMessOutboundOptions{
+		ServerOptions: option.ServerOptions{Server: v.Address, ServerPort: uint16(port)},
+		UUID:          v.ID,
+		Security:      v.Security,
+		AlterId:       v.AlterID.int(),
+	}
+	if opts.Security == "" {
+		opts.Security = "auto"
+	}
+	// Reuse the share link parameter handling by mapping the JSON fields onto their query names.
+	q := url.Values{
+		"type":       {v.Network},
+		"headerType": {v.HeaderType},
+		"host":       {v.Host},
+		"path":       {v.Path},
+		"security":   {v.TLS},
+		"sni":        {v.SNI},
+		"alpn":       {v.ALPN},
+		"fp":         {v.Fingerprint},
+	}
+	if v.Network == "grpc" {
+		q.Set("serviceName", v.Path)
+	}
+	if v.Insecure.int() == 1 {
+		q.Set("allowInsecure", "1")
+	}
+	if opts.TLS, err = linkTLS(q, false); err != nil {
+		return option.Outbound{}, fmt.Errorf("invalid vmess link: %w", err)
+	}
+	if opts.Transport, err = linkTransport(q); err != nil {
+		return option.Outbound{}, fmt.Errorf("invalid vmess link: %w", err)
+	}
+	tag := v.Name
+	if tag == "" {
+		tag = net.JoinHostPort(v.Address, string(v.Port))
+	}
+	return option.Outbound{Type: constant.TypeVMess, Tag: tag, Options: opts}, nil
+}
+
+func parseTrojanLink(u *url.URL) (option.Outbound, error) {
+	server, port, err := linkServer(u)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	q := u.Query()
+	opts := &option.TrojanOutboundOptions{
+		ServerOptions: option.ServerOptions{Server: server, ServerPort: port},
+		Password:      u.User.Username(),
+	}
+	if opts.Password == "" {
+		return option.Outbound{}, fmt.Errorf("missing password")
+	}
+	// Trojan-Go links name the SNI "peer".
+	if q.Get("sni") == "" && q.Get("peer") != "" {
+		q.Set("sni", q.Get("peer"))
+	}
+	if opts.TLS, err = linkTLS(q, true); err != nil {
+		return option.Outbound{}, err
+	}
+	if opts.Transport, err = linkTransport(q); err != nil {
+		return option.Outbound{}, err
+	}
+	return linkOutbound(constant.TypeTrojan, u, opts), nil
+}
+
+func parseHysteria2Link(u *url.URL) (option.Outbound, error) {
+	server := u.Hostname()
+	if server == "" {
+		return option.Outbound{}, fmt.Errorf("missing server address")
+	}
+	q := u.Query()
+	opts := &option.Hysteria2OutboundOptions{
+		ServerOptions: option.ServerOptions{Server: server, ServerPort: 443},
+		Password:      u.User.Username(),
+	}
+	// The auth may be given as user:pass, which the server expects verbatim.
+	if pass, ok := u.User.Password(); ok {
+		opts.Password += ":" + pass
+	}
+	if p := u.Port(); p != "" {
+		n, err := strconv.ParseUint(p, 10, 16)
+		if err != nil || n == 0 {
+			return option.Outbound{}, fmt.Errorf("invalid port %q", p)
+		}
+		opts.ServerPort = uint16(n)
+	}
+	// Port hopping links list the ports to hop between, e.g. "443,5000-6000".
+	for r := range strings.SplitSeq(q.Get("mport"), ",") {
+		if r == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(r, "-")
+		if !isRange {
+			to = from
+		}
+		if _, err := strconv.ParseUint(from, 10, 16); err != nil {
+			return option.Outbound{}, fmt.Errorf("invalid port range %q", r)
+		}
+		if _, err := strconv.ParseUint(to, 10, 16); err != nil {
+			return option.Outbound{}, fmt.Errorf("invalid port range %q", r)
+		}
+		opts.ServerPorts = append(opts.ServerPorts, from+":"+to)
+	}
+	switch obfs := q.Get("obfs"); obfs {
+	case "", "none":
+	case "salamander":
+		opts.Obfs = &option.Hysteria2Obfs{Type: obfs, Password: q.Get("obfs-password")}
+	default:
+		return option.Outbound{}, fmt.Errorf("unsupported obfs %q", obfs)
+	}
+	if q.Get("pinSHA256") != "" {
+		return option.Outbound{}, fmt.Errorf("certificate pinning (pinSHA256) is not supported")
+	}
+	opts.TLS = &option.OutboundTLSOptions{
+		Enabled:    true,
+		ServerName: q.Get("sni"),
+		Insecure:   isTrue(q.Get("insecure")) || isTrue(q.Get("allowInsecure")),
+	}
+	return linkOutbound(constant.TypeHysteria2, u, opts), nil
+}
+
+// linkServer returns the server address and port of a share link.
+func linkServer(u *url.URL) (string, uint16, error) {
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	if err != nil || port == 0 || u.Hostname() == "" {
+		return "", 0, fmt.Errorf("invalid server address %q", u.Host)
+	}
+	return u.Hostname(), uint16(port), nil
+}
+
+// linkOutbound returns an outbound tagged with the link's fragment, or its host:port if it has
+// none.
+func linkOutbound(typ string, u *url.URL, opts any) option.Outbound {
+	tag := u.Fragment
+	if tag == "" {
+		tag = u.Host
+	}
+	return option.Outbound{Type: typ, Tag: tag, Options: opts}
+}
+
+// linkTLS returns the TLS options for the security parameters of a share link. defaultTLS is
+// whether TLS is used if the link doesn't say.
+func linkTLS(q url.Values, defaultTLS bool) (*option.OutboundTLSOptions, error) {
+	security := q.Get("security")
+	if security == "" && defaultTLS {
+		security = "tls"
+	}
+	switch security {
+	case "", "none":
+		return nil, nil
+	case "tls", "reality":
+	default:
+		return nil, fmt.Errorf("unsupported security %q", security)
+	}
+	tls := &option.OutboundTLSOptions{
+		Enabled:    true,
+		ServerName: q.Get("sni"),
+		Insecure:   isTrue(q.Get("allowInsecure")) || isTrue(q.Get("insecure")),
+	}
+	if alpn := q.Get("alpn"); alpn != "" {
+		tls.ALPN = strings.Split(alpn, ",")
+	}
+	fp := q.Get("fp")
+	if security == "reality" {
+		if q.Get("pbk") == "" {
+			return nil, fmt.Errorf("reality link is missing the public key (pbk)")
+		}
+		tls.Reality = &option.OutboundRealityOptions{
+			Enabled:   true,
+			PublicKey: q.Get("pbk"),
+			ShortID:   q.Get("sid"),
+		}
+		// sing-box only implements REALITY on top of uTLS.
+		if fp == "" {
+			fp = "chrome"
+		}
+	}
+	if fp != "" && fp != "none" {
+		tls.UTLS = &option.OutboundUTLSOptions{Enabled: true, Fingerprint: fp}
+	}
+	return tls, nil
+}
+
+// linkTransport returns the V2Ray transport for the type parameter of a share link, or nil for
+// plain TCP.
+func linkTransport(q url.Values) (*option.V2RayTransportOptions, error) {
+	host, path := q.Get("host"), q.Get("path")
+	switch typ := q.Get("type"); typ {
+	case "", "tcp", "raw":
+		if ht := q.Get("headerType"); ht != "" && ht != "none" {
+			return nil, fmt.Errorf("unsupported tcp header type %q", ht)
+		}
+		return nil, nil
+	case "ws", "websocket":
+		ws := option.V2RayWebsocketOptions{Path: path}
+		// Xray puts the early data size in the path, e.g. "/path?ed=2048".
+		if p, rawQuery, ok := strings.Cut(path, "?"); ok {
+			pq, _ := url.ParseQuery(rawQuery)
+			if ed, err := strconv.ParseUint(pq.Get("ed"), 10, 32); err == nil {
+				ws.Path = p
+				ws.MaxEarlyData = uint32(ed)
+				ws.EarlyDataHeaderName = "Sec-WebSocket-Protocol"
+			}
+		}
+		if host != "" {
+			ws.Headers = badoption.HTTPHeader{"Host": {host}}
+		}
+		return &option.V2RayTransportOptions{Type: constant.V2RayTransportTypeWebsocket, WebsocketOptions: ws}, nil
+	case "grpc":
+		if mode := q.Get("mode"); mode != "" && mode != "gun" {
+			return nil, fmt.Errorf("unsupported grpc mode %q", mode)
+		}
+		return &option.V2RayTransportOptions{
+			Type:        constant.V2RayTransportTypeGRPC,
+			GRPCOptions: option.V2RayGRPCOptions{ServiceName: q.Get("serviceName")},
+		}, nil
+	case "http", "h2":
+		h := option.V2RayHTTPOptions{Path: path}
+		if host != "" {
+			h.Host = strings.Split(host, ",")
+		}
+		return &option.V2RayTransportOptions{Type: constant.V2RayTransportTypeHTTP, HTTPOptions: h}, nil
+	case "httpupgrade":
+		return &option.V2RayTransportOptions{
+			Type:               constant.V2RayTransportTypeHTTPUpgrade,
+			HTTPUpgradeOptions: option.V2RayHTTPUpgradeOptions{Host: host, Path: path},
+		}, nil
+	case "quic":
+		if qs := q.Get("quicSecurity"); qs != "" && qs != "none" {
+			return nil, fmt.Errorf("unsupported quic security %q", qs)
+		}
+		return &option.V2RayTransportOptions{Type: constant.V2RayTransportTypeQUIC}, nil
+	default:
+		return nil, fmt.Errorf("unsupported transport type %q", typ)
+	}
+}
+
+func isTrue(s string) bool {
+	return s == "1" || strings.EqualFold(s, "true")
+}
@@ -26,6 +26,10 @@ func performLanternPing(urlToHit string, runId string, deviceId string, userId i
 		DataDir: dataDir,
 		LogDir:  dataDir,
 		Locale:  "en-US",
+		OnConfigFetch: func(info config.FetchInfo) {
+			fmt.Printf("Config fetch took %dms (status %d, transport %q, applied %t, error %q)\n",
+				info.DurationMs, info.StatusCode, info.Transport, info.Applied, info.Error)
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create radiance instance: %w", err)
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/gofrs/uuid/v5"
+	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
 	N "github.com/sagernet/sing/common/network"
 
@@ -115,6 +116,8 @@ type connTracker struct {
 
 	conns       lsync.TypedMap[uuid.UUID, *record]
 	activeCount atomic.Int64
+	// dnsCount counts connections sniffed as DNS over the tracker's lifetime.
+	dnsCount atomic.Int64
 
 	tp *throughputTracker // wired after construction
 
@@ -150,6 +153,13 @@ func (m *connTracker) Total() (up, down int64) {
 func (m *connTracker) join(r *record) {
 	m.conns.Store(r.id, r)
 	m.activeCount.Add(1)
+	if r.protocol == C.ProtocolDNS {
+		m.dnsCount.Add(1)
+	}
+}
+
+func (m *connTracker) dnsConnectionCount() int64 {
+	return m.dnsCount.Load()
 }
 
 // leave folds the connection's final accounting exactly once, gated by record.closed.
@@ -157,6 +157,18 @@ func TestConnTracker_TotalAndShim(t *testing.T) {
 	require.Len(t, active, 2)
 }
 
+func TestConnTracker_DNSConnectionCount(t *testing.T) {
+	ct := newConnTracker()
+	dns, web := newRec("direct"), newRec("vpn-a")
+	dns.protocol = "dns"
+	web.protocol = "tls"
+	ct.join(dns)
+	ct.join(web)
+	ct.leave(dns)
+
+	assert.Equal(t, int64(1), ct.dnsConnectionCount(), "closed DNS connections stay counted")
+}
+
 func TestConnTracker_CountsBytesAndFoldsOnClose(t *testing.T) {
 	ct := newConnTracker()
 	tr := newThroughputTracker(ct, time.Second)
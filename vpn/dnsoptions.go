@@ -90,6 +90,10 @@ var aliDNSLocales = map[string]struct{}{
 }
 
 func localDNSIP() string {
+	if server := settings.GetString(settings.LocalDNSServerKey); server != "" {
+		slog.Info("Using configured local DNS server", "server", server)
+		return server
+	}
 	locale := settings.GetString(settings.LocaleKey)
 	normalizedLocale := normalizeLocale(locale)
 	if _, ok := aliDNSLocales[normalizedLocale]; ok {
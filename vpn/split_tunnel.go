@@ -147,6 +147,13 @@ func (s *SplitTunnel) RemoveItems(items SplitTunnelFilter) error {
 	return s.saveToFile()
 }
 
+// SetItems replaces every item in the filter with items.
+func (s *SplitTunnel) SetItems(items SplitTunnelFilter) error {
+	s.updateFilters(items, func(_ []string, items []string) []string { return slices.Clone(items) })
+	s.logger.Debug("set filter items", "items", items.String())
+	return s.saveToFile()
+}
+
 type actionFn func(slice []string, items []string) []string
 
 func (s *SplitTunnel) updateFilter(filterType string, item string, fn actionFn) error {
@@ -22,3 +22,5 @@ func (s *SplitTunnel) Filters() SplitTunnelFilter { return SplitTunnelFilter{} }
 func (s *SplitTunnel) AddItems(_ SplitTunnelFilter) error { return nil }
 
 func (s *SplitTunnel) RemoveItems(_ SplitTunnelFilter) error { return nil }
+
+func (s *SplitTunnel) SetItems(_ SplitTunnelFilter) error { return nil }
@@ -66,3 +66,37 @@ func (f SplitTunnelFilter) String() string {
 	}
 	return "{" + strings.Join(str, ", ") + "}"
 }
+
+// ByType returns the filter's items keyed by filter type, omitting empty types.
+func (f SplitTunnelFilter) ByType() map[string][]string {
+	m := make(map[string][]string)
+	for typ, items := range map[string][]string{
+		TypeDomain:           f.Domain,
+		TypeDomainSuffix:     f.DomainSuffix,
+		TypeDomainKeyword:    f.DomainKeyword,
+		TypeDomainRegex:      f.DomainRegex,
+		TypeProcessName:      f.ProcessName,
+		TypeProcessPath:      f.ProcessPath,
+		TypeProcessPathRegex: f.ProcessPathRegex,
+		TypePackageName:      f.PackageName,
+	} {
+		if len(items) > 0 {
+			m[typ] = items
+		}
+	}
+	return m
+}
+
+// SplitTunnelFilterByType is the inverse of [SplitTunnelFilter.ByType]. Unknown types are ignored.
+func SplitTunnelFilterByType(m map[string][]string) SplitTunnelFilter {
+	return SplitTunnelFilter{
+		Domain:           m[TypeDomain],
+		DomainSuffix:     m[TypeDomainSuffix],
+		DomainKeyword:    m[TypeDomainKeyword],
+		DomainRegex:      m[TypeDomainRegex],
+		ProcessName:      m[TypeProcessName],
+		ProcessPath:      m[TypeProcessPath],
+		ProcessPathRegex: m[TypeProcessPathRegex],
+		PackageName:      m[TypePackageName],
+	}
+}
@@ -64,6 +64,8 @@ type tunnel struct {
 
 	cancel  context.CancelFunc
 	closers []io.Closer
+
+	connectedAt time.Time
 }
 
 func (t *tunnel) start(ctx context.Context, options string, platformIfce libbox.PlatformInterface, isRestart bool) error {
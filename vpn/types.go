@@ -1,6 +1,8 @@
 package vpn
 
 import (
+	"time"
+
 	"github.com/sagernet/sing-box/adapter"
 
 	lbA "github.com/getlantern/lantern-box/adapter"
@@ -51,6 +53,23 @@ type ThroughputSnapshot struct {
 	ActivePerOutbound map[string]int        `json:"active_per_outbound"`
 }
 
+// TunnelStats holds cumulative counters for the current tunnel. They reset when the tunnel
+// restarts.
+type TunnelStats struct {
+	BytesUp   int64 `json:"bytes_up"`
+	BytesDown int64 `json:"bytes_down"`
+	// DNSConnections is the number of routed connections sniffed as DNS. Each UDP flow is
+	// counted once, so it is a lower bound on the number of queries.
+	DNSConnections int64     `json:"dns_connections"`
+	ConnectedAt    time.Time `json:"connected_at"`
+}
+
+// TunnelError records an error reported through a StatusUpdateEvent.
+type TunnelError struct {
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
+
 type Connection struct {
 	ID           string   `json:"id"`
 	Inbound      string   `json:"inbound"`
@@ -73,7 +73,8 @@ type VPNClient struct {
 	offlineTestCancel context.CancelFunc
 	offlineTestDone   chan struct{}
 
-	status atomic.Value // VPNStatus
+	status  atomic.Value // VPNStatus
+	lastErr atomic.Pointer[TunnelError]
 
 	// connObserver, if set, receives connection-close pushes. It outlives individual tunnels
 	// and is re-attached to each tunnel's tracker at connect.
@@ -180,6 +181,7 @@ func (c *VPNClient) start(ctx context.Context, boxOptions BoxOptions, options st
 		c.setStatus(ErrorStatus, err)
 		return err
 	}
+	t.connectedAt = time.Now()
 	c.tunnel = &t
 	c.setStatus(Connected, nil)
 	return nil
@@ -283,6 +285,7 @@ func (c *VPNClient) setStatus(s VPNStatus, err error) {
 	evt := StatusUpdateEvent{Status: s}
 	if err != nil {
 		evt.Error = err.Error()
+		c.lastErr.Store(&TunnelError{Error: evt.Error, Time: time.Now()})
 	}
 	events.Emit(evt)
 }
@@ -405,6 +408,30 @@ func (c *VPNClient) Bytes() (up, down int64, ok bool) {
 	return up, down, true
 }
 
+// Stats returns cumulative counters for the active tunnel. Returns ErrTunnelNotConnected if the
+// tunnel is not connected.
+func (c *VPNClient) Stats() (TunnelStats, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.tunnel == nil {
+		return TunnelStats{}, ErrTunnelNotConnected
+	}
+	ct := c.tunnel.clashServer.connTracker
+	up, down := ct.Total()
+	return TunnelStats{
+		BytesUp:        up,
+		BytesDown:      down,
+		DNSConnections: ct.dnsConnectionCount(),
+		ConnectedAt:    c.tunnel.connectedAt,
+	}, nil
+}
+
+// LastError returns the most recent error that moved the tunnel into ErrorStatus, or nil if
+// there hasn't been one since the client was created.
+func (c *VPNClient) LastError() *TunnelError {
+	return c.lastErr.Load()
+}
+
 // Throughput returns the most recent global and per-outbound throughput sample.
 // Returns ErrTunnelNotConnected if the tunnel is not connected.
 func (c *VPNClient) Throughput() (ThroughputSnapshot, error) {